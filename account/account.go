@@ -1,6 +1,7 @@
 package account
 
 import (
+	"errors"
 	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -26,10 +27,20 @@ func (accntID AccountID) Validate() error {
 type Account struct {
 	AccountID AccountID         `json:"id"`
 	Currency  currency.Currency `json:"currency"`
+	Status    Status            `json:"status"`
 	Balance   decimal.Decimal   `json:"balance"`
+	// AvailableBalance is the balance less the active holds
+	AvailableBalance decimal.Decimal `json:"available_balance"`
+	// OverdraftLimit is how far the balance can go negative
+	OverdraftLimit decimal.Decimal `json:"overdraft_limit"`
+	// AvailableCredit is the unused part of the overdraft limit
+	AvailableCredit decimal.Decimal `json:"available_credit"`
+	// AsOf is set if the balances are from the postings made before it
+	AsOf *time.Time `json:"as_of,omitempty"`
 }
 
-// Validate validates the account
+// Validate validates the account, the service checks
+// that the currency is enabled
 func (ac Account) Validate() error {
 	return validation.Errors{
 		"account_id": ac.AccountID.Validate(),
@@ -41,6 +52,19 @@ func (ac Account) Validate() error {
 					return currency.ErrUnsupportedCurrency
 				}
 
+				return nil
+			})),
+		"overdraft_limit": validation.Validate(ac.OverdraftLimit,
+			validation.By(func(value interface{}) error {
+				limit, _ := value.(decimal.Decimal)
+				if limit.IsNegative() {
+					return errors.New("must not be negative")
+				}
+
+				if !ac.Currency.IsValidAmount(limit) {
+					return errors.New("must not exceed the minor units of the currency")
+				}
+
 				return nil
 			})),
 	}.Filter()
@@ -52,5 +76,7 @@ type Balance struct {
 	TotalCredit decimal.Decimal
 	TotalDebit  decimal.Decimal
 	CurrentBal  decimal.Decimal
-	Ts          *time.Time
+	// AvailableBal is the current balance less the active holds
+	AvailableBal decimal.Decimal
+	Ts           *time.Time
 }
@@ -40,6 +40,11 @@ func TestAccountValidation(t *testing.T) {
 		ac := account.Account{AccountID: "johnybravo"}
 		err := ac.Validate()
 		assert.Error(t, err)
+
+		// unknown
+		ac = account.Account{AccountID: "johnybravo", Currency: currency.Currency(1)}
+		err = ac.Validate()
+		assert.Error(t, err)
 	})
 
 }
@@ -2,16 +2,19 @@ package account
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/shopspring/decimal"
 
 	"github.com/stevenferrer/kalupi/currency"
 )
 
 // createAccountRequest is a create account request
 type createAccountRequest struct {
-	AccountID AccountID         `json:"account_id"`
-	Currency  currency.Currency `json:"currency"`
+	AccountID      AccountID         `json:"account_id"`
+	Currency       currency.Currency `json:"currency"`
+	OverdraftLimit decimal.Decimal   `json:"overdraft_limit"`
 }
 
 // createAccountResponse is a create account response
@@ -26,8 +29,9 @@ func newCreateAccountEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(createAccountRequest)
 		accnt := Account{
-			AccountID: req.AccountID,
-			Currency:  req.Currency,
+			AccountID:      req.AccountID,
+			Currency:       req.Currency,
+			OverdraftLimit: req.OverdraftLimit,
 		}
 
 		err := s.CreateAccount(ctx, accnt)
@@ -38,6 +42,8 @@ func newCreateAccountEndpoint(s Service) endpoint.Endpoint {
 // getAccountRequest is a get account request
 type getAccountRequest struct {
 	AccountID AccountID
+	// AsOf is the time of the balances, it's zero for the current balances
+	AsOf time.Time
 }
 
 // getAccountResponse is a get account response
@@ -52,6 +58,11 @@ func (r getAccountResponse) error() error { return r.Err }
 func newGetAccountEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(getAccountRequest)
+		if !req.AsOf.IsZero() {
+			accnt, err := s.GetAccountAsOf(ctx, req.AccountID, req.AsOf)
+			return getAccountResponse{Account: accnt, Err: err}, nil
+		}
+
 		accnt, err := s.GetAccount(ctx, req.AccountID)
 		return getAccountResponse{Account: accnt, Err: err}, nil
 	}
@@ -76,3 +87,78 @@ func newListAccountsEndpoint(s Service) endpoint.Endpoint {
 		return listAccountsResponse{Accounts: accnts, Err: err}, nil
 	}
 }
+
+// changeAccountStatusRequest is a change account status request
+type changeAccountStatusRequest struct {
+	AccountID AccountID `json:"-"`
+	Status    Status    `json:"status"`
+	Reason    string    `json:"reason"`
+	ChangedBy string    `json:"changed_by"`
+}
+
+// changeAccountStatusResponse is a change account status response
+type changeAccountStatusResponse struct {
+	Account *Account `json:"account,omitempty"`
+	Err     error    `json:"error,omitempty"`
+}
+
+func (r changeAccountStatusResponse) error() error { return r.Err }
+
+// newChangeAccountStatusEndpoint returns a change account status endpoint
+func newChangeAccountStatusEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(changeAccountStatusRequest)
+		accnt, err := s.ChangeAccountStatus(ctx, StatusChange{
+			AccountID: req.AccountID,
+			Status:    req.Status,
+			Reason:    req.Reason,
+			ChangedBy: req.ChangedBy,
+		})
+		return changeAccountStatusResponse{Account: accnt, Err: err}, nil
+	}
+}
+
+// listStatusChangesRequest is a list status changes request
+type listStatusChangesRequest struct {
+	AccountID AccountID
+}
+
+// listStatusChangesResponse is a list status changes response
+type listStatusChangesResponse struct {
+	StatusChanges []*StatusChange `json:"status_changes,omitempty"`
+	Err           error           `json:"error,omitempty"`
+}
+
+func (r listStatusChangesResponse) error() error { return r.Err }
+
+// newListStatusChangesEndpoint returns a list status changes endpoint
+func newListStatusChangesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listStatusChangesRequest)
+		scs, err := s.ListStatusChanges(ctx, req.AccountID)
+		return listStatusChangesResponse{StatusChanges: scs, Err: err}, nil
+	}
+}
+
+// setOverdraftLimitRequest is a set overdraft limit request
+type setOverdraftLimitRequest struct {
+	AccountID      AccountID       `json:"-"`
+	OverdraftLimit decimal.Decimal `json:"overdraft_limit"`
+}
+
+// setOverdraftLimitResponse is a set overdraft limit response
+type setOverdraftLimitResponse struct {
+	Account *Account `json:"account,omitempty"`
+	Err     error    `json:"error,omitempty"`
+}
+
+func (r setOverdraftLimitResponse) error() error { return r.Err }
+
+// newSetOverdraftLimitEndpoint returns a set overdraft limit endpoint
+func newSetOverdraftLimitEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(setOverdraftLimitRequest)
+		accnt, err := s.SetOverdraftLimit(ctx, req.AccountID, req.OverdraftLimit)
+		return setOverdraftLimitResponse{Account: accnt, Err: err}, nil
+	}
+}
@@ -10,4 +10,16 @@ var (
 	ErrAccountNotFound = errors.New("account not found")
 	// ErrValidation is an account related validation error
 	ErrValidation = errors.New("validation error")
+	// ErrAccountFrozen is an error when posting to a frozen account
+	ErrAccountFrozen = errors.New("account frozen")
+	// ErrAccountClosed is an error when posting to a closed account
+	ErrAccountClosed = errors.New("account closed")
+	// ErrInvalidStatusTransition is an error when the account
+	// status can't be changed to the requested status
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	// ErrNonZeroBalance is an error when closing an account with a balance
+	ErrNonZeroBalance = errors.New("non-zero balance")
+	// ErrOverdraftLimitTooLow is an error when lowering the
+	// overdraft limit below the overdrawn amount of the account
+	ErrOverdraftLimitTooLow = errors.New("overdraft limit too low")
 )
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/shopspring/decimal"
 )
 
 // loggingService is a service logging middleware
@@ -47,6 +48,22 @@ func (s *loggingService) GetAccount(ctx context.Context, accntID AccountID) (acc
 	return s.s.GetAccount(ctx, accntID)
 }
 
+// GetAccountAsOf logs the get account as of params
+func (s *loggingService) GetAccountAsOf(ctx context.Context, accntID AccountID,
+	asOf time.Time) (accnt *Account, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "get_account_as_of",
+			"account_id", accntID,
+			"as_of", asOf,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetAccountAsOf(ctx, accntID, asOf)
+}
+
 // ListAccounts logs the list account params
 func (s *loggingService) ListAccounts(ctx context.Context) (accnts []*Account, err error) {
 	defer func(begin time.Time) {
@@ -60,3 +77,50 @@ func (s *loggingService) ListAccounts(ctx context.Context) (accnts []*Account, e
 
 	return s.s.ListAccounts(ctx)
 }
+
+// ChangeAccountStatus logs the change account status params
+func (s *loggingService) ChangeAccountStatus(ctx context.Context, sc StatusChange) (accnt *Account, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "change_account_status",
+			"account_id", sc.AccountID,
+			"status", sc.Status,
+			"changed_by", sc.ChangedBy,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ChangeAccountStatus(ctx, sc)
+}
+
+// ListStatusChanges logs the list status changes params
+func (s *loggingService) ListStatusChanges(ctx context.Context, accntID AccountID) (scs []*StatusChange, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list_status_changes",
+			"account_id", accntID,
+			"count", len(scs),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ListStatusChanges(ctx, accntID)
+}
+
+// SetOverdraftLimit logs the set overdraft limit params
+func (s *loggingService) SetOverdraftLimit(ctx context.Context, accntID AccountID,
+	limit decimal.Decimal) (accnt *Account, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "set_overdraft_limit",
+			"account_id", accntID,
+			"overdraft_limit", limit,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.SetOverdraftLimit(ctx, accntID, limit)
+}
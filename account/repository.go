@@ -2,11 +2,15 @@ package account
 
 import (
 	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/etc/tx"
 )
 
 // Repository is an account repository
 type Repository interface {
-	// CreateAccount creates an account
+	// CreateAccount creates an active account
 	CreateAccount(context.Context, Account) (AccountID, error)
 	// GetAccount retrieves the account
 	GetAccount(context.Context, AccountID) (*Account, error)
@@ -14,4 +18,15 @@ type Repository interface {
 	IsAccountExists(context.Context, AccountID) (bool, error)
 	// ListAccounts retrieves the list of accounts
 	ListAccounts(context.Context) ([]*Account, error)
+	// GetAccountStatus retrieves the account status within tx
+	GetAccountStatus(context.Context, tx.Tx, AccountID) (Status, error)
+	// UpdateAccountStatus updates the account status and
+	// records the status change within tx
+	UpdateAccountStatus(context.Context, tx.Tx, StatusChange) error
+	// ListStatusChanges retrieves the status changes of the account
+	ListStatusChanges(context.Context, AccountID) ([]*StatusChange, error)
+	// GetOverdraftLimit retrieves the overdraft limit of the account within tx
+	GetOverdraftLimit(context.Context, tx.Tx, AccountID) (decimal.Decimal, error)
+	// UpdateOverdraftLimit updates the overdraft limit of the account within tx
+	UpdateOverdraftLimit(context.Context, tx.Tx, AccountID, decimal.Decimal) error
 }
@@ -2,6 +2,9 @@ package account
 
 import (
 	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Service is an account service
@@ -10,6 +13,14 @@ type Service interface {
 	CreateAccount(context.Context, Account) error
 	// GetAccount retrieives an account via AccountID
 	GetAccount(context.Context, AccountID) (*Account, error)
+	// GetAccountAsOf retrieves an account with the balances at the time
+	GetAccountAsOf(context.Context, AccountID, time.Time) (*Account, error)
 	// ListAccounts retrieives the list of accounts
 	ListAccounts(context.Context) ([]*Account, error)
+	// ChangeAccountStatus changes the account status i.e. freezes the account
+	ChangeAccountStatus(context.Context, StatusChange) (*Account, error)
+	// ListStatusChanges retrieves the status changes of the account
+	ListStatusChanges(context.Context, AccountID) ([]*StatusChange, error)
+	// SetOverdraftLimit sets how far the account balance can go negative
+	SetOverdraftLimit(context.Context, AccountID, decimal.Decimal) (*Account, error)
 }
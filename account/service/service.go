@@ -2,25 +2,40 @@ package service
 
 import (
 	"context"
+	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 	"go.uber.org/multierr"
 
 	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/balance"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/tx"
 )
 
 // service is an account service implementation
 type service struct {
 	accountRepo account.Repository
+	balRepo     balance.Repository
 	balService  balance.Service
+	// currs are the enabled currencies
+	currs currency.Set
 }
 
 var _ account.Service = (*service)(nil)
 
-// New takes an account and balance repository and returns an account service
-func New(accountRepo account.Repository, balService balance.Service) account.Service {
-	return &service{accountRepo: accountRepo, balService: balService}
+// New takes an account and balance repository, and the
+// enabled currencies, and returns an account service
+func New(accountRepo account.Repository, balRepo balance.Repository,
+	currs currency.Set) account.Service {
+	return &service{
+		accountRepo: accountRepo,
+		balRepo:     balRepo,
+		balService:  balance.NewService(balRepo),
+		currs:       currs,
+	}
 }
 
 // CreateAccount creates a new account
@@ -30,6 +45,12 @@ func (s *service) CreateAccount(ctx context.Context, accnt account.Account) erro
 		return multierr.Combine(account.ErrValidation, err)
 	}
 
+	if !s.currs.Contains(accnt.Currency) {
+		return multierr.Combine(account.ErrValidation, validation.Errors{
+			"currency": currency.ErrUnsupportedCurrency,
+		})
+	}
+
 	exists, err := s.accountRepo.IsAccountExists(ctx, accnt.AccountID)
 	if err != nil {
 		return errors.Wrap(err, "is account exists")
@@ -74,11 +95,48 @@ func (s *service) GetAccount(ctx context.Context,
 		return nil, errors.Wrap(err, "get account balance")
 	}
 
-	accnt.Balance = bal.CurrentBal
+	setBal(accnt, bal)
 
 	return accnt, nil
 }
 
+// GetAccountAsOf retrieves an account with the balances from the postings
+// made before the time. The holds aren't kept in history, hence, the
+// available balance is the same as the balance.
+func (s *service) GetAccountAsOf(ctx context.Context,
+	accntID account.AccountID, asOf time.Time) (*account.Account, error) {
+	err := accntID.Validate()
+	if err != nil {
+		return nil, account.ErrValidation
+	}
+
+	exists, err := s.accountRepo.IsAccountExists(ctx, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	accnt, err := s.accountRepo.GetAccount(ctx, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "repo get account")
+	}
+
+	asOf = asOf.UTC()
+	bal, err := s.balService.GetAccntBalAsOf(ctx, accnt.AccountID, asOf)
+	if err != nil {
+		return nil, errors.Wrap(err, "get account balance as of")
+	}
+
+	setBal(accnt, bal)
+	accnt.AsOf = &asOf
+
+	return accnt, nil
+}
+
+// ListAccounts retrieves the list of accounts
 func (s *service) ListAccounts(ctx context.Context) ([]*account.Account, error) {
 	accnts, err := s.accountRepo.ListAccounts(ctx)
 	if err != nil {
@@ -90,8 +148,197 @@ func (s *service) ListAccounts(ctx context.Context) ([]*account.Account, error)
 		if err != nil {
 			return nil, errors.Wrap(err, "get account balance")
 		}
-		accnt.Balance = bal.CurrentBal
+		setBal(accnt, bal)
 	}
 
 	return accnts, nil
 }
+
+// ChangeAccountStatus changes the account status and records who changed
+// it and why. A closed account must have a zero balance and no active holds.
+func (s *service) ChangeAccountStatus(ctx context.Context,
+	sc account.StatusChange) (accnt *account.Account, err error) {
+	err = sc.Validate()
+	if err != nil {
+		return nil, multierr.Combine(account.ErrValidation, err)
+	}
+
+	var exists bool
+	exists, err = s.accountRepo.IsAccountExists(ctx, sc.AccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	accnt, err = s.accountRepo.GetAccount(ctx, sc.AccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "repo get account")
+	}
+
+	var tx tx.Tx
+	tx, err = s.balRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	// lock the account so that nothing is posted until the status is changed
+	var bal *account.Balance
+	bal, err = s.balRepo.GetAccntBalForUpdate(ctx, tx, sc.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get account balance")
+		return
+	}
+
+	sc.FromStatus, err = s.accountRepo.GetAccountStatus(ctx, tx, sc.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get account status")
+		return
+	}
+
+	if !sc.FromStatus.CanTransitionTo(sc.Status) {
+		err = errors.Wrapf(account.ErrInvalidStatusTransition,
+			"%s to %s", sc.FromStatus, sc.Status)
+		return
+	}
+
+	if sc.Status == account.StatusClosed &&
+		!(bal.CurrentBal.IsZero() && bal.AvailableBal.IsZero()) {
+		err = account.ErrNonZeroBalance
+		return
+	}
+
+	err = s.accountRepo.UpdateAccountStatus(ctx, tx, sc)
+	if err != nil {
+		err = errors.Wrap(err, "update account status")
+		return
+	}
+
+	accnt.Status = sc.Status
+	setBal(accnt, bal)
+
+	return accnt, nil
+}
+
+// ListStatusChanges retrieves the status changes of the account
+func (s *service) ListStatusChanges(ctx context.Context,
+	accntID account.AccountID) ([]*account.StatusChange, error) {
+	err := accntID.Validate()
+	if err != nil {
+		return nil, account.ErrValidation
+	}
+
+	exists, err := s.accountRepo.IsAccountExists(ctx, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	scs, err := s.accountRepo.ListStatusChanges(ctx, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "repo list status changes")
+	}
+
+	return scs, nil
+}
+
+// SetOverdraftLimit sets how far the account balance can go negative. The
+// limit can't be lowered below the overdrawn amount of the account.
+func (s *service) SetOverdraftLimit(ctx context.Context, accntID account.AccountID,
+	limit decimal.Decimal) (accnt *account.Account, err error) {
+	err = accntID.Validate()
+	if err != nil {
+		return nil, account.ErrValidation
+	}
+
+	var exists bool
+	exists, err = s.accountRepo.IsAccountExists(ctx, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	accnt, err = s.accountRepo.GetAccount(ctx, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "repo get account")
+	}
+
+	accnt.OverdraftLimit = limit
+	err = accnt.Validate()
+	if err != nil {
+		return nil, multierr.Combine(account.ErrValidation, err)
+	}
+
+	var tx tx.Tx
+	tx, err = s.balRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	// lock the account so that nothing is posted until the limit is changed
+	var bal *account.Balance
+	bal, err = s.balRepo.GetAccntBalForUpdate(ctx, tx, accntID)
+	if err != nil {
+		err = errors.Wrap(err, "get account balance")
+		return
+	}
+
+	if bal.AvailableBal.Add(limit).IsNegative() {
+		err = account.ErrOverdraftLimitTooLow
+		return
+	}
+
+	err = s.accountRepo.UpdateOverdraftLimit(ctx, tx, accntID, limit)
+	if err != nil {
+		err = errors.Wrap(err, "update overdraft limit")
+		return
+	}
+
+	setBal(accnt, bal)
+
+	return accnt, nil
+}
+
+// setBal sets the balances and the available credit of the account
+func setBal(accnt *account.Account, bal *account.Balance) {
+	accnt.Balance = bal.CurrentBal
+	accnt.AvailableBalance = bal.AvailableBal
+
+	// the overdrawn amount is taken from the overdraft limit
+	accnt.AvailableCredit = accnt.OverdraftLimit
+	if bal.AvailableBal.IsNegative() {
+		accnt.AvailableCredit = accnt.OverdraftLimit.Add(bal.AvailableBal)
+	}
+}
@@ -3,6 +3,7 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -10,24 +11,20 @@ import (
 
 	"github.com/stevenferrer/kalupi/account"
 	accountservice "github.com/stevenferrer/kalupi/account/service"
-	"github.com/stevenferrer/kalupi/balance"
 	"github.com/stevenferrer/kalupi/currency"
-	"github.com/stevenferrer/kalupi/etc/txdb"
-	"github.com/stevenferrer/kalupi/postgres"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/transaction"
 )
 
 func TestAccountService(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
 
-	err := postgres.Migrate(db)
-	require.NoError(t, err)
+	balRepo := store.BalRepo
 
-	balRepo := postgres.NewBalanceRepository(db)
-	balService := balance.NewService(balRepo)
-
-	accntRepo := postgres.NewAccountRepository(db)
-	accountSvc := accountservice.New(accntRepo, balService)
+	accntRepo := store.AccountRepo
+	accountSvc := accountservice.New(accntRepo, balRepo, currency.NewSet(currency.USD))
 
 	ctx := context.TODO()
 	accountID := account.AccountID("john1234")
@@ -43,6 +40,15 @@ func TestAccountService(t *testing.T) {
 			assert.ErrorIs(t, err, account.ErrValidation)
 		})
 
+		t.Run("currency not enabled", func(t *testing.T) {
+			err = accountSvc.CreateAccount(ctx, account.Account{
+				AccountID: account.AccountID("johnyen1234"),
+				Currency:  currency.JPY,
+			})
+			assert.ErrorIs(t, err, account.ErrValidation)
+			assert.Contains(t, err.Error(), "currency: unsupported currency")
+		})
+
 		t.Run("alread exists", func(t *testing.T) {
 			err = accountSvc.CreateAccount(ctx, account.Account{
 				AccountID: accountID,
@@ -80,4 +86,222 @@ func TestAccountService(t *testing.T) {
 		assert.Len(t, acs, 1)
 	})
 
+	t.Run("change account status", func(t *testing.T) {
+		accnt, err := accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+			AccountID: accountID,
+			Status:    account.StatusFrozen,
+			Reason:    "suspicious activity",
+			ChangedBy: "compliance",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, account.StatusFrozen, accnt.Status)
+
+		accnt, err = accountSvc.GetAccount(ctx, accountID)
+		require.NoError(t, err)
+		assert.Equal(t, account.StatusFrozen, accnt.Status)
+
+		t.Run("validation error", func(t *testing.T) {
+			_, err := accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+				AccountID: accountID,
+				Status:    account.StatusActive,
+			})
+			assert.ErrorIs(t, err, account.ErrValidation)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			_, err := accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+				AccountID: account.AccountID("idontexist"),
+				Status:    account.StatusFrozen,
+				Reason:    "suspicious activity",
+				ChangedBy: "compliance",
+			})
+			assert.ErrorIs(t, err, account.ErrAccountNotFound)
+		})
+
+		t.Run("invalid transition", func(t *testing.T) {
+			_, err := accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+				AccountID: accountID,
+				Status:    account.StatusFrozen,
+				Reason:    "suspicious activity",
+				ChangedBy: "compliance",
+			})
+			assert.ErrorIs(t, err, account.ErrInvalidStatusTransition)
+		})
+
+		accnt, err = accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+			AccountID: accountID,
+			Status:    account.StatusActive,
+			Reason:    "cleared",
+			ChangedBy: "compliance",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, account.StatusActive, accnt.Status)
+
+		t.Run("close with non-zero balance", func(t *testing.T) {
+			err := ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+			require.NoError(t, err)
+
+			tx, err := store.XactRepo.BeginTx(ctx)
+			require.NoError(t, err)
+
+			xactNo, err := transaction.NewXactNo()
+			require.NoError(t, err)
+
+			err = store.XactRepo.CreateXact(ctx, tx, transaction.Transaction{
+				XactNo:      xactNo,
+				LedgerNo:    ledger.CashUSDLedgerNo,
+				XactType:    transaction.XactTypeDebit,
+				AccountID:   accountID,
+				XactTypeExt: transaction.XactTypeExtDeposit,
+				Amount:      decimal.NewFromInt(100),
+			})
+			require.NoError(t, err)
+			require.NoError(t, tx.Commit())
+
+			_, err = accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+				AccountID: accountID,
+				Status:    account.StatusClosed,
+				Reason:    "customer request",
+				ChangedBy: "support",
+			})
+			assert.ErrorIs(t, err, account.ErrNonZeroBalance)
+		})
+
+		t.Run("close", func(t *testing.T) {
+			janeID := account.AccountID("jane1234")
+			err := accountSvc.CreateAccount(ctx, account.Account{
+				AccountID: janeID,
+				Currency:  currency.USD,
+			})
+			require.NoError(t, err)
+
+			accnt, err := accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+				AccountID: janeID,
+				Status:    account.StatusClosed,
+				Reason:    "customer request",
+				ChangedBy: "support",
+			})
+			require.NoError(t, err)
+			assert.Equal(t, account.StatusClosed, accnt.Status)
+
+			// a closed account can't be re-opened
+			_, err = accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+				AccountID: janeID,
+				Status:    account.StatusActive,
+				Reason:    "customer request",
+				ChangedBy: "support",
+			})
+			assert.ErrorIs(t, err, account.ErrInvalidStatusTransition)
+		})
+	})
+
+	t.Run("set overdraft limit", func(t *testing.T) {
+		// the account has a balance of 100
+		accnt, err := accountSvc.SetOverdraftLimit(ctx, accountID, decimal.NewFromInt(50))
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(50).Equal(accnt.OverdraftLimit))
+		assert.True(t, decimal.NewFromInt(50).Equal(accnt.AvailableCredit))
+
+		accnt, err = accountSvc.GetAccount(ctx, accountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(50).Equal(accnt.OverdraftLimit))
+
+		t.Run("validation error", func(t *testing.T) {
+			_, err := accountSvc.SetOverdraftLimit(ctx, accountID, decimal.NewFromInt(-1))
+			assert.ErrorIs(t, err, account.ErrValidation)
+
+			// more than the minor units of USD
+			_, err = accountSvc.SetOverdraftLimit(ctx, accountID, decimal.RequireFromString("0.001"))
+			assert.ErrorIs(t, err, account.ErrValidation)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			_, err := accountSvc.SetOverdraftLimit(ctx, account.AccountID("idontexist"), decimal.Zero)
+			assert.ErrorIs(t, err, account.ErrAccountNotFound)
+		})
+
+		t.Run("overdrawn account", func(t *testing.T) {
+			overdrawnID := account.AccountID("overdrawn1")
+			err := accountSvc.CreateAccount(ctx, account.Account{
+				AccountID:      overdrawnID,
+				Currency:       currency.USD,
+				OverdraftLimit: decimal.NewFromInt(50),
+			})
+			require.NoError(t, err)
+
+			tx, err := store.XactRepo.BeginTx(ctx)
+			require.NoError(t, err)
+
+			xactNo, err := transaction.NewXactNo()
+			require.NoError(t, err)
+
+			err = store.XactRepo.CreateXact(ctx, tx, transaction.Transaction{
+				XactNo:      xactNo,
+				LedgerNo:    ledger.CashUSDLedgerNo,
+				XactType:    transaction.XactTypeCredit,
+				AccountID:   overdrawnID,
+				XactTypeExt: transaction.XactTypeExtWithdrawal,
+				Amount:      decimal.NewFromInt(30),
+			})
+			require.NoError(t, err)
+			require.NoError(t, tx.Commit())
+
+			accnt, err := accountSvc.GetAccount(ctx, overdrawnID)
+			require.NoError(t, err)
+			assert.True(t, decimal.NewFromInt(-30).Equal(accnt.Balance))
+			assert.True(t, decimal.NewFromInt(20).Equal(accnt.AvailableCredit))
+
+			// can't be lowered below the overdrawn amount
+			_, err = accountSvc.SetOverdraftLimit(ctx, overdrawnID, decimal.NewFromInt(29))
+			assert.ErrorIs(t, err, account.ErrOverdraftLimitTooLow)
+
+			accnt, err = accountSvc.SetOverdraftLimit(ctx, overdrawnID, decimal.NewFromInt(30))
+			require.NoError(t, err)
+			assert.True(t, accnt.AvailableCredit.IsZero())
+		})
+	})
+
+	t.Run("get account as of", func(t *testing.T) {
+		later := time.Now().Add(time.Hour)
+		accnt, err := accountSvc.GetAccountAsOf(ctx, accountID, later)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(100).Equal(accnt.Balance))
+		assert.True(t, decimal.NewFromInt(100).Equal(accnt.AvailableBalance))
+		require.NotNil(t, accnt.AsOf)
+		assert.True(t, later.Equal(*accnt.AsOf))
+
+		// before the deposit
+		accnt, err = accountSvc.GetAccountAsOf(ctx, accountID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.True(t, accnt.Balance.IsZero())
+		assert.True(t, decimal.NewFromInt(50).Equal(accnt.AvailableCredit))
+
+		t.Run("validation error", func(t *testing.T) {
+			_, err := accountSvc.GetAccountAsOf(ctx, account.AccountID("##(#*"), later)
+			assert.ErrorIs(t, err, account.ErrValidation)
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			_, err := accountSvc.GetAccountAsOf(ctx, account.AccountID("johntravolta"), later)
+			assert.ErrorIs(t, err, account.ErrAccountNotFound)
+		})
+	})
+
+	t.Run("list status changes", func(t *testing.T) {
+		scs, err := accountSvc.ListStatusChanges(ctx, accountID)
+		require.NoError(t, err)
+		require.Len(t, scs, 2)
+
+		assert.Equal(t, account.StatusActive, scs[0].FromStatus)
+		assert.Equal(t, account.StatusFrozen, scs[0].Status)
+		assert.Equal(t, "suspicious activity", scs[0].Reason)
+		assert.Equal(t, "compliance", scs[0].ChangedBy)
+		assert.Equal(t, account.StatusFrozen, scs[1].FromStatus)
+		assert.Equal(t, account.StatusActive, scs[1].Status)
+
+		t.Run("not found", func(t *testing.T) {
+			_, err := accountSvc.ListStatusChanges(ctx, account.AccountID("idontexist"))
+			assert.ErrorIs(t, err, account.ErrAccountNotFound)
+		})
+	})
 }
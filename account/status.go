@@ -0,0 +1,160 @@
+package account
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// List of status change limits
+const (
+	// maxReasonLen is the max length of the reason of a status change
+	maxReasonLen = 255
+	// maxChangedByLen is the max length of who changed the status
+	maxChangedByLen = 64
+)
+
+// Status is the account status
+type Status int
+
+// List of account statuses
+const (
+	// StatusActive is an active account, the default
+	StatusActive Status = iota + 1
+	// StatusFrozen is a frozen account, no debits or credits are posted
+	StatusFrozen
+	// StatusClosed is a closed account, it can't be re-opened
+	StatusClosed
+)
+
+// String implements Stringer
+func (st Status) String() string {
+	return [...]string{
+		"invalid",
+		"active",
+		"frozen",
+		"closed",
+	}[st]
+}
+
+// Value implements driver.Valuer interface
+func (st Status) Value() (driver.Value, error) {
+	return st.String(), nil
+}
+
+// Scan implements sql.Scanner interface
+func (st *Status) Scan(src interface{}) error {
+	if src == nil {
+		*st = Status(0)
+		return nil
+	}
+
+	val, ok := src.(string)
+	if !ok {
+		return errors.New("src is not string")
+	}
+
+	*st = strToStatus(val)
+	return nil
+}
+
+// strToStatus takes a string and returns the account status
+func strToStatus(s string) Status {
+	switch s {
+	case "active":
+		return StatusActive
+	case "frozen":
+		return StatusFrozen
+	case "closed":
+		return StatusClosed
+	}
+
+	return Status(0)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (st Status) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(st.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (st *Status) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	*st = strToStatus(s)
+	return nil
+}
+
+// statusTransitions are the allowed status transitions
+var statusTransitions = map[Status][]Status{
+	StatusActive: {StatusFrozen, StatusClosed},
+	StatusFrozen: {StatusActive, StatusClosed},
+}
+
+// CanTransitionTo returns true if the status can be changed to the given status
+func (st Status) CanTransitionTo(to Status) bool {
+	for _, s := range statusTransitions[st] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckPostable returns an error if the account is frozen or closed
+func (st Status) CheckPostable() error {
+	switch st {
+	case StatusFrozen:
+		return ErrAccountFrozen
+	case StatusClosed:
+		return ErrAccountClosed
+	}
+
+	return nil
+}
+
+// StatusChange is the audit record of an account status change
+type StatusChange struct {
+	AccountID AccountID `json:"account_id"`
+	// FromStatus is the status before the change
+	FromStatus Status `json:"from_status"`
+	// Status is the new status
+	Status Status `json:"status"`
+	// Reason is the reason of the change
+	Reason string `json:"reason"`
+	// ChangedBy is who changed the status
+	ChangedBy string     `json:"changed_by"`
+	Ts        *time.Time `json:"ts,omitempty"`
+}
+
+// Validate validates the status change params
+func (sc StatusChange) Validate() error {
+	return validation.Errors{
+		"account_id": sc.AccountID.Validate(),
+		// the status is validated by its driver value i.e. frozen
+		"status": validation.Validate(sc.Status,
+			validation.In(StatusActive.String(), StatusFrozen.String(), StatusClosed.String()).
+				Error("must be either active, frozen or closed"),
+		),
+		"reason": validation.Validate(sc.Reason,
+			validation.Required.Error("must not be empty"),
+			validation.Length(1, maxReasonLen),
+		),
+		"changed_by": validation.Validate(sc.ChangedBy,
+			validation.Required.Error("must not be empty"),
+			validation.Length(1, maxChangedByLen),
+		),
+	}.Filter()
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/transport"
 	kithttp "github.com/go-kit/kit/transport/http"
+	"go.uber.org/multierr"
 )
 
 // NewHTTPHandler returns the account http handler
@@ -40,11 +42,35 @@ func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
 		opts...,
 	)
 
+	changeAccountStatusHandler := kithttp.NewServer(
+		newChangeAccountStatusEndpoint(s),
+		decodeChangeAccountStatusRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	listStatusChangesHandler := kithttp.NewServer(
+		newListStatusChangesEndpoint(s),
+		decodeListStatusChangesRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	setOverdraftLimitHandler := kithttp.NewServer(
+		newSetOverdraftLimitEndpoint(s),
+		decodeSetOverdraftLimitRequest,
+		encodeResponse,
+		opts...,
+	)
+
 	mux := chi.NewMux()
 
 	mux.Method(http.MethodPost, "/", createAccountHandler)
 	mux.Method(http.MethodGet, "/", listAccountsHandler)
 	mux.Method(http.MethodGet, "/{id}", getAccountHandler)
+	mux.Method(http.MethodPatch, "/{id}/status", changeAccountStatusHandler)
+	mux.Method(http.MethodGet, "/{id}/status-changes", listStatusChangesHandler)
+	mux.Method(http.MethodPut, "/{id}/overdraft-limit", setOverdraftLimitHandler)
 
 	return mux
 }
@@ -68,13 +94,62 @@ func decodeGetAccountRequest(_ context.Context, r *http.Request) (interface{}, e
 		return nil, errBadRoute
 	}
 
-	return getAccountRequest{AccountID: AccountID(accountID)}, nil
+	request := getAccountRequest{AccountID: AccountID(accountID)}
+	if v := r.URL.Query().Get("as_of"); v != "" {
+		asOf, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, multierr.Combine(ErrValidation,
+				errors.New("as_of: must be an RFC 3339 timestamp"))
+		}
+		request.AsOf = asOf
+	}
+
+	return request, nil
 }
 
 func decodeListAccountsRequest(_ context.Context, _ *http.Request) (interface{}, error) {
 	return listAccountsRequest{}, nil
 }
 
+func decodeChangeAccountStatusRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accountID := chi.URLParam(r, "id")
+	if accountID == "" {
+		return nil, errBadRoute
+	}
+
+	var request changeAccountStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.AccountID = AccountID(accountID)
+
+	return request, nil
+}
+
+func decodeListStatusChangesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accountID := chi.URLParam(r, "id")
+	if accountID == "" {
+		return nil, errBadRoute
+	}
+
+	return listStatusChangesRequest{AccountID: AccountID(accountID)}, nil
+}
+
+func decodeSetOverdraftLimitRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accountID := chi.URLParam(r, "id")
+	if accountID == "" {
+		return nil, errBadRoute
+	}
+
+	var request setOverdraftLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.AccountID = AccountID(accountID)
+
+	return request, nil
+}
+
 func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if e, ok := response.(errorer); ok && e.error() != nil {
 		encodeError(ctx, e.error(), w)
@@ -93,8 +168,12 @@ type errorer interface {
 func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
-	if errors.Is(err, ErrValidation) {
+	if errors.Is(err, ErrValidation) ||
+		errors.Is(err, ErrNonZeroBalance) ||
+		errors.Is(err, ErrOverdraftLimitTooLow) {
 		w.WriteHeader(http.StatusUnprocessableEntity)
+	} else if errors.Is(err, ErrInvalidStatusTransition) {
+		w.WriteHeader(http.StatusConflict)
 	} else if errors.Is(err, ErrAccountNotFound) {
 		w.WriteHeader(http.StatusNotFound)
 	} else {
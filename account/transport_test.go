@@ -14,23 +14,18 @@ import (
 
 	"github.com/stevenferrer/kalupi/account"
 	accountsvc "github.com/stevenferrer/kalupi/account/service"
-	"github.com/stevenferrer/kalupi/balance"
-	"github.com/stevenferrer/kalupi/etc/txdb"
-	"github.com/stevenferrer/kalupi/postgres"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/teststore"
 )
 
 func TestHTTPHandler(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
 
-	err := postgres.Migrate(db)
-	require.NoError(t, err)
+	balRepo := store.BalRepo
 
-	balRepo := postgres.NewBalanceRepository(db)
-	balService := balance.NewService(balRepo)
-
-	accountRepo := postgres.NewAccountRepository(db)
-	accountService := accountsvc.New(accountRepo, balService)
+	accountRepo := store.AccountRepo
+	accountService := accountsvc.New(accountRepo, balRepo, currency.NewSet(currency.USD))
 
 	logger := log.NewNopLogger()
 	accountService = account.NewLoggingService(logger, accountService)
@@ -106,6 +101,37 @@ func TestHTTPHandler(t *testing.T) {
 			accountHandler.ServeHTTP(rr, httpReq)
 			require.Equal(t, http.StatusNotFound, rr.Code)
 		})
+
+		t.Run("as of", func(t *testing.T) {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet,
+				"/"+accountID+"?as_of=2021-01-01T00:00:00Z", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			accountHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp struct {
+				Account struct {
+					Balance string `json:"balance"`
+					AsOf    string `json:"as_of"`
+				} `json:"account"`
+			}
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.Equal(t, "0", resp.Account.Balance)
+			assert.Equal(t, "2021-01-01T00:00:00Z", resp.Account.AsOf)
+		})
+
+		t.Run("invalid as of", func(t *testing.T) {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet,
+				"/"+accountID+"?as_of=yesterday", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			accountHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
 	})
 
 	t.Run("list accounts", func(t *testing.T) {
@@ -135,4 +161,113 @@ func TestHTTPHandler(t *testing.T) {
 			assert.Equal(t, "0", accnt.Balance)
 		}
 	})
+
+	t.Run("change account status", func(t *testing.T) {
+		changeStatus := func(t *testing.T, req map[string]interface{}) *httptest.ResponseRecorder {
+			b, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPatch,
+				"/"+accountID+"/status", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			accountHandler.ServeHTTP(rr, httpReq)
+			return rr
+		}
+
+		freeze := map[string]interface{}{
+			"status":     "frozen",
+			"reason":     "suspicious activity",
+			"changed_by": "compliance",
+		}
+		rr := changeStatus(t, freeze)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp = struct {
+			Account struct {
+				AccountID string `json:"id"`
+				Status    string `json:"status"`
+			} `json:"account"`
+			Err string `json:"error"`
+		}{}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Err)
+		assert.Equal(t, accountID, resp.Account.AccountID)
+		assert.Equal(t, "frozen", resp.Account.Status)
+
+		t.Run("invalid transition", func(t *testing.T) {
+			rr := changeStatus(t, freeze)
+			require.Equal(t, http.StatusConflict, rr.Code)
+		})
+
+		t.Run("validation error", func(t *testing.T) {
+			rr := changeStatus(t, map[string]interface{}{"status": "dormant"})
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+
+		t.Run("status changes", func(t *testing.T) {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet,
+				"/"+accountID+"/status-changes", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			accountHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var resp = struct {
+				StatusChanges []struct {
+					FromStatus string `json:"from_status"`
+					Status     string `json:"status"`
+					Reason     string `json:"reason"`
+					ChangedBy  string `json:"changed_by"`
+				} `json:"status_changes"`
+			}{}
+			err = json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+
+			require.Len(t, resp.StatusChanges, 1)
+			assert.Equal(t, "active", resp.StatusChanges[0].FromStatus)
+			assert.Equal(t, "frozen", resp.StatusChanges[0].Status)
+			assert.Equal(t, "suspicious activity", resp.StatusChanges[0].Reason)
+			assert.Equal(t, "compliance", resp.StatusChanges[0].ChangedBy)
+		})
+	})
+
+	t.Run("set overdraft limit", func(t *testing.T) {
+		setLimit := func(t *testing.T, req map[string]interface{}) *httptest.ResponseRecorder {
+			b, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPut,
+				"/"+accountID+"/overdraft-limit", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			accountHandler.ServeHTTP(rr, httpReq)
+			return rr
+		}
+
+		rr := setLimit(t, map[string]interface{}{"overdraft_limit": "100"})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp = struct {
+			Account struct {
+				OverdraftLimit  string `json:"overdraft_limit"`
+				AvailableCredit string `json:"available_credit"`
+			} `json:"account"`
+			Err string `json:"error"`
+		}{}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Err)
+		assert.Equal(t, "100", resp.Account.OverdraftLimit)
+		assert.Equal(t, "100", resp.Account.AvailableCredit)
+
+		t.Run("validation error", func(t *testing.T) {
+			rr := setLimit(t, map[string]interface{}{"overdraft_limit": "-1"})
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+	})
 }
@@ -2,6 +2,7 @@ package balance
 
 import (
 	"context"
+	"time"
 
 	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/etc/tx"
@@ -11,6 +12,15 @@ import (
 type Repository interface {
 	// BeginTx begins a new transaction
 	BeginTx(context.Context) (tx.Tx, error)
-	// GetAccntBal retreives the account balance within tx
-	GetAccntBal(context.Context, tx.Tx, account.AccountID) (*account.Balance, error)
+	// GetAccntBal retrieves the account balance without locking the account
+	GetAccntBal(context.Context, account.AccountID) (*account.Balance, error)
+	// GetAccntBalForUpdate retrieves the account balance within tx,
+	// the account is locked until the tx is committed or rolled back
+	GetAccntBalForUpdate(context.Context, tx.Tx, account.AccountID) (*account.Balance, error)
+	// GetAccntBalAsOf retrieves the account balance from the postings made
+	// before the time. The holds aren't kept in history, hence, the
+	// available balance is the same as the current balance.
+	GetAccntBalAsOf(context.Context, account.AccountID, time.Time) (*account.Balance, error)
+	// LockAccnts locks the accounts within tx in a deterministic order
+	LockAccnts(context.Context, tx.Tx, ...account.AccountID) error
 }
@@ -2,18 +2,19 @@ package balance
 
 import (
 	"context"
+	"time"
 
 	"github.com/pkg/errors"
-	"go.uber.org/multierr"
 
 	"github.com/stevenferrer/kalupi/account"
-	"github.com/stevenferrer/kalupi/etc/tx"
 )
 
 // Service is an account balance service
 type Service interface {
 	// GetAccntBal retrieives the account balance
 	GetAccntBal(context.Context, account.AccountID) (*account.Balance, error)
+	// GetAccntBalAsOf retrieves the account balance at the time
+	GetAccntBalAsOf(context.Context, account.AccountID, time.Time) (*account.Balance, error)
 }
 
 // service an a balance service implementation
@@ -28,30 +29,25 @@ func NewService(balRepo Repository) Service {
 	return &service{balRepo: balRepo}
 }
 
-// GetAccntBal retreives the account balance
-func (s *service) GetAccntBal(ctx context.Context, accntID account.AccountID) (accntBal *account.Balance, err error) {
-	var tx tx.Tx
-	tx, err = s.balRepo.BeginTx(ctx)
+// GetAccntBal retreives the account balance, the account isn't
+// locked so that the reads don't wait for the postings
+func (s *service) GetAccntBal(ctx context.Context, accntID account.AccountID) (*account.Balance, error) {
+	accntBal, err := s.balRepo.GetAccntBal(ctx, accntID)
 	if err != nil {
-		return nil, errors.Wrap(err, "begin tx")
+		return nil, errors.Wrap(err, "get accnt bal")
 	}
-	defer func() {
-		// rollback if there are errors
-		if err != nil {
-			_ = tx.Rollback()
-			return
-		}
-
-		// commit if no errors
-		if commitErr := tx.Commit(); commitErr != nil {
-			err = multierr.Combine(err, commitErr)
-		}
-	}()
-
-	accntBal, err = s.balRepo.GetAccntBal(ctx, tx, accntID)
+
+	return accntBal, nil
+}
+
+// GetAccntBalAsOf retrieves the account balance from the postings made before
+// the time i.e. the balance right before the time, it's consistent with the
+// timestamps of the account transactions
+func (s *service) GetAccntBalAsOf(ctx context.Context, accntID account.AccountID,
+	asOf time.Time) (*account.Balance, error) {
+	accntBal, err := s.balRepo.GetAccntBalAsOf(ctx, accntID, asOf.UTC())
 	if err != nil {
-		err = errors.Wrap(err, "get accnt bal")
-		return
+		return nil, errors.Wrap(err, "get accnt bal as of")
 	}
 
 	return accntBal, nil
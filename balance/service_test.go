@@ -3,6 +3,7 @@ package balance_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -11,28 +12,24 @@ import (
 	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/balance"
 	"github.com/stevenferrer/kalupi/currency"
-	"github.com/stevenferrer/kalupi/etc/txdb"
+	"github.com/stevenferrer/kalupi/etc/teststore"
 	"github.com/stevenferrer/kalupi/ledger"
-	"github.com/stevenferrer/kalupi/postgres"
 	"github.com/stevenferrer/kalupi/transaction"
 )
 
 func TestBalanceService(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
-
-	err := postgres.Migrate(db)
-	require.NoError(t, err)
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
 
 	ctx := context.TODO()
 
 	// setup accounts and ledgers
-	accountRepo := postgres.NewAccountRepository(db)
+	accountRepo := store.AccountRepo
 	johnDoe := account.Account{
 		AccountID: account.AccountID("johndoe"),
 		Currency:  currency.USD,
 	}
-	_, err = accountRepo.CreateAccount(ctx, johnDoe)
+	_, err := accountRepo.CreateAccount(ctx, johnDoe)
 	require.NoError(t, err)
 	maryJane := account.Account{
 		AccountID: account.AccountID("maryjane"),
@@ -41,34 +38,34 @@ func TestBalanceService(t *testing.T) {
 	_, err = accountRepo.CreateAccount(ctx, maryJane)
 	require.NoError(t, err)
 
-	ledgerRepo := postgres.NewLedgerRepository(db)
-	ledgerService := ledger.NewService(ledgerRepo)
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
 	err = ledgerService.CreateCashLedgers(ctx)
 	require.NoError(t, err)
 
-	balRepo := postgres.NewBalanceRepository(db)
+	balRepo := store.BalRepo
 	balService := balance.NewService(balRepo)
 
-	xactRepo := postgres.NewXactRepository(db)
+	xactRepo := store.XactRepo
 	xactService := transaction.NewService(accountRepo,
 		ledgerRepo, xactRepo, balRepo)
 
 	// john deposits 100USD
-	err = xactService.MakeDeposit(ctx, transaction.DepositXact{
+	_, err = xactService.MakeDeposit(ctx, transaction.DepositXact{
 		AccountID: johnDoe.AccountID,
 		Amount:    decimal.NewFromInt(100),
 	})
 	require.NoError(t, err)
 
 	// john withdraws 25USD
-	err = xactService.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+	_, err = xactService.MakeWithdrawal(ctx, transaction.WithdrawalXact{
 		AccountID: johnDoe.AccountID,
 		Amount:    decimal.NewFromInt(25),
 	})
 	require.NoError(t, err)
 
 	// john sends 25USD to mary
-	err = xactService.MakeTransfer(ctx, transaction.TransferXact{
+	_, err = xactService.MakeTransfer(ctx, transaction.TransferXact{
 		FromAccount: johnDoe.AccountID,
 		ToAccount:   maryJane.AccountID,
 		Amount:      decimal.NewFromInt(25),
@@ -83,4 +80,19 @@ func TestBalanceService(t *testing.T) {
 	maryBal, err := balService.GetAccntBal(ctx, maryJane.AccountID)
 	require.NoError(t, err)
 	assert.True(t, decimal.NewFromInt(25).Equal(maryBal.CurrentBal))
+
+	// the balance as of now is the same as the running balance
+	johnBalAsOf, err := balService.GetAccntBalAsOf(ctx, johnDoe.AccountID, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, johnBal.CurrentBal.Equal(johnBalAsOf.CurrentBal))
+	assert.True(t, johnBal.TotalCredit.Equal(johnBalAsOf.TotalCredit))
+	assert.True(t, johnBal.TotalDebit.Equal(johnBalAsOf.TotalDebit))
+
+	// nothing was posted an hour ago
+	johnBalAsOf, err = balService.GetAccntBalAsOf(ctx, johnDoe.AccountID, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, johnBalAsOf.CurrentBal.IsZero())
+
+	_, err = balService.GetAccntBalAsOf(ctx, account.AccountID("idontexist"), time.Now())
+	assert.ErrorIs(t, err, account.ErrAccountNotFound)
 }
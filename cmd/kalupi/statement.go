@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/statement"
+)
+
+// cmdStatement is the subcommand that writes a statement i.e.
+//
+//	kalupi statement -account johndoe -from 2021-05-01T00:00:00Z \
+//		-to 2021-06-01T00:00:00Z -format camt053 -out johndoe.xml
+const cmdStatement = "statement"
+
+// runStatement writes the statement of the account or ledger
+func runStatement(ctx context.Context, s statement.Service, args []string, w io.Writer) (err error) {
+	fs := flag.NewFlagSet(cmdStatement, flag.ContinueOnError)
+	var (
+		accntID  = fs.String("account", "", "account id")
+		ledgerNo = fs.String("ledger", "", "ledger no")
+		from     = fs.String("from", "", "inclusive start of the period, RFC 3339 timestamp")
+		to       = fs.String("to", "", "exclusive end of the period, RFC 3339 timestamp")
+		format   = fs.String("format", "json", "json, csv or camt053")
+		out      = fs.String("out", "", "output file, stdout if empty")
+	)
+
+	err = fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if (*accntID == "") == (*ledgerNo == "") {
+		return errors.New("expecting either -account or -ledger")
+	}
+
+	fromTs, err := time.Parse(time.RFC3339, *from)
+	if err != nil {
+		return fmt.Errorf("parse -from: %w", err)
+	}
+
+	toTs, err := time.Parse(time.RFC3339, *to)
+	if err != nil {
+		return fmt.Errorf("parse -to: %w", err)
+	}
+
+	f, err := statement.ParseFormat(*format)
+	if err != nil {
+		return fmt.Errorf("parse -format: %w", err)
+	}
+
+	var st *statement.Statement
+	if *accntID != "" {
+		st, err = s.GetStatement(ctx, account.AccountID(*accntID), fromTs, toTs)
+	} else {
+		st, err = s.GetLedgerStatement(ctx, ledger.LedgerNo(*ledgerNo), fromTs, toTs)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = file
+	}
+
+	switch f {
+	case statement.FormatCSV:
+		return st.WriteCSV(w)
+	case statement.FormatCamt053:
+		msgID, err := statement.NewMsgID()
+		if err != nil {
+			return err
+		}
+		return st.WriteCamt053(w, msgID, time.Now())
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(st)
+	}
+}
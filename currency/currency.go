@@ -5,29 +5,65 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"sort"
+
+	"github.com/shopspring/decimal"
 )
 
-// Currency is a currency
+// Currency is an ISO 4217 currency, the value is the numeric code
 type Currency int
 
-// List of supported currencies
-const (
-	USD Currency = iota + 1
-	// EUR
-)
+// NoMinorUnits is the minor units of currencies where
+// the minor unit is not applicable i.e. XAU (gold)
+const NoMinorUnits = -1
+
+// details is the ISO 4217 details of a currency
+type details struct {
+	code       string
+	minorUnits int
+	name       string
+}
 
 // String implements Stringer
 func (c Currency) String() string {
-	return [...]string{
-		"invalid",
-		"USD",
-		// "EUR",
-	}[c]
+	d, ok := registry[c]
+	if !ok {
+		return "invalid"
+	}
+
+	return d.code
+}
+
+// Numeric returns the ISO 4217 numeric code
+func (c Currency) Numeric() int {
+	return int(c)
+}
+
+// MinorUnits returns the number of digits after the decimal separator
+func (c Currency) MinorUnits() int {
+	return registry[c].minorUnits
 }
 
-// IsValid returns true if currency is valid
+// Name returns the currency name
+func (c Currency) Name() string {
+	return registry[c].name
+}
+
+// IsValid returns true if currency is a known ISO 4217 currency
 func (c Currency) IsValid() bool {
-	return c != Currency(0)
+	_, ok := registry[c]
+	return ok
+}
+
+// IsValidAmount returns true if the amount doesn't have
+// more decimal places than the currency's minor units
+func (c Currency) IsValidAmount(amount decimal.Decimal) bool {
+	minorUnits := c.MinorUnits()
+	if minorUnits == NoMinorUnits {
+		return true
+	}
+
+	return amount.Equal(amount.Truncate(int32(minorUnits)))
 }
 
 // Value implements driver.Valuer interface
@@ -53,16 +89,18 @@ func (c *Currency) Scan(src interface{}) error {
 
 // strToCurrency takes a string and returns the Currency
 func strToCurrency(s string) Currency {
-	switch s {
-	case "USD":
-		return USD
-		// case "EUR":
-		// 	return EUR
-	}
-
-	return Currency(0)
+	return codes[s]
 }
 
+// codes maps the alphabetic codes to currencies
+var codes = func() map[string]Currency {
+	m := make(map[string]Currency, len(registry))
+	for c, d := range registry {
+		m[d.code] = c
+	}
+	return m
+}()
+
 // MarshalJSON implements the json.Marshaler interface
 func (c Currency) MarshalJSON() ([]byte, error) {
 	buf := bytes.NewBuffer([]byte(`"`))
@@ -82,3 +120,66 @@ func (c *Currency) UnmarshalJSON(data []byte) error {
 	*c = strToCurrency(s)
 	return nil
 }
+
+// Parse takes an ISO 4217 alphabetic code and returns the currency
+func Parse(code string) (Currency, error) {
+	c := strToCurrency(code)
+	if !c.IsValid() {
+		return Currency(0), ErrUnknownCurrency
+	}
+
+	return c, nil
+}
+
+// All returns all the ISO 4217 currencies sorted by code
+func All() []Currency {
+	currs := make([]Currency, 0, len(registry))
+	for c := range registry {
+		currs = append(currs, c)
+	}
+
+	sortCurrencies(currs)
+	return currs
+}
+
+// Set is a set of currencies i.e. the enabled currencies. It's
+// read-only once created, hence, it's safe for concurrent use.
+type Set struct {
+	currs map[Currency]bool
+}
+
+// NewSet returns the set of the currencies, the unknown
+// currencies are left out since they can't be supported
+func NewSet(currs ...Currency) Set {
+	m := make(map[Currency]bool, len(currs))
+	for _, c := range currs {
+		if c.IsValid() {
+			m[c] = true
+		}
+	}
+
+	return Set{currs: m}
+}
+
+// Contains returns true if the currency is in the set
+func (s Set) Contains(c Currency) bool {
+	return s.currs[c]
+}
+
+// List returns the currencies in the set sorted by code
+func (s Set) List() []Currency {
+	currs := make([]Currency, 0, len(s.currs))
+	for c := range s.currs {
+		currs = append(currs, c)
+	}
+
+	sortCurrencies(currs)
+	return currs
+}
+
+// sortCurrencies sorts the currencies by code
+func sortCurrencies(currs []Currency) {
+	sort.Slice(currs, func(i, j int) bool {
+		return currs[i].String() < currs[j].String()
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -24,10 +25,14 @@ func TestCurrency(t *testing.T) {
 				c:      currency.USD,
 				expect: "USD",
 			},
-			// {
-			// 	c:      currency.EUR,
-			// 	expect: "EUR",
-			// },
+			{
+				c:      currency.EUR,
+				expect: "EUR",
+			},
+			{
+				c:      currency.Currency(1),
+				expect: "invalid",
+			},
 		}
 
 		for _, tt := range tc {
@@ -48,10 +53,14 @@ func TestCurrency(t *testing.T) {
 				c:      currency.USD,
 				expect: true,
 			},
-			// {
-			// 	c:      currency.EUR,
-			// 	expect: true,
-			// },
+			{
+				c:      currency.EUR,
+				expect: true,
+			},
+			{
+				c:      currency.Currency(1),
+				expect: false,
+			},
 		}
 
 		for _, tt := range tc {
@@ -59,6 +68,119 @@ func TestCurrency(t *testing.T) {
 		}
 	})
 
+	t.Run("iso details", func(t *testing.T) {
+		tc := []struct {
+			c          currency.Currency
+			numeric    int
+			minorUnits int
+			name       string
+		}{
+			{
+				c:          currency.USD,
+				numeric:    840,
+				minorUnits: 2,
+				name:       "US Dollar",
+			},
+			{
+				c:          currency.JPY,
+				numeric:    392,
+				minorUnits: 0,
+				name:       "Yen",
+			},
+			{
+				c:          currency.BHD,
+				numeric:    48,
+				minorUnits: 3,
+				name:       "Bahraini Dinar",
+			},
+			{
+				c:          currency.XAU,
+				numeric:    959,
+				minorUnits: currency.NoMinorUnits,
+				name:       "Gold",
+			},
+		}
+
+		for _, tt := range tc {
+			assert.Equal(t, tt.numeric, tt.c.Numeric())
+			assert.Equal(t, tt.minorUnits, tt.c.MinorUnits())
+			assert.Equal(t, tt.name, tt.c.Name())
+		}
+	})
+
+	t.Run("is valid amount", func(t *testing.T) {
+		tc := []struct {
+			c      currency.Currency
+			amount string
+			expect bool
+		}{
+			{
+				c:      currency.USD,
+				amount: "10.25",
+				expect: true,
+			},
+			{
+				c:      currency.USD,
+				amount: "10.255",
+				expect: false,
+			},
+			{
+				c:      currency.JPY,
+				amount: "1000",
+				expect: true,
+			},
+			{
+				c:      currency.JPY,
+				amount: "1000.5",
+				expect: false,
+			},
+			{
+				c:      currency.XAU,
+				amount: "0.0001",
+				expect: true,
+			},
+		}
+
+		for _, tt := range tc {
+			amount := decimal.RequireFromString(tt.amount)
+			assert.Equal(t, tt.expect, tt.c.IsValidAmount(amount), "%s %s", tt.c, tt.amount)
+		}
+	})
+
+	t.Run("parse", func(t *testing.T) {
+		c, err := currency.Parse("EUR")
+		require.NoError(t, err)
+		assert.Equal(t, currency.EUR, c)
+
+		_, err = currency.Parse("ABC")
+		assert.ErrorIs(t, err, currency.ErrUnknownCurrency)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		currs := currency.All()
+		assert.Contains(t, currs, currency.USD)
+		assert.Contains(t, currs, currency.EUR)
+
+		for i := 1; i < len(currs); i++ {
+			assert.Less(t, currs[i-1].String(), currs[i].String())
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		set := currency.NewSet(currency.USD, currency.JPY, currency.EUR, currency.USD)
+		assert.Equal(t, []currency.Currency{currency.EUR, currency.JPY, currency.USD}, set.List())
+		assert.True(t, set.Contains(currency.USD))
+		assert.False(t, set.Contains(currency.GBP))
+
+		// unknown currencies are left out
+		set = currency.NewSet(currency.Currency(1))
+		assert.Empty(t, set.List())
+		assert.False(t, set.Contains(currency.Currency(1)))
+
+		assert.Empty(t, currency.Set{}.List())
+		assert.False(t, currency.Set{}.Contains(currency.USD))
+	})
+
 	t.Run("sql scanner", func(t *testing.T) {
 		var c currency.Currency
 		err := c.Scan("USD")
@@ -6,4 +6,6 @@ import "errors"
 var (
 	// ErrUnsupportedCurrency is an error when a currency is not supported
 	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	// ErrUnknownCurrency is an error when a currency is not in ISO 4217
+	ErrUnknownCurrency = errors.New("unknown currency")
 )
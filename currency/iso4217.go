@@ -0,0 +1,369 @@
+// List one of ISO 4217, "Current currency & funds code list".
+
+package currency
+
+// List of ISO 4217 currencies, the values are the numeric codes
+const (
+	AED Currency = 784
+	AFN Currency = 971
+	ALL Currency = 8
+	AMD Currency = 51
+	AOA Currency = 973
+	ARS Currency = 32
+	AUD Currency = 36
+	AWG Currency = 533
+	AZN Currency = 944
+	BAM Currency = 977
+	BBD Currency = 52
+	BDT Currency = 50
+	BGN Currency = 975
+	BHD Currency = 48
+	BIF Currency = 108
+	BMD Currency = 60
+	BND Currency = 96
+	BOB Currency = 68
+	BOV Currency = 984
+	BRL Currency = 986
+	BSD Currency = 44
+	BTN Currency = 64
+	BWP Currency = 72
+	BYN Currency = 933
+	BZD Currency = 84
+	CAD Currency = 124
+	CDF Currency = 976
+	CHE Currency = 947
+	CHF Currency = 756
+	CHW Currency = 948
+	CLF Currency = 990
+	CLP Currency = 152
+	CNY Currency = 156
+	COP Currency = 170
+	COU Currency = 970
+	CRC Currency = 188
+	CUC Currency = 931
+	CUP Currency = 192
+	CVE Currency = 132
+	CZK Currency = 203
+	DJF Currency = 262
+	DKK Currency = 208
+	DOP Currency = 214
+	DZD Currency = 12
+	EGP Currency = 818
+	ERN Currency = 232
+	ETB Currency = 230
+	EUR Currency = 978
+	FJD Currency = 242
+	FKP Currency = 238
+	GBP Currency = 826
+	GEL Currency = 981
+	GHS Currency = 936
+	GIP Currency = 292
+	GMD Currency = 270
+	GNF Currency = 324
+	GTQ Currency = 320
+	GYD Currency = 328
+	HKD Currency = 344
+	HNL Currency = 340
+	HTG Currency = 332
+	HUF Currency = 348
+	IDR Currency = 360
+	ILS Currency = 376
+	INR Currency = 356
+	IQD Currency = 368
+	IRR Currency = 364
+	ISK Currency = 352
+	JMD Currency = 388
+	JOD Currency = 400
+	JPY Currency = 392
+	KES Currency = 404
+	KGS Currency = 417
+	KHR Currency = 116
+	KMF Currency = 174
+	KPW Currency = 408
+	KRW Currency = 410
+	KWD Currency = 414
+	KYD Currency = 136
+	KZT Currency = 398
+	LAK Currency = 418
+	LBP Currency = 422
+	LKR Currency = 144
+	LRD Currency = 430
+	LSL Currency = 426
+	LYD Currency = 434
+	MAD Currency = 504
+	MDL Currency = 498
+	MGA Currency = 969
+	MKD Currency = 807
+	MMK Currency = 104
+	MNT Currency = 496
+	MOP Currency = 446
+	MRU Currency = 929
+	MUR Currency = 480
+	MVR Currency = 462
+	MWK Currency = 454
+	MXN Currency = 484
+	MXV Currency = 979
+	MYR Currency = 458
+	MZN Currency = 943
+	NAD Currency = 516
+	NGN Currency = 566
+	NIO Currency = 558
+	NOK Currency = 578
+	NPR Currency = 524
+	NZD Currency = 554
+	OMR Currency = 512
+	PAB Currency = 590
+	PEN Currency = 604
+	PGK Currency = 598
+	PHP Currency = 608
+	PKR Currency = 586
+	PLN Currency = 985
+	PYG Currency = 600
+	QAR Currency = 634
+	RON Currency = 946
+	RSD Currency = 941
+	RUB Currency = 643
+	RWF Currency = 646
+	SAR Currency = 682
+	SBD Currency = 90
+	SCR Currency = 690
+	SDG Currency = 938
+	SEK Currency = 752
+	SGD Currency = 702
+	SHP Currency = 654
+	SLE Currency = 925
+	SOS Currency = 706
+	SRD Currency = 968
+	SSP Currency = 728
+	STN Currency = 930
+	SVC Currency = 222
+	SYP Currency = 760
+	SZL Currency = 748
+	THB Currency = 764
+	TJS Currency = 972
+	TMT Currency = 934
+	TND Currency = 788
+	TOP Currency = 776
+	TRY Currency = 949
+	TTD Currency = 780
+	TWD Currency = 901
+	TZS Currency = 834
+	UAH Currency = 980
+	UGX Currency = 800
+	USD Currency = 840
+	USN Currency = 997
+	UYI Currency = 940
+	UYU Currency = 858
+	UYW Currency = 927
+	UZS Currency = 860
+	VED Currency = 926
+	VES Currency = 928
+	VND Currency = 704
+	VUV Currency = 548
+	WST Currency = 882
+	XAF Currency = 950
+	XAG Currency = 961
+	XAU Currency = 959
+	XBA Currency = 955
+	XBB Currency = 956
+	XBC Currency = 957
+	XBD Currency = 958
+	XCD Currency = 951
+	XCG Currency = 532
+	XDR Currency = 960
+	XOF Currency = 952
+	XPD Currency = 964
+	XPF Currency = 953
+	XPT Currency = 962
+	XSU Currency = 994
+	XTS Currency = 963
+	XUA Currency = 965
+	XXX Currency = 999
+	YER Currency = 886
+	ZAR Currency = 710
+	ZMW Currency = 967
+	ZWG Currency = 924
+)
+
+// registry contains the ISO 4217 currency details
+var registry = map[Currency]details{
+	AED: {code: "AED", minorUnits: 2, name: "UAE Dirham"},
+	AFN: {code: "AFN", minorUnits: 2, name: "Afghani"},
+	ALL: {code: "ALL", minorUnits: 2, name: "Lek"},
+	AMD: {code: "AMD", minorUnits: 2, name: "Armenian Dram"},
+	AOA: {code: "AOA", minorUnits: 2, name: "Kwanza"},
+	ARS: {code: "ARS", minorUnits: 2, name: "Argentine Peso"},
+	AUD: {code: "AUD", minorUnits: 2, name: "Australian Dollar"},
+	AWG: {code: "AWG", minorUnits: 2, name: "Aruban Florin"},
+	AZN: {code: "AZN", minorUnits: 2, name: "Azerbaijan Manat"},
+	BAM: {code: "BAM", minorUnits: 2, name: "Convertible Mark"},
+	BBD: {code: "BBD", minorUnits: 2, name: "Barbados Dollar"},
+	BDT: {code: "BDT", minorUnits: 2, name: "Taka"},
+	BGN: {code: "BGN", minorUnits: 2, name: "Bulgarian Lev"},
+	BHD: {code: "BHD", minorUnits: 3, name: "Bahraini Dinar"},
+	BIF: {code: "BIF", minorUnits: 0, name: "Burundi Franc"},
+	BMD: {code: "BMD", minorUnits: 2, name: "Bermudian Dollar"},
+	BND: {code: "BND", minorUnits: 2, name: "Brunei Dollar"},
+	BOB: {code: "BOB", minorUnits: 2, name: "Boliviano"},
+	BOV: {code: "BOV", minorUnits: 2, name: "Mvdol"},
+	BRL: {code: "BRL", minorUnits: 2, name: "Brazilian Real"},
+	BSD: {code: "BSD", minorUnits: 2, name: "Bahamian Dollar"},
+	BTN: {code: "BTN", minorUnits: 2, name: "Ngultrum"},
+	BWP: {code: "BWP", minorUnits: 2, name: "Pula"},
+	BYN: {code: "BYN", minorUnits: 2, name: "Belarusian Ruble"},
+	BZD: {code: "BZD", minorUnits: 2, name: "Belize Dollar"},
+	CAD: {code: "CAD", minorUnits: 2, name: "Canadian Dollar"},
+	CDF: {code: "CDF", minorUnits: 2, name: "Congolese Franc"},
+	CHE: {code: "CHE", minorUnits: 2, name: "WIR Euro"},
+	CHF: {code: "CHF", minorUnits: 2, name: "Swiss Franc"},
+	CHW: {code: "CHW", minorUnits: 2, name: "WIR Franc"},
+	CLF: {code: "CLF", minorUnits: 4, name: "Unidad de Fomento"},
+	CLP: {code: "CLP", minorUnits: 0, name: "Chilean Peso"},
+	CNY: {code: "CNY", minorUnits: 2, name: "Yuan Renminbi"},
+	COP: {code: "COP", minorUnits: 2, name: "Colombian Peso"},
+	COU: {code: "COU", minorUnits: 2, name: "Unidad de Valor Real"},
+	CRC: {code: "CRC", minorUnits: 2, name: "Costa Rican Colon"},
+	CUC: {code: "CUC", minorUnits: 2, name: "Peso Convertible"},
+	CUP: {code: "CUP", minorUnits: 2, name: "Cuban Peso"},
+	CVE: {code: "CVE", minorUnits: 2, name: "Cabo Verde Escudo"},
+	CZK: {code: "CZK", minorUnits: 2, name: "Czech Koruna"},
+	DJF: {code: "DJF", minorUnits: 0, name: "Djibouti Franc"},
+	DKK: {code: "DKK", minorUnits: 2, name: "Danish Krone"},
+	DOP: {code: "DOP", minorUnits: 2, name: "Dominican Peso"},
+	DZD: {code: "DZD", minorUnits: 2, name: "Algerian Dinar"},
+	EGP: {code: "EGP", minorUnits: 2, name: "Egyptian Pound"},
+	ERN: {code: "ERN", minorUnits: 2, name: "Nakfa"},
+	ETB: {code: "ETB", minorUnits: 2, name: "Ethiopian Birr"},
+	EUR: {code: "EUR", minorUnits: 2, name: "Euro"},
+	FJD: {code: "FJD", minorUnits: 2, name: "Fiji Dollar"},
+	FKP: {code: "FKP", minorUnits: 2, name: "Falkland Islands Pound"},
+	GBP: {code: "GBP", minorUnits: 2, name: "Pound Sterling"},
+	GEL: {code: "GEL", minorUnits: 2, name: "Lari"},
+	GHS: {code: "GHS", minorUnits: 2, name: "Ghana Cedi"},
+	GIP: {code: "GIP", minorUnits: 2, name: "Gibraltar Pound"},
+	GMD: {code: "GMD", minorUnits: 2, name: "Dalasi"},
+	GNF: {code: "GNF", minorUnits: 0, name: "Guinean Franc"},
+	GTQ: {code: "GTQ", minorUnits: 2, name: "Quetzal"},
+	GYD: {code: "GYD", minorUnits: 2, name: "Guyana Dollar"},
+	HKD: {code: "HKD", minorUnits: 2, name: "Hong Kong Dollar"},
+	HNL: {code: "HNL", minorUnits: 2, name: "Lempira"},
+	HTG: {code: "HTG", minorUnits: 2, name: "Gourde"},
+	HUF: {code: "HUF", minorUnits: 2, name: "Forint"},
+	IDR: {code: "IDR", minorUnits: 2, name: "Rupiah"},
+	ILS: {code: "ILS", minorUnits: 2, name: "New Israeli Sheqel"},
+	INR: {code: "INR", minorUnits: 2, name: "Indian Rupee"},
+	IQD: {code: "IQD", minorUnits: 3, name: "Iraqi Dinar"},
+	IRR: {code: "IRR", minorUnits: 2, name: "Iranian Rial"},
+	ISK: {code: "ISK", minorUnits: 0, name: "Iceland Krona"},
+	JMD: {code: "JMD", minorUnits: 2, name: "Jamaican Dollar"},
+	JOD: {code: "JOD", minorUnits: 3, name: "Jordanian Dinar"},
+	JPY: {code: "JPY", minorUnits: 0, name: "Yen"},
+	KES: {code: "KES", minorUnits: 2, name: "Kenyan Shilling"},
+	KGS: {code: "KGS", minorUnits: 2, name: "Som"},
+	KHR: {code: "KHR", minorUnits: 2, name: "Riel"},
+	KMF: {code: "KMF", minorUnits: 0, name: "Comorian Franc"},
+	KPW: {code: "KPW", minorUnits: 2, name: "North Korean Won"},
+	KRW: {code: "KRW", minorUnits: 0, name: "Won"},
+	KWD: {code: "KWD", minorUnits: 3, name: "Kuwaiti Dinar"},
+	KYD: {code: "KYD", minorUnits: 2, name: "Cayman Islands Dollar"},
+	KZT: {code: "KZT", minorUnits: 2, name: "Tenge"},
+	LAK: {code: "LAK", minorUnits: 2, name: "Lao Kip"},
+	LBP: {code: "LBP", minorUnits: 2, name: "Lebanese Pound"},
+	LKR: {code: "LKR", minorUnits: 2, name: "Sri Lanka Rupee"},
+	LRD: {code: "LRD", minorUnits: 2, name: "Liberian Dollar"},
+	LSL: {code: "LSL", minorUnits: 2, name: "Loti"},
+	LYD: {code: "LYD", minorUnits: 3, name: "Libyan Dinar"},
+	MAD: {code: "MAD", minorUnits: 2, name: "Moroccan Dirham"},
+	MDL: {code: "MDL", minorUnits: 2, name: "Moldovan Leu"},
+	MGA: {code: "MGA", minorUnits: 2, name: "Malagasy Ariary"},
+	MKD: {code: "MKD", minorUnits: 2, name: "Denar"},
+	MMK: {code: "MMK", minorUnits: 2, name: "Kyat"},
+	MNT: {code: "MNT", minorUnits: 2, name: "Tugrik"},
+	MOP: {code: "MOP", minorUnits: 2, name: "Pataca"},
+	MRU: {code: "MRU", minorUnits: 2, name: "Ouguiya"},
+	MUR: {code: "MUR", minorUnits: 2, name: "Mauritius Rupee"},
+	MVR: {code: "MVR", minorUnits: 2, name: "Rufiyaa"},
+	MWK: {code: "MWK", minorUnits: 2, name: "Malawi Kwacha"},
+	MXN: {code: "MXN", minorUnits: 2, name: "Mexican Peso"},
+	MXV: {code: "MXV", minorUnits: 2, name: "Mexican Unidad de Inversion (UDI)"},
+	MYR: {code: "MYR", minorUnits: 2, name: "Malaysian Ringgit"},
+	MZN: {code: "MZN", minorUnits: 2, name: "Mozambique Metical"},
+	NAD: {code: "NAD", minorUnits: 2, name: "Namibia Dollar"},
+	NGN: {code: "NGN", minorUnits: 2, name: "Naira"},
+	NIO: {code: "NIO", minorUnits: 2, name: "Cordoba Oro"},
+	NOK: {code: "NOK", minorUnits: 2, name: "Norwegian Krone"},
+	NPR: {code: "NPR", minorUnits: 2, name: "Nepalese Rupee"},
+	NZD: {code: "NZD", minorUnits: 2, name: "New Zealand Dollar"},
+	OMR: {code: "OMR", minorUnits: 3, name: "Rial Omani"},
+	PAB: {code: "PAB", minorUnits: 2, name: "Balboa"},
+	PEN: {code: "PEN", minorUnits: 2, name: "Sol"},
+	PGK: {code: "PGK", minorUnits: 2, name: "Kina"},
+	PHP: {code: "PHP", minorUnits: 2, name: "Philippine Peso"},
+	PKR: {code: "PKR", minorUnits: 2, name: "Pakistan Rupee"},
+	PLN: {code: "PLN", minorUnits: 2, name: "Zloty"},
+	PYG: {code: "PYG", minorUnits: 0, name: "Guarani"},
+	QAR: {code: "QAR", minorUnits: 2, name: "Qatari Rial"},
+	RON: {code: "RON", minorUnits: 2, name: "Romanian Leu"},
+	RSD: {code: "RSD", minorUnits: 2, name: "Serbian Dinar"},
+	RUB: {code: "RUB", minorUnits: 2, name: "Russian Ruble"},
+	RWF: {code: "RWF", minorUnits: 0, name: "Rwanda Franc"},
+	SAR: {code: "SAR", minorUnits: 2, name: "Saudi Riyal"},
+	SBD: {code: "SBD", minorUnits: 2, name: "Solomon Islands Dollar"},
+	SCR: {code: "SCR", minorUnits: 2, name: "Seychelles Rupee"},
+	SDG: {code: "SDG", minorUnits: 2, name: "Sudanese Pound"},
+	SEK: {code: "SEK", minorUnits: 2, name: "Swedish Krona"},
+	SGD: {code: "SGD", minorUnits: 2, name: "Singapore Dollar"},
+	SHP: {code: "SHP", minorUnits: 2, name: "Saint Helena Pound"},
+	SLE: {code: "SLE", minorUnits: 2, name: "Leone"},
+	SOS: {code: "SOS", minorUnits: 2, name: "Somali Shilling"},
+	SRD: {code: "SRD", minorUnits: 2, name: "Surinam Dollar"},
+	SSP: {code: "SSP", minorUnits: 2, name: "South Sudanese Pound"},
+	STN: {code: "STN", minorUnits: 2, name: "Dobra"},
+	SVC: {code: "SVC", minorUnits: 2, name: "El Salvador Colon"},
+	SYP: {code: "SYP", minorUnits: 2, name: "Syrian Pound"},
+	SZL: {code: "SZL", minorUnits: 2, name: "Lilangeni"},
+	THB: {code: "THB", minorUnits: 2, name: "Baht"},
+	TJS: {code: "TJS", minorUnits: 2, name: "Somoni"},
+	TMT: {code: "TMT", minorUnits: 2, name: "Turkmenistan New Manat"},
+	TND: {code: "TND", minorUnits: 3, name: "Tunisian Dinar"},
+	TOP: {code: "TOP", minorUnits: 2, name: "Pa'anga"},
+	TRY: {code: "TRY", minorUnits: 2, name: "Turkish Lira"},
+	TTD: {code: "TTD", minorUnits: 2, name: "Trinidad and Tobago Dollar"},
+	TWD: {code: "TWD", minorUnits: 2, name: "New Taiwan Dollar"},
+	TZS: {code: "TZS", minorUnits: 2, name: "Tanzanian Shilling"},
+	UAH: {code: "UAH", minorUnits: 2, name: "Hryvnia"},
+	UGX: {code: "UGX", minorUnits: 0, name: "Uganda Shilling"},
+	USD: {code: "USD", minorUnits: 2, name: "US Dollar"},
+	USN: {code: "USN", minorUnits: 2, name: "US Dollar (Next day)"},
+	UYI: {code: "UYI", minorUnits: 0, name: "Uruguay Peso en Unidades Indexadas (UI)"},
+	UYU: {code: "UYU", minorUnits: 2, name: "Peso Uruguayo"},
+	UYW: {code: "UYW", minorUnits: 4, name: "Unidad Previsional"},
+	UZS: {code: "UZS", minorUnits: 2, name: "Uzbekistan Sum"},
+	VED: {code: "VED", minorUnits: 2, name: "Bolivar Soberano"},
+	VES: {code: "VES", minorUnits: 2, name: "Bolivar Soberano"},
+	VND: {code: "VND", minorUnits: 0, name: "Dong"},
+	VUV: {code: "VUV", minorUnits: 0, name: "Vatu"},
+	WST: {code: "WST", minorUnits: 2, name: "Tala"},
+	XAF: {code: "XAF", minorUnits: 0, name: "CFA Franc BEAC"},
+	XAG: {code: "XAG", minorUnits: NoMinorUnits, name: "Silver"},
+	XAU: {code: "XAU", minorUnits: NoMinorUnits, name: "Gold"},
+	XBA: {code: "XBA", minorUnits: NoMinorUnits, name: "Bond Markets Unit European Composite Unit (EURCO)"},
+	XBB: {code: "XBB", minorUnits: NoMinorUnits, name: "Bond Markets Unit European Monetary Unit (E.M.U.-6)"},
+	XBC: {code: "XBC", minorUnits: NoMinorUnits, name: "Bond Markets Unit European Unit of Account 9 (E.U.A.-9)"},
+	XBD: {code: "XBD", minorUnits: NoMinorUnits, name: "Bond Markets Unit European Unit of Account 17 (E.U.A.-17)"},
+	XCD: {code: "XCD", minorUnits: 2, name: "East Caribbean Dollar"},
+	XCG: {code: "XCG", minorUnits: 2, name: "Caribbean Guilder"},
+	XDR: {code: "XDR", minorUnits: NoMinorUnits, name: "SDR (Special Drawing Right)"},
+	XOF: {code: "XOF", minorUnits: 0, name: "CFA Franc BCEAO"},
+	XPD: {code: "XPD", minorUnits: NoMinorUnits, name: "Palladium"},
+	XPF: {code: "XPF", minorUnits: 0, name: "CFP Franc"},
+	XPT: {code: "XPT", minorUnits: NoMinorUnits, name: "Platinum"},
+	XSU: {code: "XSU", minorUnits: NoMinorUnits, name: "Sucre"},
+	XTS: {code: "XTS", minorUnits: NoMinorUnits, name: "Codes specifically reserved for testing purposes"},
+	XUA: {code: "XUA", minorUnits: NoMinorUnits, name: "ADB Unit of Account"},
+	XXX: {code: "XXX", minorUnits: NoMinorUnits, name: "The codes assigned for transactions where no currency is involved"},
+	YER: {code: "YER", minorUnits: 2, name: "Yemeni Rial"},
+	ZAR: {code: "ZAR", minorUnits: 2, name: "Rand"},
+	ZMW: {code: "ZMW", minorUnits: 2, name: "Zambian Kwacha"},
+	ZWG: {code: "ZWG", minorUnits: 2, name: "Zimbabwe Gold"},
+}
@@ -0,0 +1,1517 @@
+// Package repotest is the conformance test suite of the repositories. A
+// storage back-end proves its compatibility by running the suite:
+//
+//	func TestRepositories(t *testing.T) {
+//		repotest.Run(t, func(t *testing.T) (*repotest.Repos, func()) {
+//			db := open()
+//			return &repotest.Repos{...}, func() { db.Close() }
+//		})
+//	}
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/balance"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
+	"github.com/stevenferrer/kalupi/outbox"
+	"github.com/stevenferrer/kalupi/schedule"
+	"github.com/stevenferrer/kalupi/transaction"
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+// Repos is the set of repositories under test
+type Repos struct {
+	AccountRepo  account.Repository
+	LedgerRepo   ledger.Repository
+	XactRepo     transaction.Repository
+	BalRepo      balance.Repository
+	ScheduleRepo schedule.Repository
+	OutboxRepo   outbox.Repository
+	WebhookRepo  webhook.Repository
+}
+
+// Factory returns an empty set of repositories and a func that releases them
+type Factory func(t *testing.T) (*Repos, func())
+
+// Run runs the conformance tests, each test uses its own set of repositories
+func Run(t *testing.T, newRepos Factory) {
+	tests := []struct {
+		name string
+		test func(*testing.T, *Repos)
+	}{
+		{"account repository", testAccountRepo},
+		{"ledger repository", testLedgerRepo},
+		{"ledger balances", testLedgerBals},
+		{"balance repository", testBalanceRepo},
+		{"xact repository", testXactRepo},
+		{"schedule repository", testScheduleRepo},
+		{"outbox repository", testOutboxRepo},
+		{"webhook repository", testWebhookRepo},
+		{"tx", testTx},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r, release := newRepos(t)
+			defer release()
+
+			tc.test(t, r)
+		})
+	}
+}
+
+// List of accounts used by the tests
+var (
+	johnDoe = account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	maryJane = account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+)
+
+// cashUSDLedger is the ledger used by the tests
+var cashUSDLedger = ledger.Ledger{
+	LedgerNo:    ledger.CashUSDLedgerNo,
+	AccountType: ledger.AccountTypeLiability,
+	Currency:    currency.USD,
+	Name:        "Cash USD",
+}
+
+// setup creates the accounts and ledger used by the tests
+func setup(t *testing.T, r *Repos) {
+	ctx := context.TODO()
+
+	for _, accnt := range []account.Account{johnDoe, maryJane} {
+		_, err := r.AccountRepo.CreateAccount(ctx, accnt)
+		require.NoError(t, err)
+	}
+
+	err := r.LedgerRepo.CreateLedgersIfNotExists(ctx, cashUSDLedger)
+	require.NoError(t, err)
+}
+
+func testAccountRepo(t *testing.T, r *Repos) {
+	ctx := context.TODO()
+
+	_, err := r.AccountRepo.CreateAccount(ctx, johnDoe)
+	require.NoError(t, err)
+
+	t.Run("get account", func(t *testing.T) {
+		accnt, err := r.AccountRepo.GetAccount(ctx, johnDoe.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, johnDoe.AccountID, accnt.AccountID)
+		assert.Equal(t, johnDoe.Currency, accnt.Currency)
+		assert.Equal(t, account.StatusActive, accnt.Status)
+	})
+
+	t.Run("account exists", func(t *testing.T) {
+		exists, err := r.AccountRepo.IsAccountExists(ctx, johnDoe.AccountID)
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = r.AccountRepo.IsAccountExists(ctx, account.AccountID("idontexist"))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("list accounts", func(t *testing.T) {
+		_, err := r.AccountRepo.CreateAccount(ctx, maryJane)
+		require.NoError(t, err)
+
+		accnts, err := r.AccountRepo.ListAccounts(ctx)
+		require.NoError(t, err)
+		require.Len(t, accnts, 2)
+
+		accntIDs := []account.AccountID{accnts[0].AccountID, accnts[1].AccountID}
+		assert.ElementsMatch(t, []account.AccountID{johnDoe.AccountID, maryJane.AccountID}, accntIDs)
+	})
+
+	t.Run("account status", func(t *testing.T) {
+		tx, err := r.BalRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		status, err := r.AccountRepo.GetAccountStatus(ctx, tx, maryJane.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, account.StatusActive, status)
+
+		err = r.AccountRepo.UpdateAccountStatus(ctx, tx, account.StatusChange{
+			AccountID:  maryJane.AccountID,
+			FromStatus: account.StatusActive,
+			Status:     account.StatusFrozen,
+			Reason:     "suspicious activity",
+			ChangedBy:  "compliance",
+		})
+		require.NoError(t, err)
+
+		status, err = r.AccountRepo.GetAccountStatus(ctx, tx, maryJane.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, account.StatusFrozen, status)
+
+		_, err = r.AccountRepo.GetAccountStatus(ctx, tx, account.AccountID("idontexist"))
+		assert.ErrorIs(t, err, account.ErrAccountNotFound)
+
+		require.NoError(t, tx.Commit())
+
+		accnt, err := r.AccountRepo.GetAccount(ctx, maryJane.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, account.StatusFrozen, accnt.Status)
+
+		scs, err := r.AccountRepo.ListStatusChanges(ctx, maryJane.AccountID)
+		require.NoError(t, err)
+		require.Len(t, scs, 1)
+		assert.Equal(t, maryJane.AccountID, scs[0].AccountID)
+		assert.Equal(t, account.StatusActive, scs[0].FromStatus)
+		assert.Equal(t, account.StatusFrozen, scs[0].Status)
+		assert.Equal(t, "suspicious activity", scs[0].Reason)
+		assert.Equal(t, "compliance", scs[0].ChangedBy)
+		assert.NotNil(t, scs[0].Ts)
+
+		scs, err = r.AccountRepo.ListStatusChanges(ctx, johnDoe.AccountID)
+		require.NoError(t, err)
+		assert.Len(t, scs, 0)
+	})
+
+	t.Run("overdraft limit", func(t *testing.T) {
+		janeDoe := account.Account{
+			AccountID:      account.AccountID("janedoe"),
+			Currency:       currency.USD,
+			OverdraftLimit: decimal.RequireFromString("50.25"),
+		}
+		_, err := r.AccountRepo.CreateAccount(ctx, janeDoe)
+		require.NoError(t, err)
+
+		accnt, err := r.AccountRepo.GetAccount(ctx, janeDoe.AccountID)
+		require.NoError(t, err)
+		assert.True(t, janeDoe.OverdraftLimit.Equal(accnt.OverdraftLimit))
+
+		tx, err := r.BalRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		limit, err := r.AccountRepo.GetOverdraftLimit(ctx, tx, janeDoe.AccountID)
+		require.NoError(t, err)
+		assert.True(t, janeDoe.OverdraftLimit.Equal(limit))
+
+		err = r.AccountRepo.UpdateOverdraftLimit(ctx, tx, janeDoe.AccountID, decimal.NewFromInt(100))
+		require.NoError(t, err)
+
+		limit, err = r.AccountRepo.GetOverdraftLimit(ctx, tx, janeDoe.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(100).Equal(limit))
+
+		_, err = r.AccountRepo.GetOverdraftLimit(ctx, tx, account.AccountID("idontexist"))
+		assert.ErrorIs(t, err, account.ErrAccountNotFound)
+
+		require.NoError(t, tx.Commit())
+
+		accnt, err = r.AccountRepo.GetAccount(ctx, janeDoe.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(100).Equal(accnt.OverdraftLimit))
+
+		// accounts are created without an overdraft by default
+		accnt, err = r.AccountRepo.GetAccount(ctx, johnDoe.AccountID)
+		require.NoError(t, err)
+		assert.True(t, accnt.OverdraftLimit.IsZero())
+	})
+
+	// keep this last, a failed statement may abort the
+	// underlying transaction of some back-ends i.e. txdb
+	t.Run("duplicate account", func(t *testing.T) {
+		_, err := r.AccountRepo.CreateAccount(ctx, johnDoe)
+		assert.Error(t, err)
+	})
+}
+
+func testLedgerRepo(t *testing.T, r *Repos) {
+	ctx := context.TODO()
+
+	t.Run("create ledgers", func(t *testing.T) {
+		err := r.LedgerRepo.CreateLedgersIfNotExists(ctx, cashUSDLedger)
+		require.NoError(t, err)
+
+		// creating the ledger again does nothing
+		err = r.LedgerRepo.CreateLedgersIfNotExists(ctx, cashUSDLedger)
+		require.NoError(t, err)
+
+		lgs, err := r.LedgerRepo.ListLedgers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, lgs, 1)
+	})
+
+	t.Run("get ledger", func(t *testing.T) {
+		lg, err := r.LedgerRepo.GetLedger(ctx, cashUSDLedger.LedgerNo)
+		require.NoError(t, err)
+		assert.Equal(t, cashUSDLedger, *lg)
+
+		_, err = r.LedgerRepo.GetLedger(ctx, ledger.LedgerNo("idontexist"))
+		assert.ErrorIs(t, err, ledger.ErrLedgerNotFound)
+	})
+}
+
+func testLedgerBals(t *testing.T, r *Repos) {
+	setup(t, r)
+
+	ctx := context.TODO()
+
+	fxUSDLedger := ledger.Ledger{
+		LedgerNo:    ledger.LedgerNo("200-USD"),
+		AccountType: ledger.AccountTypeLiability,
+		Currency:    currency.USD,
+		Name:        "FX Position USD",
+	}
+	err := r.LedgerRepo.CreateLedgersIfNotExists(ctx, fxUSDLedger)
+	require.NoError(t, err)
+
+	createXact(t, r, newXact(t, transaction.XactTypeExtDeposit, johnDoe.AccountID, 100))
+	createXact(t, r, newXact(t, transaction.XactTypeExtWithdrawal, johnDoe.AccountID, 10))
+
+	sndXact := newXact(t, transaction.XactTypeExtSndTransfer, johnDoe.AccountID, 25)
+	rcvXact := newXact(t, transaction.XactTypeExtRcvTransfer, maryJane.AccountID, 25)
+	rcvXact.XactNo = sndXact.XactNo
+	createXact(t, r, sndXact, rcvXact)
+
+	tx, err := r.XactRepo.BeginTx(ctx)
+	require.NoError(t, err)
+	for _, lx := range []transaction.LedgerXact{
+		{
+			XactNo:   sndXact.XactNo,
+			LedgerNo: cashUSDLedger.LedgerNo,
+			XactType: transaction.XactTypeDebit,
+			Amount:   decimal.NewFromInt(5),
+		},
+		{
+			XactNo:   sndXact.XactNo,
+			LedgerNo: fxUSDLedger.LedgerNo,
+			XactType: transaction.XactTypeCredit,
+			Amount:   decimal.NewFromInt(5),
+		},
+	} {
+		err = r.XactRepo.CreateLedgerXact(ctx, tx, lx)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tx.Commit())
+
+	later := time.Now().Add(time.Hour)
+
+	t.Run("get ledger bal", func(t *testing.T) {
+		lgBal, err := r.LedgerRepo.GetLedgerBal(ctx, cashUSDLedger.LedgerNo, later)
+		require.NoError(t, err)
+		assert.Equal(t, cashUSDLedger.LedgerNo, lgBal.LedgerNo)
+		assert.Equal(t, currency.USD, lgBal.Currency)
+		assert.True(t, decimal.NewFromInt(130).Equal(lgBal.TotalDebit))
+		assert.True(t, decimal.NewFromInt(35).Equal(lgBal.TotalCredit))
+		assert.True(t, decimal.NewFromInt(95).Equal(lgBal.CurrentBal))
+		assert.NotNil(t, lgBal.Ts)
+
+		lgBal, err = r.LedgerRepo.GetLedgerBal(ctx, fxUSDLedger.LedgerNo, later)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(-5).Equal(lgBal.CurrentBal))
+
+		_, err = r.LedgerRepo.GetLedgerBal(ctx, ledger.LedgerNo("idontexist"), later)
+		assert.ErrorIs(t, err, ledger.ErrLedgerNotFound)
+	})
+
+	t.Run("ledger bal before the postings", func(t *testing.T) {
+		lgBal, err := r.LedgerRepo.GetLedgerBal(ctx, cashUSDLedger.LedgerNo,
+			time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.True(t, lgBal.TotalDebit.IsZero())
+		assert.True(t, lgBal.TotalCredit.IsZero())
+		assert.True(t, lgBal.CurrentBal.IsZero())
+		assert.Nil(t, lgBal.Ts)
+	})
+
+	t.Run("get trial balance", func(t *testing.T) {
+		tb, err := r.LedgerRepo.GetTrialBalance(ctx, later)
+		require.NoError(t, err)
+		assert.True(t, later.Equal(tb.AsOf))
+
+		// sorted by ledger no
+		require.Len(t, tb.Ledgers, 2)
+		assert.Equal(t, cashUSDLedger.LedgerNo, tb.Ledgers[0].LedgerNo)
+		assert.True(t, decimal.NewFromInt(95).Equal(tb.Ledgers[0].CurrentBal))
+		assert.Equal(t, fxUSDLedger.LedgerNo, tb.Ledgers[1].LedgerNo)
+		assert.True(t, decimal.NewFromInt(-5).Equal(tb.Ledgers[1].CurrentBal))
+
+		// sorted by account id
+		require.Len(t, tb.Accounts, 2)
+		john, mary := tb.Accounts[0], tb.Accounts[1]
+		assert.Equal(t, johnDoe.AccountID, john.AccountID)
+		assert.Equal(t, currency.USD, john.Currency)
+		assert.True(t, decimal.NewFromInt(100).Equal(john.TotalCredit))
+		assert.True(t, decimal.NewFromInt(35).Equal(john.TotalDebit))
+		assert.True(t, decimal.NewFromInt(65).Equal(john.CurrentBal))
+		assert.NotNil(t, john.Ts)
+		assert.Equal(t, maryJane.AccountID, mary.AccountID)
+		assert.True(t, decimal.NewFromInt(25).Equal(mary.CurrentBal))
+	})
+
+	t.Run("trial balance before the postings", func(t *testing.T) {
+		tb, err := r.LedgerRepo.GetTrialBalance(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+
+		require.Len(t, tb.Ledgers, 2)
+		for _, lgBal := range tb.Ledgers {
+			assert.True(t, lgBal.CurrentBal.IsZero())
+		}
+		assert.Len(t, tb.Accounts, 0)
+	})
+}
+
+func testBalanceRepo(t *testing.T, r *Repos) {
+	setup(t, r)
+
+	ctx := context.TODO()
+
+	t.Run("zero balance", func(t *testing.T) {
+		tx, err := r.BalRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Commit())
+		}()
+
+		// no transaction record yet
+		bal, err := r.BalRepo.GetAccntBalForUpdate(ctx, tx, johnDoe.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, johnDoe.AccountID, bal.AccountID)
+		assert.True(t, bal.TotalCredit.IsZero())
+		assert.True(t, bal.TotalDebit.IsZero())
+		assert.True(t, bal.CurrentBal.IsZero())
+		assert.True(t, bal.AvailableBal.IsZero())
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		tx, err := r.BalRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Rollback())
+		}()
+
+		_, err = r.BalRepo.GetAccntBalForUpdate(ctx, tx, account.AccountID("idontexist"))
+		assert.Error(t, err)
+	})
+
+	t.Run("lock accounts", func(t *testing.T) {
+		tx, err := r.BalRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Rollback())
+		}()
+
+		err = r.BalRepo.LockAccnts(ctx, tx, maryJane.AccountID, johnDoe.AccountID)
+		require.NoError(t, err)
+
+		err = r.BalRepo.LockAccnts(ctx, tx, account.AccountID("idontexist"))
+		assert.Error(t, err)
+	})
+
+	t.Run("running balance", func(t *testing.T) {
+		createXact(t, r, newXact(t, transaction.XactTypeExtDeposit, johnDoe.AccountID, 100))
+		createXact(t, r, newXact(t, transaction.XactTypeExtWithdrawal, johnDoe.AccountID, 30))
+
+		bal := getAccntBal(t, r, johnDoe.AccountID)
+		assert.True(t, decimal.NewFromInt(100).Equal(bal.TotalCredit))
+		assert.True(t, decimal.NewFromInt(30).Equal(bal.TotalDebit))
+		assert.True(t, decimal.NewFromInt(70).Equal(bal.CurrentBal))
+		assert.True(t, decimal.NewFromInt(70).Equal(bal.AvailableBal))
+	})
+
+	t.Run("available balance", func(t *testing.T) {
+		now := time.Now()
+		active := newHold(t, johnDoe.AccountID, 20, now.Add(time.Hour))
+		expired := newHold(t, johnDoe.AccountID, 5, now.Add(-time.Hour))
+		voided := newHold(t, johnDoe.AccountID, 10, now.Add(time.Hour))
+
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		for _, hold := range []transaction.Hold{active, expired, voided} {
+			err = r.XactRepo.CreateHold(ctx, tx, hold)
+			require.NoError(t, err)
+		}
+
+		voided.Status = transaction.HoldStatusVoided
+		err = r.XactRepo.UpdateHold(ctx, tx, voided)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		// only the active and unexpired holds are deducted
+		bal := getAccntBal(t, r, johnDoe.AccountID)
+		assert.True(t, decimal.NewFromInt(70).Equal(bal.CurrentBal))
+		assert.True(t, decimal.NewFromInt(50).Equal(bal.AvailableBal))
+	})
+
+	t.Run("balance without lock", func(t *testing.T) {
+		locked := getAccntBal(t, r, johnDoe.AccountID)
+
+		bal, err := r.BalRepo.GetAccntBal(ctx, johnDoe.AccountID)
+		require.NoError(t, err)
+		assert.Equal(t, johnDoe.AccountID, bal.AccountID)
+		assert.True(t, locked.TotalCredit.Equal(bal.TotalCredit))
+		assert.True(t, locked.TotalDebit.Equal(bal.TotalDebit))
+		assert.True(t, locked.CurrentBal.Equal(bal.CurrentBal))
+		assert.True(t, locked.AvailableBal.Equal(bal.AvailableBal))
+
+		_, err = r.BalRepo.GetAccntBal(ctx, account.AccountID("idontexist"))
+		assert.ErrorIs(t, err, account.ErrAccountNotFound)
+	})
+
+	t.Run("balance as of", func(t *testing.T) {
+		running := getAccntBal(t, r, johnDoe.AccountID)
+
+		bal, err := r.BalRepo.GetAccntBalAsOf(ctx, johnDoe.AccountID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, johnDoe.AccountID, bal.AccountID)
+		assert.True(t, running.TotalCredit.Equal(bal.TotalCredit))
+		assert.True(t, running.TotalDebit.Equal(bal.TotalDebit))
+		assert.True(t, running.CurrentBal.Equal(bal.CurrentBal))
+		// the holds aren't kept in history
+		assert.True(t, bal.CurrentBal.Equal(bal.AvailableBal))
+		require.NotNil(t, bal.Ts)
+		assert.True(t, running.Ts.Equal(*bal.Ts))
+
+		// before the postings
+		bal, err = r.BalRepo.GetAccntBalAsOf(ctx, johnDoe.AccountID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.True(t, bal.CurrentBal.IsZero())
+		assert.Nil(t, bal.Ts)
+
+		// no postings at all
+		bal, err = r.BalRepo.GetAccntBalAsOf(ctx, maryJane.AccountID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, bal.CurrentBal.IsZero())
+
+		_, err = r.BalRepo.GetAccntBalAsOf(ctx, account.AccountID("idontexist"), time.Now())
+		assert.ErrorIs(t, err, account.ErrAccountNotFound)
+	})
+}
+
+func testXactRepo(t *testing.T, r *Repos) {
+	setup(t, r)
+
+	ctx := context.TODO()
+
+	dpXact := newXact(t, transaction.XactTypeExtDeposit, johnDoe.AccountID, 100)
+	createXact(t, r, dpXact)
+
+	wdXact := newXact(t, transaction.XactTypeExtWithdrawal, johnDoe.AccountID, 10)
+	createXact(t, r, wdXact)
+
+	sndXact := newXact(t, transaction.XactTypeExtSndTransfer, johnDoe.AccountID, 25)
+	rcvXact := newXact(t, transaction.XactTypeExtRcvTransfer, maryJane.AccountID, 25)
+	rcvXact.XactNo = sndXact.XactNo
+	createXact(t, r, sndXact, rcvXact)
+
+	t.Run("list xacts", func(t *testing.T) {
+		xacts, err := r.XactRepo.ListXacts(ctx)
+		require.NoError(t, err)
+		require.Len(t, xacts, 4)
+
+		// sorted by timestamp
+		for i := 1; i < len(xacts); i++ {
+			assert.False(t, xacts[i].Ts.Before(*xacts[i-1].Ts))
+		}
+	})
+
+	t.Run("list transfers", func(t *testing.T) {
+		xacts, err := r.XactRepo.ListTransfers(ctx)
+		require.NoError(t, err)
+		require.Len(t, xacts, 2)
+
+		for _, xact := range xacts {
+			assert.Equal(t, sndXact.XactNo, xact.XactNo)
+		}
+	})
+
+	t.Run("get xact", func(t *testing.T) {
+		xact, err := r.XactRepo.GetXact(ctx, sndXact.XactNo)
+		require.NoError(t, err)
+		assert.Equal(t, sndXact.XactNo, xact.XactNo)
+		assert.Len(t, xact.Legs, 2)
+		assert.NotNil(t, xact.Ts)
+
+		_, err = r.XactRepo.GetXact(ctx, transaction.XactNo("idontexist"))
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+	})
+
+	t.Run("ledger xacts", func(t *testing.T) {
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Commit())
+		}()
+
+		err = r.XactRepo.CreateLedgerXact(ctx, tx, transaction.LedgerXact{
+			XactNo:   wdXact.XactNo,
+			LedgerNo: cashUSDLedger.LedgerNo,
+			XactType: transaction.XactTypeCredit,
+			Amount:   decimal.NewFromInt(10),
+			Desc:     "Withdrawal fee",
+		})
+		require.NoError(t, err)
+
+		legs, err := r.XactRepo.ListXactLegs(ctx, tx, wdXact.XactNo)
+		require.NoError(t, err)
+		assert.Len(t, legs, 1)
+
+		lgLegs, err := r.XactRepo.ListLedgerXactLegs(ctx, tx, wdXact.XactNo)
+		require.NoError(t, err)
+		require.Len(t, lgLegs, 1)
+		assert.True(t, decimal.NewFromInt(10).Equal(lgLegs[0].Amount))
+	})
+
+	t.Run("list ledger postings", func(t *testing.T) {
+		from, to := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+		postings, err := r.XactRepo.ListLedgerPostings(ctx, cashUSDLedger.LedgerNo, from, to)
+		require.NoError(t, err)
+		// the account transactions and the withdrawal fee ledger transaction
+		require.Len(t, postings, 5)
+
+		var lgXacts int
+		for i, posting := range postings {
+			assert.Equal(t, cashUSDLedger.LedgerNo, posting.LedgerNo)
+			if i > 0 {
+				assert.False(t, posting.Ts.Before(*postings[i-1].Ts))
+			}
+
+			if posting.AccountID == "" {
+				lgXacts++
+				assert.Equal(t, wdXact.XactNo, posting.XactNo)
+				assert.Equal(t, transaction.XactTypeExt(0), posting.XactTypeExt)
+				assert.Equal(t, transaction.XactTypeCredit, posting.XactType)
+				assert.Equal(t, "Withdrawal fee", posting.Desc)
+			}
+		}
+		assert.Equal(t, 1, lgXacts)
+
+		postings, err = r.XactRepo.ListLedgerPostings(ctx, cashUSDLedger.LedgerNo, to, to.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Empty(t, postings)
+	})
+
+	t.Run("idempotency keys", func(t *testing.T) {
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Commit())
+		}()
+
+		ik := transaction.IdempotencyKey{
+			Key:         "key1",
+			Fingerprint: "fingerprint",
+			XactNo:      dpXact.XactNo,
+		}
+		created, err := r.XactRepo.CreateIdempotencyKeyIfNotExists(ctx, tx, ik)
+		require.NoError(t, err)
+		assert.True(t, created)
+
+		created, err = r.XactRepo.CreateIdempotencyKeyIfNotExists(ctx, tx, ik)
+		require.NoError(t, err)
+		assert.False(t, created)
+
+		got, err := r.XactRepo.GetIdempotencyKey(ctx, tx, ik.Key)
+		require.NoError(t, err)
+		assert.Equal(t, ik.Fingerprint, got.Fingerprint)
+		assert.Equal(t, ik.XactNo, got.XactNo)
+
+		_, err = r.XactRepo.GetIdempotencyKey(ctx, tx, "key2")
+		assert.ErrorIs(t, err, transaction.ErrIdempotencyKeyNotFound)
+	})
+
+	t.Run("fx transfers", func(t *testing.T) {
+		fxTr := transaction.FXTransfer{
+			XactNo:       sndXact.XactNo,
+			FromAccount:  johnDoe.AccountID,
+			ToAccount:    maryJane.AccountID,
+			FromCurrency: currency.USD,
+			ToCurrency:   currency.EUR,
+			FromAmount:   decimal.NewFromInt(25),
+			ToAmount:     decimal.NewFromInt(23),
+			Rate:         decimal.RequireFromString("0.92"),
+		}
+
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		err = r.XactRepo.CreateFXTransfer(ctx, tx, fxTr)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		got, err := r.XactRepo.GetFXTransfer(ctx, fxTr.XactNo)
+		require.NoError(t, err)
+		assert.Equal(t, fxTr.ToCurrency, got.ToCurrency)
+		assert.True(t, fxTr.ToAmount.Equal(got.ToAmount))
+		assert.True(t, fxTr.Rate.Equal(got.Rate))
+	})
+
+	t.Run("reversals", func(t *testing.T) {
+		rfXactNo, err := transaction.NewXactNo()
+		require.NoError(t, err)
+
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Commit())
+		}()
+
+		err = r.XactRepo.CreateReversal(ctx, tx, transaction.Reversal{
+			XactNo:      rfXactNo,
+			OrigXactNo:  sndXact.XactNo,
+			XactTypeExt: transaction.XactTypeExtRefund,
+			Amount:      decimal.NewFromInt(5),
+			Reason:      "partial refund",
+		})
+		require.NoError(t, err)
+
+		revs, err := r.XactRepo.ListReversals(ctx, tx, sndXact.XactNo)
+		require.NoError(t, err)
+		require.Len(t, revs, 1)
+		assert.Equal(t, rfXactNo, revs[0].XactNo)
+		assert.Equal(t, transaction.XactTypeExtRefund, revs[0].XactTypeExt)
+		assert.True(t, decimal.NewFromInt(5).Equal(revs[0].Amount))
+
+		revs, err = r.XactRepo.ListReversals(ctx, tx, dpXact.XactNo)
+		require.NoError(t, err)
+		assert.Empty(t, revs)
+	})
+
+	t.Run("holds", func(t *testing.T) {
+		hold := newHold(t, johnDoe.AccountID, 20, time.Now().Add(time.Hour))
+
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		err = r.XactRepo.CreateHold(ctx, tx, hold)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		got, err := r.XactRepo.GetHold(ctx, hold.HoldID)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.HoldStatusActive, got.Status)
+		assert.True(t, got.CapturedAmount.IsZero())
+		assert.Empty(t, got.XactNo)
+
+		tx, err = r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		got, err = r.XactRepo.GetHoldForUpdate(ctx, tx, hold.HoldID)
+		require.NoError(t, err)
+
+		got.Status = transaction.HoldStatusCaptured
+		got.CapturedAmount = decimal.NewFromInt(15)
+		got.XactNo = wdXact.XactNo
+		err = r.XactRepo.UpdateHold(ctx, tx, *got)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		got, err = r.XactRepo.GetHold(ctx, hold.HoldID)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.HoldStatusCaptured, got.Status)
+		assert.True(t, decimal.NewFromInt(15).Equal(got.CapturedAmount))
+		assert.Equal(t, wdXact.XactNo, got.XactNo)
+
+		_, err = r.XactRepo.GetHold(ctx, transaction.HoldID("idontexist"))
+		assert.ErrorIs(t, err, transaction.ErrHoldNotFound)
+	})
+
+	t.Run("list account xacts", func(t *testing.T) {
+		for i := 1; i <= 3; i++ {
+			createXact(t, r, newXact(t, transaction.XactTypeExtDeposit, maryJane.AccountID, int64(i)))
+		}
+
+		list := func(f transaction.XactFilter) []*transaction.Transaction {
+			xacts, err := r.XactRepo.ListAccntXacts(ctx, f)
+			require.NoError(t, err)
+			return xacts
+		}
+
+		asc := list(transaction.XactFilter{AccountID: maryJane.AccountID, Sort: transaction.SortAsc, Limit: 10})
+		require.Len(t, asc, 4)
+
+		desc := list(transaction.XactFilter{AccountID: maryJane.AccountID, Sort: transaction.SortDesc, Limit: 10})
+		require.Len(t, desc, 4)
+		for i := range asc {
+			assert.Equal(t, asc[i].XactNo, desc[len(desc)-1-i].XactNo)
+		}
+
+		// paginate
+		for _, sort := range []transaction.SortDirection{transaction.SortAsc, transaction.SortDesc} {
+			page := list(transaction.XactFilter{AccountID: maryJane.AccountID, Sort: sort, Limit: 3})
+			require.Len(t, page, 3)
+
+			last := page[len(page)-1]
+			next := list(transaction.XactFilter{
+				AccountID: maryJane.AccountID,
+				Sort:      sort,
+				Limit:     3,
+				Cursor:    &transaction.Cursor{Ts: *last.Ts, XactNo: last.XactNo, LegID: last.LegID},
+			})
+			require.Len(t, next, 1)
+
+			if sort == transaction.SortAsc {
+				assert.Equal(t, asc[3].XactNo, next[0].XactNo)
+			} else {
+				assert.Equal(t, desc[3].XactNo, next[0].XactNo)
+			}
+		}
+
+		// filter
+		minAmount, maxAmount := decimal.NewFromInt(2), decimal.NewFromInt(3)
+		filtered := list(transaction.XactFilter{
+			AccountID:    maryJane.AccountID,
+			XactTypeExts: []transaction.XactTypeExt{transaction.XactTypeExtDeposit},
+			MinAmount:    &minAmount,
+			MaxAmount:    &maxAmount,
+			Limit:        10,
+		})
+		assert.Len(t, filtered, 2)
+
+		future := time.Now().Add(time.Hour)
+		assert.Empty(t, list(transaction.XactFilter{AccountID: maryJane.AccountID, From: &future, Limit: 10}))
+		assert.Len(t, list(transaction.XactFilter{AccountID: maryJane.AccountID, To: &future, Limit: 10}), 4)
+
+		// the legs of a multi-leg xact share the (ts, xact no)
+		jnXactNo, err := transaction.NewXactNo()
+		require.NoError(t, err)
+		legs := make([]transaction.Transaction, 3)
+		for i := range legs {
+			legs[i] = newXact(t, transaction.XactTypeExtJournal, maryJane.AccountID, int64(i+1))
+			legs[i].XactNo = jnXactNo
+		}
+		createXact(t, r, legs...)
+
+		// paginate a leg at a time
+		for _, sort := range []transaction.SortDirection{transaction.SortAsc, transaction.SortDesc} {
+			all := list(transaction.XactFilter{AccountID: maryJane.AccountID, Sort: sort, Limit: 10})
+			require.Len(t, all, 7)
+
+			paged := []*transaction.Transaction{}
+			f := transaction.XactFilter{AccountID: maryJane.AccountID, Sort: sort, Limit: 1}
+			for len(paged) <= len(all) {
+				page := list(f)
+				if len(page) == 0 {
+					break
+				}
+				require.Len(t, page, 1)
+
+				paged = append(paged, page[0])
+				f.Cursor = &transaction.Cursor{Ts: *page[0].Ts,
+					XactNo: page[0].XactNo, LegID: page[0].LegID}
+			}
+
+			require.Len(t, paged, len(all))
+			for i := range all {
+				assert.Equal(t, all[i].XactNo, paged[i].XactNo)
+				assert.Equal(t, all[i].LegID, paged[i].LegID)
+			}
+		}
+	})
+
+	t.Run("list transfers with fees", func(t *testing.T) {
+		trFee := newXact(t, transaction.XactTypeExtFee, johnDoe.AccountID, 1)
+		trFee.XactNo = sndXact.XactNo
+		wdFee := newXact(t, transaction.XactTypeExtFee, johnDoe.AccountID, 2)
+		wdFee.XactNo = wdXact.XactNo
+		createXact(t, r, trFee, wdFee)
+
+		// only the fees of the transfers are included
+		xacts, err := r.XactRepo.ListTransfers(ctx)
+		require.NoError(t, err)
+		require.Len(t, xacts, 3)
+
+		for _, xact := range xacts {
+			assert.Equal(t, sndXact.XactNo, xact.XactNo)
+		}
+	})
+	t.Run("outgoing usage", func(t *testing.T) {
+		getUsage := func(t *testing.T, since time.Time) *limit.Usage {
+			tx, err := r.XactRepo.BeginTx(ctx)
+			require.NoError(t, err)
+			defer func() {
+				assert.NoError(t, tx.Commit())
+			}()
+
+			usage, err := r.XactRepo.GetOutgoingUsage(ctx, tx, maryJane.AccountID, since)
+			require.NoError(t, err)
+			return usage
+		}
+
+		since := time.Now().Add(-time.Hour)
+		before := getUsage(t, since)
+
+		// only the withdrawals and the sent transfers are included
+		createXact(t, r,
+			newXact(t, transaction.XactTypeExtDeposit, maryJane.AccountID, 100),
+			newXact(t, transaction.XactTypeExtWithdrawal, maryJane.AccountID, 7),
+			newXact(t, transaction.XactTypeExtSndTransfer, maryJane.AccountID, 3),
+			newXact(t, transaction.XactTypeExtFee, maryJane.AccountID, 1),
+			newXact(t, transaction.XactTypeExtSndTransfer, johnDoe.AccountID, 5),
+		)
+
+		after := getUsage(t, since)
+		assert.True(t, decimal.NewFromInt(10).Equal(after.Amount.Sub(before.Amount)),
+			"expecting 10 got %s", after.Amount.Sub(before.Amount))
+		assert.Equal(t, 1, after.Transfers-before.Transfers)
+
+		usage := getUsage(t, time.Now().Add(time.Hour))
+		assert.True(t, usage.Amount.IsZero())
+		assert.Equal(t, 0, usage.Transfers)
+	})
+
+	t.Run("amount out of range", func(t *testing.T) {
+		before := getAccntBal(t, r, johnDoe.AccountID)
+
+		// the amounts must fit in numeric(15, 4) after rounding
+		for _, amount := range []string{"100000000000", "99999999999.99995"} {
+			xact := newXact(t, transaction.XactTypeExtDeposit, johnDoe.AccountID, 0)
+			xact.Amount = decimal.RequireFromString(amount)
+
+			tx, err := r.XactRepo.BeginTx(ctx)
+			require.NoError(t, err)
+
+			err = r.XactRepo.CreateXact(ctx, tx, xact)
+			assert.Error(t, err, amount)
+			require.NoError(t, tx.Rollback())
+		}
+
+		after := getAccntBal(t, r, johnDoe.AccountID)
+		assert.True(t, before.CurrentBal.Equal(after.CurrentBal))
+	})
+}
+
+func testScheduleRepo(t *testing.T, r *Repos) {
+	ctx := context.TODO()
+	setup(t, r)
+
+	startAt := time.Now().UTC().Truncate(time.Second).Add(time.Hour)
+	endAt := startAt.AddDate(1, 0, 0)
+	inst := schedule.Instruction{
+		InstructionID: schedule.InstructionID("inst1"),
+		FromAccount:   johnDoe.AccountID,
+		ToAccount:     maryJane.AccountID,
+		Amount:        decimal.RequireFromString("10.5"),
+		Desc:          "rent",
+		Frequency:     schedule.FrequencyMonthly,
+		StartAt:       startAt,
+		EndAt:         &endAt,
+		Status:        schedule.StatusActive,
+		NextRunAt:     startAt,
+		NextAttemptAt: startAt,
+	}
+	err := r.ScheduleRepo.CreateInstruction(ctx, inst)
+	require.NoError(t, err)
+
+	t.Run("get instruction", func(t *testing.T) {
+		got, err := r.ScheduleRepo.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, johnDoe.AccountID, got.FromAccount)
+		assert.Equal(t, maryJane.AccountID, got.ToAccount)
+		assert.True(t, inst.Amount.Equal(got.Amount))
+		assert.Equal(t, "rent", got.Desc)
+		assert.Equal(t, schedule.FrequencyMonthly, got.Frequency)
+		assert.Equal(t, schedule.StatusActive, got.Status)
+		assert.True(t, startAt.Equal(got.StartAt))
+		require.NotNil(t, got.EndAt)
+		assert.True(t, endAt.Equal(*got.EndAt))
+		assert.True(t, startAt.Equal(got.NextRunAt))
+		assert.True(t, startAt.Equal(got.NextAttemptAt))
+		assert.Zero(t, got.Attempts)
+		assert.Empty(t, got.LeaseOwner)
+		assert.Nil(t, got.LeaseExpiresAt)
+		assert.NotNil(t, got.Ts)
+
+		_, err = r.ScheduleRepo.GetInstruction(ctx, schedule.InstructionID("idontexist"))
+		assert.ErrorIs(t, err, schedule.ErrInstructionNotFound)
+	})
+
+	t.Run("list instructions", func(t *testing.T) {
+		for _, accntID := range []account.AccountID{"", johnDoe.AccountID, maryJane.AccountID} {
+			insts, err := r.ScheduleRepo.ListInstructions(ctx, accntID)
+			require.NoError(t, err)
+			require.Len(t, insts, 1)
+			assert.Equal(t, inst.InstructionID, insts[0].InstructionID)
+		}
+
+		insts, err := r.ScheduleRepo.ListInstructions(ctx, account.AccountID("idontexist"))
+		require.NoError(t, err)
+		assert.Empty(t, insts)
+	})
+
+	t.Run("update instruction", func(t *testing.T) {
+		upd := inst
+		upd.Amount = decimal.NewFromInt(20)
+		upd.Frequency = schedule.FrequencyCron
+		upd.CronExpr = "0 9 * * 1"
+		upd.EndAt = nil
+		err := r.ScheduleRepo.UpdateInstruction(ctx, upd, time.Now())
+		require.NoError(t, err)
+
+		got, err := r.ScheduleRepo.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(20).Equal(got.Amount))
+		assert.Equal(t, schedule.FrequencyCron, got.Frequency)
+		assert.Equal(t, "0 9 * * 1", got.CronExpr)
+		assert.Nil(t, got.EndAt)
+
+		upd.InstructionID = schedule.InstructionID("idontexist")
+		err = r.ScheduleRepo.UpdateInstruction(ctx, upd, time.Now())
+		assert.ErrorIs(t, err, schedule.ErrInstructionNotFound)
+	})
+
+	t.Run("lease due instructions", func(t *testing.T) {
+		insts, err := r.ScheduleRepo.LeaseDueInstructions(ctx, "worker1",
+			startAt.Add(-time.Second), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, insts, "not yet due")
+
+		insts, err = r.ScheduleRepo.LeaseDueInstructions(ctx, "worker1",
+			startAt, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, insts, 1)
+		assert.Equal(t, "worker1", insts[0].LeaseOwner)
+		require.NotNil(t, insts[0].LeaseExpiresAt)
+		assert.True(t, startAt.Add(time.Minute).Equal(*insts[0].LeaseExpiresAt))
+
+		insts, err = r.ScheduleRepo.LeaseDueInstructions(ctx, "worker2",
+			startAt.Add(time.Second), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, insts, "leased by worker1")
+
+		err = r.ScheduleRepo.UpdateInstruction(ctx, inst, startAt.Add(time.Second))
+		assert.ErrorIs(t, err, schedule.ErrInstructionBusy)
+
+		// the lease of worker1 has expired
+		insts, err = r.ScheduleRepo.LeaseDueInstructions(ctx, "worker2",
+			startAt.Add(2*time.Minute), time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, insts, 1)
+
+		leased := *insts[0]
+		nextRunAt := startAt.AddDate(0, 1, 0)
+		leased.NextRunAt = nextRunAt
+		leased.NextAttemptAt = nextRunAt
+		run := schedule.Run{
+			InstructionID: inst.InstructionID,
+			ScheduledAt:   startAt,
+			Attempt:       1,
+			XactNo:        transaction.XactNo("xact1"),
+		}
+
+		leased.LeaseOwner = "worker1"
+		err = r.ScheduleRepo.RecordRun(ctx, leased, run)
+		assert.ErrorIs(t, err, schedule.ErrLeaseLost)
+
+		leased.LeaseOwner = "worker2"
+		err = r.ScheduleRepo.RecordRun(ctx, leased, run)
+		require.NoError(t, err)
+
+		got, err := r.ScheduleRepo.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.True(t, nextRunAt.Equal(got.NextRunAt))
+		assert.True(t, nextRunAt.Equal(got.NextAttemptAt))
+		assert.Empty(t, got.LeaseOwner)
+		assert.Nil(t, got.LeaseExpiresAt)
+
+		// the lease was released
+		err = r.ScheduleRepo.RecordRun(ctx, leased, run)
+		assert.ErrorIs(t, err, schedule.ErrLeaseLost)
+	})
+
+	t.Run("list runs", func(t *testing.T) {
+		runs, err := r.ScheduleRepo.ListRuns(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.True(t, startAt.Equal(runs[0].ScheduledAt))
+		assert.Equal(t, 1, runs[0].Attempt)
+		assert.Equal(t, transaction.XactNo("xact1"), runs[0].XactNo)
+		assert.Empty(t, runs[0].Error)
+		assert.NotNil(t, runs[0].Ts)
+	})
+
+	t.Run("failed run", func(t *testing.T) {
+		now := startAt.AddDate(0, 1, 0)
+		insts, err := r.ScheduleRepo.LeaseDueInstructions(ctx, "worker1", now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, insts, 1)
+
+		leased := *insts[0]
+		leased.Attempts = 1
+		leased.LastError = "insufficient balance"
+		leased.NextAttemptAt = now.Add(time.Minute)
+		err = r.ScheduleRepo.RecordRun(ctx, leased, schedule.Run{
+			InstructionID: inst.InstructionID,
+			ScheduledAt:   leased.NextRunAt,
+			Attempt:       1,
+			Error:         "insufficient balance",
+		})
+		require.NoError(t, err)
+
+		got, err := r.ScheduleRepo.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, got.Attempts)
+		assert.Equal(t, "insufficient balance", got.LastError)
+
+		runs, err := r.ScheduleRepo.ListRuns(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		assert.Empty(t, runs[1].XactNo)
+		assert.Equal(t, "insufficient balance", runs[1].Error)
+
+		insts, err = r.ScheduleRepo.LeaseDueInstructions(ctx, "worker1", now, time.Minute, 10)
+		require.NoError(t, err)
+		assert.Empty(t, insts, "retried later")
+	})
+}
+
+func testOutboxRepo(t *testing.T, r *Repos) {
+	ctx := context.TODO()
+	setup(t, r)
+
+	// the postings write the events
+	postXacts := func(t *testing.T, xacts ...transaction.Transaction) {
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		for _, xact := range xacts {
+			err = r.XactRepo.CreateXact(ctx, tx, xact)
+			require.NoError(t, err)
+		}
+		require.NoError(t, tx.Commit())
+	}
+
+	deposit := func(xactNo string, amount int64) transaction.Transaction {
+		return transaction.Transaction{
+			XactNo:      transaction.XactNo(xactNo),
+			LedgerNo:    cashUSDLedger.LedgerNo,
+			XactType:    transaction.XactTypeDebit,
+			AccountID:   johnDoe.AccountID,
+			XactTypeExt: transaction.XactTypeExtDeposit,
+			Amount:      decimal.NewFromInt(amount),
+			Desc:        "Cash deposit",
+		}
+	}
+
+	postXacts(t, deposit("xact1", 10), deposit("xact2", 20))
+
+	t.Run("unsequenced events", func(t *testing.T) {
+		evs, err := r.OutboxRepo.ListEvents(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.Len(t, evs, 0)
+	})
+
+	t.Run("rolled back events", func(t *testing.T) {
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		err = r.XactRepo.CreateXact(ctx, tx, deposit("xact0", 5))
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+	})
+
+	t.Run("sequence events", func(t *testing.T) {
+		err := r.OutboxRepo.SequenceEvents(ctx)
+		require.NoError(t, err)
+
+		postXacts(t, deposit("xact3", 30))
+		err = r.OutboxRepo.SequenceEvents(ctx)
+		require.NoError(t, err)
+
+		evs, err := r.OutboxRepo.ListEvents(ctx, 0, 10)
+		require.NoError(t, err)
+		require.Len(t, evs, 3)
+
+		for i, ev := range evs {
+			assert.Equal(t, int64(i+1), ev.Seq)
+			assert.Equal(t, transaction.EventTypeXactPosted, ev.Type)
+			assert.NotNil(t, ev.Ts)
+
+			var xact transaction.Transaction
+			err = json.Unmarshal(ev.Payload, &xact)
+			require.NoError(t, err)
+			assert.Equal(t, transaction.XactNo(fmt.Sprintf("xact%d", i+1)), xact.XactNo)
+			assert.Equal(t, johnDoe.AccountID, xact.AccountID)
+			assert.Equal(t, transaction.XactTypeExtDeposit, xact.XactTypeExt)
+			assert.True(t, decimal.NewFromInt(int64(10*(i+1))).Equal(xact.Amount))
+			assert.NotNil(t, xact.Ts)
+		}
+
+		evs, err = r.OutboxRepo.ListEvents(ctx, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, evs, 1)
+		assert.Equal(t, int64(2), evs[0].Seq)
+	})
+
+	t.Run("cursors", func(t *testing.T) {
+		seq, err := r.OutboxRepo.GetCursor(ctx, "relay1")
+		require.NoError(t, err)
+		assert.Zero(t, seq)
+
+		err = r.OutboxRepo.SetCursor(ctx, "relay1", 2)
+		require.NoError(t, err)
+		err = r.OutboxRepo.SetCursor(ctx, "relay1", 3)
+		require.NoError(t, err)
+
+		seq, err = r.OutboxRepo.GetCursor(ctx, "relay1")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), seq)
+
+		seq, err = r.OutboxRepo.GetCursor(ctx, "relay2")
+		require.NoError(t, err)
+		assert.Zero(t, seq)
+	})
+}
+
+func testWebhookRepo(t *testing.T, r *Repos) {
+	ctx := context.TODO()
+	setup(t, r)
+
+	johnSub := webhook.Subscription{
+		SubscriptionID: webhook.SubscriptionID("sub1"),
+		AccountID:      johnDoe.AccountID,
+		XactTypeExts: []transaction.XactTypeExt{
+			transaction.XactTypeExtDeposit,
+			transaction.XactTypeExtWithdrawal,
+		},
+		URL:    "https://example.com/john",
+		Secret: "secret1secret1secret1",
+	}
+	globalSub := webhook.Subscription{
+		SubscriptionID: webhook.SubscriptionID("sub2"),
+		URL:            "https://example.com/all",
+		Secret:         "secret2secret2secret2",
+	}
+
+	for _, sub := range []webhook.Subscription{johnSub, globalSub} {
+		err := r.WebhookRepo.CreateSubscription(ctx, sub)
+		require.NoError(t, err)
+	}
+
+	t.Run("get subscription", func(t *testing.T) {
+		got, err := r.WebhookRepo.GetSubscription(ctx, johnSub.SubscriptionID)
+		require.NoError(t, err)
+		assert.Equal(t, johnSub.AccountID, got.AccountID)
+		assert.Equal(t, johnSub.XactTypeExts, got.XactTypeExts)
+		assert.Equal(t, johnSub.URL, got.URL)
+		assert.Equal(t, johnSub.Secret, got.Secret)
+		assert.NotNil(t, got.Ts)
+
+		got, err = r.WebhookRepo.GetSubscription(ctx, globalSub.SubscriptionID)
+		require.NoError(t, err)
+		assert.Empty(t, got.AccountID)
+		assert.Empty(t, got.XactTypeExts)
+
+		_, err = r.WebhookRepo.GetSubscription(ctx, webhook.SubscriptionID("idontexist"))
+		assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+	})
+
+	t.Run("list subscriptions", func(t *testing.T) {
+		subs, err := r.WebhookRepo.ListSubscriptions(ctx, johnDoe.AccountID)
+		require.NoError(t, err)
+		require.Len(t, subs, 2)
+		assert.Equal(t, johnSub.SubscriptionID, subs[0].SubscriptionID)
+		assert.Equal(t, globalSub.SubscriptionID, subs[1].SubscriptionID)
+
+		subs, err = r.WebhookRepo.ListSubscriptions(ctx, maryJane.AccountID)
+		require.NoError(t, err)
+		require.Len(t, subs, 1)
+		assert.Equal(t, globalSub.SubscriptionID, subs[0].SubscriptionID)
+
+		subs, err = r.WebhookRepo.ListSubscriptions(ctx, "")
+		require.NoError(t, err)
+		assert.Len(t, subs, 2)
+	})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	delivery := func(deliveryID string, sub webhook.Subscription,
+		seq int64) webhook.Delivery {
+		return webhook.Delivery{
+			DeliveryID:     webhook.DeliveryID(deliveryID),
+			SubscriptionID: sub.SubscriptionID,
+			EventSeq:       seq,
+			EventType:      transaction.EventTypeXactPosted,
+			Payload:        json.RawMessage(fmt.Sprintf(`{"seq":%d}`, seq)),
+			Status:         webhook.StatusPending,
+			NextAttemptAt:  now,
+		}
+	}
+
+	t.Run("create deliveries", func(t *testing.T) {
+		err := r.WebhookRepo.CreateDeliveries(ctx, []webhook.Delivery{
+			delivery("dlv1", johnSub, 1),
+			delivery("dlv2", globalSub, 1),
+			delivery("dlv3", globalSub, 2),
+		})
+		require.NoError(t, err)
+
+		// the event was already delivered to the subscription
+		err = r.WebhookRepo.CreateDeliveries(ctx, []webhook.Delivery{
+			delivery("dlv4", globalSub, 2),
+		})
+		require.NoError(t, err)
+
+		got, err := r.WebhookRepo.GetDelivery(ctx, webhook.DeliveryID("dlv1"))
+		require.NoError(t, err)
+		assert.Equal(t, johnSub.SubscriptionID, got.SubscriptionID)
+		assert.Equal(t, int64(1), got.EventSeq)
+		assert.Equal(t, transaction.EventTypeXactPosted, got.EventType)
+		assert.Equal(t, `{"seq":1}`, string(got.Payload))
+		assert.Equal(t, webhook.StatusPending, got.Status)
+		assert.Zero(t, got.Attempts)
+		assert.True(t, now.Equal(got.NextAttemptAt))
+		assert.Nil(t, got.DeliveredAt)
+		assert.Empty(t, got.LeaseOwner)
+		assert.NotNil(t, got.Ts)
+
+		_, err = r.WebhookRepo.GetDelivery(ctx, webhook.DeliveryID("dlv4"))
+		assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+
+		deliveries, err := r.WebhookRepo.ListDeliveries(ctx, globalSub.SubscriptionID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		assert.Equal(t, webhook.DeliveryID("dlv2"), deliveries[0].DeliveryID)
+		assert.Equal(t, webhook.DeliveryID("dlv3"), deliveries[1].DeliveryID)
+	})
+
+	t.Run("lease due deliveries", func(t *testing.T) {
+		deliveries, err := r.WebhookRepo.LeaseDueDeliveries(ctx, "worker1",
+			now.Add(-time.Second), time.Minute, 10)
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 0, "not yet due")
+
+		deliveries, err = r.WebhookRepo.LeaseDueDeliveries(ctx, "worker1",
+			now, time.Minute, 2)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		assert.Equal(t, "worker1", deliveries[0].LeaseOwner)
+		require.NotNil(t, deliveries[0].LeaseExpiresAt)
+		assert.True(t, now.Add(time.Minute).Equal(*deliveries[0].LeaseExpiresAt))
+
+		deliveries, err = r.WebhookRepo.LeaseDueDeliveries(ctx, "worker2",
+			now, time.Minute, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1, "the others are leased by worker1")
+		leased := *deliveries[0]
+
+		err = r.WebhookRepo.UpdateDelivery(ctx, leased, now)
+		assert.ErrorIs(t, err, webhook.ErrDeliveryBusy)
+
+		leased.Attempts = 1
+		leased.LastStatusCode = 200
+		leased.Status = webhook.StatusSucceeded
+		leased.DeliveredAt = &now
+
+		leased.LeaseOwner = "worker1"
+		err = r.WebhookRepo.RecordAttempt(ctx, leased)
+		assert.ErrorIs(t, err, webhook.ErrLeaseLost)
+
+		leased.LeaseOwner = "worker2"
+		err = r.WebhookRepo.RecordAttempt(ctx, leased)
+		require.NoError(t, err)
+
+		got, err := r.WebhookRepo.GetDelivery(ctx, leased.DeliveryID)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.StatusSucceeded, got.Status)
+		assert.Equal(t, 1, got.Attempts)
+		assert.Equal(t, 200, got.LastStatusCode)
+		require.NotNil(t, got.DeliveredAt)
+		assert.True(t, now.Equal(*got.DeliveredAt))
+		assert.Empty(t, got.LeaseOwner)
+		assert.Nil(t, got.LeaseExpiresAt)
+
+		err = r.WebhookRepo.RecordAttempt(ctx, leased)
+		assert.ErrorIs(t, err, webhook.ErrLeaseLost)
+	})
+
+	t.Run("update delivery", func(t *testing.T) {
+		// the lease of worker1 has expired
+		later := now.Add(time.Hour)
+		got, err := r.WebhookRepo.GetDelivery(ctx, webhook.DeliveryID("dlv1"))
+		require.NoError(t, err)
+
+		got.Status = webhook.StatusFailed
+		got.Attempts = 3
+		got.NextAttemptAt = later
+		err = r.WebhookRepo.UpdateDelivery(ctx, *got, later)
+		require.NoError(t, err)
+
+		got, err = r.WebhookRepo.GetDelivery(ctx, webhook.DeliveryID("dlv1"))
+		require.NoError(t, err)
+		assert.Equal(t, webhook.StatusFailed, got.Status)
+		assert.Equal(t, 3, got.Attempts)
+		assert.True(t, later.Equal(got.NextAttemptAt))
+
+		got.DeliveryID = webhook.DeliveryID("idontexist")
+		err = r.WebhookRepo.UpdateDelivery(ctx, *got, later)
+		assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+	})
+
+	t.Run("delete subscription", func(t *testing.T) {
+		err := r.WebhookRepo.DeleteSubscription(ctx, globalSub.SubscriptionID)
+		require.NoError(t, err)
+
+		_, err = r.WebhookRepo.GetSubscription(ctx, globalSub.SubscriptionID)
+		assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+
+		deliveries, err := r.WebhookRepo.ListDeliveries(ctx, globalSub.SubscriptionID)
+		require.NoError(t, err)
+		assert.Len(t, deliveries, 0)
+
+		_, err = r.WebhookRepo.GetDelivery(ctx, webhook.DeliveryID("dlv2"))
+		assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+
+		_, err = r.WebhookRepo.GetDelivery(ctx, webhook.DeliveryID("dlv1"))
+		require.NoError(t, err)
+
+		err = r.WebhookRepo.DeleteSubscription(ctx, globalSub.SubscriptionID)
+		assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+	})
+}
+
+func testTx(t *testing.T, r *Repos) {
+	setup(t, r)
+
+	ctx := context.TODO()
+
+	t.Run("rollback", func(t *testing.T) {
+		xact := newXact(t, transaction.XactTypeExtDeposit, johnDoe.AccountID, 100)
+		hold := newHold(t, johnDoe.AccountID, 20, time.Now().Add(time.Hour))
+
+		tx, err := r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		err = r.XactRepo.CreateXact(ctx, tx, xact)
+		require.NoError(t, err)
+
+		err = r.XactRepo.CreateHold(ctx, tx, hold)
+		require.NoError(t, err)
+
+		created, err := r.XactRepo.CreateIdempotencyKeyIfNotExists(ctx, tx,
+			transaction.IdempotencyKey{Key: "key1", Fingerprint: "fingerprint", XactNo: xact.XactNo})
+		require.NoError(t, err)
+		require.True(t, created)
+
+		// the writes are visible within the tx
+		legs, err := r.XactRepo.ListXactLegs(ctx, tx, xact.XactNo)
+		require.NoError(t, err)
+		assert.Len(t, legs, 1)
+
+		require.NoError(t, tx.Rollback())
+
+		// and discarded after the rollback
+		_, err = r.XactRepo.GetXact(ctx, xact.XactNo)
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+
+		xacts, err := r.XactRepo.ListXacts(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, xacts)
+
+		_, err = r.XactRepo.GetHold(ctx, hold.HoldID)
+		assert.ErrorIs(t, err, transaction.ErrHoldNotFound)
+
+		bal := getAccntBal(t, r, johnDoe.AccountID)
+		assert.True(t, bal.CurrentBal.IsZero())
+		assert.True(t, bal.AvailableBal.IsZero())
+
+		tx, err = r.XactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Rollback())
+		}()
+
+		created, err = r.XactRepo.CreateIdempotencyKeyIfNotExists(ctx, tx,
+			transaction.IdempotencyKey{Key: "key1", Fingerprint: "fingerprint", XactNo: xact.XactNo})
+		require.NoError(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		xact := newXact(t, transaction.XactTypeExtDeposit, johnDoe.AccountID, 100)
+		createXact(t, r, xact)
+
+		got, err := r.XactRepo.GetXact(ctx, xact.XactNo)
+		require.NoError(t, err)
+		assert.Len(t, got.Legs, 1)
+
+		bal := getAccntBal(t, r, johnDoe.AccountID)
+		assert.True(t, decimal.NewFromInt(100).Equal(bal.CurrentBal))
+	})
+}
+
+// newXact returns an account transaction with a new xact no
+func newXact(t *testing.T, xactTypeExt transaction.XactTypeExt,
+	accntID account.AccountID, amount int64) transaction.Transaction {
+	xactNo, err := transaction.NewXactNo()
+	require.NoError(t, err)
+
+	// a ledger's debit is the account's credit and vice versa
+	xactType := transaction.XactTypeCredit
+	if xactTypeExt == transaction.XactTypeExtDeposit ||
+		xactTypeExt == transaction.XactTypeExtRcvTransfer {
+		xactType = transaction.XactTypeDebit
+	}
+
+	return transaction.Transaction{
+		XactNo:      xactNo,
+		LedgerNo:    cashUSDLedger.LedgerNo,
+		XactType:    xactType,
+		AccountID:   accntID,
+		XactTypeExt: xactTypeExt,
+		Amount:      decimal.NewFromInt(amount),
+		Desc:        xactTypeExt.String(),
+	}
+}
+
+// newHold returns an active hold with a new hold id
+func newHold(t *testing.T, accntID account.AccountID,
+	amount int64, expiresAt time.Time) transaction.Hold {
+	holdID, err := transaction.NewHoldID()
+	require.NoError(t, err)
+
+	return transaction.Hold{
+		HoldID:    holdID,
+		AccountID: accntID,
+		Amount:    decimal.NewFromInt(amount),
+		Status:    transaction.HoldStatusActive,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// createXact creates the transactions in a tx
+func createXact(t *testing.T, r *Repos, xacts ...transaction.Transaction) {
+	ctx := context.TODO()
+
+	tx, err := r.XactRepo.BeginTx(ctx)
+	require.NoError(t, err)
+
+	for _, xact := range xacts {
+		err = r.XactRepo.CreateXact(ctx, tx, xact)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tx.Commit())
+}
+
+// getAccntBal retrieves the account balance in a tx
+func getAccntBal(t *testing.T, r *Repos, accntID account.AccountID) *account.Balance {
+	ctx := context.TODO()
+
+	tx, err := r.BalRepo.BeginTx(ctx)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, tx.Commit())
+	}()
+
+	bal, err := r.BalRepo.GetAccntBalForUpdate(ctx, tx, accntID)
+	require.NoError(t, err)
+
+	return bal
+}
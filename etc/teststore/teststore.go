@@ -0,0 +1,148 @@
+// Package teststore opens the storage back-end used by the service tests.
+// The back-end is selected with the TEST_STORAGE env, it's either postgres
+// (default), sqlite or memory.
+package teststore
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/etc/txdb"
+	"github.com/stevenferrer/kalupi/inmem"
+	"github.com/stevenferrer/kalupi/postgres"
+	"github.com/stevenferrer/kalupi/sqlite"
+)
+
+// List of storage back-ends
+const (
+	Postgres = "postgres"
+	Sqlite   = "sqlite"
+	Memory   = "memory"
+)
+
+// storage is the storage back-end
+var storage string
+
+func init() {
+	storage = os.Getenv("TEST_STORAGE")
+	if storage == "" {
+		storage = Postgres
+	}
+}
+
+// MustOpen opens the storage back-end and panics if an error occurred.
+// With postgres, the repositories share a single txdb transaction. The
+// returned func releases the back-end.
+func MustOpen() (*repotest.Repos, func() error) {
+	switch storage {
+	case Postgres:
+		db := txdb.MustOpen()
+		err := postgres.Migrate(db)
+		if err != nil {
+			db.Close()
+			panic(err)
+		}
+
+		return newPostgresStore(db), db.Close
+	case Sqlite:
+		return mustOpenSqlite()
+	case Memory:
+		return newMemoryStore(), func() error { return nil }
+	default:
+		panic("unknown storage: " + storage)
+	}
+}
+
+// MustOpenSchema opens the storage back-end that supports concurrent txs
+// and panics if an error occurred. With postgres, the repositories use a
+// new schema. The returned func releases the back-end.
+func MustOpenSchema(schema string) (*repotest.Repos, func() error) {
+	switch storage {
+	case Postgres:
+		db, cleanup, err := txdb.OpenSchema(schema)
+		if err != nil {
+			panic(err)
+		}
+
+		err = postgres.Migrate(db)
+		if err != nil {
+			_ = cleanup()
+			panic(err)
+		}
+
+		return newPostgresStore(db), cleanup
+	case Sqlite:
+		return mustOpenSqlite()
+	case Memory:
+		return newMemoryStore(), func() error { return nil }
+	default:
+		panic("unknown storage: " + storage)
+	}
+}
+
+func newPostgresStore(db *sql.DB) *repotest.Repos {
+	return &repotest.Repos{
+		AccountRepo:  postgres.NewAccountRepository(db),
+		LedgerRepo:   postgres.NewLedgerRepository(db),
+		XactRepo:     postgres.NewXactRepository(db),
+		BalRepo:      postgres.NewBalanceRepository(db),
+		ScheduleRepo: postgres.NewScheduleRepository(db),
+		OutboxRepo:   postgres.NewOutboxRepository(db),
+		WebhookRepo:  postgres.NewWebhookRepository(db),
+	}
+}
+
+// mustOpenSqlite opens a new sqlite database in a temp dir, the
+// returned func closes the database and removes the temp dir
+func mustOpenSqlite() (*repotest.Repos, func() error) {
+	dir, err := ioutil.TempDir("", "kalupi")
+	if err != nil {
+		panic(err)
+	}
+
+	db, err := sqlite.Open(filepath.Join(dir, "kalupi.db"))
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		panic(err)
+	}
+
+	cleanup := func() error {
+		err := db.Close()
+		if err != nil {
+			return err
+		}
+		return os.RemoveAll(dir)
+	}
+
+	err = sqlite.Migrate(db)
+	if err != nil {
+		_ = cleanup()
+		panic(err)
+	}
+
+	return &repotest.Repos{
+		AccountRepo:  sqlite.NewAccountRepository(db),
+		LedgerRepo:   sqlite.NewLedgerRepository(db),
+		XactRepo:     sqlite.NewXactRepository(db),
+		BalRepo:      sqlite.NewBalanceRepository(db),
+		ScheduleRepo: sqlite.NewScheduleRepository(db),
+		OutboxRepo:   sqlite.NewOutboxRepository(db),
+		WebhookRepo:  sqlite.NewWebhookRepository(db),
+	}, cleanup
+}
+
+func newMemoryStore() *repotest.Repos {
+	db := inmem.New()
+	return &repotest.Repos{
+		AccountRepo:  inmem.NewAccountRepository(db),
+		LedgerRepo:   inmem.NewLedgerRepository(db),
+		XactRepo:     inmem.NewXactRepository(db),
+		BalRepo:      inmem.NewBalanceRepository(db),
+		ScheduleRepo: inmem.NewScheduleRepository(db),
+		OutboxRepo:   inmem.NewOutboxRepository(db),
+		WebhookRepo:  inmem.NewWebhookRepository(db),
+	}
+}
@@ -3,6 +3,7 @@ package txdb
 
 import (
 	"database/sql"
+	"net/url"
 	"os"
 
 	"github.com/DATA-DOG/go-txdb"
@@ -53,3 +54,51 @@ func MustOpen() *sql.DB {
 
 	return db
 }
+
+// OpenSchema opens a postgres connection pool that uses a new schema. Unlike
+// txdb, the connections are not sharing a single transaction, hence, it is
+// used for testing concurrent transactions. The returned func drops the
+// schema and closes the connection pool.
+func OpenSchema(schema string) (*sql.DB, func() error, error) {
+	db, err := sql.Open(dialect, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	_, err = db.Exec("create schema " + schema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	schemaDB, err := sql.Open(dialect, u.String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() error {
+		err := schemaDB.Close()
+		if err != nil {
+			return err
+		}
+
+		db, err := sql.Open(dialect, dsn)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		_, err = db.Exec("drop schema " + schema + " cascade")
+		return err
+	}
+
+	return schemaDB, cleanup, nil
+}
@@ -0,0 +1,9 @@
+package fee
+
+import "errors"
+
+// List of fee related errors
+var (
+	// ErrInvalidRule is an error when a fee rule is malformed
+	ErrInvalidRule = errors.New("invalid fee rule")
+)
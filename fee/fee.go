@@ -0,0 +1,151 @@
+// Package fee contains the transaction fee schedules
+package fee
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// Type is a fee type
+type Type string
+
+// List of fee types
+const (
+	// TypeFlat is a fixed fee per transaction
+	TypeFlat Type = "flat"
+	// TypePercentage is a percentage of the transaction amount
+	TypePercentage Type = "percentage"
+	// TypeTiered is a flat and percentage fee that
+	// depends on the tier of the transaction amount
+	TypeTiered Type = "tiered"
+)
+
+// Rule is the fee of the transactions in a currency
+// with the external transaction type i.e. Wd
+type Rule struct {
+	Currency currency.Currency `json:"currency"`
+	// XactTypeExt is the external transaction type i.e. Wd, STr
+	XactTypeExt string `json:"xact_type_ext"`
+	Type        Type   `json:"type"`
+	// Amount is the fee of a flat fee
+	Amount decimal.Decimal `json:"amount"`
+	// Percentage is the fee of a percentage fee i.e. 1.5 is 1.5%
+	Percentage decimal.Decimal `json:"percentage"`
+	// Tiers are the tiers of a tiered fee, sorted by the upper bound
+	Tiers []Tier `json:"tiers,omitempty"`
+	// Min is the minimum fee
+	Min decimal.Decimal `json:"min"`
+	// Max is the maximum fee, zero means there's no maximum
+	Max decimal.Decimal `json:"max"`
+}
+
+// Tier is a tier of a tiered fee
+type Tier struct {
+	// UpTo is the inclusive upper bound of the transaction
+	// amount, zero means there's no upper bound
+	UpTo decimal.Decimal `json:"up_to"`
+	// Amount is the flat part of the fee
+	Amount decimal.Decimal `json:"amount"`
+	// Percentage is the percentage part of the fee
+	Percentage decimal.Decimal `json:"percentage"`
+}
+
+// validate validates the rule
+func (r Rule) validate() error {
+	if !r.Currency.IsValid() {
+		return currency.ErrUnknownCurrency
+	}
+
+	if r.XactTypeExt == "" {
+		return errors.Wrap(ErrInvalidRule, "missing xact type ext")
+	}
+
+	amounts := []decimal.Decimal{r.Amount, r.Percentage, r.Min, r.Max}
+	switch r.Type {
+	case TypeFlat, TypePercentage:
+	case TypeTiered:
+		if len(r.Tiers) == 0 {
+			return errors.Wrap(ErrInvalidRule, "missing tiers")
+		}
+
+		for i, tier := range r.Tiers {
+			amounts = append(amounts, tier.UpTo, tier.Amount, tier.Percentage)
+
+			if i == len(r.Tiers)-1 {
+				break
+			}
+
+			if tier.UpTo.IsZero() {
+				return errors.Wrap(ErrInvalidRule, "only the last tier can be unbounded")
+			}
+
+			next := r.Tiers[i+1].UpTo
+			if !next.IsZero() && !tier.UpTo.LessThan(next) {
+				return errors.Wrap(ErrInvalidRule, "tiers must be sorted by the upper bound")
+			}
+		}
+	default:
+		return errors.Wrapf(ErrInvalidRule, "unknown type %q", r.Type)
+	}
+
+	for _, amount := range amounts {
+		if amount.IsNegative() {
+			return errors.Wrap(ErrInvalidRule, "negative amount")
+		}
+	}
+
+	if r.Max.IsPositive() && r.Max.LessThan(r.Min) {
+		return errors.Wrap(ErrInvalidRule, "max is less than min")
+	}
+
+	return nil
+}
+
+// calc returns the fee of the transaction amount, it's capped
+// by the min and max and rounded to the minor units of the currency
+func (r Rule) calc(amount decimal.Decimal) decimal.Decimal {
+	var f decimal.Decimal
+	switch r.Type {
+	case TypeFlat:
+		f = r.Amount
+	case TypePercentage:
+		f = percentOf(amount, r.Percentage)
+	case TypeTiered:
+		tier := r.tier(amount)
+		f = tier.Amount.Add(percentOf(amount, tier.Percentage))
+	}
+
+	if f.LessThan(r.Min) {
+		f = r.Min
+	}
+
+	if r.Max.IsPositive() && f.GreaterThan(r.Max) {
+		f = r.Max
+	}
+
+	places := r.Currency.MinorUnits()
+	if places == currency.NoMinorUnits {
+		return f
+	}
+
+	return f.Round(int32(places))
+}
+
+// tier returns the tier of the transaction amount, the last
+// tier is used if the amount exceeds all of the upper bounds
+func (r Rule) tier(amount decimal.Decimal) Tier {
+	for _, tier := range r.Tiers {
+		if tier.UpTo.IsZero() || amount.LessThanOrEqual(tier.UpTo) {
+			return tier
+		}
+	}
+
+	return r.Tiers[len(r.Tiers)-1]
+}
+
+// percentOf returns the percentage of the amount
+func percentOf(amount, percentage decimal.Decimal) decimal.Decimal {
+	return amount.Mul(percentage).Div(decimal.NewFromInt(100))
+}
@@ -0,0 +1,79 @@
+package fee
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// Schedule is a fee schedule, it has at most one rule for
+// each currency and external transaction type. A nil
+// schedule doesn't charge any fees.
+type Schedule struct {
+	rules map[key]Rule
+}
+
+// key is the key of a rule
+type key struct {
+	curr        currency.Currency
+	xactTypeExt string
+}
+
+// NewSchedule takes the fee rules and returns a fee schedule
+func NewSchedule(rules ...Rule) (*Schedule, error) {
+	s := &Schedule{rules: map[key]Rule{}}
+	for _, r := range rules {
+		err := r.validate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s %s", r.Currency, r.XactTypeExt)
+		}
+
+		k := key{r.Currency, r.XactTypeExt}
+		if _, ok := s.rules[k]; ok {
+			return nil, errors.Wrapf(ErrInvalidRule, "%s %s: duplicate rule",
+				r.Currency, r.XactTypeExt)
+		}
+
+		s.rules[k] = r
+	}
+
+	return s, nil
+}
+
+// LoadSchedule reads the fee rules from a JSON file and returns a fee schedule
+//
+//	[{"currency": "USD", "xact_type_ext": "Wd", "type": "flat", "amount": "1.5"}]
+func LoadSchedule(path string) (*Schedule, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	var rules []Rule
+	err = json.Unmarshal(b, &rules)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	return NewSchedule(rules...)
+}
+
+// Fee returns the fee of a transaction in the currency with
+// the external transaction type i.e. Wd, the fee is zero
+// if there's no matching rule
+func (s *Schedule) Fee(curr currency.Currency, xactTypeExt string, amount decimal.Decimal) decimal.Decimal {
+	if s == nil {
+		return decimal.Zero
+	}
+
+	r, ok := s.rules[key{curr, xactTypeExt}]
+	if !ok {
+		return decimal.Zero
+	}
+
+	return r.calc(amount)
+}
@@ -0,0 +1,174 @@
+package fee_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/fee"
+)
+
+func TestSchedule(t *testing.T) {
+	d := decimal.RequireFromString
+
+	s, err := fee.NewSchedule(
+		fee.Rule{
+			Currency:    currency.USD,
+			XactTypeExt: "Wd",
+			Type:        fee.TypeFlat,
+			Amount:      d("1.5"),
+		},
+		fee.Rule{
+			Currency:    currency.USD,
+			XactTypeExt: "STr",
+			Type:        fee.TypePercentage,
+			Percentage:  d("1.5"),
+			Min:         d("0.5"),
+			Max:         d("10"),
+		},
+		fee.Rule{
+			Currency:    currency.EUR,
+			XactTypeExt: "STr",
+			Type:        fee.TypeTiered,
+			Tiers: []fee.Tier{
+				{UpTo: d("100"), Amount: d("1")},
+				{UpTo: d("1000"), Amount: d("2"), Percentage: d("0.5")},
+				{Percentage: d("0.25")},
+			},
+		},
+		fee.Rule{
+			Currency:    currency.JPY,
+			XactTypeExt: "Wd",
+			Type:        fee.TypePercentage,
+			Percentage:  d("1"),
+		},
+	)
+	require.NoError(t, err)
+
+	tc := []struct {
+		name        string
+		curr        currency.Currency
+		xactTypeExt string
+		amount      string
+		expect      string
+	}{
+		{name: "flat", curr: currency.USD, xactTypeExt: "Wd", amount: "100", expect: "1.5"},
+		{name: "percentage", curr: currency.USD, xactTypeExt: "STr", amount: "100", expect: "1.5"},
+		{name: "rounded", curr: currency.USD, xactTypeExt: "STr", amount: "123.45", expect: "1.85"},
+		{name: "min", curr: currency.USD, xactTypeExt: "STr", amount: "10", expect: "0.5"},
+		{name: "max", curr: currency.USD, xactTypeExt: "STr", amount: "1000", expect: "10"},
+		{name: "first tier", curr: currency.EUR, xactTypeExt: "STr", amount: "100", expect: "1"},
+		{name: "second tier", curr: currency.EUR, xactTypeExt: "STr", amount: "500", expect: "4.5"},
+		{name: "last tier", curr: currency.EUR, xactTypeExt: "STr", amount: "2000", expect: "5"},
+		{name: "no minor units", curr: currency.JPY, xactTypeExt: "Wd", amount: "150", expect: "2"},
+		{name: "no rule", curr: currency.USD, xactTypeExt: "Dp", amount: "100", expect: "0"},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.Fee(tt.curr, tt.xactTypeExt, d(tt.amount))
+			assert.True(t, d(tt.expect).Equal(got), "expecting %s got %s", tt.expect, got)
+		})
+	}
+
+	t.Run("nil schedule", func(t *testing.T) {
+		var s *fee.Schedule
+		assert.True(t, s.Fee(currency.USD, "Wd", d("100")).IsZero())
+	})
+}
+
+func TestNewSchedule(t *testing.T) {
+	d := decimal.RequireFromString
+
+	tc := []struct {
+		name string
+		rule fee.Rule
+	}{
+		{
+			name: "unknown type",
+			rule: fee.Rule{Currency: currency.USD, XactTypeExt: "Wd", Type: "free"},
+		},
+		{
+			name: "missing xact type ext",
+			rule: fee.Rule{Currency: currency.USD, Type: fee.TypeFlat, Amount: d("1")},
+		},
+		{
+			name: "negative amount",
+			rule: fee.Rule{Currency: currency.USD, XactTypeExt: "Wd", Type: fee.TypeFlat, Amount: d("-1")},
+		},
+		{
+			name: "max less than min",
+			rule: fee.Rule{Currency: currency.USD, XactTypeExt: "Wd", Type: fee.TypePercentage,
+				Percentage: d("1"), Min: d("2"), Max: d("1")},
+		},
+		{
+			name: "missing tiers",
+			rule: fee.Rule{Currency: currency.USD, XactTypeExt: "Wd", Type: fee.TypeTiered},
+		},
+		{
+			name: "unsorted tiers",
+			rule: fee.Rule{Currency: currency.USD, XactTypeExt: "Wd", Type: fee.TypeTiered,
+				Tiers: []fee.Tier{{UpTo: d("100")}, {UpTo: d("50")}}},
+		},
+		{
+			name: "unbounded tier",
+			rule: fee.Rule{Currency: currency.USD, XactTypeExt: "Wd", Type: fee.TypeTiered,
+				Tiers: []fee.Tier{{Amount: d("1")}, {UpTo: d("50")}}},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fee.NewSchedule(tt.rule)
+			assert.ErrorIs(t, err, fee.ErrInvalidRule)
+		})
+	}
+
+	t.Run("unknown currency", func(t *testing.T) {
+		_, err := fee.NewSchedule(fee.Rule{XactTypeExt: "Wd", Type: fee.TypeFlat})
+		assert.ErrorIs(t, err, currency.ErrUnknownCurrency)
+	})
+
+	t.Run("duplicate rule", func(t *testing.T) {
+		r := fee.Rule{Currency: currency.USD, XactTypeExt: "Wd", Type: fee.TypeFlat, Amount: d("1")}
+		_, err := fee.NewSchedule(r, r)
+		assert.ErrorIs(t, err, fee.ErrInvalidRule)
+	})
+}
+
+func TestLoadSchedule(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "fees.json")
+	err := os.WriteFile(path, []byte(`[
+		{"currency": "USD", "xact_type_ext": "Wd", "type": "flat", "amount": "2"},
+		{"currency": "USD", "xact_type_ext": "STr", "type": "tiered", "tiers": [
+			{"up_to": "100", "amount": "1"},
+			{"percentage": "1"}
+		]}
+	]`), 0600)
+	require.NoError(t, err)
+
+	s, err := fee.LoadSchedule(path)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(2).Equal(s.Fee(currency.USD, "Wd", decimal.NewFromInt(10))))
+	assert.True(t, decimal.NewFromInt(3).Equal(s.Fee(currency.USD, "STr", decimal.NewFromInt(300))))
+
+	t.Run("file not found", func(t *testing.T) {
+		_, err := fee.LoadSchedule(filepath.Join(dir, "idontexist.json"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid rule", func(t *testing.T) {
+		err := os.WriteFile(path, []byte(`[{"currency": "USD", "xact_type_ext": "Wd", "type": "free"}]`), 0600)
+		require.NoError(t, err)
+
+		_, err = fee.LoadSchedule(path)
+		assert.ErrorIs(t, err, fee.ErrInvalidRule)
+	})
+}
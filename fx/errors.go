@@ -0,0 +1,11 @@
+package fx
+
+import "errors"
+
+// List of fx related errors
+var (
+	// ErrRateNotFound is an error when there's no rate for the currency pair
+	ErrRateNotFound = errors.New("rate not found")
+	// ErrInvalidRate is an error when the rate is zero or negative
+	ErrInvalidRate = errors.New("invalid rate")
+)
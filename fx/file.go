@@ -0,0 +1,83 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// FileRateProvider is a rate provider backed by a JSON file containing
+// a list of rates. The file is reloaded whenever it is modified.
+//
+//	[{"base": "USD", "quote": "EUR", "rate": "0.92"}]
+type FileRateProvider struct {
+	path string
+
+	mu      sync.Mutex
+	tbl     rateTable
+	modTime time.Time
+}
+
+var _ RateProvider = (*FileRateProvider)(nil)
+
+// NewFileRateProvider takes the path to the rates file and returns a file rate provider
+func NewFileRateProvider(path string) (*FileRateProvider, error) {
+	p := &FileRateProvider{path: path}
+	err := p.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetRate retrieves the rate for converting the base to quote currency
+func (p *FileRateProvider) GetRate(_ context.Context, base, quote currency.Currency) (decimal.Decimal, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.reload()
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	return p.tbl.getRate(base, quote)
+}
+
+// reload reloads the rates if the file was modified
+func (p *FileRateProvider) reload() error {
+	fi, err := os.Stat(p.path)
+	if err != nil {
+		return errors.Wrap(err, "stat")
+	}
+
+	if p.tbl != nil && fi.ModTime().Equal(p.modTime) {
+		return nil
+	}
+
+	b, err := os.ReadFile(p.path)
+	if err != nil {
+		return errors.Wrap(err, "read file")
+	}
+
+	var rates []Rate
+	err = json.Unmarshal(b, &rates)
+	if err != nil {
+		return errors.Wrap(err, "json unmarshal")
+	}
+
+	tbl, err := newRateTable(rates...)
+	if err != nil {
+		return errors.Wrap(err, "new rate table")
+	}
+
+	p.tbl, p.modTime = tbl, fi.ModTime()
+	return nil
+}
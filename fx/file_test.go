@@ -0,0 +1,57 @@
+package fx_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/fx"
+)
+
+func TestFileRateProvider(t *testing.T) {
+	dir, err := os.MkdirTemp("", "kalupi-fx")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "rates.json")
+	err = os.WriteFile(path, []byte(`[{"base": "USD", "quote": "EUR", "rate": "0.9"}]`), 0600)
+	require.NoError(t, err)
+
+	p, err := fx.NewFileRateProvider(path)
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+	rate, err := p.GetRate(ctx, currency.USD, currency.EUR)
+	require.NoError(t, err)
+	assert.True(t, decimal.RequireFromString("0.9").Equal(rate))
+
+	_, err = p.GetRate(ctx, currency.USD, currency.JPY)
+	assert.ErrorIs(t, err, fx.ErrRateNotFound)
+
+	t.Run("reload", func(t *testing.T) {
+		err = os.WriteFile(path, []byte(`[{"base": "USD", "quote": "JPY", "rate": "150"}]`), 0600)
+		require.NoError(t, err)
+		// make sure the modification time changes
+		modTime := time.Now().Add(time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+
+		rate, err := p.GetRate(ctx, currency.USD, currency.JPY)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(150).Equal(rate))
+
+		_, err = p.GetRate(ctx, currency.USD, currency.EUR)
+		assert.ErrorIs(t, err, fx.ErrRateNotFound)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		_, err := fx.NewFileRateProvider(filepath.Join(dir, "idontexist.json"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,67 @@
+// Package fx contains the foreign exchange rate providers
+package fx
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// RateProvider provides the foreign exchange rates
+type RateProvider interface {
+	// GetRate retrieves the rate for converting the base to quote currency
+	GetRate(ctx context.Context, base, quote currency.Currency) (decimal.Decimal, error)
+}
+
+// Rate is a foreign exchange rate i.e. 1 USD = 0.92 EUR
+type Rate struct {
+	Base  currency.Currency `json:"base"`
+	Quote currency.Currency `json:"quote"`
+	Rate  decimal.Decimal   `json:"rate"`
+}
+
+// pair is a currency pair
+type pair struct {
+	base, quote currency.Currency
+}
+
+// rateTable is a table of rates
+type rateTable map[pair]decimal.Decimal
+
+// newRateTable takes the rates and returns a rate table
+func newRateTable(rates ...Rate) (rateTable, error) {
+	tbl := rateTable{}
+	for _, r := range rates {
+		if !r.Base.IsValid() || !r.Quote.IsValid() {
+			return nil, currency.ErrUnknownCurrency
+		}
+
+		if !r.Rate.IsPositive() {
+			return nil, ErrInvalidRate
+		}
+
+		tbl[pair{r.Base, r.Quote}] = r.Rate
+	}
+
+	return tbl, nil
+}
+
+// getRate retrieves the rate from the table, the
+// inverse rate is used if only the reverse pair exists
+func (tbl rateTable) getRate(base, quote currency.Currency) (decimal.Decimal, error) {
+	if base == quote {
+		return decimal.NewFromInt(1), nil
+	}
+
+	if rate, ok := tbl[pair{base, quote}]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := tbl[pair{quote, base}]; ok {
+		return decimal.NewFromInt(1).Div(rate), nil
+	}
+
+	return decimal.Zero, ErrRateNotFound
+}
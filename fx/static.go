@@ -0,0 +1,31 @@
+package fx
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// StaticRateProvider is a rate provider backed by a fixed table of rates
+type StaticRateProvider struct {
+	tbl rateTable
+}
+
+var _ RateProvider = (*StaticRateProvider)(nil)
+
+// NewStaticRateProvider takes the rates and returns a static rate provider
+func NewStaticRateProvider(rates ...Rate) (*StaticRateProvider, error) {
+	tbl, err := newRateTable(rates...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaticRateProvider{tbl: tbl}, nil
+}
+
+// GetRate retrieves the rate for converting the base to quote currency
+func (p *StaticRateProvider) GetRate(_ context.Context, base, quote currency.Currency) (decimal.Decimal, error) {
+	return p.tbl.getRate(base, quote)
+}
@@ -0,0 +1,79 @@
+package fx_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/fx"
+)
+
+func TestStaticRateProvider(t *testing.T) {
+	p, err := fx.NewStaticRateProvider(fx.Rate{
+		Base:  currency.USD,
+		Quote: currency.EUR,
+		Rate:  decimal.RequireFromString("0.8"),
+	})
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+	tc := []struct {
+		base, quote currency.Currency
+		expect      decimal.Decimal
+		err         error
+	}{
+		{
+			base:   currency.USD,
+			quote:  currency.EUR,
+			expect: decimal.RequireFromString("0.8"),
+		},
+		{
+			// inverse
+			base:   currency.EUR,
+			quote:  currency.USD,
+			expect: decimal.RequireFromString("1.25"),
+		},
+		{
+			base:   currency.USD,
+			quote:  currency.USD,
+			expect: decimal.NewFromInt(1),
+		},
+		{
+			base:  currency.USD,
+			quote: currency.JPY,
+			err:   fx.ErrRateNotFound,
+		},
+	}
+
+	for _, tt := range tc {
+		rate, err := p.GetRate(ctx, tt.base, tt.quote)
+		if tt.err != nil {
+			assert.ErrorIs(t, err, tt.err)
+			continue
+		}
+
+		require.NoError(t, err)
+		assert.True(t, tt.expect.Equal(rate), "%s/%s: expecting %s got %s",
+			tt.base, tt.quote, tt.expect, rate)
+	}
+
+	t.Run("invalid rate", func(t *testing.T) {
+		_, err := fx.NewStaticRateProvider(fx.Rate{
+			Base:  currency.USD,
+			Quote: currency.EUR,
+			Rate:  decimal.Zero,
+		})
+		assert.ErrorIs(t, err, fx.ErrInvalidRate)
+
+		_, err = fx.NewStaticRateProvider(fx.Rate{
+			Base:  currency.USD,
+			Quote: currency.Currency(0),
+			Rate:  decimal.NewFromInt(1),
+		})
+		assert.ErrorIs(t, err, currency.ErrUnknownCurrency)
+	})
+}
@@ -14,4 +14,5 @@ require (
 	github.com/shopspring/decimal v1.2.0
 	github.com/stretchr/testify v1.7.0
 	go.uber.org/multierr v1.7.0
+	modernc.org/sqlite v1.10.0
 )
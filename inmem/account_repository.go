@@ -0,0 +1,182 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/etc/tx"
+)
+
+// AccountRepository implements the account repository
+// interface and uses memory as back-end
+type AccountRepository struct{ db *DB }
+
+var _ account.Repository = (*AccountRepository)(nil)
+
+// NewAccountRepository returns an account repository
+func NewAccountRepository(db *DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+// CreateAccount creates an account
+func (ar *AccountRepository) CreateAccount(ctx context.Context, accnt account.Account) (account.AccountID, error) {
+	err := ar.db.update(ctx, func(t *Tx) error {
+		if _, ok := t.data.accounts[accnt.AccountID]; ok {
+			return account.ErrAccountAlreadyExists
+		}
+
+		if err := checkAmounts(accnt.OverdraftLimit); err != nil {
+			return err
+		}
+
+		t.data.accounts[accnt.AccountID] = account.Account{
+			AccountID:      accnt.AccountID,
+			Currency:       accnt.Currency,
+			Status:         account.StatusActive,
+			OverdraftLimit: accnt.OverdraftLimit,
+		}
+		t.data.accountIDs = append(t.data.accountIDs, accnt.AccountID)
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return accnt.AccountID, nil
+}
+
+// GetAccount retrieves an account
+func (ar *AccountRepository) GetAccount(ctx context.Context, accntID account.AccountID) (*account.Account, error) {
+	var (
+		accnt account.Account
+		ok    bool
+	)
+	ar.db.view(func(d *data) {
+		accnt, ok = d.accounts[accntID]
+	})
+
+	if !ok {
+		return nil, account.ErrAccountNotFound
+	}
+
+	return &accnt, nil
+}
+
+// ListAccounts retrieves the list of accounts
+func (ar *AccountRepository) ListAccounts(ctx context.Context) ([]*account.Account, error) {
+	accnts := []*account.Account{}
+	ar.db.view(func(d *data) {
+		for _, accntID := range d.accountIDs {
+			accnt := d.accounts[accntID]
+			accnts = append(accnts, &accnt)
+		}
+	})
+
+	return accnts, nil
+}
+
+// IsAccountExists returns true if an account exists
+func (ar *AccountRepository) IsAccountExists(ctx context.Context, accntID account.AccountID) (bool, error) {
+	var exists bool
+	ar.db.view(func(d *data) {
+		_, exists = d.accounts[accntID]
+	})
+
+	return exists, nil
+}
+
+// GetAccountStatus retrieves the account status within tx
+func (ar *AccountRepository) GetAccountStatus(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID) (account.Status, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	accnt, ok := txx.data.accounts[accntID]
+	if !ok {
+		return 0, account.ErrAccountNotFound
+	}
+
+	return accnt.Status, nil
+}
+
+// UpdateAccountStatus updates the account status and records the status change within tx
+func (ar *AccountRepository) UpdateAccountStatus(ctx context.Context,
+	tx tx.Tx, sc account.StatusChange) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	accnt, ok := txx.data.accounts[sc.AccountID]
+	if !ok {
+		return account.ErrAccountNotFound
+	}
+
+	accnt.Status = sc.Status
+	txx.data.accounts[sc.AccountID] = accnt
+
+	sc.Ts = timePtr(txx.ts)
+	txx.data.accntStatusChanges = append(txx.data.accntStatusChanges, sc)
+
+	return nil
+}
+
+// ListStatusChanges retrieves the status changes of the account
+func (ar *AccountRepository) ListStatusChanges(ctx context.Context,
+	accntID account.AccountID) ([]*account.StatusChange, error) {
+	scs := []*account.StatusChange{}
+	ar.db.view(func(d *data) {
+		for _, sc := range d.accntStatusChanges {
+			if sc.AccountID == accntID {
+				sc := sc
+				scs = append(scs, &sc)
+			}
+		}
+	})
+
+	return scs, nil
+}
+
+// GetOverdraftLimit retrieves the overdraft limit of the account within tx
+func (ar *AccountRepository) GetOverdraftLimit(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID) (decimal.Decimal, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	accnt, ok := txx.data.accounts[accntID]
+	if !ok {
+		return decimal.Zero, account.ErrAccountNotFound
+	}
+
+	return accnt.OverdraftLimit, nil
+}
+
+// UpdateOverdraftLimit updates the overdraft limit of the account within tx
+func (ar *AccountRepository) UpdateOverdraftLimit(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID, limit decimal.Decimal) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAmounts(limit); err != nil {
+		return err
+	}
+
+	accnt, ok := txx.data.accounts[accntID]
+	if !ok {
+		return account.ErrAccountNotFound
+	}
+
+	accnt.OverdraftLimit = limit
+	txx.data.accounts[accntID] = accnt
+
+	return nil
+}
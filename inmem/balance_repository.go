@@ -0,0 +1,138 @@
+package inmem
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/balance"
+	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// BalanceRepository implements the balance repository
+// interface and uses memory as back-end
+type BalanceRepository struct{ db *DB }
+
+var _ balance.Repository = (*BalanceRepository)(nil)
+
+// NewBalanceRepository returns a balance repository
+func NewBalanceRepository(db *DB) *BalanceRepository {
+	return &BalanceRepository{db: db}
+}
+
+// BeginTx begins a new tx
+func (br *BalanceRepository) BeginTx(ctx context.Context) (tx.Tx, error) {
+	return br.db.begin(ctx)
+}
+
+// GetAccntBal retrieves the account balance
+func (br *BalanceRepository) GetAccntBal(ctx context.Context, accntID account.AccountID) (*account.Balance, error) {
+	var (
+		accntBal *account.Balance
+		err      error
+	)
+	br.db.view(func(d *data) {
+		accntBal, err = getAccntBal(d, accntID)
+	})
+
+	return accntBal, err
+}
+
+// GetAccntBalForUpdate retrieves the account balance within tx. The
+// txs are serialized, hence, the account is already locked.
+func (br *BalanceRepository) GetAccntBalForUpdate(ctx context.Context, tx tx.Tx, accntID account.AccountID) (*account.Balance, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	accntBal, err := getAccntBal(txx.data, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "lock account")
+	}
+
+	return accntBal, nil
+}
+
+// getAccntBal returns the account balance and the available balance less the active holds
+func getAccntBal(d *data, accntID account.AccountID) (*account.Balance, error) {
+	if _, ok := d.accounts[accntID]; !ok {
+		return nil, account.ErrAccountNotFound
+	}
+
+	accntBal, ok := d.balances[accntID]
+	if !ok {
+		// no transaction record yet
+		accntBal = account.Balance{AccountID: accntID}
+	}
+
+	now := time.Now()
+	held := decimal.Zero
+	for _, hold := range d.holds {
+		if hold.AccountID == accntID &&
+			hold.Status == transaction.HoldStatusActive &&
+			hold.ExpiresAt.After(now) {
+			held = held.Add(hold.Amount)
+		}
+	}
+	accntBal.AvailableBal = accntBal.CurrentBal.Sub(held)
+
+	return &accntBal, nil
+}
+
+// GetAccntBalAsOf retrieves the account balance from the postings made before the time
+func (br *BalanceRepository) GetAccntBalAsOf(ctx context.Context,
+	accntID account.AccountID, asOf time.Time) (*account.Balance, error) {
+	var (
+		accntBal = account.Balance{AccountID: accntID}
+		ok       bool
+	)
+	br.db.view(func(d *data) {
+		if _, ok = d.accounts[accntID]; !ok {
+			return
+		}
+
+		for _, xact := range d.xacts {
+			if xact.AccountID != accntID || !xact.Ts.Before(asOf) {
+				continue
+			}
+
+			// a ledger's debit is the account's credit and vice versa
+			if xact.XactType == transaction.XactTypeDebit {
+				accntBal.TotalCredit = accntBal.TotalCredit.Add(xact.Amount)
+			} else {
+				accntBal.TotalDebit = accntBal.TotalDebit.Add(xact.Amount)
+			}
+			accntBal.Ts = xact.Ts
+		}
+	})
+
+	if !ok {
+		return nil, account.ErrAccountNotFound
+	}
+	accntBal.CurrentBal = accntBal.TotalCredit.Sub(accntBal.TotalDebit)
+	accntBal.AvailableBal = accntBal.CurrentBal
+
+	return &accntBal, nil
+}
+
+// LockAccnts locks the accounts within tx. The txs are serialized, hence,
+// the accounts are already locked, it only checks that the accounts exist.
+func (br *BalanceRepository) LockAccnts(ctx context.Context, tx tx.Tx, accntIDs ...account.AccountID) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, accntID := range accntIDs {
+		if _, ok := txx.data.accounts[accntID]; !ok {
+			return errors.Wrapf(account.ErrAccountNotFound, "lock account %s", accntID)
+		}
+	}
+
+	return nil
+}
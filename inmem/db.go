@@ -0,0 +1,254 @@
+// Package inmem implements the repositories in memory, it is used for
+// testing and demos. The data is lost when the process exits.
+package inmem
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/outbox"
+	"github.com/stevenferrer/kalupi/schedule"
+	"github.com/stevenferrer/kalupi/transaction"
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+// ErrTxDone is an error when using a tx that was already committed or rolled back
+var ErrTxDone = errors.New("tx has already been committed or rolled back")
+
+// DB is an in-memory database. The txs are serialized i.e. only one tx is
+// running at a time, and the changes of a tx are only visible to the others
+// after it is committed. The writes outside of a tx run in their own tx.
+type DB struct {
+	// sem is held by the running tx
+	sem chan struct{}
+
+	// mu guards the committed data
+	mu   sync.RWMutex
+	data *data
+}
+
+// New returns an empty in-memory database
+func New() *DB {
+	return &DB{
+		sem:  make(chan struct{}, 1),
+		data: newData(),
+	}
+}
+
+// Tx is an in-memory tx, it works on a copy of the committed data
+type Tx struct {
+	db   *DB
+	data *data
+	// ts is the start of the tx, used as the timestamp of the rows
+	ts   time.Time
+	done bool
+}
+
+var _ tx.Tx = (*Tx)(nil)
+
+// Commit commits the tx
+func (t *Tx) Commit() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+
+	t.db.mu.Lock()
+	t.db.data = t.data
+	t.db.mu.Unlock()
+
+	<-t.db.sem
+	return nil
+}
+
+// Rollback discards the changes of the tx
+func (t *Tx) Rollback() error {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+
+	<-t.db.sem
+	return nil
+}
+
+// begin begins a new tx, it blocks until the running tx is done
+func (db *DB) begin(ctx context.Context) (*Tx, error) {
+	select {
+	case db.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	db.mu.RLock()
+	d := db.data.clone()
+	db.mu.RUnlock()
+
+	return &Tx{db: db, data: d, ts: time.Now()}, nil
+}
+
+// update runs fn in a tx and commits it if fn succeeds
+func (db *DB) update(ctx context.Context, fn func(*Tx) error) error {
+	t, err := db.begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = fn(t)
+	if err != nil {
+		_ = t.Rollback()
+		return err
+	}
+
+	return t.Commit()
+}
+
+// view runs fn against the committed data, the data must not be modified
+func (db *DB) view(fn func(*data)) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	fn(db.data)
+}
+
+// asTx asserts that the tx is a running in-memory tx
+func asTx(t tx.Tx) (*Tx, error) {
+	txx, ok := t.(*Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *inmem.Tx")
+	}
+
+	if txx.done {
+		return nil, ErrTxDone
+	}
+
+	return txx, nil
+}
+
+// data is the set of tables, the rows are stored as values so
+// that a shallow copy of the maps and slices is a full copy
+type data struct {
+	accounts           map[account.AccountID]account.Account
+	accountIDs         []account.AccountID // in order of creation
+	accntStatusChanges []account.StatusChange
+	ledgers            map[ledger.LedgerNo]ledger.Ledger
+	ledgerNos          []ledger.LedgerNo // in order of creation
+	xacts              []transaction.Transaction
+	ledgerXacts        []transaction.LedgerXact
+	balances           map[account.AccountID]account.Balance
+	fxTransfers        map[transaction.XactNo]transaction.FXTransfer
+	idemKeys           map[string]transaction.IdempotencyKey
+	reversals          []transaction.Reversal
+	holds              map[transaction.HoldID]transaction.Hold
+	instructions       map[schedule.InstructionID]schedule.Instruction
+	instructionIDs     []schedule.InstructionID // in order of creation
+	instructionRuns    []schedule.Run
+	outboxEvents       []outbox.Event // in order of commit
+	outboxSeq          int64          // last assigned sequence number
+	outboxCursors      map[string]int64
+	webhookSubs        map[webhook.SubscriptionID]webhook.Subscription
+	webhookSubIDs      []webhook.SubscriptionID // in order of creation
+	webhookDeliveries  map[webhook.DeliveryID]webhook.Delivery
+	webhookDlvIDs      []webhook.DeliveryID // in order of creation
+}
+
+func newData() *data {
+	return &data{
+		accounts:          map[account.AccountID]account.Account{},
+		ledgers:           map[ledger.LedgerNo]ledger.Ledger{},
+		balances:          map[account.AccountID]account.Balance{},
+		fxTransfers:       map[transaction.XactNo]transaction.FXTransfer{},
+		idemKeys:          map[string]transaction.IdempotencyKey{},
+		holds:             map[transaction.HoldID]transaction.Hold{},
+		instructions:      map[schedule.InstructionID]schedule.Instruction{},
+		outboxCursors:     map[string]int64{},
+		webhookSubs:       map[webhook.SubscriptionID]webhook.Subscription{},
+		webhookDeliveries: map[webhook.DeliveryID]webhook.Delivery{},
+	}
+}
+
+// clone returns a copy of the data
+func (d *data) clone() *data {
+	c := &data{
+		accounts:           make(map[account.AccountID]account.Account, len(d.accounts)),
+		accountIDs:         append([]account.AccountID(nil), d.accountIDs...),
+		accntStatusChanges: append([]account.StatusChange(nil), d.accntStatusChanges...),
+		ledgers:            make(map[ledger.LedgerNo]ledger.Ledger, len(d.ledgers)),
+		ledgerNos:          append([]ledger.LedgerNo(nil), d.ledgerNos...),
+		xacts:              append([]transaction.Transaction(nil), d.xacts...),
+		ledgerXacts:        append([]transaction.LedgerXact(nil), d.ledgerXacts...),
+		balances:           make(map[account.AccountID]account.Balance, len(d.balances)),
+		fxTransfers:        make(map[transaction.XactNo]transaction.FXTransfer, len(d.fxTransfers)),
+		idemKeys:           make(map[string]transaction.IdempotencyKey, len(d.idemKeys)),
+		reversals:          append([]transaction.Reversal(nil), d.reversals...),
+		holds:              make(map[transaction.HoldID]transaction.Hold, len(d.holds)),
+		instructions:       make(map[schedule.InstructionID]schedule.Instruction, len(d.instructions)),
+		instructionIDs:     append([]schedule.InstructionID(nil), d.instructionIDs...),
+		instructionRuns:    append([]schedule.Run(nil), d.instructionRuns...),
+		outboxEvents:       append([]outbox.Event(nil), d.outboxEvents...),
+		outboxSeq:          d.outboxSeq,
+		outboxCursors:      make(map[string]int64, len(d.outboxCursors)),
+		webhookSubs:        make(map[webhook.SubscriptionID]webhook.Subscription, len(d.webhookSubs)),
+		webhookSubIDs:      append([]webhook.SubscriptionID(nil), d.webhookSubIDs...),
+		webhookDeliveries:  make(map[webhook.DeliveryID]webhook.Delivery, len(d.webhookDeliveries)),
+		webhookDlvIDs:      append([]webhook.DeliveryID(nil), d.webhookDlvIDs...),
+	}
+
+	for k, v := range d.accounts {
+		c.accounts[k] = v
+	}
+	for k, v := range d.ledgers {
+		c.ledgers[k] = v
+	}
+	for k, v := range d.balances {
+		c.balances[k] = v
+	}
+	for k, v := range d.fxTransfers {
+		c.fxTransfers[k] = v
+	}
+	for k, v := range d.idemKeys {
+		c.idemKeys[k] = v
+	}
+	for k, v := range d.holds {
+		c.holds[k] = v
+	}
+	for k, v := range d.instructions {
+		c.instructions[k] = v
+	}
+	for k, v := range d.outboxCursors {
+		c.outboxCursors[k] = v
+	}
+	for k, v := range d.webhookSubs {
+		c.webhookSubs[k] = v
+	}
+	for k, v := range d.webhookDeliveries {
+		c.webhookDeliveries[k] = v
+	}
+
+	return c
+}
+
+// maxAmount is the exclusive limit of the amounts, same as numeric(15, 4)
+var maxAmount = decimal.New(1, 11)
+
+// checkAmounts returns an error if an amount doesn't fit in numeric(15, 4)
+func checkAmounts(amounts ...decimal.Decimal) error {
+	for _, amount := range amounts {
+		if amount.Round(4).Abs().GreaterThanOrEqual(maxAmount) {
+			return errors.Errorf("amount %s is out of range", amount)
+		}
+	}
+
+	return nil
+}
+
+// timePtr returns a pointer to a copy of the time
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,121 @@
+package inmem_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/inmem"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+func TestTx(t *testing.T) {
+	db := inmem.New()
+	ctx := context.TODO()
+
+	accountRepo := inmem.NewAccountRepository(db)
+	johnDoe := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, johnDoe)
+	require.NoError(t, err)
+
+	ledgerRepo := inmem.NewLedgerRepository(db)
+	err = ledger.NewService(ledgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := inmem.NewBalanceRepository(db)
+	xactRepo := inmem.NewXactRepository(db)
+
+	deposit := func(t *testing.T, tx tx.Tx) transaction.XactNo {
+		xactNo, err := transaction.NewXactNo()
+		require.NoError(t, err)
+
+		err = xactRepo.CreateXact(ctx, tx, transaction.Transaction{
+			XactNo:      xactNo,
+			LedgerNo:    ledger.CashUSDLedgerNo,
+			XactType:    transaction.XactTypeDebit,
+			AccountID:   johnDoe.AccountID,
+			XactTypeExt: transaction.XactTypeExtDeposit,
+			Amount:      decimal.NewFromInt(100),
+		})
+		require.NoError(t, err)
+
+		return xactNo
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+		tx, err := xactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		xactNo := deposit(t, tx)
+
+		// visible within the tx
+		legs, err := xactRepo.ListXactLegs(ctx, tx, xactNo)
+		require.NoError(t, err)
+		assert.Len(t, legs, 1)
+
+		// not visible outside of the tx
+		_, err = xactRepo.GetXact(ctx, xactNo)
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+
+		err = tx.Rollback()
+		require.NoError(t, err)
+
+		_, err = xactRepo.GetXact(ctx, xactNo)
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+
+		err = tx.Commit()
+		assert.ErrorIs(t, err, inmem.ErrTxDone)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		tx, err := xactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		xactNo := deposit(t, tx)
+
+		err = tx.Commit()
+		require.NoError(t, err)
+
+		xact, err := xactRepo.GetXact(ctx, xactNo)
+		require.NoError(t, err)
+		assert.Len(t, xact.Legs, 1)
+
+		_, err = xactRepo.ListXactLegs(ctx, tx, xactNo)
+		assert.ErrorIs(t, err, inmem.ErrTxDone)
+
+		tx, err = balRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Commit())
+		}()
+
+		bal, err := balRepo.GetAccntBalForUpdate(ctx, tx, johnDoe.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(100).Equal(bal.CurrentBal))
+	})
+
+	t.Run("serialized", func(t *testing.T) {
+		tx, err := xactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		// blocks until the running tx is done
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = xactRepo.BeginTx(timeoutCtx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		err = tx.Rollback()
+		require.NoError(t, err)
+	})
+}
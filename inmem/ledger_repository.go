@@ -0,0 +1,183 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// LedgerRepository implements the ledger repository
+// interface and uses memory as back-end
+type LedgerRepository struct{ db *DB }
+
+var _ ledger.Repository = (*LedgerRepository)(nil)
+
+// NewLedgerRepository returns a new ledger repository
+func NewLedgerRepository(db *DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// CreateLedgersIfNotExists will create the ledgers if it doesn't exists yet
+func (lr *LedgerRepository) CreateLedgersIfNotExists(ctx context.Context, lgs ...ledger.Ledger) error {
+	return lr.db.update(ctx, func(t *Tx) error {
+		for _, lg := range lgs {
+			if _, ok := t.data.ledgers[lg.LedgerNo]; ok {
+				continue
+			}
+
+			t.data.ledgers[lg.LedgerNo] = lg
+			t.data.ledgerNos = append(t.data.ledgerNos, lg.LedgerNo)
+		}
+
+		return nil
+	})
+}
+
+// GetLedger retrieves the ledger
+func (lr *LedgerRepository) GetLedger(ctx context.Context, ledgerNo ledger.LedgerNo) (*ledger.Ledger, error) {
+	var (
+		lg ledger.Ledger
+		ok bool
+	)
+	lr.db.view(func(d *data) {
+		lg, ok = d.ledgers[ledgerNo]
+	})
+
+	if !ok {
+		return nil, ledger.ErrLedgerNotFound
+	}
+
+	return &lg, nil
+}
+
+// ListLedgers retrieves the list of ledgers
+func (lr *LedgerRepository) ListLedgers(ctx context.Context) ([]*ledger.Ledger, error) {
+	lgs := []*ledger.Ledger{}
+	lr.db.view(func(d *data) {
+		for _, ledgerNo := range d.ledgerNos {
+			lg := d.ledgers[ledgerNo]
+			lgs = append(lgs, &lg)
+		}
+	})
+
+	return lgs, nil
+}
+
+// GetLedgerBal retrieves the ledger balance from the postings made before the time
+func (lr *LedgerRepository) GetLedgerBal(ctx context.Context,
+	ledgerNo ledger.LedgerNo, asOf time.Time) (*ledger.Balance, error) {
+	var (
+		lgBal *ledger.Balance
+		ok    bool
+	)
+	lr.db.view(func(d *data) {
+		var lg ledger.Ledger
+		lg, ok = d.ledgers[ledgerNo]
+		if !ok {
+			return
+		}
+
+		lgBal = ledgerBals(d, asOf)[lg.LedgerNo]
+	})
+
+	if !ok {
+		return nil, ledger.ErrLedgerNotFound
+	}
+
+	return lgBal, nil
+}
+
+// GetTrialBalance retrieves the ledger and account balances from the postings made before the time
+func (lr *LedgerRepository) GetTrialBalance(ctx context.Context,
+	asOf time.Time) (*ledger.TrialBalance, error) {
+	tb := ledger.TrialBalance{
+		AsOf:     asOf,
+		Ledgers:  []*ledger.Balance{},
+		Accounts: []*ledger.AccntBalance{},
+	}
+	lr.db.view(func(d *data) {
+		for _, lgBal := range ledgerBals(d, asOf) {
+			tb.Ledgers = append(tb.Ledgers, lgBal)
+		}
+
+		accntBals := map[account.AccountID]*ledger.AccntBalance{}
+		for _, xact := range d.xacts {
+			if !xact.Ts.Before(asOf) {
+				continue
+			}
+
+			accntBal, ok := accntBals[xact.AccountID]
+			if !ok {
+				accntBal = &ledger.AccntBalance{
+					AccountID: xact.AccountID,
+					Currency:  d.accounts[xact.AccountID].Currency,
+				}
+				accntBals[xact.AccountID] = accntBal
+				tb.Accounts = append(tb.Accounts, accntBal)
+			}
+
+			// a ledger's debit is the account's credit and vice versa
+			if xact.XactType == transaction.XactTypeDebit {
+				accntBal.TotalCredit = accntBal.TotalCredit.Add(xact.Amount)
+			} else {
+				accntBal.TotalDebit = accntBal.TotalDebit.Add(xact.Amount)
+			}
+			accntBal.CurrentBal = accntBal.TotalCredit.Sub(accntBal.TotalDebit)
+			accntBal.Ts = xact.Ts
+		}
+	})
+
+	sort.Slice(tb.Ledgers, func(i, j int) bool {
+		return tb.Ledgers[i].LedgerNo < tb.Ledgers[j].LedgerNo
+	})
+	sort.Slice(tb.Accounts, func(i, j int) bool {
+		return tb.Accounts[i].AccountID < tb.Accounts[j].AccountID
+	})
+
+	return &tb, nil
+}
+
+// ledgerBals returns the balances of the ledgers from the postings made before the time
+func ledgerBals(d *data, asOf time.Time) map[ledger.LedgerNo]*ledger.Balance {
+	lgBals := make(map[ledger.LedgerNo]*ledger.Balance, len(d.ledgers))
+	for ledgerNo, lg := range d.ledgers {
+		lgBals[ledgerNo] = &ledger.Balance{
+			LedgerNo: ledgerNo,
+			Currency: lg.Currency,
+		}
+	}
+
+	post := func(ledgerNo ledger.LedgerNo, xactType transaction.XactType,
+		amount decimal.Decimal, ts *time.Time) {
+		if !ts.Before(asOf) {
+			return
+		}
+
+		lgBal := lgBals[ledgerNo]
+		if xactType == transaction.XactTypeDebit {
+			lgBal.TotalDebit = lgBal.TotalDebit.Add(amount)
+		} else {
+			lgBal.TotalCredit = lgBal.TotalCredit.Add(amount)
+		}
+		lgBal.CurrentBal = lgBal.TotalDebit.Sub(lgBal.TotalCredit)
+		if lgBal.Ts == nil || ts.After(*lgBal.Ts) {
+			lgBal.Ts = ts
+		}
+	}
+
+	for _, xact := range d.xacts {
+		post(xact.LedgerNo, xact.XactType, xact.Amount, xact.Ts)
+	}
+
+	for _, lx := range d.ledgerXacts {
+		post(lx.LedgerNo, lx.XactType, lx.Amount, lx.Ts)
+	}
+
+	return lgBals
+}
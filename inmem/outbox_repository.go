@@ -0,0 +1,74 @@
+package inmem
+
+import (
+	"context"
+
+	"github.com/stevenferrer/kalupi/outbox"
+)
+
+// OutboxRepository implements the outbox repository
+// interface and uses memory as back-end
+type OutboxRepository struct{ db *DB }
+
+var _ outbox.Repository = (*OutboxRepository)(nil)
+
+// NewOutboxRepository returns an outbox repository
+func NewOutboxRepository(db *DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// SequenceEvents assigns the next sequence numbers to the committed
+// events that don't have one, in the order they were committed
+func (or *OutboxRepository) SequenceEvents(ctx context.Context) error {
+	return or.db.update(ctx, func(t *Tx) error {
+		for i, ev := range t.data.outboxEvents {
+			if ev.Seq != 0 {
+				continue
+			}
+
+			t.data.outboxSeq++
+			t.data.outboxEvents[i].Seq = t.data.outboxSeq
+		}
+
+		return nil
+	})
+}
+
+// ListEvents retrieves up to n sequenced events after the sequence number
+func (or *OutboxRepository) ListEvents(ctx context.Context,
+	after int64, n int) ([]*outbox.Event, error) {
+	evs := []*outbox.Event{}
+	or.db.view(func(d *data) {
+		// the sequenced events come first and are in order
+		for _, ev := range d.outboxEvents {
+			if len(evs) == n || ev.Seq == 0 {
+				break
+			}
+
+			if ev.Seq > after {
+				ev := ev
+				evs = append(evs, &ev)
+			}
+		}
+	})
+
+	return evs, nil
+}
+
+// GetCursor retrieves the sequence number of the last event published by the relay
+func (or *OutboxRepository) GetCursor(ctx context.Context, relay string) (int64, error) {
+	var seq int64
+	or.db.view(func(d *data) {
+		seq = d.outboxCursors[relay]
+	})
+
+	return seq, nil
+}
+
+// SetCursor sets the sequence number of the last event published by the relay
+func (or *OutboxRepository) SetCursor(ctx context.Context, relay string, seq int64) error {
+	return or.db.update(ctx, func(t *Tx) error {
+		t.data.outboxCursors[relay] = seq
+		return nil
+	})
+}
@@ -0,0 +1,23 @@
+package inmem_test
+
+import (
+	"testing"
+
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/inmem"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (*repotest.Repos, func()) {
+		db := inmem.New()
+		return &repotest.Repos{
+			AccountRepo:  inmem.NewAccountRepository(db),
+			LedgerRepo:   inmem.NewLedgerRepository(db),
+			XactRepo:     inmem.NewXactRepository(db),
+			BalRepo:      inmem.NewBalanceRepository(db),
+			ScheduleRepo: inmem.NewScheduleRepository(db),
+			OutboxRepo:   inmem.NewOutboxRepository(db),
+			WebhookRepo:  inmem.NewWebhookRepository(db),
+		}, func() {}
+	})
+}
@@ -0,0 +1,197 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/schedule"
+)
+
+// ScheduleRepository implements the schedule repository
+// interface and uses memory as back-end
+type ScheduleRepository struct{ db *DB }
+
+var _ schedule.Repository = (*ScheduleRepository)(nil)
+
+// NewScheduleRepository returns a schedule repository
+func NewScheduleRepository(db *DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// CreateInstruction creates an instruction
+func (sr *ScheduleRepository) CreateInstruction(ctx context.Context,
+	inst schedule.Instruction) error {
+	if err := checkAmounts(inst.Amount); err != nil {
+		return err
+	}
+
+	return sr.db.update(ctx, func(t *Tx) error {
+		inst.Attempts = 0
+		inst.LastError = ""
+		inst.LeaseOwner = ""
+		inst.LeaseExpiresAt = nil
+		inst.Ts = timePtr(t.ts)
+
+		t.data.instructions[inst.InstructionID] = inst
+		t.data.instructionIDs = append(t.data.instructionIDs, inst.InstructionID)
+		return nil
+	})
+}
+
+// GetInstruction retrieves the instruction
+func (sr *ScheduleRepository) GetInstruction(ctx context.Context,
+	instID schedule.InstructionID) (*schedule.Instruction, error) {
+	var (
+		inst schedule.Instruction
+		ok   bool
+	)
+	sr.db.view(func(d *data) {
+		inst, ok = d.instructions[instID]
+	})
+
+	if !ok {
+		return nil, schedule.ErrInstructionNotFound
+	}
+
+	return &inst, nil
+}
+
+// ListInstructions retrieves the instructions sent from or to
+// the account, or all the instructions if the account id is empty
+func (sr *ScheduleRepository) ListInstructions(ctx context.Context,
+	accntID account.AccountID) ([]*schedule.Instruction, error) {
+	insts := []*schedule.Instruction{}
+	sr.db.view(func(d *data) {
+		for _, instID := range d.instructionIDs {
+			inst := d.instructions[instID]
+			if accntID == "" || inst.FromAccount == accntID ||
+				inst.ToAccount == accntID {
+				insts = append(insts, &inst)
+			}
+		}
+	})
+
+	return insts, nil
+}
+
+// UpdateInstruction updates the amount, desc, schedule, status,
+// next run and attempts of the instruction unless it is leased
+func (sr *ScheduleRepository) UpdateInstruction(ctx context.Context,
+	upd schedule.Instruction, now time.Time) error {
+	if err := checkAmounts(upd.Amount); err != nil {
+		return err
+	}
+
+	return sr.db.update(ctx, func(t *Tx) error {
+		inst, ok := t.data.instructions[upd.InstructionID]
+		if !ok {
+			return schedule.ErrInstructionNotFound
+		}
+
+		if isLeased(inst, now) {
+			return schedule.ErrInstructionBusy
+		}
+
+		inst.Amount = upd.Amount
+		inst.Desc = upd.Desc
+		inst.Frequency = upd.Frequency
+		inst.CronExpr = upd.CronExpr
+		inst.StartAt = upd.StartAt
+		inst.EndAt = upd.EndAt
+		inst.Status = upd.Status
+		inst.NextRunAt = upd.NextRunAt
+		inst.NextAttemptAt = upd.NextAttemptAt
+		inst.Attempts = upd.Attempts
+		t.data.instructions[inst.InstructionID] = inst
+
+		return nil
+	})
+}
+
+// LeaseDueInstructions leases up to n active instructions that are due
+func (sr *ScheduleRepository) LeaseDueInstructions(ctx context.Context, owner string,
+	now time.Time, ttl time.Duration, n int) ([]*schedule.Instruction, error) {
+	insts := []*schedule.Instruction{}
+	err := sr.db.update(ctx, func(t *Tx) error {
+		due := []schedule.Instruction{}
+		for _, instID := range t.data.instructionIDs {
+			inst := t.data.instructions[instID]
+			if inst.Status == schedule.StatusActive &&
+				!inst.NextAttemptAt.After(now) && !isLeased(inst, now) {
+				due = append(due, inst)
+			}
+		}
+
+		sort.SliceStable(due, func(i, j int) bool {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		})
+
+		if len(due) > n {
+			due = due[:n]
+		}
+
+		for _, inst := range due {
+			inst := inst
+			inst.LeaseOwner = owner
+			inst.LeaseExpiresAt = timePtr(now.Add(ttl))
+			t.data.instructions[inst.InstructionID] = inst
+			insts = append(insts, &inst)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return insts, nil
+}
+
+// RecordRun updates the instruction leased by the owner,
+// releases the lease and records the run
+func (sr *ScheduleRepository) RecordRun(ctx context.Context,
+	upd schedule.Instruction, run schedule.Run) error {
+	return sr.db.update(ctx, func(t *Tx) error {
+		inst, ok := t.data.instructions[upd.InstructionID]
+		if !ok || inst.LeaseOwner == "" || inst.LeaseOwner != upd.LeaseOwner {
+			return schedule.ErrLeaseLost
+		}
+
+		inst.Status = upd.Status
+		inst.NextRunAt = upd.NextRunAt
+		inst.NextAttemptAt = upd.NextAttemptAt
+		inst.Attempts = upd.Attempts
+		inst.LastError = upd.LastError
+		inst.LeaseOwner = ""
+		inst.LeaseExpiresAt = nil
+		t.data.instructions[inst.InstructionID] = inst
+
+		run.Ts = timePtr(t.ts)
+		t.data.instructionRuns = append(t.data.instructionRuns, run)
+
+		return nil
+	})
+}
+
+// ListRuns retrieves the runs of the instruction
+func (sr *ScheduleRepository) ListRuns(ctx context.Context,
+	instID schedule.InstructionID) ([]*schedule.Run, error) {
+	runs := []*schedule.Run{}
+	sr.db.view(func(d *data) {
+		for _, run := range d.instructionRuns {
+			if run.InstructionID == instID {
+				run := run
+				runs = append(runs, &run)
+			}
+		}
+	})
+
+	return runs, nil
+}
+
+// isLeased returns true if the instruction is leased at now
+func isLeased(inst schedule.Instruction, now time.Time) bool {
+	return inst.LeaseExpiresAt != nil && inst.LeaseExpiresAt.After(now)
+}
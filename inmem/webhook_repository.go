@@ -0,0 +1,282 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+// WebhookRepository implements the webhook repository
+// interface and uses memory as back-end
+type WebhookRepository struct{ db *DB }
+
+var _ webhook.Repository = (*WebhookRepository)(nil)
+
+// NewWebhookRepository returns a webhook repository
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription creates a subscription
+func (wr *WebhookRepository) CreateSubscription(ctx context.Context,
+	sub webhook.Subscription) error {
+	return wr.db.update(ctx, func(t *Tx) error {
+		// the rows must not share the slice with the caller
+		if len(sub.XactTypeExts) > 0 {
+			sub.XactTypeExts = append([]transaction.XactTypeExt(nil),
+				sub.XactTypeExts...)
+		} else {
+			sub.XactTypeExts = nil
+		}
+		sub.Ts = timePtr(t.ts)
+
+		t.data.webhookSubs[sub.SubscriptionID] = sub
+		t.data.webhookSubIDs = append(t.data.webhookSubIDs, sub.SubscriptionID)
+		return nil
+	})
+}
+
+// GetSubscription retrieves the subscription
+func (wr *WebhookRepository) GetSubscription(ctx context.Context,
+	subID webhook.SubscriptionID) (*webhook.Subscription, error) {
+	var (
+		sub webhook.Subscription
+		ok  bool
+	)
+	wr.db.view(func(d *data) {
+		sub, ok = d.webhookSubs[subID]
+	})
+
+	if !ok {
+		return nil, webhook.ErrSubscriptionNotFound
+	}
+
+	return &sub, nil
+}
+
+// ListSubscriptions retrieves the subscriptions to the account and
+// to all the accounts, or all the subscriptions if the account id is empty
+func (wr *WebhookRepository) ListSubscriptions(ctx context.Context,
+	accntID account.AccountID) ([]*webhook.Subscription, error) {
+	subs := []*webhook.Subscription{}
+	wr.db.view(func(d *data) {
+		for _, subID := range d.webhookSubIDs {
+			sub := d.webhookSubs[subID]
+			if accntID == "" || sub.AccountID == "" || sub.AccountID == accntID {
+				subs = append(subs, &sub)
+			}
+		}
+	})
+
+	return subs, nil
+}
+
+// DeleteSubscription deletes the subscription and its deliveries
+func (wr *WebhookRepository) DeleteSubscription(ctx context.Context,
+	subID webhook.SubscriptionID) error {
+	return wr.db.update(ctx, func(t *Tx) error {
+		if _, ok := t.data.webhookSubs[subID]; !ok {
+			return webhook.ErrSubscriptionNotFound
+		}
+
+		delete(t.data.webhookSubs, subID)
+		subIDs := []webhook.SubscriptionID{}
+		for _, id := range t.data.webhookSubIDs {
+			if id != subID {
+				subIDs = append(subIDs, id)
+			}
+		}
+		t.data.webhookSubIDs = subIDs
+
+		dlvIDs := []webhook.DeliveryID{}
+		for _, id := range t.data.webhookDlvIDs {
+			if t.data.webhookDeliveries[id].SubscriptionID == subID {
+				delete(t.data.webhookDeliveries, id)
+				continue
+			}
+			dlvIDs = append(dlvIDs, id)
+		}
+		t.data.webhookDlvIDs = dlvIDs
+
+		return nil
+	})
+}
+
+// CreateDeliveries creates the deliveries that don't exist
+func (wr *WebhookRepository) CreateDeliveries(ctx context.Context,
+	deliveries []webhook.Delivery) error {
+	return wr.db.update(ctx, func(t *Tx) error {
+		exists := map[webhook.SubscriptionID]map[int64]bool{}
+		for _, id := range t.data.webhookDlvIDs {
+			d := t.data.webhookDeliveries[id]
+			if exists[d.SubscriptionID] == nil {
+				exists[d.SubscriptionID] = map[int64]bool{}
+			}
+			exists[d.SubscriptionID][d.EventSeq] = true
+		}
+
+		for _, d := range deliveries {
+			if _, ok := t.data.webhookSubs[d.SubscriptionID]; !ok {
+				return webhook.ErrSubscriptionNotFound
+			}
+
+			if exists[d.SubscriptionID][d.EventSeq] {
+				continue
+			}
+			if exists[d.SubscriptionID] == nil {
+				exists[d.SubscriptionID] = map[int64]bool{}
+			}
+			exists[d.SubscriptionID][d.EventSeq] = true
+
+			d.Attempts = 0
+			d.LastStatusCode = 0
+			d.LastError = ""
+			d.DeliveredAt = nil
+			d.LeaseOwner = ""
+			d.LeaseExpiresAt = nil
+			d.Ts = timePtr(t.ts)
+
+			t.data.webhookDeliveries[d.DeliveryID] = d
+			t.data.webhookDlvIDs = append(t.data.webhookDlvIDs, d.DeliveryID)
+		}
+
+		return nil
+	})
+}
+
+// GetDelivery retrieves the delivery
+func (wr *WebhookRepository) GetDelivery(ctx context.Context,
+	deliveryID webhook.DeliveryID) (*webhook.Delivery, error) {
+	var (
+		d  webhook.Delivery
+		ok bool
+	)
+	wr.db.view(func(dt *data) {
+		d, ok = dt.webhookDeliveries[deliveryID]
+	})
+
+	if !ok {
+		return nil, webhook.ErrDeliveryNotFound
+	}
+
+	return &d, nil
+}
+
+// ListDeliveries retrieves the deliveries to the subscription
+func (wr *WebhookRepository) ListDeliveries(ctx context.Context,
+	subID webhook.SubscriptionID) ([]*webhook.Delivery, error) {
+	deliveries := []*webhook.Delivery{}
+	wr.db.view(func(dt *data) {
+		for _, id := range dt.webhookDlvIDs {
+			d := dt.webhookDeliveries[id]
+			if d.SubscriptionID == subID {
+				deliveries = append(deliveries, &d)
+			}
+		}
+	})
+
+	sort.SliceStable(deliveries, func(i, j int) bool {
+		return deliveries[i].EventSeq < deliveries[j].EventSeq
+	})
+
+	return deliveries, nil
+}
+
+// UpdateDelivery updates the status, attempts and
+// next attempt of the delivery unless it is leased
+func (wr *WebhookRepository) UpdateDelivery(ctx context.Context,
+	upd webhook.Delivery, now time.Time) error {
+	return wr.db.update(ctx, func(t *Tx) error {
+		d, ok := t.data.webhookDeliveries[upd.DeliveryID]
+		if !ok {
+			return webhook.ErrDeliveryNotFound
+		}
+
+		if isDeliveryLeased(d, now) {
+			return webhook.ErrDeliveryBusy
+		}
+
+		d.Status = upd.Status
+		d.Attempts = upd.Attempts
+		d.NextAttemptAt = upd.NextAttemptAt
+		t.data.webhookDeliveries[d.DeliveryID] = d
+
+		return nil
+	})
+}
+
+// LeaseDueDeliveries leases up to n pending deliveries that are due
+func (wr *WebhookRepository) LeaseDueDeliveries(ctx context.Context, owner string,
+	now time.Time, ttl time.Duration, n int) ([]*webhook.Delivery, error) {
+	deliveries := []*webhook.Delivery{}
+	err := wr.db.update(ctx, func(t *Tx) error {
+		due := []webhook.Delivery{}
+		for _, id := range t.data.webhookDlvIDs {
+			d := t.data.webhookDeliveries[id]
+			if d.Status == webhook.StatusPending &&
+				!d.NextAttemptAt.After(now) && !isDeliveryLeased(d, now) {
+				due = append(due, d)
+			}
+		}
+
+		sort.SliceStable(due, func(i, j int) bool {
+			if due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+				return due[i].EventSeq < due[j].EventSeq
+			}
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		})
+
+		if len(due) > n {
+			due = due[:n]
+		}
+
+		for _, d := range due {
+			d := d
+			d.LeaseOwner = owner
+			d.LeaseExpiresAt = timePtr(now.Add(ttl))
+			t.data.webhookDeliveries[d.DeliveryID] = d
+			deliveries = append(deliveries, &d)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt updates the delivery leased by the owner and releases the lease
+func (wr *WebhookRepository) RecordAttempt(ctx context.Context, upd webhook.Delivery) error {
+	return wr.db.update(ctx, func(t *Tx) error {
+		d, ok := t.data.webhookDeliveries[upd.DeliveryID]
+		if !ok || d.LeaseOwner == "" || d.LeaseOwner != upd.LeaseOwner {
+			return webhook.ErrLeaseLost
+		}
+
+		d.Status = upd.Status
+		d.Attempts = upd.Attempts
+		d.NextAttemptAt = upd.NextAttemptAt
+		d.LastStatusCode = upd.LastStatusCode
+		d.LastError = upd.LastError
+		d.DeliveredAt = nil
+		if upd.DeliveredAt != nil {
+			d.DeliveredAt = timePtr(*upd.DeliveredAt)
+		}
+		d.LeaseOwner = ""
+		d.LeaseExpiresAt = nil
+		t.data.webhookDeliveries[d.DeliveryID] = d
+
+		return nil
+	})
+}
+
+// isDeliveryLeased returns true if the delivery is leased at now
+func isDeliveryLeased(d webhook.Delivery, now time.Time) bool {
+	return d.LeaseExpiresAt != nil && d.LeaseExpiresAt.After(now)
+}
@@ -0,0 +1,624 @@
+package inmem
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// XactRepository implements the account transaction repository
+// interface and uses memory as back-end
+type XactRepository struct{ db *DB }
+
+var _ transaction.Repository = (*XactRepository)(nil)
+
+// NewXactRepository returns an account transaction repository
+func NewXactRepository(db *DB) *XactRepository {
+	return &XactRepository{db: db}
+}
+
+// BeginTx begins a new tx
+func (tr *XactRepository) BeginTx(ctx context.Context) (tx.Tx, error) {
+	return tr.db.begin(ctx)
+}
+
+// CreateXact creates an account transaction within a tx
+func (tr *XactRepository) CreateXact(ctx context.Context,
+	tx tx.Tx, xact transaction.Transaction) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAmounts(xact.Amount); err != nil {
+		return err
+	}
+
+	if _, ok := txx.data.accounts[xact.AccountID]; !ok {
+		return errors.Wrapf(account.ErrAccountNotFound, "account %s", xact.AccountID)
+	}
+
+	if _, ok := txx.data.ledgers[xact.LedgerNo]; !ok {
+		return errors.Errorf("ledger %s not found", xact.LedgerNo)
+	}
+
+	xact.Ts = timePtr(txx.ts)
+	// the insertion index is the serial leg id
+	xact.LegID = int64(len(txx.data.xacts) + 1)
+	txx.data.xacts = append(txx.data.xacts, xact)
+
+	// a ledger's debit is the account's credit and vice versa
+	credit, debit := decimal.Zero, decimal.Zero
+	if xact.XactType == transaction.XactTypeDebit {
+		credit = xact.Amount
+	} else {
+		debit = xact.Amount
+	}
+
+	// update the running balance of the account
+	bal, ok := txx.data.balances[xact.AccountID]
+	if !ok {
+		bal = account.Balance{AccountID: xact.AccountID}
+	}
+	bal.TotalCredit = bal.TotalCredit.Add(credit)
+	bal.TotalDebit = bal.TotalDebit.Add(debit)
+	bal.CurrentBal = bal.CurrentBal.Add(credit.Sub(debit))
+	bal.Ts = timePtr(txx.ts)
+	txx.data.balances[xact.AccountID] = bal
+
+	// the event is published once the tx is committed
+	ev, err := transaction.NewXactEvent(xact)
+	if err != nil {
+		return errors.Wrap(err, "new xact event")
+	}
+	txx.data.outboxEvents = append(txx.data.outboxEvents, *ev)
+
+	return nil
+}
+
+// CreateLedgerXact creates a ledger transaction within a tx
+func (tr *XactRepository) CreateLedgerXact(ctx context.Context,
+	tx tx.Tx, lx transaction.LedgerXact) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAmounts(lx.Amount); err != nil {
+		return err
+	}
+
+	if _, ok := txx.data.ledgers[lx.LedgerNo]; !ok {
+		return errors.Errorf("ledger %s not found", lx.LedgerNo)
+	}
+
+	lx.Ts = timePtr(txx.ts)
+	txx.data.ledgerXacts = append(txx.data.ledgerXacts, lx)
+
+	return nil
+}
+
+// CreateFXTransfer records the fx details of a transfer within a tx
+func (tr *XactRepository) CreateFXTransfer(ctx context.Context,
+	tx tx.Tx, fxTr transaction.FXTransfer) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAmounts(fxTr.FromAmount, fxTr.ToAmount); err != nil {
+		return err
+	}
+
+	if _, ok := txx.data.fxTransfers[fxTr.XactNo]; ok {
+		return errors.Errorf("fx transfer %s already exists", fxTr.XactNo)
+	}
+
+	fxTr.Ts = timePtr(txx.ts)
+	txx.data.fxTransfers[fxTr.XactNo] = fxTr
+
+	return nil
+}
+
+// CreateIdempotencyKeyIfNotExists creates the idempotency key
+// within a tx, returns false if the key already exists
+func (tr *XactRepository) CreateIdempotencyKeyIfNotExists(ctx context.Context,
+	tx tx.Tx, ik transaction.IdempotencyKey) (bool, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := txx.data.idemKeys[ik.Key]; ok {
+		return false, nil
+	}
+
+	ik.Ts = timePtr(txx.ts)
+	txx.data.idemKeys[ik.Key] = ik
+
+	return true, nil
+}
+
+// GetIdempotencyKey retrieves the idempotency key within a tx
+func (tr *XactRepository) GetIdempotencyKey(ctx context.Context,
+	tx tx.Tx, key string) (*transaction.IdempotencyKey, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	ik, ok := txx.data.idemKeys[key]
+	if !ok {
+		return nil, transaction.ErrIdempotencyKeyNotFound
+	}
+
+	return &ik, nil
+}
+
+// GetFXTransfer retrieves the fx details of a transfer
+func (tr *XactRepository) GetFXTransfer(ctx context.Context,
+	xactNo transaction.XactNo) (*transaction.FXTransfer, error) {
+	var (
+		fxTr transaction.FXTransfer
+		ok   bool
+	)
+	tr.db.view(func(d *data) {
+		fxTr, ok = d.fxTransfers[xactNo]
+	})
+
+	if !ok {
+		return nil, errors.Errorf("fx transfer %s not found", xactNo)
+	}
+
+	return &fxTr, nil
+}
+
+// GetXact retrieves the transaction and all of its legs
+func (tr *XactRepository) GetXact(ctx context.Context,
+	xactNo transaction.XactNo) (*transaction.Xact, error) {
+	var (
+		legs   []*transaction.Transaction
+		lgLegs []*transaction.LedgerXact
+	)
+	tr.db.view(func(d *data) {
+		legs = d.xactLegs(xactNo)
+		lgLegs = d.ledgerXactLegs(xactNo)
+	})
+
+	if len(legs) == 0 {
+		return nil, transaction.ErrXactNotFound
+	}
+
+	return &transaction.Xact{
+		XactNo:     xactNo,
+		Legs:       legs,
+		LedgerLegs: lgLegs,
+		Ts:         legs[0].Ts,
+	}, nil
+}
+
+// ListXactLegs retrieves the account transactions sharing the xact no within a tx
+func (tr *XactRepository) ListXactLegs(ctx context.Context, tx tx.Tx,
+	xactNo transaction.XactNo) ([]*transaction.Transaction, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return txx.data.xactLegs(xactNo), nil
+}
+
+// ListLedgerXactLegs retrieves the ledger transactions sharing the xact no within a tx
+func (tr *XactRepository) ListLedgerXactLegs(ctx context.Context, tx tx.Tx,
+	xactNo transaction.XactNo) ([]*transaction.LedgerXact, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return txx.data.ledgerXactLegs(xactNo), nil
+}
+
+// xactLegs returns the account transactions sharing the xact no
+func (d *data) xactLegs(xactNo transaction.XactNo) []*transaction.Transaction {
+	xacts := []*transaction.Transaction{}
+	for _, xact := range d.xacts {
+		if xact.XactNo == xactNo {
+			xact := xact
+			xacts = append(xacts, &xact)
+		}
+	}
+
+	return xacts
+}
+
+// ledgerXactLegs returns the ledger transactions sharing the xact no
+func (d *data) ledgerXactLegs(xactNo transaction.XactNo) []*transaction.LedgerXact {
+	lxs := []*transaction.LedgerXact{}
+	for _, lx := range d.ledgerXacts {
+		if lx.XactNo == xactNo {
+			lx := lx
+			lxs = append(lxs, &lx)
+		}
+	}
+
+	return lxs
+}
+
+// CreateReversal creates a reversal within a tx
+func (tr *XactRepository) CreateReversal(ctx context.Context,
+	tx tx.Tx, rev transaction.Reversal) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAmounts(rev.Amount); err != nil {
+		return err
+	}
+
+	for _, r := range txx.data.reversals {
+		if r.XactNo == rev.XactNo {
+			return errors.Errorf("reversal %s already exists", rev.XactNo)
+		}
+
+		// a transaction can only be reversed once
+		if r.OrigXactNo == rev.OrigXactNo &&
+			r.XactTypeExt == transaction.XactTypeExtReversal &&
+			rev.XactTypeExt == transaction.XactTypeExtReversal {
+			return errors.Errorf("transaction %s already reversed", rev.OrigXactNo)
+		}
+	}
+
+	rev.Ts = timePtr(txx.ts)
+	txx.data.reversals = append(txx.data.reversals, rev)
+
+	return nil
+}
+
+// ListReversals retrieves the reversals of the original transaction within a tx
+func (tr *XactRepository) ListReversals(ctx context.Context, tx tx.Tx,
+	origXactNo transaction.XactNo) ([]*transaction.Reversal, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	revs := []*transaction.Reversal{}
+	for _, rev := range txx.data.reversals {
+		if rev.OrigXactNo == origXactNo {
+			rev := rev
+			revs = append(revs, &rev)
+		}
+	}
+
+	return revs, nil
+}
+
+// CreateHold creates a hold within a tx
+func (tr *XactRepository) CreateHold(ctx context.Context,
+	tx tx.Tx, hold transaction.Hold) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAmounts(hold.Amount); err != nil {
+		return err
+	}
+
+	if _, ok := txx.data.accounts[hold.AccountID]; !ok {
+		return errors.Wrapf(account.ErrAccountNotFound, "account %s", hold.AccountID)
+	}
+
+	if _, ok := txx.data.holds[hold.HoldID]; ok {
+		return errors.Errorf("hold %s already exists", hold.HoldID)
+	}
+
+	hold.CapturedAmount = decimal.Zero
+	hold.XactNo = ""
+	hold.Ts = timePtr(txx.ts)
+	txx.data.holds[hold.HoldID] = hold
+
+	return nil
+}
+
+// GetHold retrieves the hold
+func (tr *XactRepository) GetHold(ctx context.Context,
+	holdID transaction.HoldID) (*transaction.Hold, error) {
+	var (
+		hold transaction.Hold
+		ok   bool
+	)
+	tr.db.view(func(d *data) {
+		hold, ok = d.holds[holdID]
+	})
+
+	if !ok {
+		return nil, transaction.ErrHoldNotFound
+	}
+
+	return &hold, nil
+}
+
+// GetHoldForUpdate retrieves the hold within a tx. The txs
+// are serialized, hence, the hold is already locked.
+func (tr *XactRepository) GetHoldForUpdate(ctx context.Context,
+	tx tx.Tx, holdID transaction.HoldID) (*transaction.Hold, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, ok := txx.data.holds[holdID]
+	if !ok {
+		return nil, transaction.ErrHoldNotFound
+	}
+
+	return &hold, nil
+}
+
+// UpdateHold updates the status, captured amount
+// and capture xact no of the hold within a tx
+func (tr *XactRepository) UpdateHold(ctx context.Context,
+	tx tx.Tx, hold transaction.Hold) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAmounts(hold.CapturedAmount); err != nil {
+		return err
+	}
+
+	h, ok := txx.data.holds[hold.HoldID]
+	if !ok {
+		return nil
+	}
+
+	h.Status = hold.Status
+	h.CapturedAmount = hold.CapturedAmount
+	h.XactNo = hold.XactNo
+	txx.data.holds[hold.HoldID] = h
+
+	return nil
+}
+
+// ListXacts retrieves the list of account transactions
+func (tr *XactRepository) ListXacts(ctx context.Context) ([]*transaction.Transaction, error) {
+	xacts := []*transaction.Transaction{}
+	tr.db.view(func(d *data) {
+		for _, xact := range d.xacts {
+			xact := xact
+			xacts = append(xacts, &xact)
+		}
+	})
+
+	return xacts, nil
+}
+
+// ListTransfers retrieves the list of transfer related account transactions,
+// including the fees of the transfers
+func (tr *XactRepository) ListTransfers(ctx context.Context) ([]*transaction.Transaction, error) {
+	xacts := []*transaction.Transaction{}
+	tr.db.view(func(d *data) {
+		transfers := map[transaction.XactNo]bool{}
+		for _, xact := range d.xacts {
+			if xact.XactTypeExt == transaction.XactTypeExtSndTransfer {
+				transfers[xact.XactNo] = true
+			}
+		}
+
+		for _, xact := range d.xacts {
+			switch xact.XactTypeExt {
+			case transaction.XactTypeExtSndTransfer,
+				transaction.XactTypeExtRcvTransfer:
+			case transaction.XactTypeExtFee:
+				if !transfers[xact.XactNo] {
+					continue
+				}
+			default:
+				continue
+			}
+
+			xact := xact
+			xacts = append(xacts, &xact)
+		}
+	})
+
+	return xacts, nil
+}
+
+// ListAccntXacts retrieves the account transactions matching the filter,
+// sorted by (ts, xact no, leg id) and starting after the cursor if it's set
+func (tr *XactRepository) ListAccntXacts(ctx context.Context,
+	f transaction.XactFilter) ([]*transaction.Transaction, error) {
+	xacts := []*transaction.Transaction{}
+	tr.db.view(func(d *data) {
+		for _, xact := range d.xacts {
+			if matchXactFilter(xact, f) {
+				xact := xact
+				xacts = append(xacts, &xact)
+			}
+		}
+	})
+
+	sort.Slice(xacts, func(i, j int) bool {
+		c := legCursor(xacts[j])
+		if f.Sort == transaction.SortAsc {
+			return legBefore(xacts[i], c)
+		}
+		return legAfter(xacts[i], c)
+	})
+
+	if f.Limit >= 0 && len(xacts) > f.Limit {
+		xacts = xacts[:f.Limit]
+	}
+
+	return xacts, nil
+}
+
+// matchXactFilter returns true if the transaction matches the filter
+func matchXactFilter(xact transaction.Transaction, f transaction.XactFilter) bool {
+	if xact.AccountID != f.AccountID {
+		return false
+	}
+
+	if len(f.XactTypeExts) > 0 {
+		found := false
+		for _, ttx := range f.XactTypeExts {
+			if xact.XactTypeExt == ttx {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if f.From != nil && xact.Ts.Before(*f.From) {
+		return false
+	}
+
+	if f.To != nil && !xact.Ts.Before(*f.To) {
+		return false
+	}
+
+	if f.MinAmount != nil && xact.Amount.LessThan(*f.MinAmount) {
+		return false
+	}
+
+	if f.MaxAmount != nil && xact.Amount.GreaterThan(*f.MaxAmount) {
+		return false
+	}
+
+	if f.Cursor != nil {
+		if f.Sort == transaction.SortAsc {
+			return legAfter(&xact, f.Cursor)
+		}
+		return legBefore(&xact, f.Cursor)
+	}
+
+	return true
+}
+
+// xactBefore returns true if (ts, xact no) of the transaction is before the given
+func xactBefore(xact *transaction.Transaction, ts *time.Time, xactNo transaction.XactNo) bool {
+	if !xact.Ts.Equal(*ts) {
+		return xact.Ts.Before(*ts)
+	}
+
+	return xact.XactNo < xactNo
+}
+
+// xactAfter returns true if (ts, xact no) of the transaction is after the given
+func xactAfter(xact *transaction.Transaction, ts *time.Time, xactNo transaction.XactNo) bool {
+	if !xact.Ts.Equal(*ts) {
+		return xact.Ts.After(*ts)
+	}
+
+	return xact.XactNo > xactNo
+}
+
+// legCursor returns the cursor pointing to the leg
+func legCursor(xact *transaction.Transaction) *transaction.Cursor {
+	return &transaction.Cursor{Ts: *xact.Ts, XactNo: xact.XactNo, LegID: xact.LegID}
+}
+
+// legBefore returns true if (ts, xact no, leg id) of the leg is before the cursor
+func legBefore(xact *transaction.Transaction, c *transaction.Cursor) bool {
+	if !xact.Ts.Equal(c.Ts) || xact.XactNo != c.XactNo {
+		return xactBefore(xact, &c.Ts, c.XactNo)
+	}
+
+	return xact.LegID < c.LegID
+}
+
+// legAfter returns true if (ts, xact no, leg id) of the leg is after the cursor
+func legAfter(xact *transaction.Transaction, c *transaction.Cursor) bool {
+	if !xact.Ts.Equal(c.Ts) || xact.XactNo != c.XactNo {
+		return xactAfter(xact, &c.Ts, c.XactNo)
+	}
+
+	return xact.LegID > c.LegID
+}
+
+// ListLedgerPostings retrieves the account and ledger transactions of the
+// ledger made from the inclusive start to the exclusive end time, sorted by (ts, xact no)
+func (tr *XactRepository) ListLedgerPostings(ctx context.Context, ledgerNo ledger.LedgerNo,
+	from, to time.Time) ([]*transaction.Transaction, error) {
+	xacts := []*transaction.Transaction{}
+	inPeriod := func(ts *time.Time) bool {
+		return !ts.Before(from) && ts.Before(to)
+	}
+
+	tr.db.view(func(d *data) {
+		for _, xact := range d.xacts {
+			if xact.LedgerNo == ledgerNo && inPeriod(xact.Ts) {
+				xact := xact
+				xacts = append(xacts, &xact)
+			}
+		}
+
+		for _, lx := range d.ledgerXacts {
+			if lx.LedgerNo == ledgerNo && inPeriod(lx.Ts) {
+				xacts = append(xacts, &transaction.Transaction{
+					XactNo:   lx.XactNo,
+					LedgerNo: lx.LedgerNo,
+					XactType: lx.XactType,
+					Amount:   lx.Amount,
+					Desc:     lx.Desc,
+					Ts:       lx.Ts,
+				})
+			}
+		}
+	})
+
+	// the account transactions are before the ledger transactions
+	// of the same (ts, xact no), the stable sort keeps them so
+	sort.SliceStable(xacts, func(i, j int) bool {
+		return xactBefore(xacts[i], xacts[j].Ts, xacts[j].XactNo)
+	})
+
+	return xacts, nil
+}
+
+// GetOutgoingUsage retrieves the amount withdrawn and sent, and the
+// number of transfers sent by the account since the time within tx
+func (tr *XactRepository) GetOutgoingUsage(ctx context.Context, tx tx.Tx,
+	accntID account.AccountID, since time.Time) (*limit.Usage, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := limit.Usage{}
+	for _, xact := range txx.data.xacts {
+		if xact.AccountID != accntID || xact.Ts.Before(since) {
+			continue
+		}
+
+		switch xact.XactTypeExt {
+		case transaction.XactTypeExtSndTransfer:
+			usage.Transfers++
+		case transaction.XactTypeExtWithdrawal:
+		default:
+			continue
+		}
+
+		usage.Amount = usage.Amount.Add(xact.Amount)
+	}
+
+	return &usage, nil
+}
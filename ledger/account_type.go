@@ -1,7 +1,9 @@
 package ledger
 
 import (
+	"bytes"
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 )
 
@@ -12,6 +14,8 @@ type AccountType int
 const (
 	// AccountTypeLiability is a liability account
 	AccountTypeLiability AccountType = iota + 1
+	// AccountTypeRevenue is a revenue account
+	AccountTypeRevenue
 )
 
 // String implements Stringer interface
@@ -19,6 +23,7 @@ func (at AccountType) String() string {
 	return [...]string{
 		"invalid",
 		"AL",
+		"AR",
 	}[at]
 }
 
@@ -48,7 +53,29 @@ func strToAccountType(s string) AccountType {
 	switch s {
 	case "AL":
 		return AccountTypeLiability
+	case "AR":
+		return AccountTypeRevenue
 	}
 
 	return AccountType(0)
 }
+
+// MarshalJSON implements the json.Marshaler interface
+func (at AccountType) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(at.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (at *AccountType) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+
+	*at = strToAccountType(str)
+	return nil
+}
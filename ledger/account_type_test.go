@@ -1,6 +1,7 @@
 package ledger_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stevenferrer/kalupi/ledger"
@@ -22,6 +23,10 @@ func TestAccountType(t *testing.T) {
 				at:     ledger.AccountTypeLiability,
 				expect: "AL",
 			},
+			{
+				at:     ledger.AccountTypeRevenue,
+				expect: "AR",
+			},
 		}
 
 		for _, tt := range tc {
@@ -34,6 +39,10 @@ func TestAccountType(t *testing.T) {
 		err := at.Scan("AL")
 		require.NoError(t, err)
 		assert.Equal(t, ledger.AccountTypeLiability, at)
+
+		err = at.Scan("AR")
+		require.NoError(t, err)
+		assert.Equal(t, ledger.AccountTypeRevenue, at)
 	})
 
 	t.Run("driver valuer", func(t *testing.T) {
@@ -41,4 +50,15 @@ func TestAccountType(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotNil(t, value)
 	})
+
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(ledger.AccountTypeRevenue)
+		require.NoError(t, err)
+		assert.Equal(t, `"AR"`, string(b))
+
+		var at ledger.AccountType
+		err = json.Unmarshal([]byte(`"AL"`), &at)
+		require.NoError(t, err)
+		assert.Equal(t, ledger.AccountTypeLiability, at)
+	})
 }
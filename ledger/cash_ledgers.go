@@ -1,44 +1,56 @@
 package ledger
 
 import (
+	"fmt"
+
 	"github.com/stevenferrer/kalupi/currency"
 )
 
 // List of cash ledger account numbers
 const (
+	// CashUSDLedgerNo is the USD cash ledger number. It predates the
+	// currency registry, hence, it doesn't follow the numbering scheme.
 	CashUSDLedgerNo LedgerNo = "100"
-	// CashEURLedgerNo LedgerNo = "110"
 )
 
-// cashLedgers contains the list of cash ledgers
-var cashLedgers = [...]Ledger{
-	cashUSD,
-	// cashEUR,
+// cashLedgerNoPrefix is the prefix of the cash ledger numbers
+const cashLedgerNoPrefix = "100"
+
+// cashLedgers returns the list of cash ledgers for the currencies
+func cashLedgers(currs currency.Set) []Ledger {
+	lgs := []Ledger{}
+	for _, curr := range currs.List() {
+		lgs = append(lgs, cashLedger(curr))
+	}
+
+	return lgs
 }
 
-// List of cash ledgers
-var (
-	cashUSD = Ledger{
-		LedgerNo:    CashUSDLedgerNo,
+// cashLedger returns the cash ledger of the currency
+func cashLedger(curr currency.Currency) Ledger {
+	return Ledger{
+		LedgerNo:    cashLedgerNo(curr),
 		AccountType: AccountTypeLiability,
-		Currency:    currency.USD,
-		Name:        "Cash USD",
+		Currency:    curr,
+		Name:        fmt.Sprintf("Cash %s", curr),
 	}
+}
 
-	// cashEUR = Ledger{
-	// 	LedgerNo:    CashEURLedgerNo,
-	// 	AccountType: AccountTypeLiability,
-	// 	Currency:    currency.USD,
-	// 	Name:        "Cash EUR",
-	// }
-)
+// cashLedgerNo returns the cash ledger number of the currency i.e. 100-EUR
+func cashLedgerNo(curr currency.Currency) LedgerNo {
+	if curr == currency.USD {
+		return CashUSDLedgerNo
+	}
+
+	return LedgerNo(fmt.Sprintf("%s-%s", cashLedgerNoPrefix, curr))
+}
 
-//GetCashLedgerNo retrieives the cash ledger number for the given currency
+// GetCashLedgerNo retrieives the cash ledger number for the given currency,
+// the ledger only exists if the currency is enabled
 func GetCashLedgerNo(curr currency.Currency) (LedgerNo, error) {
-	switch curr {
-	case currency.USD:
-		return CashUSDLedgerNo, nil
+	if !curr.IsValid() {
+		return "", currency.ErrUnsupportedCurrency
 	}
 
-	return "", currency.ErrUnsupportedCurrency
+	return cashLedgerNo(curr), nil
 }
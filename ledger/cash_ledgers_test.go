@@ -22,6 +22,15 @@ func TestGetCashLedgerNo(t *testing.T) {
 			curr:   currency.USD,
 			expect: ledger.CashUSDLedgerNo,
 		},
+		{
+			curr:   currency.EUR,
+			expect: ledger.LedgerNo("100-EUR"),
+		},
+		{
+			// the ledger number doesn't depend on the enabled currencies
+			curr:   currency.JPY,
+			expect: ledger.LedgerNo("100-JPY"),
+		},
 	}
 
 	for _, tt := range tc {
@@ -0,0 +1,72 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// listLedgersRequest is a list ledgers request
+type listLedgersRequest struct{}
+
+// listLedgersResponse is a list ledgers response
+type listLedgersResponse struct {
+	Ledgers []*Ledger `json:"ledgers,omitempty"`
+	Err     error     `json:"error,omitempty"`
+}
+
+func (r listLedgersResponse) error() error { return r.Err }
+
+// newListLedgersEndpoint returns a list ledgers endpoint
+func newListLedgersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		lgs, err := s.ListLedgers(ctx)
+		return listLedgersResponse{Ledgers: lgs, Err: err}, nil
+	}
+}
+
+// getLedgerBalRequest is a get ledger balance request
+type getLedgerBalRequest struct {
+	LedgerNo LedgerNo
+	AsOf     time.Time
+}
+
+// getLedgerBalResponse is a get ledger balance response
+type getLedgerBalResponse struct {
+	Balance *Balance `json:"balance,omitempty"`
+	Err     error    `json:"error,omitempty"`
+}
+
+func (r getLedgerBalResponse) error() error { return r.Err }
+
+// newGetLedgerBalEndpoint returns a get ledger balance endpoint
+func newGetLedgerBalEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getLedgerBalRequest)
+		lgBal, err := s.GetLedgerBal(ctx, req.LedgerNo, req.AsOf)
+		return getLedgerBalResponse{Balance: lgBal, Err: err}, nil
+	}
+}
+
+// getTrialBalanceRequest is a get trial balance request
+type getTrialBalanceRequest struct {
+	AsOf time.Time
+}
+
+// getTrialBalanceResponse is a get trial balance response
+type getTrialBalanceResponse struct {
+	TrialBalance *TrialBalance `json:"trial_balance,omitempty"`
+	Err          error         `json:"error,omitempty"`
+}
+
+func (r getTrialBalanceResponse) error() error { return r.Err }
+
+// newGetTrialBalanceEndpoint returns a get trial balance endpoint
+func newGetTrialBalanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getTrialBalanceRequest)
+		tb, err := s.GetTrialBalance(ctx, req.AsOf)
+		return getTrialBalanceResponse{TrialBalance: tb, Err: err}, nil
+	}
+}
@@ -0,0 +1,11 @@
+package ledger
+
+import "errors"
+
+// List of ledger related errors
+var (
+	// ErrValidation is a ledger related validation error
+	ErrValidation = errors.New("validation error")
+	// ErrLedgerNotFound is an error when retrieving a ledger that doesn't exists
+	ErrLedgerNotFound = errors.New("ledger not found")
+)
@@ -0,0 +1,40 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// fxLedgerNoPrefix is the prefix of the fx position ledger numbers
+const fxLedgerNoPrefix = "200"
+
+// fxLedgers returns the list of fx position ledgers for the currencies
+func fxLedgers(currs currency.Set) []Ledger {
+	lgs := []Ledger{}
+	for _, curr := range currs.List() {
+		lgs = append(lgs, Ledger{
+			LedgerNo:    fxLedgerNo(curr),
+			AccountType: AccountTypeLiability,
+			Currency:    curr,
+			Name:        fmt.Sprintf("FX Position %s", curr),
+		})
+	}
+
+	return lgs
+}
+
+// fxLedgerNo returns the fx position ledger number of the currency i.e. 200-EUR
+func fxLedgerNo(curr currency.Currency) LedgerNo {
+	return LedgerNo(fmt.Sprintf("%s-%s", fxLedgerNoPrefix, curr))
+}
+
+// GetFXLedgerNo retrieves the fx position ledger number for the given currency,
+// the ledger only exists if the currency is enabled
+func GetFXLedgerNo(curr currency.Currency) (LedgerNo, error) {
+	if !curr.IsValid() {
+		return "", currency.ErrUnsupportedCurrency
+	}
+
+	return fxLedgerNo(curr), nil
+}
@@ -0,0 +1,46 @@
+package ledger_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/ledger"
+)
+
+func TestGetFXLedgerNo(t *testing.T) {
+	tc := []struct {
+		curr     currency.Currency
+		expect   ledger.LedgerNo
+		hasError bool
+	}{
+		{
+			curr:     currency.Currency(0),
+			hasError: true,
+		},
+		{
+			curr:   currency.USD,
+			expect: ledger.LedgerNo("200-USD"),
+		},
+		{
+			curr:   currency.EUR,
+			expect: ledger.LedgerNo("200-EUR"),
+		},
+		{
+			// the ledger number doesn't depend on the enabled currencies
+			curr:   currency.JPY,
+			expect: ledger.LedgerNo("200-JPY"),
+		},
+	}
+
+	for _, tt := range tc {
+		got, err := ledger.GetFXLedgerNo(tt.curr)
+		if tt.hasError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expect, got)
+		}
+	}
+}
@@ -12,17 +12,20 @@ type LedgerNo string
 
 // Ledger is an internal ledger account
 type Ledger struct {
-	LedgerNo    LedgerNo
-	AccountType AccountType       // i.e. Liability
-	Currency    currency.Currency // i.e. USD
-	Name        string            // i.e. Cash - USD
+	LedgerNo    LedgerNo          `json:"ledger_no"`
+	AccountType AccountType       `json:"account_type"` // i.e. Liability
+	Currency    currency.Currency `json:"currency"`     // i.e. USD
+	Name        string            `json:"name"`         // i.e. Cash - USD
 }
 
-// Balance is a ledger balance
+// Balance is a ledger balance. The current balance is the
+// debit balance of the ledger, a credit balance is negative.
 type Balance struct {
-	LedgerNo    LedgerNo
-	TotalCredit decimal.Decimal
-	TotalDebit  decimal.Decimal
-	CurrentBal  decimal.Decimal
-	Ts          *time.Time
+	LedgerNo    LedgerNo          `json:"ledger_no"`
+	Currency    currency.Currency `json:"currency"`
+	TotalCredit decimal.Decimal   `json:"total_credit"`
+	TotalDebit  decimal.Decimal   `json:"total_debit"`
+	CurrentBal  decimal.Decimal   `json:"current_balance"`
+	// Ts is the timestamp of the last posting
+	Ts *time.Time `json:"ts,omitempty"`
 }
@@ -0,0 +1,108 @@
+package ledger
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+)
+
+// loggingService is a service logging middleware
+type loggingService struct {
+	logger log.Logger
+	s      Service
+}
+
+// NewLoggingService returns a logging service middleware
+func NewLoggingService(logger log.Logger, s Service) Service {
+	return &loggingService{logger: logger, s: s}
+}
+
+// CreateCashLedgers logs the create cash ledgers call
+func (s *loggingService) CreateCashLedgers(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_cash_ledgers",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.CreateCashLedgers(ctx)
+}
+
+// CreateFXLedgers logs the create fx ledgers call
+func (s *loggingService) CreateFXLedgers(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_fx_ledgers",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.CreateFXLedgers(ctx)
+}
+
+// CreateRevenueLedgers logs the create revenue ledgers call
+func (s *loggingService) CreateRevenueLedgers(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_revenue_ledgers",
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.CreateRevenueLedgers(ctx)
+}
+
+// ListLedgers logs the list ledgers call
+func (s *loggingService) ListLedgers(ctx context.Context) (lgs []*Ledger, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list_ledgers",
+			"count", len(lgs),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ListLedgers(ctx)
+}
+
+// GetLedgerBal logs the get ledger bal params
+func (s *loggingService) GetLedgerBal(ctx context.Context, ledgerNo LedgerNo,
+	asOf time.Time) (lgBal *Balance, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "get_ledger_bal",
+			"ledger_no", ledgerNo,
+			"as_of", asOf,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetLedgerBal(ctx, ledgerNo, asOf)
+}
+
+// GetTrialBalance logs the get trial balance params
+func (s *loggingService) GetTrialBalance(ctx context.Context,
+	asOf time.Time) (tb *TrialBalance, err error) {
+	defer func(begin time.Time) {
+		balanced := false
+		if tb != nil {
+			balanced = tb.Balanced
+		}
+		_ = s.logger.Log(
+			"method", "get_trial_balance",
+			"as_of", asOf,
+			"balanced", balanced,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetTrialBalance(ctx, asOf)
+}
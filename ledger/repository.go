@@ -2,6 +2,7 @@ package ledger
 
 import (
 	"context"
+	"time"
 )
 
 // Repository is a ledger repository
@@ -12,4 +13,9 @@ type Repository interface {
 	GetLedger(context.Context, LedgerNo) (*Ledger, error)
 	// ListLedgers retrieves the list of ledgers
 	ListLedgers(context.Context) ([]*Ledger, error)
+	// GetLedgerBal retrieves the ledger balance from the postings made before the time
+	GetLedgerBal(context.Context, LedgerNo, time.Time) (*Balance, error)
+	// GetTrialBalance retrieves the ledger and account balances from the
+	// postings made before the time, the balances are read at once
+	GetTrialBalance(context.Context, time.Time) (*TrialBalance, error)
 }
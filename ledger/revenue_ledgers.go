@@ -0,0 +1,40 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// revenueLedgerNoPrefix is the prefix of the revenue ledger numbers
+const revenueLedgerNoPrefix = "400"
+
+// revenueLedgers returns the list of revenue ledgers for the currencies
+func revenueLedgers(currs currency.Set) []Ledger {
+	lgs := []Ledger{}
+	for _, curr := range currs.List() {
+		lgs = append(lgs, Ledger{
+			LedgerNo:    revenueLedgerNo(curr),
+			AccountType: AccountTypeRevenue,
+			Currency:    curr,
+			Name:        fmt.Sprintf("Fee Revenue %s", curr),
+		})
+	}
+
+	return lgs
+}
+
+// revenueLedgerNo returns the revenue ledger number of the currency i.e. 400-EUR
+func revenueLedgerNo(curr currency.Currency) LedgerNo {
+	return LedgerNo(fmt.Sprintf("%s-%s", revenueLedgerNoPrefix, curr))
+}
+
+// GetRevenueLedgerNo retrieves the revenue ledger number for the given currency,
+// the ledger only exists if the currency is enabled
+func GetRevenueLedgerNo(curr currency.Currency) (LedgerNo, error) {
+	if !curr.IsValid() {
+		return "", currency.ErrUnsupportedCurrency
+	}
+
+	return revenueLedgerNo(curr), nil
+}
@@ -2,27 +2,104 @@ package ledger
 
 import (
 	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/kalupi/currency"
 )
 
 // Service is a ledger service
 type Service interface {
 	// CreateCashLedgers creates the internal cash ledger accounts (USD, EUR, etc.)
 	CreateCashLedgers(context.Context) error
+	// CreateFXLedgers creates the internal fx position ledger accounts
+	CreateFXLedgers(context.Context) error
+	// CreateRevenueLedgers creates the internal fee revenue ledger accounts
+	CreateRevenueLedgers(context.Context) error
+	// ListLedgers retrieves the list of ledgers
+	ListLedgers(context.Context) ([]*Ledger, error)
+	// GetLedgerBal retrieves the ledger balance at the time,
+	// or the current balance if the time is zero
+	GetLedgerBal(context.Context, LedgerNo, time.Time) (*Balance, error)
+	// GetTrialBalance retrieves the trial balance at the time,
+	// or the current trial balance if the time is zero
+	GetTrialBalance(context.Context, time.Time) (*TrialBalance, error)
 }
 
 // service is a ledger service implementation
 type service struct {
 	ledgerRepo Repository
+	// currs are the enabled currencies
+	currs currency.Set
 }
 
 var _ Service = (*service)(nil)
 
-// NewService takes a ledger repository and returns a ledger service
-func NewService(ledgerRepo Repository) Service {
-	return &service{ledgerRepo: ledgerRepo}
+// NewService takes a ledger repository and the enabled
+// currencies, and returns a ledger service
+func NewService(ledgerRepo Repository, currs currency.Set) Service {
+	return &service{ledgerRepo: ledgerRepo, currs: currs}
 }
 
-// CreateCashLedgers creates the internal cash ledger accounts
+// CreateCashLedgers creates the internal cash ledger
+// accounts for each of the enabled currencies
 func (s *service) CreateCashLedgers(ctx context.Context) error {
-	return s.ledgerRepo.CreateLedgersIfNotExists(ctx, cashLedgers[:]...)
+	return s.ledgerRepo.CreateLedgersIfNotExists(ctx, cashLedgers(s.currs)...)
+}
+
+// CreateFXLedgers creates the internal fx position ledger
+// accounts for each of the enabled currencies
+func (s *service) CreateFXLedgers(ctx context.Context) error {
+	return s.ledgerRepo.CreateLedgersIfNotExists(ctx, fxLedgers(s.currs)...)
+}
+
+// CreateRevenueLedgers creates the internal fee revenue
+// ledger accounts for each of the enabled currencies
+func (s *service) CreateRevenueLedgers(ctx context.Context) error {
+	return s.ledgerRepo.CreateLedgersIfNotExists(ctx, revenueLedgers(s.currs)...)
+}
+
+// ListLedgers retrieves the list of ledgers
+func (s *service) ListLedgers(ctx context.Context) ([]*Ledger, error) {
+	return s.ledgerRepo.ListLedgers(ctx)
+}
+
+// GetLedgerBal retrieves the ledger balance from the postings made before
+// the time, or the current balance if the time is zero
+func (s *service) GetLedgerBal(ctx context.Context, ledgerNo LedgerNo,
+	asOf time.Time) (*Balance, error) {
+	_, err := s.ledgerRepo.GetLedger(ctx, ledgerNo)
+	if err != nil {
+		return nil, err
+	}
+
+	lgBal, err := s.ledgerRepo.GetLedgerBal(ctx, ledgerNo, asOfOrNow(asOf))
+	if err != nil {
+		return nil, errors.Wrap(err, "get ledger bal")
+	}
+
+	return lgBal, nil
+}
+
+// GetTrialBalance retrieves the ledger and account balances from the
+// postings made before the time, or the current ones if the time is zero.
+// The balances are summed by currency and checked that they balance.
+func (s *service) GetTrialBalance(ctx context.Context, asOf time.Time) (*TrialBalance, error) {
+	tb, err := s.ledgerRepo.GetTrialBalance(ctx, asOfOrNow(asOf))
+	if err != nil {
+		return nil, errors.Wrap(err, "get trial balance")
+	}
+
+	tb.reconcile()
+	return tb, nil
+}
+
+// asOfOrNow returns the time in UTC, or the current time if it's zero
+func asOfOrNow(asOf time.Time) time.Time {
+	if asOf.IsZero() {
+		return time.Now().UTC()
+	}
+
+	return asOf.UTC()
 }
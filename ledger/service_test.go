@@ -3,24 +3,28 @@ package ledger_test
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
-	"github.com/stevenferrer/kalupi/etc/txdb"
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/fee"
+	"github.com/stevenferrer/kalupi/fx"
 	"github.com/stevenferrer/kalupi/ledger"
-	"github.com/stevenferrer/kalupi/postgres"
+	"github.com/stevenferrer/kalupi/transaction"
 )
 
 func TestLedgerService(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
 
-	err := postgres.Migrate(db)
-	require.NoError(t, err)
-
-	ledgerRepo := postgres.NewLedgerRepository(db)
-	ledgerService := ledger.NewService(ledgerRepo)
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
 
 	ctx := context.TODO()
 	t.Run("create cash ledgers", func(t *testing.T) {
@@ -30,5 +34,187 @@ func TestLedgerService(t *testing.T) {
 		lgs, err := ledgerRepo.ListLedgers(ctx)
 		require.NoError(t, err)
 		assert.Len(t, lgs, 1)
+
+		t.Run("enabled currencies", func(t *testing.T) {
+			ledgerService := ledger.NewService(ledgerRepo,
+				currency.NewSet(currency.USD, currency.EUR, currency.JPY))
+			err = ledgerService.CreateCashLedgers(ctx)
+			require.NoError(t, err)
+
+			lgs, err := ledgerRepo.ListLedgers(ctx)
+			require.NoError(t, err)
+			assert.Len(t, lgs, 3)
+
+			lg, err := ledgerRepo.GetLedger(ctx, ledger.LedgerNo("100-JPY"))
+			require.NoError(t, err)
+			assert.Equal(t, currency.JPY, lg.Currency)
+			assert.Equal(t, "Cash JPY", lg.Name)
+		})
+	})
+
+	t.Run("create fx ledgers", func(t *testing.T) {
+		err := ledgerService.CreateFXLedgers(ctx)
+		require.NoError(t, err)
+
+		lg, err := ledgerRepo.GetLedger(ctx, ledger.LedgerNo("200-USD"))
+		require.NoError(t, err)
+		assert.Equal(t, currency.USD, lg.Currency)
+		assert.Equal(t, "FX Position USD", lg.Name)
+	})
+
+	t.Run("create revenue ledgers", func(t *testing.T) {
+		err := ledgerService.CreateRevenueLedgers(ctx)
+		require.NoError(t, err)
+
+		lg, err := ledgerRepo.GetLedger(ctx, ledger.LedgerNo("400-USD"))
+		require.NoError(t, err)
+		assert.Equal(t, ledger.AccountTypeRevenue, lg.AccountType)
+		assert.Equal(t, "Fee Revenue USD", lg.Name)
+	})
+}
+
+func TestLedgerBalances(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ledgerService := ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD, currency.EUR))
+	setupXacts(t, store, ledgerService)
+
+	ctx := context.TODO()
+
+	assertBal := func(t *testing.T, ledgerNo ledger.LedgerNo, debit, credit, bal string) {
+		lgBal, err := ledgerService.GetLedgerBal(ctx, ledgerNo, time.Time{})
+		require.NoError(t, err)
+		assert.Equal(t, ledgerNo, lgBal.LedgerNo)
+		assert.True(t, decimal.RequireFromString(debit).Equal(lgBal.TotalDebit),
+			"expecting %s debit to be %s, got %s", ledgerNo, debit, lgBal.TotalDebit)
+		assert.True(t, decimal.RequireFromString(credit).Equal(lgBal.TotalCredit),
+			"expecting %s credit to be %s, got %s", ledgerNo, credit, lgBal.TotalCredit)
+		assert.True(t, decimal.RequireFromString(bal).Equal(lgBal.CurrentBal),
+			"expecting %s balance to be %s, got %s", ledgerNo, bal, lgBal.CurrentBal)
+	}
+
+	t.Run("get ledger bal", func(t *testing.T) {
+		// deposit 100, withdraw 10 and fx 50, the fx is moved to the fx position
+		assertBal(t, ledger.CashUSDLedgerNo, "150", "60", "90")
+		assertBal(t, ledger.LedgerNo("200-USD"), "0", "50", "-50")
+		// the withdrawal fee
+		assertBal(t, ledger.LedgerNo("400-USD"), "0", "2", "-2")
+		// the received fx is funded by the fx position
+		assertBal(t, ledger.LedgerNo("100-EUR"), "45", "45", "0")
+		assertBal(t, ledger.LedgerNo("200-EUR"), "45", "0", "45")
+
+		_, err := ledgerService.GetLedgerBal(ctx, ledger.LedgerNo("idontexist"), time.Time{})
+		assert.ErrorIs(t, err, ledger.ErrLedgerNotFound)
+	})
+
+	t.Run("get ledger bal as of", func(t *testing.T) {
+		lgBal, err := ledgerService.GetLedgerBal(ctx, ledger.CashUSDLedgerNo,
+			time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.True(t, lgBal.CurrentBal.IsZero())
+		assert.Nil(t, lgBal.Ts)
+	})
+
+	t.Run("get trial balance", func(t *testing.T) {
+		tb, err := ledgerService.GetTrialBalance(ctx, time.Time{})
+		require.NoError(t, err)
+		assert.True(t, tb.Balanced)
+		assert.Len(t, tb.Ledgers, 6)
+		require.Len(t, tb.Accounts, 2)
+
+		// sorted by currency code
+		require.Len(t, tb.Totals, 2)
+		eur, usd := tb.Totals[0], tb.Totals[1]
+
+		assert.Equal(t, currency.EUR, eur.Currency)
+		assert.True(t, decimal.NewFromInt(90).Equal(eur.LedgerDebit))
+		assert.True(t, decimal.NewFromInt(45).Equal(eur.LedgerCredit))
+		assert.True(t, decimal.Zero.Equal(eur.AccntDebit))
+		assert.True(t, decimal.NewFromInt(45).Equal(eur.AccntCredit))
+		assert.True(t, eur.Balanced)
+
+		assert.Equal(t, currency.USD, usd.Currency)
+		assert.True(t, decimal.NewFromInt(150).Equal(usd.LedgerDebit))
+		assert.True(t, decimal.NewFromInt(112).Equal(usd.LedgerCredit))
+		assert.True(t, decimal.NewFromInt(62).Equal(usd.AccntDebit))
+		assert.True(t, decimal.NewFromInt(100).Equal(usd.AccntCredit))
+		assert.True(t, usd.Balanced)
+	})
+
+	t.Run("get trial balance as of", func(t *testing.T) {
+		asOf := time.Now().Add(-time.Hour)
+		tb, err := ledgerService.GetTrialBalance(ctx, asOf)
+		require.NoError(t, err)
+		assert.True(t, asOf.Equal(tb.AsOf))
+		assert.True(t, tb.Balanced)
+		assert.Len(t, tb.Accounts, 0)
+		for _, total := range tb.Totals {
+			assert.True(t, total.LedgerDebit.IsZero())
+			assert.True(t, total.LedgerCredit.IsZero())
+		}
+	})
+}
+
+// setupXacts creates the ledgers, a usd and an eur account, and posts a
+// deposit, a withdrawal with a fee and an fx transfer from usd to eur
+func setupXacts(t *testing.T, store *repotest.Repos, ledgerService ledger.Service) {
+	ctx := context.TODO()
+
+	require.NoError(t, ledgerService.CreateCashLedgers(ctx))
+	require.NoError(t, ledgerService.CreateFXLedgers(ctx))
+	require.NoError(t, ledgerService.CreateRevenueLedgers(ctx))
+
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := store.AccountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	jean := account.Account{
+		AccountID: account.AccountID("jeandupont"),
+		Currency:  currency.EUR,
+	}
+	_, err = store.AccountRepo.CreateAccount(ctx, jean)
+	require.NoError(t, err)
+
+	rateProvider, err := fx.NewStaticRateProvider(fx.Rate{
+		Base:  currency.USD,
+		Quote: currency.EUR,
+		Rate:  decimal.RequireFromString("0.9"),
+	})
+	require.NoError(t, err)
+
+	feeSchedule, err := fee.NewSchedule(fee.Rule{
+		Currency:    currency.USD,
+		XactTypeExt: transaction.XactTypeExtWithdrawal.String(),
+		Type:        fee.TypeFlat,
+		Amount:      decimal.NewFromInt(2),
+	})
+	require.NoError(t, err)
+
+	xactSvc := transaction.NewService(store.AccountRepo, store.LedgerRepo,
+		store.XactRepo, store.BalRepo,
+		transaction.WithRateProvider(rateProvider),
+		transaction.WithFeeSchedule(feeSchedule),
+	)
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
 	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(10),
+	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeFXTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   jean.AccountID,
+		Amount:      decimal.NewFromInt(50),
+	})
+	require.NoError(t, err)
 }
@@ -0,0 +1,128 @@
+package ledger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	"go.uber.org/multierr"
+)
+
+// NewHTTPHandler returns the ledger http handler
+func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	listLedgersHandler := kithttp.NewServer(
+		newListLedgersEndpoint(s),
+		decodeListLedgersRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	getTrialBalanceHandler := kithttp.NewServer(
+		newGetTrialBalanceEndpoint(s),
+		decodeGetTrialBalanceRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	getLedgerBalHandler := kithttp.NewServer(
+		newGetLedgerBalEndpoint(s),
+		decodeGetLedgerBalRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", listLedgersHandler)
+	mux.Method(http.MethodGet, "/trial-balance", getTrialBalanceHandler)
+	mux.Method(http.MethodGet, "/{no}/balance", getLedgerBalHandler)
+
+	return mux
+}
+
+var (
+	errBadRoute = errors.New("bad route")
+)
+
+func decodeListLedgersRequest(_ context.Context, _ *http.Request) (interface{}, error) {
+	return listLedgersRequest{}, nil
+}
+
+func decodeGetTrialBalanceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	asOf, err := parseAsOf(r.URL.Query())
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	return getTrialBalanceRequest{AsOf: asOf}, nil
+}
+
+func decodeGetLedgerBalRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ledgerNo := chi.URLParam(r, "no")
+	if ledgerNo == "" {
+		return nil, errBadRoute
+	}
+
+	asOf, err := parseAsOf(r.URL.Query())
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	return getLedgerBalRequest{LedgerNo: LedgerNo(ledgerNo), AsOf: asOf}, nil
+}
+
+// parseAsOf parses the as_of query param, it's zero if not set
+func parseAsOf(q url.Values) (time.Time, error) {
+	v := q.Get("as_of")
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	asOf, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errors.New("as_of: must be an RFC 3339 timestamp")
+	}
+
+	return asOf, nil
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+// errorer is an error interface for response
+type errorer interface {
+	error() error
+}
+
+// encode errors from business-logic
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if errors.Is(err, ErrValidation) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	} else if errors.Is(err, ErrLedgerNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
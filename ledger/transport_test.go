@@ -0,0 +1,147 @@
+package ledger_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/ledger"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	logger := log.NewNopLogger()
+	var ledgerService ledger.Service
+	ledgerService = ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD, currency.EUR))
+	ledgerService = ledger.NewLoggingService(logger, ledgerService)
+
+	setupXacts(t, store, ledgerService)
+
+	ledgerHandler := ledger.NewHTTPHandler(ledgerService, logger)
+
+	ctx := context.TODO()
+
+	serve := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		ledgerHandler.ServeHTTP(rr, httpReq)
+		return rr
+	}
+
+	t.Run("list ledgers", func(t *testing.T) {
+		rr := serve(t, "/")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Ledgers []struct {
+				LedgerNo    string `json:"ledger_no"`
+				AccountType string `json:"account_type"`
+				Currency    string `json:"currency"`
+				Name        string `json:"name"`
+			} `json:"ledgers"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Ledgers, 6)
+
+		ledgerNos := []string{}
+		for _, lg := range resp.Ledgers {
+			ledgerNos = append(ledgerNos, lg.LedgerNo)
+			if lg.LedgerNo == "100" {
+				assert.Equal(t, "AL", lg.AccountType)
+				assert.Equal(t, "USD", lg.Currency)
+				assert.Equal(t, "Cash USD", lg.Name)
+			}
+		}
+		assert.ElementsMatch(t, []string{"100", "100-EUR", "200-USD",
+			"200-EUR", "400-USD", "400-EUR"}, ledgerNos)
+	})
+
+	t.Run("get ledger balance", func(t *testing.T) {
+		rr := serve(t, "/100/balance")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Balance struct {
+				LedgerNo    string `json:"ledger_no"`
+				Currency    string `json:"currency"`
+				TotalDebit  string `json:"total_debit"`
+				TotalCredit string `json:"total_credit"`
+				CurrentBal  string `json:"current_balance"`
+			} `json:"balance"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "100", resp.Balance.LedgerNo)
+		assert.Equal(t, "USD", resp.Balance.Currency)
+		assert.Equal(t, "150", resp.Balance.TotalDebit)
+		assert.Equal(t, "60", resp.Balance.TotalCredit)
+		assert.Equal(t, "90", resp.Balance.CurrentBal)
+
+		t.Run("as of", func(t *testing.T) {
+			rr := serve(t, "/100/balance?as_of=2021-01-01T00:00:00Z")
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			err := json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.Equal(t, "0", resp.Balance.CurrentBal)
+		})
+
+		t.Run("invalid as of", func(t *testing.T) {
+			rr := serve(t, "/100/balance?as_of=yesterday")
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+
+		t.Run("ledger not found", func(t *testing.T) {
+			rr := serve(t, "/idontexist/balance")
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+
+	t.Run("get trial balance", func(t *testing.T) {
+		rr := serve(t, "/trial-balance")
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			TrialBalance struct {
+				Ledgers  []json.RawMessage `json:"ledgers"`
+				Accounts []struct {
+					AccountID  string `json:"account_id"`
+					CurrentBal string `json:"current_balance"`
+				} `json:"accounts"`
+				Totals []struct {
+					Currency string `json:"currency"`
+					Balanced bool   `json:"balanced"`
+				} `json:"totals"`
+				Balanced bool `json:"balanced"`
+			} `json:"trial_balance"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.True(t, resp.TrialBalance.Balanced)
+		assert.Len(t, resp.TrialBalance.Ledgers, 6)
+		require.Len(t, resp.TrialBalance.Accounts, 2)
+		assert.Equal(t, "jeandupont", resp.TrialBalance.Accounts[0].AccountID)
+		assert.Equal(t, "45", resp.TrialBalance.Accounts[0].CurrentBal)
+		assert.Equal(t, "johndoe", resp.TrialBalance.Accounts[1].AccountID)
+		assert.Equal(t, "38", resp.TrialBalance.Accounts[1].CurrentBal)
+		require.Len(t, resp.TrialBalance.Totals, 2)
+		assert.Equal(t, "EUR", resp.TrialBalance.Totals[0].Currency)
+		assert.Equal(t, "USD", resp.TrialBalance.Totals[1].Currency)
+
+		rr = serve(t, "/trial-balance?as_of=yesterday")
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+}
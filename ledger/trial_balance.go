@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// AccntBalance is an account balance in the trial balance. The
+// current balance is the credit balance of the account i.e. a
+// deposit is a credit, an overdrawn account has a negative balance.
+type AccntBalance struct {
+	AccountID   account.AccountID `json:"account_id"`
+	Currency    currency.Currency `json:"currency"`
+	TotalCredit decimal.Decimal   `json:"total_credit"`
+	TotalDebit  decimal.Decimal   `json:"total_debit"`
+	CurrentBal  decimal.Decimal   `json:"current_balance"`
+	// Ts is the timestamp of the last posting
+	Ts *time.Time `json:"ts,omitempty"`
+}
+
+// Total is the sum of the ledger and account balances in a currency
+type Total struct {
+	Currency     currency.Currency `json:"currency"`
+	LedgerDebit  decimal.Decimal   `json:"ledger_debit"`
+	LedgerCredit decimal.Decimal   `json:"ledger_credit"`
+	AccntDebit   decimal.Decimal   `json:"account_debit"`
+	AccntCredit  decimal.Decimal   `json:"account_credit"`
+	// Balanced is true if the net debits of the
+	// ledgers equals the net credits of the accounts
+	Balanced bool `json:"balanced"`
+}
+
+// TrialBalance is the balances of the ledgers and the accounts from the
+// postings made before AsOf. The ledger to ledger postings (i.e. fx) net
+// out, hence, the ledgers' net debits equals the accounts' net credits.
+type TrialBalance struct {
+	AsOf time.Time `json:"as_of"`
+	// Ledgers are the balances of all the ledgers
+	Ledgers []*Balance `json:"ledgers"`
+	// Accounts are the balances of the accounts with postings
+	Accounts []*AccntBalance `json:"accounts"`
+	// Totals are the sums by currency
+	Totals   []*Total `json:"totals"`
+	Balanced bool     `json:"balanced"`
+}
+
+// reconcile sums the balances by currency and checks that they balance
+func (tb *TrialBalance) reconcile() {
+	totals := map[currency.Currency]*Total{}
+	total := func(curr currency.Currency) *Total {
+		t, ok := totals[curr]
+		if !ok {
+			t = &Total{Currency: curr}
+			totals[curr] = t
+		}
+		return t
+	}
+
+	for _, lb := range tb.Ledgers {
+		t := total(lb.Currency)
+		t.LedgerDebit = t.LedgerDebit.Add(lb.TotalDebit)
+		t.LedgerCredit = t.LedgerCredit.Add(lb.TotalCredit)
+	}
+
+	for _, ab := range tb.Accounts {
+		t := total(ab.Currency)
+		t.AccntDebit = t.AccntDebit.Add(ab.TotalDebit)
+		t.AccntCredit = t.AccntCredit.Add(ab.TotalCredit)
+	}
+
+	tb.Totals = []*Total{}
+	tb.Balanced = true
+	for _, t := range totals {
+		netDebit := t.LedgerDebit.Sub(t.LedgerCredit)
+		netCredit := t.AccntCredit.Sub(t.AccntDebit)
+		t.Balanced = netDebit.Equal(netCredit)
+		tb.Balanced = tb.Balanced && t.Balanced
+		tb.Totals = append(tb.Totals, t)
+	}
+
+	sort.Slice(tb.Totals, func(i, j int) bool {
+		return tb.Totals[i].Currency.String() < tb.Totals[j].Currency.String()
+	})
+}
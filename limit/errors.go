@@ -0,0 +1,11 @@
+package limit
+
+import "errors"
+
+// List of limit related errors
+var (
+	// ErrLimitExceeded is an error when a transaction exceeds a limit
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// ErrInvalidLimits is an error when the limits are malformed
+	ErrInvalidLimits = errors.New("invalid limits")
+)
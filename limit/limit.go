@@ -0,0 +1,105 @@
+// Package limit contains the risk limits of the outgoing money movement
+package limit
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// Limits are the limits of the withdrawals and transfers of the accounts in
+// a currency, or of a single account. A zero limit means there's no limit.
+type Limits struct {
+	// Currency is the currency of the global limits
+	Currency currency.Currency `json:"currency,omitempty"`
+	// AccountID is the account of the per-account limits
+	AccountID account.AccountID `json:"account_id,omitempty"`
+	// MaxAmount is the max amount of a single transaction
+	MaxAmount decimal.Decimal `json:"max_amount"`
+	// MaxDailyAmount is the max total amount per day
+	MaxDailyAmount decimal.Decimal `json:"max_daily_amount"`
+	// MaxMonthlyAmount is the max total amount per month
+	MaxMonthlyAmount decimal.Decimal `json:"max_monthly_amount"`
+	// MaxDailyTransfers is the max number of transfers per day
+	MaxDailyTransfers int `json:"max_daily_transfers"`
+}
+
+// Usage is the outgoing money movement of an account in a period
+type Usage struct {
+	// Amount is the total amount withdrawn and sent
+	Amount decimal.Decimal
+	// Transfers is the number of transfers sent
+	Transfers int
+}
+
+// IsZero returns true if there are no limits
+func (l Limits) IsZero() bool {
+	return l.MaxAmount.IsZero() && l.MaxDailyAmount.IsZero() &&
+		l.MaxMonthlyAmount.IsZero() && l.MaxDailyTransfers == 0
+}
+
+// Check checks the amount of a withdrawal, or a transfer if transfer is
+// true, against the limits given the daily and monthly usage before it
+func (l Limits) Check(amount decimal.Decimal, transfer bool, daily, monthly Usage) error {
+	if l.MaxAmount.IsPositive() && amount.GreaterThan(l.MaxAmount) {
+		return errors.Wrapf(ErrLimitExceeded, "max amount %s", l.MaxAmount)
+	}
+
+	if l.MaxDailyAmount.IsPositive() &&
+		daily.Amount.Add(amount).GreaterThan(l.MaxDailyAmount) {
+		return errors.Wrapf(ErrLimitExceeded, "max daily amount %s", l.MaxDailyAmount)
+	}
+
+	if l.MaxMonthlyAmount.IsPositive() &&
+		monthly.Amount.Add(amount).GreaterThan(l.MaxMonthlyAmount) {
+		return errors.Wrapf(ErrLimitExceeded, "max monthly amount %s", l.MaxMonthlyAmount)
+	}
+
+	if transfer && l.MaxDailyTransfers > 0 &&
+		daily.Transfers+1 > l.MaxDailyTransfers {
+		return errors.Wrapf(ErrLimitExceeded, "max daily transfers %d", l.MaxDailyTransfers)
+	}
+
+	return nil
+}
+
+// name returns the account id of the per-account
+// limits or the currency of the global limits
+func (l Limits) name() string {
+	if l.AccountID != "" {
+		return string(l.AccountID)
+	}
+
+	return l.Currency.String()
+}
+
+// validate validates the limits
+func (l Limits) validate() error {
+	switch {
+	case l.Currency != 0 && l.AccountID != "",
+		l.Currency == 0 && l.AccountID == "":
+		return errors.Wrap(ErrInvalidLimits, "either the currency or the account id must be set")
+	case l.Currency != 0 && !l.Currency.IsValid():
+		return currency.ErrUnknownCurrency
+	case l.AccountID != "":
+		err := l.AccountID.Validate()
+		if err != nil {
+			return errors.Wrap(ErrInvalidLimits, err.Error())
+		}
+	}
+
+	for _, amount := range []decimal.Decimal{l.MaxAmount,
+		l.MaxDailyAmount, l.MaxMonthlyAmount} {
+		if amount.IsNegative() {
+			return errors.Wrap(ErrInvalidLimits, "negative amount")
+		}
+	}
+
+	if l.MaxDailyTransfers < 0 {
+		return errors.Wrap(ErrInvalidLimits, "negative max daily transfers")
+	}
+
+	return nil
+}
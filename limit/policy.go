@@ -0,0 +1,80 @@
+package limit
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// Policy is a limit policy, it has the global limits of each currency
+// and the per-account limits. The limits of an account replace the
+// global limits of its currency. A nil policy doesn't limit anything.
+type Policy struct {
+	global   map[currency.Currency]Limits
+	accounts map[account.AccountID]Limits
+}
+
+// NewPolicy takes the global and per-account limits and returns a limit policy
+func NewPolicy(limits ...Limits) (*Policy, error) {
+	p := &Policy{
+		global:   map[currency.Currency]Limits{},
+		accounts: map[account.AccountID]Limits{},
+	}
+	for _, l := range limits {
+		err := l.validate()
+		if err != nil {
+			return nil, errors.Wrap(err, l.name())
+		}
+
+		if l.AccountID != "" {
+			if _, ok := p.accounts[l.AccountID]; ok {
+				return nil, errors.Wrapf(ErrInvalidLimits, "%s: duplicate limits", l.AccountID)
+			}
+			p.accounts[l.AccountID] = l
+			continue
+		}
+
+		if _, ok := p.global[l.Currency]; ok {
+			return nil, errors.Wrapf(ErrInvalidLimits, "%s: duplicate limits", l.Currency)
+		}
+		p.global[l.Currency] = l
+	}
+
+	return p, nil
+}
+
+// LoadPolicy reads the limits from a JSON file and returns a limit policy
+//
+//	[{"currency": "USD", "max_amount": "1000", "max_daily_transfers": 10}]
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file")
+	}
+
+	var limits []Limits
+	err = json.Unmarshal(b, &limits)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	return NewPolicy(limits...)
+}
+
+// Limits returns the limits of the account, the global limits of the
+// currency are returned if the account doesn't have its own limits
+func (p *Policy) Limits(accntID account.AccountID, curr currency.Currency) Limits {
+	if p == nil {
+		return Limits{}
+	}
+
+	if l, ok := p.accounts[accntID]; ok {
+		return l
+	}
+
+	return p.global[curr]
+}
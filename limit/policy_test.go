@@ -0,0 +1,182 @@
+package limit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/limit"
+)
+
+func TestPolicy(t *testing.T) {
+	d := decimal.RequireFromString
+
+	usd := limit.Limits{Currency: currency.USD, MaxAmount: d("1000")}
+	johnDoe := limit.Limits{AccountID: account.AccountID("johndoe"), MaxAmount: d("5000")}
+	p, err := limit.NewPolicy(usd, johnDoe)
+	require.NoError(t, err)
+
+	t.Run("global limits", func(t *testing.T) {
+		l := p.Limits(account.AccountID("maryjane"), currency.USD)
+		assert.True(t, d("1000").Equal(l.MaxAmount))
+	})
+
+	t.Run("account limits", func(t *testing.T) {
+		l := p.Limits(account.AccountID("johndoe"), currency.USD)
+		assert.True(t, d("5000").Equal(l.MaxAmount))
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		l := p.Limits(account.AccountID("maryjane"), currency.EUR)
+		assert.True(t, l.IsZero())
+	})
+
+	t.Run("nil policy", func(t *testing.T) {
+		var p *limit.Policy
+		assert.True(t, p.Limits(account.AccountID("johndoe"), currency.USD).IsZero())
+	})
+}
+
+func TestLimitsCheck(t *testing.T) {
+	d := decimal.RequireFromString
+
+	l := limit.Limits{
+		Currency:          currency.USD,
+		MaxAmount:         d("100"),
+		MaxDailyAmount:    d("200"),
+		MaxMonthlyAmount:  d("500"),
+		MaxDailyTransfers: 2,
+	}
+
+	tc := []struct {
+		name     string
+		amount   string
+		transfer bool
+		daily    limit.Usage
+		monthly  limit.Usage
+		expect   string
+	}{
+		{name: "within limits", amount: "100", daily: limit.Usage{Amount: d("100")},
+			monthly: limit.Usage{Amount: d("400")}},
+		{name: "max amount", amount: "100.01", expect: "max amount 100: limit exceeded"},
+		{name: "max daily amount", amount: "50", daily: limit.Usage{Amount: d("150.01")},
+			expect: "max daily amount 200: limit exceeded"},
+		{name: "max monthly amount", amount: "50", monthly: limit.Usage{Amount: d("450.01")},
+			expect: "max monthly amount 500: limit exceeded"},
+		{name: "max daily transfers", amount: "1", transfer: true,
+			daily: limit.Usage{Transfers: 2}, expect: "max daily transfers 2: limit exceeded"},
+		{name: "withdrawal is not a transfer", amount: "1", daily: limit.Usage{Transfers: 2}},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.Check(d(tt.amount), tt.transfer, tt.daily, tt.monthly)
+			if tt.expect == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, limit.ErrLimitExceeded)
+			assert.EqualError(t, err, tt.expect)
+		})
+	}
+
+	t.Run("no limits", func(t *testing.T) {
+		err := limit.Limits{}.Check(d("1000000"), true,
+			limit.Usage{Amount: d("1000000"), Transfers: 1000}, limit.Usage{})
+		assert.NoError(t, err)
+	})
+}
+
+func TestNewPolicy(t *testing.T) {
+	d := decimal.RequireFromString
+
+	tc := []struct {
+		name   string
+		limits []limit.Limits
+	}{
+		{
+			name:   "missing currency and account id",
+			limits: []limit.Limits{{MaxAmount: d("1")}},
+		},
+		{
+			name: "both currency and account id",
+			limits: []limit.Limits{{Currency: currency.USD,
+				AccountID: account.AccountID("johndoe")}},
+		},
+		{
+			name:   "invalid account id",
+			limits: []limit.Limits{{AccountID: account.AccountID("john")}},
+		},
+		{
+			name:   "negative amount",
+			limits: []limit.Limits{{Currency: currency.USD, MaxDailyAmount: d("-1")}},
+		},
+		{
+			name:   "negative max daily transfers",
+			limits: []limit.Limits{{Currency: currency.USD, MaxDailyTransfers: -1}},
+		},
+		{
+			name:   "duplicate global limits",
+			limits: []limit.Limits{{Currency: currency.USD}, {Currency: currency.USD}},
+		},
+		{
+			name: "duplicate account limits",
+			limits: []limit.Limits{{AccountID: account.AccountID("johndoe")},
+				{AccountID: account.AccountID("johndoe")}},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := limit.NewPolicy(tt.limits...)
+			assert.ErrorIs(t, err, limit.ErrInvalidLimits)
+		})
+	}
+
+	t.Run("unknown currency", func(t *testing.T) {
+		_, err := limit.NewPolicy(limit.Limits{Currency: currency.Currency(1)})
+		assert.ErrorIs(t, err, currency.ErrUnknownCurrency)
+	})
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "limits.json")
+	err := os.WriteFile(path, []byte(`[
+		{"currency": "USD", "max_amount": "1000", "max_daily_transfers": 10},
+		{"account_id": "johndoe", "max_daily_amount": "5000"}
+	]`), 0600)
+	require.NoError(t, err)
+
+	p, err := limit.LoadPolicy(path)
+	require.NoError(t, err)
+
+	l := p.Limits(account.AccountID("maryjane"), currency.USD)
+	assert.True(t, decimal.NewFromInt(1000).Equal(l.MaxAmount))
+	assert.Equal(t, 10, l.MaxDailyTransfers)
+
+	l = p.Limits(account.AccountID("johndoe"), currency.USD)
+	assert.True(t, decimal.NewFromInt(5000).Equal(l.MaxDailyAmount))
+	assert.True(t, l.MaxAmount.IsZero())
+
+	t.Run("file not found", func(t *testing.T) {
+		_, err := limit.LoadPolicy(filepath.Join(dir, "idontexist.json"))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid limits", func(t *testing.T) {
+		err := os.WriteFile(path, []byte(`[{"max_amount": "1000"}]`), 0600)
+		require.NoError(t, err)
+
+		_, err = limit.LoadPolicy(path)
+		assert.ErrorIs(t, err, limit.ErrInvalidLimits)
+	})
+}
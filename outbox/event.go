@@ -0,0 +1,21 @@
+// Package outbox contains the transactional outbox of the ledger events. The
+// events are written in the same tx as the postings they describe, and are
+// published after commit by the relay.
+package outbox
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is an event of the outbox
+type Event struct {
+	// Seq is the sequence number of the event. It is assigned once the
+	// event is committed and it is the order the events are published in.
+	Seq int64 `json:"seq"`
+	// Type is the type of the event i.e. transaction.posted
+	Type string `json:"type"`
+	// Payload is the json encoded subject of the event
+	Payload json.RawMessage `json:"payload"`
+	Ts      *time.Time      `json:"ts,omitempty"`
+}
@@ -0,0 +1,107 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Publisher publishes the events
+type Publisher interface {
+	// Publish publishes the event, it returns an
+	// error if the event may not have been published
+	Publish(context.Context, *Event) error
+}
+
+// JSONLPublisher writes the events as json lines
+type JSONLPublisher struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+var _ Publisher = (*JSONLPublisher)(nil)
+
+// NewJSONLPublisher takes a writer e.g. os.Stdout and returns a json lines publisher
+func NewJSONLPublisher(w io.Writer) *JSONLPublisher {
+	return &JSONLPublisher{enc: json.NewEncoder(w)}
+}
+
+// Publish writes the event as a json line
+func (p *JSONLPublisher) Publish(_ context.Context, ev *Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.enc.Encode(ev)
+}
+
+// defaultHTTPTimeout is the default timeout of the webhook requests
+const defaultHTTPTimeout = 10 * time.Second
+
+// HTTPPublisher posts the events as json to a webhook url, any
+// response other than 2xx is an error and the event is retried
+type HTTPPublisher struct {
+	url    string
+	client *http.Client
+}
+
+var _ Publisher = (*HTTPPublisher)(nil)
+
+// HTTPOption is an option for the http publisher
+type HTTPOption func(*HTTPPublisher)
+
+// WithHTTPClient sets the http client of the publisher
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return func(p *HTTPPublisher) {
+		p.client = client
+	}
+}
+
+// NewHTTPPublisher takes the webhook url and returns an http publisher
+func NewHTTPPublisher(url string, opts ...HTTPOption) *HTTPPublisher {
+	p := &HTTPPublisher{
+		url:    url,
+		client: &http.Client{Timeout: defaultHTTPTimeout},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Publish posts the event to the webhook url
+func (p *HTTPPublisher) Publish(ctx context.Context, ev *Event) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrap(err, "json marshal")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "do request")
+	}
+	defer resp.Body.Close()
+
+	// drain the body so that the connection is reused
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package outbox_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/outbox"
+)
+
+func TestJSONLPublisher(t *testing.T) {
+	ctx := context.TODO()
+
+	var buf bytes.Buffer
+	publisher := outbox.NewJSONLPublisher(&buf)
+
+	for seq := int64(1); seq <= 2; seq++ {
+		err := publisher.Publish(ctx, &outbox.Event{
+			Seq:     seq,
+			Type:    "transaction.posted",
+			Payload: json.RawMessage(`{"xact_no":"xact1"}`),
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, `{"seq":1,"type":"transaction.posted","payload":{"xact_no":"xact1"}}
+{"seq":2,"type":"transaction.posted","payload":{"xact_no":"xact1"}}
+`, buf.String())
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	ctx := context.TODO()
+
+	status := http.StatusOK
+	var got []outbox.Event
+	srvr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Contains(t, r.Header.Get("Content-Type"), "application/json")
+
+		var ev outbox.Event
+		err := json.NewDecoder(r.Body).Decode(&ev)
+		require.NoError(t, err)
+		got = append(got, ev)
+
+		w.WriteHeader(status)
+	}))
+	defer srvr.Close()
+
+	publisher := outbox.NewHTTPPublisher(srvr.URL,
+		outbox.WithHTTPClient(srvr.Client()))
+
+	ev := &outbox.Event{
+		Seq:     1,
+		Type:    "transaction.posted",
+		Payload: json.RawMessage(`{"xact_no":"xact1"}`),
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		err := publisher.Publish(ctx, ev)
+		require.NoError(t, err)
+		require.Len(t, got, 1)
+		assert.Equal(t, int64(1), got[0].Seq)
+		assert.JSONEq(t, `{"xact_no":"xact1"}`, string(got[0].Payload))
+	})
+
+	t.Run("unexpected status", func(t *testing.T) {
+		status = http.StatusServiceUnavailable
+		err := publisher.Publish(ctx, ev)
+		assert.EqualError(t, err, "unexpected status 503")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		publisher := outbox.NewHTTPPublisher("http://127.0.0.1:0")
+		err := publisher.Publish(ctx, ev)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,148 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+// List of relay defaults
+const (
+	defaultInterval  = time.Second
+	defaultBatchSize = 100
+)
+
+// Relay publishes the events of the outbox in the order of their sequence
+// numbers. The sequence number of the last published event is stored as the
+// cursor of the relay after the events are published, hence, the events are
+// delivered at least once i.e. the events published after the last stored
+// cursor are published again if the relay stops or the publisher fails.
+//
+// The relays with different names e.g. one per publisher, publish all the
+// events independently. The relays with the same name share the cursor.
+type Relay struct {
+	outboxRepo Repository
+	publisher  Publisher
+	// name identifies the cursor of the relay
+	name      string
+	logger    log.Logger
+	interval  time.Duration
+	batchSize int
+}
+
+// RelayOption is an option for the relay
+type RelayOption func(*Relay)
+
+// WithInterval sets how often the relay polls for events
+func WithInterval(d time.Duration) RelayOption {
+	return func(r *Relay) {
+		r.interval = d
+	}
+}
+
+// WithBatchSize sets the max number of events retrieved per poll
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		r.batchSize = n
+	}
+}
+
+// WithLogger sets the logger of the relay
+func WithLogger(logger log.Logger) RelayOption {
+	return func(r *Relay) {
+		r.logger = logger
+	}
+}
+
+// NewRelay takes an outbox repository, a publisher and
+// the name of the relay's cursor, and returns a relay
+func NewRelay(outboxRepo Repository, publisher Publisher,
+	name string, opts ...RelayOption) *Relay {
+	r := &Relay{
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		name:       name,
+		logger:     log.NewNopLogger(),
+		interval:   defaultInterval,
+		batchSize:  defaultBatchSize,
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Run publishes the new events every interval until the context is done
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := r.RelayEvents(ctx)
+		if err != nil {
+			_ = r.logger.Log("err", err)
+		} else if n > 0 {
+			_ = r.logger.Log("msg", "published events", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayEvents publishes the events after the cursor, and returns the number
+// of published events. It keeps going until there are no events left.
+func (r *Relay) RelayEvents(ctx context.Context) (int, error) {
+	err := r.outboxRepo.SequenceEvents(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "sequence events")
+	}
+
+	cursor, err := r.outboxRepo.GetCursor(ctx, r.name)
+	if err != nil {
+		return 0, errors.Wrap(err, "get cursor")
+	}
+
+	var count int
+	for {
+		evs, err := r.outboxRepo.ListEvents(ctx, cursor, r.batchSize)
+		if err != nil {
+			return count, errors.Wrap(err, "list events")
+		}
+
+		published := cursor
+		for _, ev := range evs {
+			err = r.publisher.Publish(ctx, ev)
+			if err != nil {
+				err = errors.Wrapf(err, "publish event %d", ev.Seq)
+				break
+			}
+			published = ev.Seq
+			count++
+		}
+
+		// keep the progress even if the publisher failed
+		if published > cursor {
+			cursor = published
+			setErr := r.outboxRepo.SetCursor(ctx, r.name, cursor)
+			if setErr != nil {
+				return count, errors.Wrap(setErr, "set cursor")
+			}
+		}
+
+		if err != nil {
+			return count, err
+		}
+
+		if len(evs) < r.batchSize {
+			return count, nil
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package outbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/outbox"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// recorder records the published events, it fails
+// the publish after the number of events in failAfter
+type recorder struct {
+	events    []*outbox.Event
+	failAfter int
+}
+
+func (r *recorder) Publish(_ context.Context, ev *outbox.Event) error {
+	if r.failAfter > 0 && len(r.events) >= r.failAfter {
+		return errors.New("publisher is down")
+	}
+
+	r.events = append(r.events, ev)
+	return nil
+}
+
+func TestRelay(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+
+	for _, accnt := range []account.Account{john, mary} {
+		_, err := store.AccountRepo.CreateAccount(ctx, accnt)
+		require.NoError(t, err)
+	}
+
+	err := ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	xactSvc := transaction.NewService(store.AccountRepo,
+		store.LedgerRepo, store.XactRepo, store.BalRepo)
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(40),
+	})
+	require.NoError(t, err)
+
+	decodeXact := func(t *testing.T, ev *outbox.Event) transaction.Transaction {
+		var xact transaction.Transaction
+		err := json.Unmarshal(ev.Payload, &xact)
+		require.NoError(t, err)
+		return xact
+	}
+
+	pub := &recorder{}
+	relay := outbox.NewRelay(store.OutboxRepo, pub, "relay1", outbox.WithBatchSize(2))
+
+	t.Run("relay events", func(t *testing.T) {
+		n, err := relay.RelayEvents(ctx)
+		require.NoError(t, err)
+		// the deposit and the two legs of the transfer
+		require.Equal(t, 3, n)
+		require.Len(t, pub.events, 3)
+
+		for i, ev := range pub.events {
+			assert.Equal(t, int64(i+1), ev.Seq)
+			assert.Equal(t, transaction.EventTypeXactPosted, ev.Type)
+		}
+
+		deposit := decodeXact(t, pub.events[0])
+		assert.Equal(t, transaction.XactTypeExtDeposit, deposit.XactTypeExt)
+		assert.Equal(t, john.AccountID, deposit.AccountID)
+		assert.True(t, decimal.NewFromInt(100).Equal(deposit.Amount))
+
+		sent := decodeXact(t, pub.events[1])
+		received := decodeXact(t, pub.events[2])
+		assert.Equal(t, sent.XactNo, received.XactNo)
+		assert.ElementsMatch(t,
+			[]transaction.XactTypeExt{transaction.XactTypeExtSndTransfer,
+				transaction.XactTypeExtRcvTransfer},
+			[]transaction.XactTypeExt{sent.XactTypeExt, received.XactTypeExt})
+
+		n, err = relay.RelayEvents(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, n, "already published")
+	})
+
+	t.Run("publisher failure", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+				AccountID: mary.AccountID,
+				Amount:    decimal.NewFromInt(10),
+			})
+			require.NoError(t, err)
+		}
+
+		pub.failAfter = 4
+		n, err := relay.RelayEvents(ctx)
+		assert.EqualError(t, err, "publish event 5: publisher is down")
+		assert.Equal(t, 1, n)
+
+		// the relay resumes after the last published event
+		pub.failAfter = 0
+		n, err = relay.RelayEvents(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+
+		require.Len(t, pub.events, 6)
+		for i, ev := range pub.events {
+			assert.Equal(t, int64(i+1), ev.Seq)
+		}
+	})
+
+	t.Run("independent relays", func(t *testing.T) {
+		pub2 := &recorder{}
+		relay2 := outbox.NewRelay(store.OutboxRepo, pub2, "relay2")
+
+		n, err := relay2.RelayEvents(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 6, n)
+		assert.Len(t, pub2.events, 6)
+	})
+}
@@ -0,0 +1,19 @@
+package outbox
+
+import "context"
+
+// Repository is an outbox repository, the events are
+// written by the repositories of the events' subjects
+type Repository interface {
+	// SequenceEvents assigns the next sequence numbers to the
+	// committed events that don't have one, in the order they were
+	// written. The events committed later get greater numbers.
+	SequenceEvents(context.Context) error
+	// ListEvents retrieves up to n sequenced events after the sequence number
+	ListEvents(ctx context.Context, after int64, n int) ([]*Event, error)
+	// GetCursor retrieves the sequence number of the last
+	// event published by the relay, 0 if it hasn't published any
+	GetCursor(ctx context.Context, relay string) (int64, error)
+	// SetCursor sets the sequence number of the last event published by the relay
+	SetCursor(ctx context.Context, relay string, seq int64) error
+}
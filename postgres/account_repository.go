@@ -5,8 +5,10 @@ import (
 	"database/sql"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 
 	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/etc/tx"
 )
 
 // AccountRepository implements the account repository
@@ -22,8 +24,10 @@ func NewAccountRepository(db *sql.DB) *AccountRepository {
 
 // CreateAccount creates an account
 func (ar *AccountRepository) CreateAccount(ctx context.Context, accnt account.Account) (account.AccountID, error) {
-	stmnt := "insert into accounts (account_id, currency) values ($1, $2)"
-	_, err := ar.db.ExecContext(ctx, stmnt, accnt.AccountID, accnt.Currency)
+	stmnt := `insert into accounts (account_id, currency, overdraft_limit)
+		values ($1, $2, $3)`
+	_, err := ar.db.ExecContext(ctx, stmnt, accnt.AccountID,
+		accnt.Currency, accnt.OverdraftLimit)
 	if err != nil {
 		return "", errors.Wrap(err, "exec context")
 	}
@@ -33,12 +37,12 @@ func (ar *AccountRepository) CreateAccount(ctx context.Context, accnt account.Ac
 
 // GetAccount retrieves an account
 func (ar *AccountRepository) GetAccount(ctx context.Context, accntID account.AccountID) (*account.Account, error) {
-	stmnt := `select account_id, currency from accounts
+	stmnt := `select account_id, currency, status, overdraft_limit from accounts
 		where account_id = $1`
 
 	var ac account.Account
 	err := ar.db.QueryRowContext(ctx, stmnt, accntID).
-		Scan(&ac.AccountID, &ac.Currency)
+		Scan(&ac.AccountID, &ac.Currency, &ac.Status, &ac.OverdraftLimit)
 	if err != nil {
 		return nil, errors.Wrap(err, "query row context")
 	}
@@ -48,7 +52,7 @@ func (ar *AccountRepository) GetAccount(ctx context.Context, accntID account.Acc
 
 // ListAccounts retrieves the list of accounts
 func (ar *AccountRepository) ListAccounts(ctx context.Context) ([]*account.Account, error) {
-	stmnt := `select account_id, currency from accounts`
+	stmnt := `select account_id, currency, status, overdraft_limit from accounts`
 
 	rows, err := ar.db.QueryContext(ctx, stmnt)
 	if err != nil {
@@ -59,7 +63,8 @@ func (ar *AccountRepository) ListAccounts(ctx context.Context) ([]*account.Accou
 	accnts := []*account.Account{}
 	for rows.Next() {
 		var accnt account.Account
-		err = rows.Scan(&accnt.AccountID, &accnt.Currency)
+		err = rows.Scan(&accnt.AccountID, &accnt.Currency,
+			&accnt.Status, &accnt.OverdraftLimit)
 		if err != nil {
 			return nil, errors.Wrap(err, "row scan")
 		}
@@ -80,3 +85,114 @@ func (ar *AccountRepository) IsAccountExists(ctx context.Context, accntID accoun
 
 	return exists, nil
 }
+
+// GetAccountStatus retrieves the account status within tx
+func (ar *AccountRepository) GetAccountStatus(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID) (account.Status, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return 0, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := "select status from accounts where account_id = $1"
+	var status account.Status
+	err := txx.QueryRowContext(ctx, stmnt, accntID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, account.ErrAccountNotFound
+		}
+		return 0, errors.Wrap(err, "query row context")
+	}
+
+	return status, nil
+}
+
+// UpdateAccountStatus updates the account status and records the status change within tx
+func (ar *AccountRepository) UpdateAccountStatus(ctx context.Context,
+	tx tx.Tx, sc account.StatusChange) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := "update accounts set status = $1 where account_id = $2"
+	_, err := txx.ExecContext(ctx, stmnt, sc.Status, sc.AccountID)
+	if err != nil {
+		return errors.Wrap(err, "update account status")
+	}
+
+	stmnt = `insert into account_status_changes (
+			account_id, from_status, status, reason, changed_by
+		) values ($1, $2, $3, $4, $5)`
+	_, err = txx.ExecContext(ctx, stmnt, sc.AccountID,
+		sc.FromStatus, sc.Status, sc.Reason, sc.ChangedBy)
+	if err != nil {
+		return errors.Wrap(err, "insert status change")
+	}
+
+	return nil
+}
+
+// ListStatusChanges retrieves the status changes of the account
+func (ar *AccountRepository) ListStatusChanges(ctx context.Context,
+	accntID account.AccountID) ([]*account.StatusChange, error) {
+	stmnt := `select account_id, from_status, status, reason, changed_by, ts
+		from account_status_changes where account_id = $1 order by ts`
+
+	rows, err := ar.db.QueryContext(ctx, stmnt, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	scs := []*account.StatusChange{}
+	for rows.Next() {
+		var sc account.StatusChange
+		err = rows.Scan(&sc.AccountID, &sc.FromStatus, &sc.Status,
+			&sc.Reason, &sc.ChangedBy, &sc.Ts)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		scs = append(scs, &sc)
+	}
+
+	return scs, nil
+}
+
+// GetOverdraftLimit retrieves the overdraft limit of the account within tx
+func (ar *AccountRepository) GetOverdraftLimit(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID) (decimal.Decimal, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return decimal.Zero, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := "select overdraft_limit from accounts where account_id = $1"
+	var limit decimal.Decimal
+	err := txx.QueryRowContext(ctx, stmnt, accntID).Scan(&limit)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return decimal.Zero, account.ErrAccountNotFound
+		}
+		return decimal.Zero, errors.Wrap(err, "query row context")
+	}
+
+	return limit, nil
+}
+
+// UpdateOverdraftLimit updates the overdraft limit of the account within tx
+func (ar *AccountRepository) UpdateOverdraftLimit(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID, limit decimal.Decimal) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := "update accounts set overdraft_limit = $1 where account_id = $2"
+	_, err := txx.ExecContext(ctx, stmnt, limit, accntID)
+	if err != nil {
+		return errors.Wrap(err, "update overdraft limit")
+	}
+
+	return nil
+}
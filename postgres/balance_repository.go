@@ -3,8 +3,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
 	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/balance"
 	"github.com/stevenferrer/kalupi/etc/tx"
@@ -26,23 +30,55 @@ func (br *BalanceRepository) BeginTx(ctx context.Context) (tx.Tx, error) {
 	return br.db.BeginTx(ctx, nil)
 }
 
-// GetAccntBal retrieves the account balance within tx
-func (br *BalanceRepository) GetAccntBal(ctx context.Context, tx tx.Tx, accntID account.AccountID) (*account.Balance, error) {
+// GetAccntBal retrieves the account balance without locking the account,
+// the balance and the held amount are read in a single statement
+func (br *BalanceRepository) GetAccntBal(ctx context.Context, accntID account.AccountID) (*account.Balance, error) {
+	stmnt := `select coalesce(b.total_debit, 0), coalesce(b.total_credit, 0),
+			coalesce(b.current_balance, 0), b.ts,
+			(select coalesce(sum(h.amount), 0) from holds h
+				where h.account_id = a.account_id and h.status = 'active'
+				and h.expires_at > statement_timestamp())
+		from accounts a left join account_balances b
+			on b.account_id = a.account_id
+		where a.account_id = $1`
+
+	accntBal := account.Balance{AccountID: accntID}
+	var held decimal.Decimal
+	err := br.db.QueryRowContext(ctx, stmnt, accntID).Scan(
+		&accntBal.TotalDebit,
+		&accntBal.TotalCredit,
+		&accntBal.CurrentBal,
+		&accntBal.Ts,
+		&held,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, account.ErrAccountNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+	accntBal.AvailableBal = accntBal.CurrentBal.Sub(held)
+
+	return &accntBal, nil
+}
+
+// GetAccntBalForUpdate retrieves the account balance within tx,
+// the account is locked until the tx is committed or rolled back
+func (br *BalanceRepository) GetAccntBalForUpdate(ctx context.Context, tx tx.Tx, accntID account.AccountID) (*account.Balance, error) {
 	txx, ok := tx.(*sql.Tx)
 	if !ok {
 		return nil, errors.New("expecting tx to be *sql.Tx")
 	}
 
-	// This will block when somebody is trying to insert in account_transactions
-	// i.e. share lock mode conflicts with row exclusive mode, hence, the select
-	// statement to account_balances view should block
-	_, err := txx.ExecContext(ctx, "lock table account_transactions in share mode")
+	err := lockAccnt(ctx, txx, accntID)
 	if err != nil {
-		return nil, errors.Wrap(err, "acquire share lock")
+		return nil, errors.Wrap(err, "lock account")
 	}
 
+	// This will block when somebody is inserting in account_transactions for
+	// the same account i.e. the running balance is being updated
 	stmnt := `select account_id, total_debit, total_credit, current_balance, ts 
-		from account_balances where account_id=$1`
+		from account_balances where account_id=$1 for share`
 
 	var accntBal account.Balance
 	err = txx.QueryRowContext(ctx, stmnt, accntID).Scan(
@@ -54,11 +90,92 @@ func (br *BalanceRepository) GetAccntBal(ctx context.Context, tx tx.Tx, accntID
 	)
 	if err != nil {
 		// no transaction record yet
+		if err != sql.ErrNoRows {
+			return nil, errors.Wrap(err, "query row context")
+		}
+		accntBal = account.Balance{AccountID: accntID}
+	}
+
+	stmnt = `select coalesce(sum(amount), 0) from holds where account_id=$1
+		and status = 'active' and expires_at > statement_timestamp()`
+
+	var held decimal.Decimal
+	err = txx.QueryRowContext(ctx, stmnt, accntID).Scan(&held)
+	if err != nil {
+		return nil, errors.Wrap(err, "query held amount")
+	}
+	accntBal.AvailableBal = accntBal.CurrentBal.Sub(held)
+
+	return &accntBal, nil
+}
+
+// GetAccntBalAsOf retrieves the account balance from the postings made before the time
+func (br *BalanceRepository) GetAccntBalAsOf(ctx context.Context,
+	accntID account.AccountID, asOf time.Time) (*account.Balance, error) {
+	// a ledger's debit is the account's credit and vice versa
+	stmnt := `select
+			coalesce(sum(at.amount) filter (where at.xact_type = 'Cr'), 0),
+			coalesce(sum(at.amount) filter (where at.xact_type = 'Dr'), 0),
+			max(at.ts)
+		from accounts a left join account_transactions at
+			on at.account_id = a.account_id and at.ts < $2
+		where a.account_id = $1
+		group by a.account_id`
+
+	accntBal := account.Balance{AccountID: accntID}
+	err := br.db.QueryRowContext(ctx, stmnt, accntID, asOf).Scan(
+		&accntBal.TotalDebit,
+		&accntBal.TotalCredit,
+		&accntBal.Ts,
+	)
+	if err != nil {
 		if err == sql.ErrNoRows {
-			return &account.Balance{AccountID: accntID}, nil
+			return nil, account.ErrAccountNotFound
 		}
 		return nil, errors.Wrap(err, "query row context")
 	}
+	accntBal.CurrentBal = accntBal.TotalCredit.Sub(accntBal.TotalDebit)
+	accntBal.AvailableBal = accntBal.CurrentBal
 
 	return &accntBal, nil
 }
+
+// LockAccnts locks the accounts within tx. The accounts are locked in order
+// of account id so that the txs locking the same accounts won't deadlock.
+func (br *BalanceRepository) LockAccnts(ctx context.Context, tx tx.Tx, accntIDs ...account.AccountID) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	sorted := make([]account.AccountID, len(accntIDs))
+	copy(sorted, accntIDs)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	for _, accntID := range sorted {
+		err := lockAccnt(ctx, txx, accntID)
+		if err != nil {
+			return errors.Wrapf(err, "lock account %s", accntID)
+		}
+	}
+
+	return nil
+}
+
+// lockAccnt locks the account row within tx, this will block when somebody
+// is holding the lock until that tx is committed or rolled back. The lock
+// doesn't conflict with the foreign key checks of account_transactions.
+func lockAccnt(ctx context.Context, txx *sql.Tx, accntID account.AccountID) error {
+	stmnt := `select account_id from accounts
+		where account_id=$1 for no key update`
+
+	var id account.AccountID
+	err := txx.QueryRowContext(ctx, stmnt, accntID).Scan(&id)
+	if err != nil {
+		return errors.Wrap(err, "query row context")
+	}
+
+	return nil
+}
@@ -61,7 +61,7 @@ func TestBalanceRepository(t *testing.T) {
 		require.NoError(t, err)
 
 		// john should have 0 balance
-		johnBal, err := balRepo.GetAccntBal(ctx, tx, john.AccountID)
+		johnBal, err := balRepo.GetAccntBalForUpdate(ctx, tx, john.AccountID)
 		require.NoError(t, err)
 		assert.True(t, decimal.NewFromInt(0).Equal(johnBal.CurrentBal),
 			"john should have an initial balance of 0")
@@ -129,7 +129,7 @@ func TestBalanceRepository(t *testing.T) {
 				assert.NoError(t, tx.Commit(), "check balance commit")
 			}()
 
-			johnBal, err := balRepo.GetAccntBal(ctx, tx, john.AccountID)
+			johnBal, err := balRepo.GetAccntBalForUpdate(ctx, tx, john.AccountID)
 			require.NoError(t, err, "check balance")
 
 			assert.True(t, decimal.NewFromInt(50).Equal(johnBal.CurrentBal),
@@ -139,4 +139,37 @@ func TestBalanceRepository(t *testing.T) {
 		wg.Wait()
 	})
 
+	t.Run("running balance", func(t *testing.T) {
+		tx, err := balRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Commit())
+		}()
+
+		johnBal, err := balRepo.GetAccntBalForUpdate(ctx, tx, john.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(100).Equal(johnBal.TotalCredit))
+		assert.True(t, decimal.NewFromInt(50).Equal(johnBal.TotalDebit))
+		assert.True(t, decimal.NewFromInt(50).Equal(johnBal.CurrentBal))
+		assert.NotNil(t, johnBal.Ts)
+
+		// no transaction record yet
+		maryBal, err := balRepo.GetAccntBalForUpdate(ctx, tx, mary.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.Zero.Equal(maryBal.CurrentBal))
+	})
+
+	t.Run("lock accounts", func(t *testing.T) {
+		tx, err := balRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, tx.Rollback())
+		}()
+
+		err = balRepo.LockAccnts(ctx, tx, mary.AccountID, john.AccountID)
+		assert.NoError(t, err)
+
+		err = balRepo.LockAccnts(ctx, tx, account.AccountID("idontexist"))
+		assert.Error(t, err)
+	})
 }
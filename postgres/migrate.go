@@ -132,4 +132,466 @@ var migrations = migrator.Migrations(
 			return nil
 		},
 	},
+
+	&migrator.Migration{
+		Name: "create ledger_transactions table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table ledger_transactions (
+				xact_no varchar not null, -- reference number
+				ledger_no varchar(64) not null, -- fk
+				xact_type varchar(3) not null,
+				amount numeric(15, 4),
+				"desc" text not null default '',
+				ts timestamptz not null default now(),
+				constraint fk_ledger
+					foreign key (ledger_no)
+						references ledgers(ledger_no)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create fx_transfers table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table fx_transfers (
+				xact_no varchar primary key, -- reference number
+				from_account varchar(64) not null, -- fk
+				to_account varchar(64) not null, -- fk
+				from_currency varchar(3) not null,
+				to_currency varchar(3) not null,
+				from_amount numeric(15, 4) not null,
+				to_amount numeric(15, 4) not null,
+				rate numeric(19, 10) not null,
+				ts timestamptz not null default now(),
+				constraint fk_from_account
+					foreign key (from_account)
+						references accounts(account_id),
+				constraint fk_to_account
+					foreign key (to_account)
+						references accounts(account_id)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create idempotency_keys table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table idempotency_keys (
+				key varchar(255) primary key,
+				fingerprint varchar(64) not null, -- sha256 of request
+				xact_no varchar not null, -- reference number
+				ts timestamptz not null default now()
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "update account_balances view to use xact_type",
+		Func: func(tx *sql.Tx) error {
+			// The direction is taken from the xact_type so that the
+			// reversal and refund legs are counted, a ledger's debit
+			// is the account's credit and vice versa.
+			stmnt := `create or replace view account_balances as 
+				select 
+					account_id,
+					coalesce((
+						select sum(amount) from account_transactions
+						where account_id=at.account_id and xact_type = 'Dr'
+					), 0) as total_credit,
+					coalesce((
+						select sum(amount) from account_transactions
+						where account_id=at.account_id and xact_type = 'Cr'
+					), 0) as total_debit,
+					coalesce((
+						select sum(amount) from account_transactions
+						where account_id=at.account_id and xact_type = 'Dr'
+					), 0) - coalesce((
+						select sum(amount) from account_transactions
+						where account_id=at.account_id and xact_type = 'Cr'
+					), 0) as current_balance,
+					now() as ts
+				from  account_transactions at
+			`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create xact_reversals table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table xact_reversals (
+				xact_no varchar primary key, -- reversal or refund reference number
+				orig_xact_no varchar not null, -- original reference number
+				xact_type_ext varchar(3) not null, -- Rv or Rf
+				amount numeric(15, 4) not null,
+				reason text not null default '',
+				ts timestamptz not null default now()
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// a transaction can only be reversed once
+			stmnt = `create unique index xact_reversals_orig_xact_no_rv_idx
+				on xact_reversals (orig_xact_no) where xact_type_ext = 'Rv'`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create holds table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table holds (
+				hold_id varchar primary key,
+				account_id varchar(64) not null,
+				amount numeric(15, 4) not null,
+				status varchar(16) not null, -- active, captured or voided
+				captured_amount numeric(15, 4) not null default 0,
+				xact_no varchar, -- capture reference number
+				expires_at timestamptz not null,
+				ts timestamptz not null default now(),
+				constraint fk_account
+					foreign key(account_id)
+						references accounts(account_id)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index holds_account_id_status_idx
+				on holds (account_id, status)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "replace account_balances view with a table",
+		Func: func(tx *sql.Tx) error {
+			// block the inserts to account_transactions until the backfill is done
+			_, err := tx.Exec("lock table account_transactions in share mode")
+			if err != nil {
+				return err
+			}
+
+			if _, err = tx.Exec("drop view account_balances"); err != nil {
+				return err
+			}
+
+			// the running balances are updated on every insert to account_transactions
+			stmnt := `create table account_balances (
+				account_id varchar(64) primary key,
+				total_credit numeric(19, 4) not null default 0,
+				total_debit numeric(19, 4) not null default 0,
+				current_balance numeric(19, 4) not null default 0,
+				ts timestamptz not null default now(), -- last update
+				constraint fk_account
+					foreign key(account_id)
+						references accounts(account_id)
+			)`
+			if _, err = tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// backfill the running balances
+			stmnt = `insert into account_balances (
+					account_id, total_credit, total_debit, current_balance, ts
+				)
+				select 
+					account_id,
+					coalesce(sum(amount) filter (where xact_type = 'Dr'), 0),
+					coalesce(sum(amount) filter (where xact_type = 'Cr'), 0),
+					coalesce(sum(amount) filter (where xact_type = 'Dr'), 0) - 
+						coalesce(sum(amount) filter (where xact_type = 'Cr'), 0),
+					max(ts)
+				from account_transactions
+				group by account_id`
+			if _, err = tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create account_transactions account_id, ts, xact_no index",
+		Func: func(tx *sql.Tx) error {
+			// used by the paginated transaction history
+			stmnt := `create index account_transactions_account_id_ts_xact_no_idx
+				on account_transactions (account_id, ts, xact_no)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "add accounts status column",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `alter table accounts
+				add column status varchar(16) not null default 'active'`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create account_status_changes table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table account_status_changes (
+				account_id varchar(64) not null,
+				from_status varchar(16) not null,
+				status varchar(16) not null,
+				reason text not null,
+				changed_by varchar(64) not null,
+				ts timestamptz not null default now(),
+				constraint fk_account
+					foreign key (account_id)
+						references accounts(account_id)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index account_status_changes_account_id_ts_idx
+				on account_status_changes (account_id, ts)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "add accounts overdraft_limit column",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `alter table accounts
+				add column overdraft_limit numeric(15, 4) not null default 0`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create transfer_instructions and instruction_runs tables",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table transfer_instructions (
+				instruction_id varchar primary key,
+				from_account varchar(64) not null,
+				to_account varchar(64) not null,
+				amount numeric(15, 4) not null,
+				"desc" text not null default '',
+				frequency varchar(16) not null, -- once, daily, weekly, monthly or cron
+				cron_expr varchar not null default '',
+				start_at timestamptz not null,
+				end_at timestamptz,
+				status varchar(16) not null, -- active, completed, failed or cancelled
+				next_run_at timestamptz not null,
+				next_attempt_at timestamptz not null,
+				attempts integer not null default 0,
+				last_error text not null default '',
+				lease_owner varchar,
+				lease_expires_at timestamptz,
+				ts timestamptz not null default now(),
+				constraint fk_from_account
+					foreign key(from_account)
+						references accounts(account_id),
+				constraint fk_to_account
+					foreign key(to_account)
+						references accounts(account_id)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index transfer_instructions_status_next_attempt_at_idx
+				on transfer_instructions (status, next_attempt_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create table instruction_runs (
+				instruction_id varchar not null,
+				scheduled_at timestamptz not null,
+				attempt integer not null,
+				xact_no varchar, -- transfer reference number
+				error text not null default '',
+				ts timestamptz not null default now(),
+				constraint fk_instruction
+					foreign key(instruction_id)
+						references transfer_instructions(instruction_id)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index instruction_runs_instruction_id_ts_idx
+				on instruction_runs (instruction_id, ts)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create outbox tables",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table outbox_events (
+				event_id bigserial primary key,
+				seq bigint unique, -- assigned after commit
+				type varchar(64) not null,
+				payload jsonb not null,
+				ts timestamptz not null default now()
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index outbox_events_unsequenced_idx
+				on outbox_events (event_id) where seq is null`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create table outbox_sequence (
+				last_seq bigint not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `insert into outbox_sequence (last_seq) values (0)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create table outbox_cursors (
+				relay varchar primary key,
+				seq bigint not null,
+				ts timestamptz not null default now()
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create webhook tables",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table webhook_subscriptions (
+				subscription_id varchar primary key,
+				account_id varchar(64), -- all the accounts if null
+				xact_types jsonb not null default '[]', -- all the types if empty
+				url text not null,
+				secret varchar not null,
+				ts timestamptz not null default now(),
+				constraint fk_account
+					foreign key(account_id)
+						references accounts(account_id)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create table webhook_deliveries (
+				delivery_id varchar primary key,
+				subscription_id varchar not null,
+				event_seq bigint not null, -- outbox event
+				event_type varchar(64) not null,
+				payload text not null, -- the signed request body as is
+				status varchar(16) not null, -- pending, succeeded or failed
+				attempts integer not null default 0,
+				next_attempt_at timestamptz not null,
+				last_status_code integer not null default 0,
+				last_error text not null default '',
+				delivered_at timestamptz,
+				lease_owner varchar,
+				lease_expires_at timestamptz,
+				ts timestamptz not null default now(),
+				unique(subscription_id, event_seq),
+				constraint fk_subscription
+					foreign key(subscription_id)
+						references webhook_subscriptions(subscription_id)
+						on delete cascade
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index webhook_deliveries_status_next_attempt_at_idx
+				on webhook_deliveries (status, next_attempt_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "add account_transactions id column",
+		Func: func(tx *sql.Tx) error {
+			// the legs of a transaction share the ts and xact_no,
+			// the id tells them apart in the paginated transaction history
+			stmnt := `alter table account_transactions
+				add column id bigserial not null`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `drop index account_transactions_account_id_ts_xact_no_idx`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index account_transactions_account_id_ts_xact_no_id_idx
+				on account_transactions (account_id, ts, xact_no, id)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
 )
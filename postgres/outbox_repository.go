@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/outbox"
+)
+
+// OutboxRepository implements the outbox repository
+// interface and uses postgres as back-end
+type OutboxRepository struct{ db *sql.DB }
+
+var _ outbox.Repository = (*OutboxRepository)(nil)
+
+// NewOutboxRepository returns an outbox repository
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// createEvent writes the event to the outbox within tx
+func createEvent(ctx context.Context, txx *sql.Tx, ev *outbox.Event) error {
+	stmnt := `insert into outbox_events (type, payload, ts) values ($1, $2, $3)`
+	_, err := txx.ExecContext(ctx, stmnt, ev.Type, []byte(ev.Payload), ev.Ts)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// SequenceEvents assigns the next sequence numbers to the committed
+// events that don't have one, in the order they were written
+func (or *OutboxRepository) SequenceEvents(ctx context.Context) (err error) {
+	txx, err := or.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	// the row lock serializes the relays, the events that are
+	// committed after the statement starts get the next numbers
+	var lastSeq int64
+	stmnt := `select last_seq from outbox_sequence for update`
+	err = txx.QueryRowContext(ctx, stmnt).Scan(&lastSeq)
+	if err != nil {
+		err = errors.Wrap(err, "query row context")
+		return
+	}
+
+	stmnt = `update outbox_events e set seq = s.seq
+		from (
+			select event_id, $1 + row_number() over (order by event_id) as seq
+			from outbox_events where seq is null
+		) s
+		where e.event_id = s.event_id`
+	res, err := txx.ExecContext(ctx, stmnt, lastSeq)
+	if err != nil {
+		err = errors.Wrap(err, "sequence events")
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		err = errors.Wrap(err, "rows affected")
+		return
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	stmnt = `update outbox_sequence set last_seq = $1`
+	_, err = txx.ExecContext(ctx, stmnt, lastSeq+n)
+	if err != nil {
+		err = errors.Wrap(err, "update sequence")
+		return
+	}
+
+	return nil
+}
+
+// ListEvents retrieves up to n sequenced events after the sequence number
+func (or *OutboxRepository) ListEvents(ctx context.Context,
+	after int64, n int) ([]*outbox.Event, error) {
+	stmnt := `select seq, type, payload, ts from outbox_events
+		where seq > $1 order by seq limit $2`
+	rows, err := or.db.QueryContext(ctx, stmnt, after, n)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	evs := []*outbox.Event{}
+	for rows.Next() {
+		var (
+			ev      outbox.Event
+			payload []byte
+		)
+		err = rows.Scan(&ev.Seq, &ev.Type, &payload, &ev.Ts)
+		if err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+		ev.Payload = payload
+
+		evs = append(evs, &ev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return evs, nil
+}
+
+// GetCursor retrieves the sequence number of the last event published by the relay
+func (or *OutboxRepository) GetCursor(ctx context.Context, relay string) (int64, error) {
+	stmnt := `select seq from outbox_cursors where relay = $1`
+
+	var seq int64
+	err := or.db.QueryRowContext(ctx, stmnt, relay).Scan(&seq)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, errors.Wrap(err, "query row context")
+	}
+
+	return seq, nil
+}
+
+// SetCursor sets the sequence number of the last event published by the relay
+func (or *OutboxRepository) SetCursor(ctx context.Context, relay string, seq int64) error {
+	stmnt := `insert into outbox_cursors (relay, seq, ts) values ($1, $2, now())
+		on conflict (relay) do update set seq = excluded.seq, ts = excluded.ts`
+	_, err := or.db.ExecContext(ctx, stmnt, relay, seq)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package postgres_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/etc/txdb"
+	"github.com/stevenferrer/kalupi/postgres"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (*repotest.Repos, func()) {
+		db := txdb.MustOpen()
+		err := postgres.Migrate(db)
+		require.NoError(t, err)
+
+		return &repotest.Repos{
+			AccountRepo:  postgres.NewAccountRepository(db),
+			LedgerRepo:   postgres.NewLedgerRepository(db),
+			XactRepo:     postgres.NewXactRepository(db),
+			BalRepo:      postgres.NewBalanceRepository(db),
+			ScheduleRepo: postgres.NewScheduleRepository(db),
+			OutboxRepo:   postgres.NewOutboxRepository(db),
+			WebhookRepo:  postgres.NewWebhookRepository(db),
+		}, func() { db.Close() }
+	})
+}
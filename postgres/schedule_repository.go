@@ -0,0 +1,282 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/schedule"
+)
+
+// ScheduleRepository implements the schedule repository
+// interface and uses postgres as back-end
+type ScheduleRepository struct{ db *sql.DB }
+
+var _ schedule.Repository = (*ScheduleRepository)(nil)
+
+// NewScheduleRepository returns a schedule repository
+func NewScheduleRepository(db *sql.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// instructionCols are the columns scanned by scanInstruction
+const instructionCols = `instruction_id, from_account, to_account, amount,
+	"desc", frequency, cron_expr, start_at, end_at, status, next_run_at,
+	next_attempt_at, attempts, last_error, coalesce(lease_owner, ''),
+	lease_expires_at, ts`
+
+// CreateInstruction creates an instruction
+func (sr *ScheduleRepository) CreateInstruction(ctx context.Context,
+	inst schedule.Instruction) error {
+	stmnt := `insert into transfer_instructions (
+			instruction_id, from_account, to_account, amount, "desc",
+			frequency, cron_expr, start_at, end_at, status,
+			next_run_at, next_attempt_at
+		) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+	_, err := sr.db.ExecContext(ctx, stmnt,
+		inst.InstructionID, inst.FromAccount, inst.ToAccount,
+		inst.Amount, inst.Desc, inst.Frequency, inst.CronExpr,
+		inst.StartAt, inst.EndAt, inst.Status,
+		inst.NextRunAt, inst.NextAttemptAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// GetInstruction retrieves the instruction
+func (sr *ScheduleRepository) GetInstruction(ctx context.Context,
+	instID schedule.InstructionID) (*schedule.Instruction, error) {
+	stmnt := `select ` + instructionCols + `
+		from transfer_instructions where instruction_id = $1`
+
+	inst, err := scanInstruction(sr.db.QueryRowContext(ctx, stmnt, instID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, schedule.ErrInstructionNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return inst, nil
+}
+
+// ListInstructions retrieves the instructions sent from or to
+// the account, or all the instructions if the account id is empty
+func (sr *ScheduleRepository) ListInstructions(ctx context.Context,
+	accntID account.AccountID) ([]*schedule.Instruction, error) {
+	stmnt := `select ` + instructionCols + `
+		from transfer_instructions
+		where $1 = '' or from_account = $1 or to_account = $1
+		order by ts, instruction_id`
+
+	rows, err := sr.db.QueryContext(ctx, stmnt, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanInstructions(rows)
+}
+
+// UpdateInstruction updates the amount, desc, schedule, status,
+// next run and attempts of the instruction unless it is leased
+func (sr *ScheduleRepository) UpdateInstruction(ctx context.Context,
+	inst schedule.Instruction, now time.Time) error {
+	stmnt := `update transfer_instructions set amount = $2, "desc" = $3,
+			frequency = $4, cron_expr = $5, start_at = $6, end_at = $7,
+			status = $8, next_run_at = $9, next_attempt_at = $10, attempts = $11
+		where instruction_id = $1
+			and (lease_expires_at is null or lease_expires_at <= $12)`
+	res, err := sr.db.ExecContext(ctx, stmnt, inst.InstructionID,
+		inst.Amount, inst.Desc, inst.Frequency, inst.CronExpr,
+		inst.StartAt, inst.EndAt, inst.Status, inst.NextRunAt,
+		inst.NextAttemptAt, inst.Attempts, now,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		_, err = sr.GetInstruction(ctx, inst.InstructionID)
+		if err != nil {
+			return err
+		}
+		return schedule.ErrInstructionBusy
+	}
+
+	return nil
+}
+
+// LeaseDueInstructions leases up to n active instructions that are due
+func (sr *ScheduleRepository) LeaseDueInstructions(ctx context.Context, owner string,
+	now time.Time, ttl time.Duration, n int) ([]*schedule.Instruction, error) {
+	// The instructions being leased by another worker are skipped
+	stmnt := `update transfer_instructions
+		set lease_owner = $1, lease_expires_at = $2
+		where instruction_id in (
+			select instruction_id from transfer_instructions
+			where status = $3 and next_attempt_at <= $4
+				and (lease_expires_at is null or lease_expires_at <= $4)
+			order by next_attempt_at
+			limit $5
+			for update skip locked
+		)
+		returning ` + instructionCols
+
+	rows, err := sr.db.QueryContext(ctx, stmnt, owner, now.Add(ttl),
+		schedule.StatusActive, now, n)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanInstructions(rows)
+}
+
+// RecordRun updates the instruction leased by the owner,
+// releases the lease and records the run
+func (sr *ScheduleRepository) RecordRun(ctx context.Context,
+	inst schedule.Instruction, run schedule.Run) (err error) {
+	txx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	stmnt := `update transfer_instructions set status = $2, next_run_at = $3,
+			next_attempt_at = $4, attempts = $5, last_error = $6,
+			lease_owner = null, lease_expires_at = null
+		where instruction_id = $1 and lease_owner = $7`
+	res, err := txx.ExecContext(ctx, stmnt, inst.InstructionID,
+		inst.Status, inst.NextRunAt, inst.NextAttemptAt,
+		inst.Attempts, inst.LastError, inst.LeaseOwner,
+	)
+	if err != nil {
+		err = errors.Wrap(err, "update instruction")
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		err = errors.Wrap(err, "rows affected")
+		return
+	}
+
+	if n == 0 {
+		err = schedule.ErrLeaseLost
+		return
+	}
+
+	stmnt = `insert into instruction_runs (
+			instruction_id, scheduled_at, attempt, xact_no, error
+		) values ($1, $2, $3, nullif($4, ''), $5)`
+	_, err = txx.ExecContext(ctx, stmnt, run.InstructionID,
+		run.ScheduledAt, run.Attempt, run.XactNo, run.Error)
+	if err != nil {
+		err = errors.Wrap(err, "insert run")
+		return
+	}
+
+	return nil
+}
+
+// ListRuns retrieves the runs of the instruction
+func (sr *ScheduleRepository) ListRuns(ctx context.Context,
+	instID schedule.InstructionID) ([]*schedule.Run, error) {
+	stmnt := `select instruction_id, scheduled_at, attempt,
+			coalesce(xact_no, ''), error, ts
+		from instruction_runs where instruction_id = $1 order by ts`
+
+	rows, err := sr.db.QueryContext(ctx, stmnt, instID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	runs := []*schedule.Run{}
+	for rows.Next() {
+		var run schedule.Run
+		err = rows.Scan(&run.InstructionID, &run.ScheduledAt,
+			&run.Attempt, &run.XactNo, &run.Error, &run.Ts)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		runs = append(runs, &run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return runs, nil
+}
+
+// scanner is a sql row or rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanInstruction scans the instruction row
+func scanInstruction(row scanner) (*schedule.Instruction, error) {
+	var inst schedule.Instruction
+	err := row.Scan(
+		&inst.InstructionID, &inst.FromAccount, &inst.ToAccount,
+		&inst.Amount, &inst.Desc, &inst.Frequency, &inst.CronExpr,
+		&inst.StartAt, &inst.EndAt, &inst.Status, &inst.NextRunAt,
+		&inst.NextAttemptAt, &inst.Attempts, &inst.LastError,
+		&inst.LeaseOwner, &inst.LeaseExpiresAt, &inst.Ts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	utc(&inst.StartAt, &inst.NextRunAt, &inst.NextAttemptAt)
+	if inst.EndAt != nil {
+		utc(inst.EndAt)
+	}
+
+	return &inst, nil
+}
+
+// scanInstructions scans the instruction rows
+func scanInstructions(rows *sql.Rows) ([]*schedule.Instruction, error) {
+	insts := []*schedule.Instruction{}
+	for rows.Next() {
+		inst, err := scanInstruction(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		insts = append(insts, inst)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return insts, nil
+}
+
+// utc converts the times to UTC
+func utc(ts ...*time.Time) {
+	for _, t := range ts {
+		*t = t.UTC()
+	}
+}
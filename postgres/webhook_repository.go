@@ -0,0 +1,345 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+// WebhookRepository implements the webhook repository
+// interface and uses postgres as back-end
+type WebhookRepository struct{ db *sql.DB }
+
+var _ webhook.Repository = (*WebhookRepository)(nil)
+
+// NewWebhookRepository returns a webhook repository
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// subscriptionCols are the columns scanned by scanSubscription
+const subscriptionCols = `subscription_id, coalesce(account_id, ''),
+	xact_types, url, secret, ts`
+
+// deliveryCols are the columns scanned by scanDelivery
+const deliveryCols = `delivery_id, subscription_id, event_seq, event_type,
+	payload, status, attempts, next_attempt_at, last_status_code, last_error,
+	delivered_at, coalesce(lease_owner, ''), lease_expires_at, ts`
+
+// CreateSubscription creates a subscription
+func (wr *WebhookRepository) CreateSubscription(ctx context.Context,
+	sub webhook.Subscription) error {
+	xactTypes, err := marshalXactTypes(sub.XactTypeExts)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `insert into webhook_subscriptions (
+			subscription_id, account_id, xact_types, url, secret
+		) values ($1, nullif($2, ''), $3, $4, $5)`
+	_, err = wr.db.ExecContext(ctx, stmnt, sub.SubscriptionID,
+		sub.AccountID, xactTypes, sub.URL, sub.Secret)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves the subscription
+func (wr *WebhookRepository) GetSubscription(ctx context.Context,
+	subID webhook.SubscriptionID) (*webhook.Subscription, error) {
+	stmnt := `select ` + subscriptionCols + `
+		from webhook_subscriptions where subscription_id = $1`
+
+	sub, err := scanSubscription(wr.db.QueryRowContext(ctx, stmnt, subID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, webhook.ErrSubscriptionNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions retrieves the subscriptions to the account and
+// to all the accounts, or all the subscriptions if the account id is empty
+func (wr *WebhookRepository) ListSubscriptions(ctx context.Context,
+	accntID account.AccountID) ([]*webhook.Subscription, error) {
+	stmnt := `select ` + subscriptionCols + `
+		from webhook_subscriptions
+		where $1 = '' or account_id is null or account_id = $1
+		order by ts, subscription_id`
+
+	rows, err := wr.db.QueryContext(ctx, stmnt, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	subs := []*webhook.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+// DeleteSubscription deletes the subscription and its deliveries
+func (wr *WebhookRepository) DeleteSubscription(ctx context.Context,
+	subID webhook.SubscriptionID) error {
+	// the deliveries are deleted on cascade
+	stmnt := `delete from webhook_subscriptions where subscription_id = $1`
+	res, err := wr.db.ExecContext(ctx, stmnt, subID)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return webhook.ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// CreateDeliveries creates the deliveries that don't exist
+func (wr *WebhookRepository) CreateDeliveries(ctx context.Context,
+	deliveries []webhook.Delivery) (err error) {
+	txx, err := wr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	stmnt := `insert into webhook_deliveries (
+			delivery_id, subscription_id, event_seq, event_type,
+			payload, status, next_attempt_at
+		) values ($1, $2, $3, $4, $5, $6, $7)
+		on conflict (subscription_id, event_seq) do nothing`
+	for _, d := range deliveries {
+		_, err = txx.ExecContext(ctx, stmnt, d.DeliveryID, d.SubscriptionID,
+			d.EventSeq, d.EventType, []byte(d.Payload), d.Status, d.NextAttemptAt)
+		if err != nil {
+			err = errors.Wrap(err, "insert delivery")
+			return
+		}
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves the delivery
+func (wr *WebhookRepository) GetDelivery(ctx context.Context,
+	deliveryID webhook.DeliveryID) (*webhook.Delivery, error) {
+	stmnt := `select ` + deliveryCols + `
+		from webhook_deliveries where delivery_id = $1`
+
+	d, err := scanDelivery(wr.db.QueryRowContext(ctx, stmnt, deliveryID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, webhook.ErrDeliveryNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return d, nil
+}
+
+// ListDeliveries retrieves the deliveries to the subscription
+func (wr *WebhookRepository) ListDeliveries(ctx context.Context,
+	subID webhook.SubscriptionID) ([]*webhook.Delivery, error) {
+	stmnt := `select ` + deliveryCols + `
+		from webhook_deliveries where subscription_id = $1
+		order by event_seq`
+
+	rows, err := wr.db.QueryContext(ctx, stmnt, subID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+// UpdateDelivery updates the status, attempts and
+// next attempt of the delivery unless it is leased
+func (wr *WebhookRepository) UpdateDelivery(ctx context.Context,
+	d webhook.Delivery, now time.Time) error {
+	stmnt := `update webhook_deliveries set status = $2,
+			attempts = $3, next_attempt_at = $4
+		where delivery_id = $1
+			and (lease_expires_at is null or lease_expires_at <= $5)`
+	res, err := wr.db.ExecContext(ctx, stmnt, d.DeliveryID,
+		d.Status, d.Attempts, d.NextAttemptAt, now)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		_, err = wr.GetDelivery(ctx, d.DeliveryID)
+		if err != nil {
+			return err
+		}
+		return webhook.ErrDeliveryBusy
+	}
+
+	return nil
+}
+
+// LeaseDueDeliveries leases up to n pending deliveries that are due
+func (wr *WebhookRepository) LeaseDueDeliveries(ctx context.Context, owner string,
+	now time.Time, ttl time.Duration, n int) ([]*webhook.Delivery, error) {
+	// The deliveries being leased by another worker are skipped
+	stmnt := `update webhook_deliveries
+		set lease_owner = $1, lease_expires_at = $2
+		where delivery_id in (
+			select delivery_id from webhook_deliveries
+			where status = $3 and next_attempt_at <= $4
+				and (lease_expires_at is null or lease_expires_at <= $4)
+			order by next_attempt_at, event_seq
+			limit $5
+			for update skip locked
+		)
+		returning ` + deliveryCols
+
+	rows, err := wr.db.QueryContext(ctx, stmnt, owner, now.Add(ttl),
+		webhook.StatusPending, now, n)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+// RecordAttempt updates the delivery leased by the owner and releases the lease
+func (wr *WebhookRepository) RecordAttempt(ctx context.Context, d webhook.Delivery) error {
+	stmnt := `update webhook_deliveries set status = $2, attempts = $3,
+			next_attempt_at = $4, last_status_code = $5, last_error = $6,
+			delivered_at = $7, lease_owner = null, lease_expires_at = null
+		where delivery_id = $1 and lease_owner = $8`
+	res, err := wr.db.ExecContext(ctx, stmnt, d.DeliveryID, d.Status,
+		d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError,
+		d.DeliveredAt, d.LeaseOwner,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return webhook.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// marshalXactTypes returns the json encoded transaction types
+func marshalXactTypes(xactTypes []transaction.XactTypeExt) ([]byte, error) {
+	if xactTypes == nil {
+		xactTypes = []transaction.XactTypeExt{}
+	}
+
+	b, err := json.Marshal(xactTypes)
+	if err != nil {
+		return nil, errors.Wrap(err, "json marshal")
+	}
+
+	return b, nil
+}
+
+// scanSubscription scans the subscription row
+func scanSubscription(row scanner) (*webhook.Subscription, error) {
+	var (
+		sub       webhook.Subscription
+		xactTypes []byte
+	)
+	err := row.Scan(&sub.SubscriptionID, &sub.AccountID,
+		&xactTypes, &sub.URL, &sub.Secret, &sub.Ts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(xactTypes, &sub.XactTypeExts)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(sub.XactTypeExts) == 0 {
+		sub.XactTypeExts = nil
+	}
+
+	return &sub, nil
+}
+
+// scanDelivery scans the delivery row
+func scanDelivery(row scanner) (*webhook.Delivery, error) {
+	var (
+		d       webhook.Delivery
+		payload []byte
+	)
+	err := row.Scan(
+		&d.DeliveryID, &d.SubscriptionID, &d.EventSeq, &d.EventType,
+		&payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.DeliveredAt,
+		&d.LeaseOwner, &d.LeaseExpiresAt, &d.Ts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Payload = payload
+	utc(&d.NextAttemptAt)
+	if d.DeliveredAt != nil {
+		utc(d.DeliveredAt)
+	}
+
+	return &d, nil
+}
+
+// scanDeliveries scans the delivery rows
+func scanDeliveries(rows *sql.Rows) ([]*webhook.Delivery, error) {
+	deliveries := []*webhook.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
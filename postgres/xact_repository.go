@@ -3,10 +3,18 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 
+	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
 	"github.com/stevenferrer/kalupi/transaction"
 )
 
@@ -37,11 +45,426 @@ func (tr *XactRepository) CreateXact(ctx context.Context,
 	stmnt := `insert into account_transactions (
 			xact_no, ledger_no, xact_type,
 			account_id, xact_type_ext, amount, "desc"
-		) values ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := txx.ExecContext(ctx, stmnt,
+		) values ($1, $2, $3, $4, $5, $6, $7)
+		returning ts`
+	err := txx.QueryRowContext(ctx, stmnt,
 		xact.XactNo, xact.LedgerNo, xact.XactType,
 		xact.AccountID, xact.XactTypeExt,
 		xact.Amount, xact.Desc,
+	).Scan(&xact.Ts)
+	if err != nil {
+		return errors.Wrap(err, "query row context")
+	}
+
+	// a ledger's debit is the account's credit and vice versa
+	credit, debit := decimal.Zero, decimal.Zero
+	if xact.XactType == transaction.XactTypeDebit {
+		credit = xact.Amount
+	} else {
+		debit = xact.Amount
+	}
+
+	// update the running balance of the account, the
+	// row is locked until the tx is committed or rolled back
+	stmnt = `insert into account_balances (
+			account_id, total_credit, total_debit, current_balance, ts
+		) values ($1, $2, $3, $4, now())
+		on conflict (account_id) do update set
+			total_credit = account_balances.total_credit + excluded.total_credit,
+			total_debit = account_balances.total_debit + excluded.total_debit,
+			current_balance = account_balances.current_balance + excluded.current_balance,
+			ts = excluded.ts`
+	_, err = txx.ExecContext(ctx, stmnt,
+		xact.AccountID, credit, debit, credit.Sub(debit),
+	)
+	if err != nil {
+		return errors.Wrap(err, "update account balance")
+	}
+
+	// the event is published once the tx is committed
+	ev, err := transaction.NewXactEvent(xact)
+	if err != nil {
+		return errors.Wrap(err, "new xact event")
+	}
+
+	err = createEvent(ctx, txx, ev)
+	if err != nil {
+		return errors.Wrap(err, "create event")
+	}
+
+	return nil
+}
+
+// CreateLedgerXact creates a ledger transaction within a tx
+func (tr *XactRepository) CreateLedgerXact(ctx context.Context,
+	tx tx.Tx, lx transaction.LedgerXact) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `insert into ledger_transactions (
+			xact_no, ledger_no, xact_type, amount, "desc"
+		) values ($1, $2, $3, $4, $5)`
+	_, err := txx.ExecContext(ctx, stmnt,
+		lx.XactNo, lx.LedgerNo, lx.XactType,
+		lx.Amount, lx.Desc,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// CreateFXTransfer records the fx details of a transfer within a tx
+func (tr *XactRepository) CreateFXTransfer(ctx context.Context,
+	tx tx.Tx, fxTr transaction.FXTransfer) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `insert into fx_transfers (
+			xact_no, from_account, to_account, from_currency,
+			to_currency, from_amount, to_amount, rate
+		) values ($1, $2, $3, $4, $5, $6, $7, $8)`
+	_, err := txx.ExecContext(ctx, stmnt,
+		fxTr.XactNo, fxTr.FromAccount, fxTr.ToAccount,
+		fxTr.FromCurrency, fxTr.ToCurrency,
+		fxTr.FromAmount, fxTr.ToAmount, fxTr.Rate,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// CreateIdempotencyKeyIfNotExists creates the idempotency key
+// within a tx, returns false if the key already exists
+func (tr *XactRepository) CreateIdempotencyKeyIfNotExists(ctx context.Context,
+	tx tx.Tx, ik transaction.IdempotencyKey) (bool, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return false, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	// This will block when another tx is inserting the same key
+	// until that tx is committed or rolled back
+	stmnt := `insert into idempotency_keys (key, fingerprint, xact_no)
+		values ($1, $2, $3) on conflict (key) do nothing`
+	res, err := txx.ExecContext(ctx, stmnt, ik.Key, ik.Fingerprint, ik.XactNo)
+	if err != nil {
+		return false, errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+
+	return n == 1, nil
+}
+
+// GetIdempotencyKey retrieves the idempotency key within a tx
+func (tr *XactRepository) GetIdempotencyKey(ctx context.Context,
+	tx tx.Tx, key string) (*transaction.IdempotencyKey, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `select key, fingerprint, xact_no, ts
+		from idempotency_keys where key = $1`
+
+	var ik transaction.IdempotencyKey
+	err := txx.QueryRowContext(ctx, stmnt, key).
+		Scan(&ik.Key, &ik.Fingerprint, &ik.XactNo, &ik.Ts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, transaction.ErrIdempotencyKeyNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &ik, nil
+}
+
+// GetFXTransfer retrieves the fx details of a transfer
+func (tr *XactRepository) GetFXTransfer(ctx context.Context,
+	xactNo transaction.XactNo) (*transaction.FXTransfer, error) {
+	stmnt := `select xact_no, from_account, to_account, from_currency,
+			to_currency, from_amount, to_amount, rate, ts
+		from fx_transfers where xact_no = $1`
+
+	var fxTr transaction.FXTransfer
+	err := tr.db.QueryRowContext(ctx, stmnt, xactNo).Scan(
+		&fxTr.XactNo, &fxTr.FromAccount, &fxTr.ToAccount,
+		&fxTr.FromCurrency, &fxTr.ToCurrency,
+		&fxTr.FromAmount, &fxTr.ToAmount,
+		&fxTr.Rate, &fxTr.Ts,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &fxTr, nil
+}
+
+// GetXact retrieves the transaction and all of its legs
+func (tr *XactRepository) GetXact(ctx context.Context,
+	xactNo transaction.XactNo) (*transaction.Xact, error) {
+	legs, err := queryXactLegs(ctx, tr.db, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query xact legs")
+	}
+
+	if len(legs) == 0 {
+		return nil, transaction.ErrXactNotFound
+	}
+
+	lgLegs, err := queryLedgerXactLegs(ctx, tr.db, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query ledger xact legs")
+	}
+
+	return &transaction.Xact{
+		XactNo:     xactNo,
+		Legs:       legs,
+		LedgerLegs: lgLegs,
+		Ts:         legs[0].Ts,
+	}, nil
+}
+
+// ListXactLegs retrieves the account transactions sharing the xact no within a tx
+func (tr *XactRepository) ListXactLegs(ctx context.Context, tx tx.Tx,
+	xactNo transaction.XactNo) ([]*transaction.Transaction, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	return queryXactLegs(ctx, txx, xactNo)
+}
+
+// ListLedgerXactLegs retrieves the ledger transactions sharing the xact no within a tx
+func (tr *XactRepository) ListLedgerXactLegs(ctx context.Context, tx tx.Tx,
+	xactNo transaction.XactNo) ([]*transaction.LedgerXact, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	return queryLedgerXactLegs(ctx, txx, xactNo)
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+// queryXactLegs retrieves the account transactions sharing the xact no
+func queryXactLegs(ctx context.Context, q queryer,
+	xactNo transaction.XactNo) ([]*transaction.Transaction, error) {
+	stmnt := `select xact_no, ledger_no, xact_type, account_id, 
+		xact_type_ext, amount, "desc", ts from account_transactions
+		where xact_no = $1 order by ts`
+
+	rows, err := q.QueryContext(ctx, stmnt, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	xacts := []*transaction.Transaction{}
+	for rows.Next() {
+		var xact transaction.Transaction
+		err = rows.Scan(
+			&xact.XactNo, &xact.LedgerNo,
+			&xact.XactType, &xact.AccountID,
+			&xact.XactTypeExt, &xact.Amount,
+			&xact.Desc, &xact.Ts,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		xacts = append(xacts, &xact)
+	}
+
+	return xacts, nil
+}
+
+// queryLedgerXactLegs retrieves the ledger transactions sharing the xact no
+func queryLedgerXactLegs(ctx context.Context, q queryer,
+	xactNo transaction.XactNo) ([]*transaction.LedgerXact, error) {
+	stmnt := `select xact_no, ledger_no, xact_type, amount, "desc", ts
+		from ledger_transactions where xact_no = $1 order by ts`
+
+	rows, err := q.QueryContext(ctx, stmnt, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	lxs := []*transaction.LedgerXact{}
+	for rows.Next() {
+		var lx transaction.LedgerXact
+		err = rows.Scan(
+			&lx.XactNo, &lx.LedgerNo,
+			&lx.XactType, &lx.Amount,
+			&lx.Desc, &lx.Ts,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		lxs = append(lxs, &lx)
+	}
+
+	return lxs, nil
+}
+
+// CreateReversal creates a reversal within a tx
+func (tr *XactRepository) CreateReversal(ctx context.Context,
+	tx tx.Tx, rev transaction.Reversal) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `insert into xact_reversals (
+			xact_no, orig_xact_no, xact_type_ext, amount, reason
+		) values ($1, $2, $3, $4, $5)`
+	_, err := txx.ExecContext(ctx, stmnt,
+		rev.XactNo, rev.OrigXactNo, rev.XactTypeExt,
+		rev.Amount, rev.Reason,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// ListReversals retrieves the reversals of the original transaction within a tx
+func (tr *XactRepository) ListReversals(ctx context.Context, tx tx.Tx,
+	origXactNo transaction.XactNo) ([]*transaction.Reversal, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `select xact_no, orig_xact_no, xact_type_ext, amount, reason, ts
+		from xact_reversals where orig_xact_no = $1 order by ts`
+
+	rows, err := txx.QueryContext(ctx, stmnt, origXactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	revs := []*transaction.Reversal{}
+	for rows.Next() {
+		var rev transaction.Reversal
+		err = rows.Scan(
+			&rev.XactNo, &rev.OrigXactNo,
+			&rev.XactTypeExt, &rev.Amount,
+			&rev.Reason, &rev.Ts,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		revs = append(revs, &rev)
+	}
+
+	return revs, nil
+}
+
+// CreateHold creates a hold within a tx
+func (tr *XactRepository) CreateHold(ctx context.Context,
+	tx tx.Tx, hold transaction.Hold) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `insert into holds (
+			hold_id, account_id, amount, status, expires_at
+		) values ($1, $2, $3, $4, $5)`
+	_, err := txx.ExecContext(ctx, stmnt,
+		hold.HoldID, hold.AccountID, hold.Amount,
+		hold.Status, hold.ExpiresAt,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// GetHold retrieves the hold
+func (tr *XactRepository) GetHold(ctx context.Context,
+	holdID transaction.HoldID) (*transaction.Hold, error) {
+	stmnt := `select hold_id, account_id, amount, status, captured_amount,
+			coalesce(xact_no, ''), expires_at, ts
+		from holds where hold_id = $1`
+
+	return scanHold(tr.db.QueryRowContext(ctx, stmnt, holdID))
+}
+
+// GetHoldForUpdate retrieves and locks the hold within a tx
+func (tr *XactRepository) GetHoldForUpdate(ctx context.Context,
+	tx tx.Tx, holdID transaction.HoldID) (*transaction.Hold, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	// This will block when another tx is capturing or voiding
+	// the same hold until that tx is committed or rolled back
+	stmnt := `select hold_id, account_id, amount, status, captured_amount,
+			coalesce(xact_no, ''), expires_at, ts
+		from holds where hold_id = $1 for update`
+
+	return scanHold(txx.QueryRowContext(ctx, stmnt, holdID))
+}
+
+// scanHold scans the hold row
+func scanHold(row *sql.Row) (*transaction.Hold, error) {
+	var hold transaction.Hold
+	err := row.Scan(
+		&hold.HoldID, &hold.AccountID,
+		&hold.Amount, &hold.Status,
+		&hold.CapturedAmount, &hold.XactNo,
+		&hold.ExpiresAt, &hold.Ts,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, transaction.ErrHoldNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &hold, nil
+}
+
+// UpdateHold updates the status, captured amount
+// and capture xact no of the hold within a tx
+func (tr *XactRepository) UpdateHold(ctx context.Context,
+	tx tx.Tx, hold transaction.Hold) error {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `update holds set status = $2, captured_amount = $3,
+		xact_no = nullif($4, '') where hold_id = $1`
+	_, err := txx.ExecContext(ctx, stmnt,
+		hold.HoldID, hold.Status,
+		hold.CapturedAmount, hold.XactNo,
 	)
 	if err != nil {
 		return errors.Wrap(err, "exec context")
@@ -81,11 +504,14 @@ func (tr *XactRepository) ListXacts(ctx context.Context) ([]*transaction.Transac
 	return xacts, nil
 }
 
-// ListTransfers retrieves the list of transfer related account transactions
+// ListTransfers retrieves the list of transfer related account transactions,
+// including the fees of the transfers
 func (tr *XactRepository) ListTransfers(ctx context.Context) ([]*transaction.Transaction, error) {
 	stmnt := `select xact_no, ledger_no, xact_type, account_id, 
 		xact_type_ext, amount, "desc", ts from account_transactions
-		where xact_type_ext in ('STr', 'RTr') order by ts`
+		where xact_type_ext in ('STr', 'RTr') or (xact_type_ext = 'Fe' and
+			xact_no in (select xact_no from account_transactions where xact_type_ext = 'STr'))
+		order by ts`
 
 	rows, err := tr.db.QueryContext(ctx, stmnt)
 	if err != nil {
@@ -111,3 +537,154 @@ func (tr *XactRepository) ListTransfers(ctx context.Context) ([]*transaction.Tra
 
 	return xacts, nil
 }
+
+// ListAccntXacts retrieves the account transactions matching the filter,
+// sorted by (ts, xact no, leg id) and starting after the cursor if it's set
+func (tr *XactRepository) ListAccntXacts(ctx context.Context,
+	f transaction.XactFilter) ([]*transaction.Transaction, error) {
+	var (
+		conds = []string{"account_id = $1"}
+		args  = []interface{}{f.AccountID}
+	)
+
+	// arg appends the arg and returns its placeholder
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if len(f.XactTypeExts) > 0 {
+		placeholders := make([]string, 0, len(f.XactTypeExts))
+		for _, ttx := range f.XactTypeExts {
+			placeholders = append(placeholders, arg(ttx))
+		}
+		conds = append(conds, "xact_type_ext in ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if f.From != nil {
+		conds = append(conds, "ts >= "+arg(*f.From))
+	}
+
+	if f.To != nil {
+		conds = append(conds, "ts < "+arg(*f.To))
+	}
+
+	if f.MinAmount != nil {
+		conds = append(conds, "amount >= "+arg(*f.MinAmount))
+	}
+
+	if f.MaxAmount != nil {
+		conds = append(conds, "amount <= "+arg(*f.MaxAmount))
+	}
+
+	op, dir := "<", "desc"
+	if f.Sort == transaction.SortAsc {
+		op, dir = ">", "asc"
+	}
+
+	if f.Cursor != nil {
+		conds = append(conds, fmt.Sprintf("(ts, xact_no, id) %s (%s, %s, %s)",
+			op, arg(f.Cursor.Ts), arg(f.Cursor.XactNo), arg(f.Cursor.LegID)))
+	}
+
+	stmnt := fmt.Sprintf(`select xact_no, ledger_no, xact_type, account_id, 
+		xact_type_ext, amount, "desc", ts, id from account_transactions
+		where %s order by ts %s, xact_no %s, id %s limit %s`,
+		strings.Join(conds, " and "), dir, dir, dir, arg(f.Limit))
+
+	rows, err := tr.db.QueryContext(ctx, stmnt, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	xacts := []*transaction.Transaction{}
+	for rows.Next() {
+		var xact transaction.Transaction
+		err = rows.Scan(
+			&xact.XactNo, &xact.LedgerNo,
+			&xact.XactType, &xact.AccountID,
+			&xact.XactTypeExt, &xact.Amount,
+			&xact.Desc, &xact.Ts, &xact.LegID,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		xacts = append(xacts, &xact)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return xacts, nil
+}
+
+// ListLedgerPostings retrieves the account and ledger transactions of the
+// ledger made from the inclusive start to the exclusive end time, sorted by (ts, xact no)
+func (tr *XactRepository) ListLedgerPostings(ctx context.Context, ledgerNo ledger.LedgerNo,
+	from, to time.Time) ([]*transaction.Transaction, error) {
+	stmnt := `select xact_no, ledger_no, xact_type, account_id,
+			xact_type_ext, amount, "desc", ts
+		from (
+			select xact_no, ledger_no, xact_type, account_id,
+				xact_type_ext, amount, "desc", ts, 0 as seq
+			from account_transactions
+			where ledger_no = $1 and ts >= $2 and ts < $3
+			union all
+			select xact_no, ledger_no, xact_type, '', null,
+				amount, "desc", ts, 1
+			from ledger_transactions
+			where ledger_no = $1 and ts >= $2 and ts < $3
+		) p order by ts, xact_no, seq`
+
+	rows, err := tr.db.QueryContext(ctx, stmnt, ledgerNo, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	xacts := []*transaction.Transaction{}
+	for rows.Next() {
+		var xact transaction.Transaction
+		err = rows.Scan(
+			&xact.XactNo, &xact.LedgerNo,
+			&xact.XactType, &xact.AccountID,
+			&xact.XactTypeExt, &xact.Amount,
+			&xact.Desc, &xact.Ts,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		xacts = append(xacts, &xact)
+	}
+
+	return xacts, rows.Err()
+}
+
+// GetOutgoingUsage retrieves the amount withdrawn and sent, and the
+// number of transfers sent by the account since the time within tx
+func (tr *XactRepository) GetOutgoingUsage(ctx context.Context, tx tx.Tx,
+	accntID account.AccountID, since time.Time) (*limit.Usage, error) {
+	txx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *sql.Tx")
+	}
+
+	stmnt := `select coalesce(sum(amount), 0),
+			count(*) filter (where xact_type_ext = $1)
+		from account_transactions
+		where account_id = $2 and xact_type_ext in ($1, $3) and ts >= $4`
+
+	var usage limit.Usage
+	err := txx.QueryRowContext(ctx, stmnt, transaction.XactTypeExtSndTransfer,
+		accntID, transaction.XactTypeExtWithdrawal, since).
+		Scan(&usage.Amount, &usage.Transfers)
+	if err != nil {
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &usage, nil
+}
@@ -40,7 +40,7 @@ func TestXactRepository(t *testing.T) {
 	require.NoError(t, err)
 
 	ledgerRepo := postgres.NewLedgerRepository(db)
-	ledgerService := ledger.NewService(ledgerRepo)
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
 	err = ledgerService.CreateCashLedgers(ctx)
 	require.NoError(t, err)
 
@@ -134,13 +134,13 @@ func TestXactRepository(t *testing.T) {
 				assert.NoError(t, tx.Commit())
 			}()
 
-			johnBal, err := balRepo.GetAccntBal(ctx, tx, johnDoe.AccountID)
+			johnBal, err := balRepo.GetAccntBalForUpdate(ctx, tx, johnDoe.AccountID)
 			require.NoError(t, err)
 			assert.True(t, decimal.NewFromInt(100).Equal(johnBal.TotalCredit))
 			assert.True(t, decimal.NewFromInt(50).Equal(johnBal.TotalDebit))
 			assert.True(t, decimal.NewFromInt(50).Equal(johnBal.CurrentBal))
 
-			maryBal, err := balRepo.GetAccntBal(ctx, tx, maryJane.AccountID)
+			maryBal, err := balRepo.GetAccntBalForUpdate(ctx, tx, maryJane.AccountID)
 			require.NoError(t, err)
 			assert.True(t, decimal.NewFromInt(25).Equal(maryBal.TotalCredit))
 			assert.True(t, decimal.NewFromInt(0).Equal(maryBal.TotalDebit))
@@ -165,4 +165,54 @@ func TestXactRepository(t *testing.T) {
 			assert.True(t, rcvXact || sndXact, "must be a sending or receiving transaction")
 		}
 	})
+
+	t.Run("get xact", func(t *testing.T) {
+		xacts, err := xactRepo.ListTransfers(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, xacts)
+
+		xact, err := xactRepo.GetXact(ctx, xacts[0].XactNo)
+		require.NoError(t, err)
+		assert.Equal(t, xacts[0].XactNo, xact.XactNo)
+		assert.Len(t, xact.Legs, 2)
+		assert.NotNil(t, xact.Ts)
+
+		_, err = xactRepo.GetXact(ctx, transaction.XactNo("notfound"))
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+	})
+
+	t.Run("list account xacts", func(t *testing.T) {
+		xacts, err := xactRepo.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID: johnDoe.AccountID,
+			Sort:      transaction.SortAsc,
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		require.Len(t, xacts, 3)
+
+		// starts after the cursor
+		cursor := &transaction.Cursor{Ts: *xacts[0].Ts, XactNo: xacts[0].XactNo}
+		next, err := xactRepo.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID: johnDoe.AccountID,
+			Sort:      transaction.SortAsc,
+			Cursor:    cursor,
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, xacts[1:], next)
+
+		min := decimal.NewFromInt(50)
+		xacts, err = xactRepo.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID: johnDoe.AccountID,
+			XactTypeExts: []transaction.XactTypeExt{
+				transaction.XactTypeExtDeposit,
+				transaction.XactTypeExtSndTransfer,
+			},
+			MinAmount: &min,
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		require.Len(t, xacts, 1)
+		assert.Equal(t, transaction.XactTypeExtDeposit, xacts[0].XactTypeExt)
+	})
 }
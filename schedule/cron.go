@@ -0,0 +1,169 @@
+package schedule
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// cronExpr is a parsed cron expression with the standard five fields:
+// minute, hour, day of month, month and day of week. A field is either
+// *, a value, a range (1-5) or a list (1,15), optionally with a step
+// (*/15, 1-5/2). The day of week is 0-7 where both 0 and 7 are Sunday.
+type cronExpr struct {
+	minute, hour, dom, month, dow bitset
+	// domAny and dowAny are set when the field is *, the day matches
+	// either field when both are restricted like in the standard cron
+	domAny, dowAny bool
+}
+
+// bitset is the set of the values of a field
+type bitset uint64
+
+func (b bitset) has(v int) bool {
+	return b&(1<<uint(v)) != 0
+}
+
+// cronField is the range of a cron field
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [...]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// parseCron parses the cron expression
+func parseCron(s string) (*cronExpr, error) {
+	fields := strings.Fields(s)
+	if len(fields) != len(cronFields) {
+		return nil, errors.Wrapf(ErrInvalidCronExpr,
+			"expecting %d fields, got %d", len(cronFields), len(fields))
+	}
+
+	sets := make([]bitset, len(fields))
+	for i, field := range fields {
+		set, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		sets[i] = set
+	}
+
+	// sunday is both 0 and 7
+	if sets[4].has(7) {
+		sets[4] |= 1
+	}
+
+	return &cronExpr{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField parses the comma-separated list of the field
+func parseCronField(s string, cf cronField) (bitset, error) {
+	var set bitset
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return 0, errors.Wrapf(ErrInvalidCronExpr, "%s step %q", cf.name, part)
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := cf.min, cf.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || lo > hi {
+				return 0, errors.Wrapf(ErrInvalidCronExpr, "%s range %q", cf.name, part)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, errors.Wrapf(ErrInvalidCronExpr, "%s value %q", cf.name, part)
+			}
+			lo, hi = n, n
+			// a value with a step runs from the value to the max i.e. 5/15
+			if step > 1 {
+				hi = cf.max
+			}
+		}
+
+		if lo < cf.min || hi > cf.max {
+			return 0, errors.Wrapf(ErrInvalidCronExpr,
+				"%s %q out of range %d-%d", cf.name, part, cf.min, cf.max)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+// maxCronSearch is how far ahead the next time is searched, an
+// expression that never matches i.e. 0 0 30 2 * has no next time
+const maxCronSearch = 5 * 366 * 24 * time.Hour
+
+// next returns the first time after t that matches the expression in UTC
+func (c *cronExpr) next(t time.Time) (time.Time, bool) {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	end := t.Add(maxCronSearch)
+
+	for t.Before(end) {
+		if !c.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+
+		if !c.hour.has(t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+
+		if !c.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+// dayMatches returns true if the day of month and day of week matches
+func (c *cronExpr) dayMatches(t time.Time) bool {
+	dom, dow := c.dom.has(t.Day()), c.dow.has(int(t.Weekday()))
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+
+	return dom || dow
+}
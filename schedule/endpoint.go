@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// instructionRequest is a create or update instruction request
+type instructionRequest struct {
+	InstructionID InstructionID     `json:"-"`
+	FromAccount   account.AccountID `json:"from_account"`
+	ToAccount     account.AccountID `json:"to_account"`
+	Amount        decimal.Decimal   `json:"amount"`
+	Desc          string            `json:"desc"`
+	Frequency     Frequency         `json:"frequency"`
+	CronExpr      string            `json:"cron_expr"`
+	StartAt       time.Time         `json:"start_at"`
+	EndAt         *time.Time        `json:"end_at"`
+}
+
+func (r instructionRequest) instruction() Instruction {
+	return Instruction{
+		InstructionID: r.InstructionID,
+		FromAccount:   r.FromAccount,
+		ToAccount:     r.ToAccount,
+		Amount:        r.Amount,
+		Desc:          r.Desc,
+		Frequency:     r.Frequency,
+		CronExpr:      r.CronExpr,
+		StartAt:       r.StartAt,
+		EndAt:         r.EndAt,
+	}
+}
+
+// instructionResponse is an instruction response
+type instructionResponse struct {
+	Instruction *Instruction `json:"instruction,omitempty"`
+	Err         error        `json:"error,omitempty"`
+}
+
+func (r instructionResponse) error() error { return r.Err }
+
+// newCreateInstructionEndpoint returns a create instruction endpoint
+func newCreateInstructionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(instructionRequest)
+		inst, err := s.CreateInstruction(ctx, req.instruction())
+		return instructionResponse{Instruction: inst, Err: err}, nil
+	}
+}
+
+// newUpdateInstructionEndpoint returns an update instruction endpoint
+func newUpdateInstructionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(instructionRequest)
+		inst, err := s.UpdateInstruction(ctx, req.instruction())
+		return instructionResponse{Instruction: inst, Err: err}, nil
+	}
+}
+
+// getInstructionRequest is a get instruction request
+type getInstructionRequest struct {
+	InstructionID InstructionID
+}
+
+// newGetInstructionEndpoint returns a get instruction endpoint
+func newGetInstructionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getInstructionRequest)
+		inst, err := s.GetInstruction(ctx, req.InstructionID)
+		return instructionResponse{Instruction: inst, Err: err}, nil
+	}
+}
+
+// cancelInstructionRequest is a cancel instruction request
+type cancelInstructionRequest struct {
+	InstructionID InstructionID
+}
+
+// newCancelInstructionEndpoint returns a cancel instruction endpoint
+func newCancelInstructionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(cancelInstructionRequest)
+		inst, err := s.CancelInstruction(ctx, req.InstructionID)
+		return instructionResponse{Instruction: inst, Err: err}, nil
+	}
+}
+
+// listInstructionsRequest is a list instructions request
+type listInstructionsRequest struct {
+	AccountID account.AccountID
+}
+
+// listInstructionsResponse is a list instructions response
+type listInstructionsResponse struct {
+	Instructions []*Instruction `json:"instructions,omitempty"`
+	Err          error          `json:"error,omitempty"`
+}
+
+func (r listInstructionsResponse) error() error { return r.Err }
+
+// newListInstructionsEndpoint returns a list instructions endpoint
+func newListInstructionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listInstructionsRequest)
+		insts, err := s.ListInstructions(ctx, req.AccountID)
+		return listInstructionsResponse{Instructions: insts, Err: err}, nil
+	}
+}
+
+// listRunsRequest is a list runs request
+type listRunsRequest struct {
+	InstructionID InstructionID
+}
+
+// listRunsResponse is a list runs response
+type listRunsResponse struct {
+	Runs []*Run `json:"runs,omitempty"`
+	Err  error  `json:"error,omitempty"`
+}
+
+func (r listRunsResponse) error() error { return r.Err }
+
+// newListRunsEndpoint returns a list runs endpoint
+func newListRunsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listRunsRequest)
+		runs, err := s.ListRuns(ctx, req.InstructionID)
+		return listRunsResponse{Runs: runs, Err: err}, nil
+	}
+}
@@ -0,0 +1,22 @@
+package schedule
+
+import "errors"
+
+// List of schedule related errors
+var (
+	// ErrValidation is a schedule related validation error
+	ErrValidation = errors.New("validation error")
+	// ErrInstructionNotFound is an error when the instruction doesn't exist
+	ErrInstructionNotFound = errors.New("instruction not found")
+	// ErrInstructionNotActive is an error when updating or cancelling
+	// an instruction that was already completed, failed or cancelled
+	ErrInstructionNotActive = errors.New("instruction not active")
+	// ErrInstructionBusy is an error when updating or cancelling
+	// an instruction while a worker is running it
+	ErrInstructionBusy = errors.New("instruction busy")
+	// ErrLeaseLost is an error when recording the run of an
+	// instruction whose lease was taken over by another worker
+	ErrLeaseLost = errors.New("lease lost")
+	// ErrInvalidCronExpr is an error when the cron expression is malformed
+	ErrInvalidCronExpr = errors.New("invalid cron expression")
+)
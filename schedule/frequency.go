@@ -0,0 +1,96 @@
+package schedule
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Frequency is how often an instruction runs
+type Frequency int
+
+// List of frequencies
+const (
+	// FrequencyOnce runs once at the start time
+	FrequencyOnce Frequency = iota + 1
+	// FrequencyDaily runs every day at the time of the start time
+	FrequencyDaily
+	// FrequencyWeekly runs every week on the weekday and time of the start time
+	FrequencyWeekly
+	// FrequencyMonthly runs every month on the day and time of the start time,
+	// or on the last day of the month if the month is shorter
+	FrequencyMonthly
+	// FrequencyCron runs on the times matched by the cron expression
+	FrequencyCron
+)
+
+// String implements Stringer
+func (f Frequency) String() string {
+	if f < 0 || int(f) > len(frequencies)-1 {
+		return frequencies[0]
+	}
+
+	return frequencies[f]
+}
+
+var frequencies = [...]string{
+	"invalid",
+	"once",
+	"daily",
+	"weekly",
+	"monthly",
+	"cron",
+}
+
+// Value implements driver.Valuer interface
+func (f Frequency) Value() (driver.Value, error) {
+	return f.String(), nil
+}
+
+// Scan implements sql.Scanner interface
+func (f *Frequency) Scan(src interface{}) error {
+	if src == nil {
+		*f = Frequency(0)
+		return nil
+	}
+
+	val, ok := src.(string)
+	if !ok {
+		return errors.New("src is not string")
+	}
+
+	*f = strToFrequency(val)
+	return nil
+}
+
+// strToFrequency takes a string and returns the frequency
+func strToFrequency(s string) Frequency {
+	for i, name := range frequencies {
+		if i > 0 && name == s {
+			return Frequency(i)
+		}
+	}
+
+	return Frequency(0)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (f Frequency) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(f.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (f *Frequency) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	*f = strToFrequency(s)
+	return nil
+}
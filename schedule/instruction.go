@@ -0,0 +1,216 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// InstructionID is an instruction id
+type InstructionID string
+
+const (
+	alphabet         = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	instructionIDLen = 12
+	// maxDescLen is the max length of the description
+	maxDescLen = 255
+)
+
+// NewInstructionID generates a new instruction id
+func NewInstructionID() (InstructionID, error) {
+	id, err := gonanoid.Generate(alphabet, instructionIDLen)
+	if err != nil {
+		return "", pkgerrors.Wrap(err, "generate")
+	}
+
+	return InstructionID(id), nil
+}
+
+// Instruction is a future-dated or recurring transfer instruction
+// i.e. a standing order. The runs are in UTC.
+type Instruction struct {
+	InstructionID InstructionID     `json:"id"`
+	FromAccount   account.AccountID `json:"from_account"`
+	ToAccount     account.AccountID `json:"to_account"`
+	Amount        decimal.Decimal   `json:"amount"`
+	Desc          string            `json:"desc,omitempty"`
+	Frequency     Frequency         `json:"frequency"`
+	// CronExpr is the cron expression of the cron frequency
+	CronExpr string `json:"cron_expr,omitempty"`
+	// StartAt is the time of the first run
+	StartAt time.Time `json:"start_at"`
+	// EndAt is the time after which there are no more runs
+	EndAt  *time.Time `json:"end_at,omitempty"`
+	Status Status     `json:"status"`
+
+	// NextRunAt is the scheduled time of the next run
+	NextRunAt time.Time `json:"next_run_at"`
+	// NextAttemptAt is when the next run is attempted,
+	// it is later than the next run when retrying
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// Attempts is the number of failed attempts of the next run
+	Attempts int `json:"attempts"`
+	// LastError is the error of the last failed attempt
+	LastError string `json:"last_error,omitempty"`
+
+	// LeaseOwner is the worker running the instruction
+	LeaseOwner string `json:"-"`
+	// LeaseExpiresAt is when the lease of the worker expires
+	LeaseExpiresAt *time.Time `json:"-"`
+
+	Ts *time.Time `json:"ts,omitempty"`
+}
+
+// Validate validates the instruction
+func (inst Instruction) Validate() error {
+	return validation.Errors{
+		"from_account": inst.FromAccount.Validate(),
+		"to_account": validation.Validate(inst.ToAccount,
+			validation.By(func(value interface{}) error {
+				if err := inst.ToAccount.Validate(); err != nil {
+					return err
+				}
+
+				if inst.ToAccount == inst.FromAccount {
+					return errors.New("must not be the same as from_account")
+				}
+
+				return nil
+			})),
+		"amount": validation.Validate(inst.Amount,
+			validation.By(func(value interface{}) error {
+				amount, _ := value.(decimal.Decimal)
+				if !amount.IsPositive() {
+					return errors.New("must be positive")
+				}
+
+				return nil
+			})),
+		"desc": validation.Validate(inst.Desc, validation.Length(0, maxDescLen)),
+		"frequency": validation.Validate(inst.Frequency,
+			validation.By(func(value interface{}) error {
+				if inst.Frequency < FrequencyOnce || inst.Frequency > FrequencyCron {
+					return errors.New("must be once, daily, weekly, monthly or cron")
+				}
+
+				return nil
+			})),
+		"cron_expr": validation.Validate(inst.CronExpr,
+			validation.By(func(value interface{}) error {
+				if inst.Frequency != FrequencyCron {
+					if inst.CronExpr != "" {
+						return errors.New("must be empty unless the frequency is cron")
+					}
+					return nil
+				}
+
+				_, err := parseCron(inst.CronExpr)
+				return err
+			})),
+		"start_at": validation.Validate(inst.StartAt,
+			validation.Required.Error("must not be empty")),
+		"end_at": validation.Validate(inst.EndAt,
+			validation.By(func(value interface{}) error {
+				if inst.EndAt != nil && inst.EndAt.Before(inst.StartAt) {
+					return errors.New("must not be before start_at")
+				}
+
+				return nil
+			})),
+	}.Filter()
+}
+
+// NextRun returns the first run after t, it returns false
+// if the instruction has no runs left after t
+func (inst Instruction) NextRun(t time.Time) (time.Time, bool) {
+	next, ok := inst.nextRun(t)
+	if !ok || (inst.EndAt != nil && next.After(*inst.EndAt)) {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
+
+func (inst Instruction) nextRun(t time.Time) (time.Time, bool) {
+	start := inst.StartAt.UTC()
+
+	if inst.Frequency == FrequencyCron {
+		c, err := parseCron(inst.CronExpr)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		if t.Before(start) {
+			// the first run is on or after the start time
+			t = start.Add(-time.Nanosecond)
+		}
+
+		return c.next(t)
+	}
+
+	if t.Before(start) {
+		return start, true
+	}
+
+	var period time.Duration
+	switch inst.Frequency {
+	case FrequencyDaily:
+		period = 24 * time.Hour
+	case FrequencyWeekly:
+		period = 7 * 24 * time.Hour
+	case FrequencyMonthly:
+		months := (t.Year()-start.Year())*12 + int(t.Month()-start.Month())
+		next := addMonths(start, months)
+		if !next.After(t) {
+			next = addMonths(start, months+1)
+		}
+		return next, true
+	default:
+		return time.Time{}, false
+	}
+
+	n := t.Sub(start)/period + 1
+	return start.Add(n * period), true
+}
+
+// addMonths adds the months to t, the day is clamped
+// to the last day of the month if the month is shorter
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	firstOfMonth := time.Date(year, month+time.Month(months), 1,
+		t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+
+	return firstOfMonth.AddDate(0, 0, day-1)
+}
+
+// idempotencyKey returns the idempotency key of the next run, the
+// retries and the concurrent runs of the same run make one transfer
+func (inst Instruction) idempotencyKey() string {
+	return fmt.Sprintf("schedule-%s-%d", inst.InstructionID, inst.NextRunAt.Unix())
+}
+
+// Run is a run of an instruction, a failed attempt is also recorded
+type Run struct {
+	InstructionID InstructionID `json:"instruction_id"`
+	// ScheduledAt is the scheduled time of the run
+	ScheduledAt time.Time `json:"scheduled_at"`
+	// Attempt is the attempt number of the run, starting from 1
+	Attempt int `json:"attempt"`
+	// XactNo is the transaction number of the transfer
+	XactNo transaction.XactNo `json:"xact_no,omitempty"`
+	// Error is the error of a failed attempt
+	Error string     `json:"error,omitempty"`
+	Ts    *time.Time `json:"ts,omitempty"`
+}
@@ -0,0 +1,163 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/schedule"
+)
+
+func TestInstructionNextRun(t *testing.T) {
+	ts := func(s string) time.Time {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tc := []struct {
+		name      string
+		frequency schedule.Frequency
+		cronExpr  string
+		startAt   string
+		endAt     string
+		after     string
+		// expect is empty if there's no next run
+		expect string
+	}{
+		{name: "once before start", frequency: schedule.FrequencyOnce,
+			startAt: "2021-01-10T09:00:00Z", after: "2021-01-01T00:00:00Z",
+			expect: "2021-01-10T09:00:00Z"},
+		{name: "once after start", frequency: schedule.FrequencyOnce,
+			startAt: "2021-01-10T09:00:00Z", after: "2021-01-10T09:00:00Z"},
+		{name: "daily", frequency: schedule.FrequencyDaily,
+			startAt: "2021-01-10T09:00:00Z", after: "2021-01-12T10:00:00Z",
+			expect: "2021-01-13T09:00:00Z"},
+		{name: "daily on the run", frequency: schedule.FrequencyDaily,
+			startAt: "2021-01-10T09:00:00Z", after: "2021-01-12T09:00:00Z",
+			expect: "2021-01-13T09:00:00Z"},
+		{name: "weekly", frequency: schedule.FrequencyWeekly,
+			startAt: "2021-01-10T09:00:00Z", after: "2021-01-12T09:00:00Z",
+			expect: "2021-01-17T09:00:00Z"},
+		{name: "monthly", frequency: schedule.FrequencyMonthly,
+			startAt: "2021-01-15T09:00:00Z", after: "2021-03-20T00:00:00Z",
+			expect: "2021-04-15T09:00:00Z"},
+		{name: "monthly on the last day", frequency: schedule.FrequencyMonthly,
+			startAt: "2021-01-31T09:00:00Z", after: "2021-01-31T09:00:00Z",
+			expect: "2021-02-28T09:00:00Z"},
+		{name: "monthly after a short month", frequency: schedule.FrequencyMonthly,
+			startAt: "2021-01-31T09:00:00Z", after: "2021-02-28T09:00:00Z",
+			expect: "2021-03-31T09:00:00Z"},
+		{name: "monthly in a leap year", frequency: schedule.FrequencyMonthly,
+			startAt: "2023-12-31T09:00:00Z", after: "2024-01-31T09:00:00Z",
+			expect: "2024-02-29T09:00:00Z"},
+		{name: "end", frequency: schedule.FrequencyDaily,
+			startAt: "2021-01-10T09:00:00Z", endAt: "2021-01-12T09:00:00Z",
+			after: "2021-01-12T09:00:00Z"},
+		{name: "cron every 15 minutes", frequency: schedule.FrequencyCron,
+			cronExpr: "*/15 * * * *", startAt: "2021-01-10T09:00:00Z",
+			after: "2021-01-10T09:07:30Z", expect: "2021-01-10T09:15:00Z"},
+		{name: "cron before start", frequency: schedule.FrequencyCron,
+			cronExpr: "0 9 * * *", startAt: "2021-01-10T09:00:00Z",
+			after: "2021-01-01T00:00:00Z", expect: "2021-01-10T09:00:00Z"},
+		{name: "cron weekdays", frequency: schedule.FrequencyCron,
+			// 2021-01-15 is a friday
+			cronExpr: "30 8 * * 1-5", startAt: "2021-01-01T00:00:00Z",
+			after: "2021-01-15T09:00:00Z", expect: "2021-01-18T08:30:00Z"},
+		{name: "cron sunday is 7", frequency: schedule.FrequencyCron,
+			cronExpr: "0 0 * * 7", startAt: "2021-01-01T00:00:00Z",
+			after: "2021-01-15T09:00:00Z", expect: "2021-01-17T00:00:00Z"},
+		{name: "cron day of month or week", frequency: schedule.FrequencyCron,
+			cronExpr: "0 12 1,15 * 0", startAt: "2021-01-01T00:00:00Z",
+			after: "2021-01-02T00:00:00Z", expect: "2021-01-03T12:00:00Z"},
+		{name: "cron months", frequency: schedule.FrequencyCron,
+			cronExpr: "0 0 1 3,9 *", startAt: "2021-01-01T00:00:00Z",
+			after: "2021-03-01T00:00:00Z", expect: "2021-09-01T00:00:00Z"},
+		{name: "cron never", frequency: schedule.FrequencyCron,
+			cronExpr: "0 0 30 2 *", startAt: "2021-01-01T00:00:00Z",
+			after: "2021-01-01T00:00:00Z"},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := schedule.Instruction{
+				Frequency: tt.frequency,
+				CronExpr:  tt.cronExpr,
+				StartAt:   ts(tt.startAt),
+			}
+			if tt.endAt != "" {
+				endAt := ts(tt.endAt)
+				inst.EndAt = &endAt
+			}
+
+			next, ok := inst.NextRun(ts(tt.after))
+			if tt.expect == "" {
+				assert.False(t, ok)
+				return
+			}
+
+			assert.True(t, ok)
+			assert.Equal(t, ts(tt.expect), next)
+		})
+	}
+}
+
+func TestInstructionValidation(t *testing.T) {
+	valid := func() schedule.Instruction {
+		return schedule.Instruction{
+			FromAccount: account.AccountID("johndoe"),
+			ToAccount:   account.AccountID("maryjane"),
+			Amount:      decimal.NewFromInt(10),
+			Frequency:   schedule.FrequencyDaily,
+			StartAt:     time.Now(),
+		}
+	}
+
+	assert.NoError(t, valid().Validate())
+
+	tc := []struct {
+		name   string
+		modify func(*schedule.Instruction)
+		expect string
+	}{
+		{name: "same accounts", modify: func(inst *schedule.Instruction) {
+			inst.ToAccount = inst.FromAccount
+		}, expect: "to_account: must not be the same as from_account."},
+		{name: "zero amount", modify: func(inst *schedule.Instruction) {
+			inst.Amount = decimal.Zero
+		}, expect: "amount: must be positive."},
+		{name: "missing frequency", modify: func(inst *schedule.Instruction) {
+			inst.Frequency = 0
+		}, expect: "frequency: must be once, daily, weekly, monthly or cron."},
+		{name: "missing cron expression", modify: func(inst *schedule.Instruction) {
+			inst.Frequency = schedule.FrequencyCron
+		}, expect: "cron_expr: expecting 5 fields, got 0: invalid cron expression."},
+		{name: "out of range cron expression", modify: func(inst *schedule.Instruction) {
+			inst.Frequency = schedule.FrequencyCron
+			inst.CronExpr = "0 24 * * *"
+		}, expect: `cron_expr: hour "24" out of range 0-23: invalid cron expression.`},
+		{name: "cron expression without cron frequency", modify: func(inst *schedule.Instruction) {
+			inst.CronExpr = "0 9 * * *"
+		}, expect: "cron_expr: must be empty unless the frequency is cron."},
+		{name: "missing start", modify: func(inst *schedule.Instruction) {
+			inst.StartAt = time.Time{}
+		}, expect: "start_at: must not be empty."},
+		{name: "end before start", modify: func(inst *schedule.Instruction) {
+			endAt := inst.StartAt.Add(-time.Hour)
+			inst.EndAt = &endAt
+		}, expect: "end_at: must not be before start_at."},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			inst := valid()
+			tt.modify(&inst)
+			assert.EqualError(t, inst.Validate(), tt.expect)
+		})
+	}
+}
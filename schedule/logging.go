@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// loggingService is a service logging middleware
+type loggingService struct {
+	logger log.Logger
+	s      Service
+}
+
+// NewLoggingService returns a logging service middleware
+func NewLoggingService(logger log.Logger, s Service) Service {
+	return &loggingService{logger: logger, s: s}
+}
+
+// CreateInstruction logs the create instruction params
+func (s *loggingService) CreateInstruction(ctx context.Context,
+	in Instruction) (inst *Instruction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_instruction",
+			"from_account", in.FromAccount,
+			"to_account", in.ToAccount,
+			"amount", in.Amount,
+			"frequency", in.Frequency,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.CreateInstruction(ctx, in)
+}
+
+// GetInstruction logs the get instruction params
+func (s *loggingService) GetInstruction(ctx context.Context,
+	instID InstructionID) (inst *Instruction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "get_instruction",
+			"instruction_id", instID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetInstruction(ctx, instID)
+}
+
+// ListInstructions logs the list instructions params
+func (s *loggingService) ListInstructions(ctx context.Context,
+	accntID account.AccountID) (insts []*Instruction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list_instructions",
+			"account_id", accntID,
+			"count", len(insts),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ListInstructions(ctx, accntID)
+}
+
+// UpdateInstruction logs the update instruction params
+func (s *loggingService) UpdateInstruction(ctx context.Context,
+	upd Instruction) (inst *Instruction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "update_instruction",
+			"instruction_id", upd.InstructionID,
+			"amount", upd.Amount,
+			"frequency", upd.Frequency,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.UpdateInstruction(ctx, upd)
+}
+
+// CancelInstruction logs the cancel instruction params
+func (s *loggingService) CancelInstruction(ctx context.Context,
+	instID InstructionID) (inst *Instruction, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "cancel_instruction",
+			"instruction_id", instID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.CancelInstruction(ctx, instID)
+}
+
+// ListRuns logs the list runs params
+func (s *loggingService) ListRuns(ctx context.Context,
+	instID InstructionID) (runs []*Run, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list_runs",
+			"instruction_id", instID,
+			"count", len(runs),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ListRuns(ctx, instID)
+}
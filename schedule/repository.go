@@ -0,0 +1,35 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// Repository is a schedule repository
+type Repository interface {
+	// CreateInstruction creates an instruction
+	CreateInstruction(context.Context, Instruction) error
+	// GetInstruction retrieves the instruction
+	GetInstruction(context.Context, InstructionID) (*Instruction, error)
+	// ListInstructions retrieves the instructions sent from or to
+	// the account, or all the instructions if the account id is empty
+	ListInstructions(context.Context, account.AccountID) ([]*Instruction, error)
+	// UpdateInstruction updates the amount, desc, schedule, status, next
+	// run and attempts of the instruction. It returns ErrInstructionBusy
+	// if the instruction is leased by a worker at the given time.
+	UpdateInstruction(context.Context, Instruction, time.Time) error
+	// LeaseDueInstructions leases up to n active instructions whose next
+	// attempt is due at the given time and whose lease has expired, to the
+	// owner until the lease ttl has elapsed. The instructions leased by
+	// another worker are skipped.
+	LeaseDueInstructions(ctx context.Context, owner string,
+		now time.Time, ttl time.Duration, n int) ([]*Instruction, error)
+	// RecordRun updates the status, next run, attempts and last error of
+	// the instruction, releases its lease and records the run. It returns
+	// ErrLeaseLost if the instruction is no longer leased by the owner.
+	RecordRun(context.Context, Instruction, Run) error
+	// ListRuns retrieves the runs of the instruction
+	ListRuns(context.Context, InstructionID) ([]*Run, error)
+}
@@ -0,0 +1,243 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// Service is a schedule service
+type Service interface {
+	// CreateInstruction creates a future-dated or recurring transfer instruction
+	CreateInstruction(context.Context, Instruction) (*Instruction, error)
+	// GetInstruction retrieves the instruction
+	GetInstruction(context.Context, InstructionID) (*Instruction, error)
+	// ListInstructions retrieves the instructions sent from or to
+	// the account, or all the instructions if the account id is empty
+	ListInstructions(context.Context, account.AccountID) ([]*Instruction, error)
+	// UpdateInstruction updates the amount, desc and schedule of the instruction
+	UpdateInstruction(context.Context, Instruction) (*Instruction, error)
+	// CancelInstruction cancels the instruction, it has no more runs
+	CancelInstruction(context.Context, InstructionID) (*Instruction, error)
+	// ListRuns retrieves the runs of the instruction
+	ListRuns(context.Context, InstructionID) ([]*Run, error)
+}
+
+// service implements the schedule service
+type service struct {
+	scheduleRepo Repository
+	accountRepo  account.Repository
+}
+
+var _ Service = (*service)(nil)
+
+// NewService takes a schedule and account repository and returns a schedule service
+func NewService(scheduleRepo Repository, accountRepo account.Repository) Service {
+	return &service{
+		scheduleRepo: scheduleRepo,
+		accountRepo:  accountRepo,
+	}
+}
+
+// CreateInstruction creates a future-dated or recurring transfer instruction
+func (s *service) CreateInstruction(ctx context.Context, inst Instruction) (*Instruction, error) {
+	normalize(&inst)
+	err := inst.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	err = s.checkAccounts(ctx, inst)
+	if err != nil {
+		return nil, err
+	}
+
+	err = scheduleFirstRun(&inst, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	inst.InstructionID, err = NewInstructionID()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "new instruction id")
+	}
+	inst.Status = StatusActive
+
+	err = s.scheduleRepo.CreateInstruction(ctx, inst)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "create instruction")
+	}
+
+	return s.scheduleRepo.GetInstruction(ctx, inst.InstructionID)
+}
+
+// GetInstruction retrieves the instruction
+func (s *service) GetInstruction(ctx context.Context, instID InstructionID) (*Instruction, error) {
+	return s.scheduleRepo.GetInstruction(ctx, instID)
+}
+
+// ListInstructions retrieves the instructions sent from or to
+// the account, or all the instructions if the account id is empty
+func (s *service) ListInstructions(ctx context.Context,
+	accntID account.AccountID) ([]*Instruction, error) {
+	if accntID != "" {
+		err := accntID.Validate()
+		if err != nil {
+			return nil, multierr.Combine(ErrValidation,
+				validation.Errors{"account_id": err})
+		}
+	}
+
+	return s.scheduleRepo.ListInstructions(ctx, accntID)
+}
+
+// UpdateInstruction updates the amount, desc and schedule of the instruction.
+// The next run is rescheduled and the failed attempts are reset.
+func (s *service) UpdateInstruction(ctx context.Context, upd Instruction) (*Instruction, error) {
+	inst, err := s.scheduleRepo.GetInstruction(ctx, upd.InstructionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.Status != StatusActive {
+		return nil, ErrInstructionNotActive
+	}
+
+	inst.Amount = upd.Amount
+	inst.Desc = upd.Desc
+	inst.Frequency = upd.Frequency
+	inst.CronExpr = upd.CronExpr
+	inst.StartAt = upd.StartAt
+	inst.EndAt = upd.EndAt
+
+	normalize(inst)
+	err = inst.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	err = s.checkAccounts(ctx, *inst)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	err = scheduleFirstRun(inst, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.scheduleRepo.UpdateInstruction(ctx, *inst, now)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "update instruction")
+	}
+
+	return s.scheduleRepo.GetInstruction(ctx, inst.InstructionID)
+}
+
+// CancelInstruction cancels the instruction, it has no more runs
+func (s *service) CancelInstruction(ctx context.Context, instID InstructionID) (*Instruction, error) {
+	inst, err := s.scheduleRepo.GetInstruction(ctx, instID)
+	if err != nil {
+		return nil, err
+	}
+
+	if inst.Status != StatusActive {
+		return nil, ErrInstructionNotActive
+	}
+
+	inst.Status = StatusCancelled
+	err = s.scheduleRepo.UpdateInstruction(ctx, *inst, time.Now())
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "update instruction")
+	}
+
+	return s.scheduleRepo.GetInstruction(ctx, instID)
+}
+
+// ListRuns retrieves the runs of the instruction
+func (s *service) ListRuns(ctx context.Context, instID InstructionID) ([]*Run, error) {
+	_, err := s.scheduleRepo.GetInstruction(ctx, instID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.scheduleRepo.ListRuns(ctx, instID)
+}
+
+// checkAccounts checks that the accounts exist and that
+// the amount is valid in the currency of the accounts
+func (s *service) checkAccounts(ctx context.Context, inst Instruction) error {
+	from, err := s.getAccount(ctx, inst.FromAccount)
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			return transaction.ErrSendingAccountNotFound
+		}
+		return pkgerrors.Wrap(err, "get sending account")
+	}
+
+	to, err := s.getAccount(ctx, inst.ToAccount)
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			return transaction.ErrReceivingAccountNotFound
+		}
+		return pkgerrors.Wrap(err, "get receiving account")
+	}
+
+	if from.Currency != to.Currency {
+		return transaction.ErrDifferentCurrencies
+	}
+
+	if !from.Currency.IsValidAmount(inst.Amount) {
+		return multierr.Combine(ErrValidation, transaction.ErrAmountPrecision)
+	}
+
+	return nil
+}
+
+// getAccount retrieves the account, it returns
+// account.ErrAccountNotFound if it doesn't exist
+func (s *service) getAccount(ctx context.Context, accntID account.AccountID) (*account.Account, error) {
+	exists, err := s.accountRepo.IsAccountExists(ctx, accntID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	return s.accountRepo.GetAccount(ctx, accntID)
+}
+
+// normalize converts the times to UTC, the start time is truncated to
+// the second so that the runs are stored as is by all the back-ends
+func normalize(inst *Instruction) {
+	inst.StartAt = inst.StartAt.UTC().Truncate(time.Second)
+	if inst.EndAt != nil {
+		endAt := inst.EndAt.UTC()
+		inst.EndAt = &endAt
+	}
+}
+
+// scheduleFirstRun schedules the next run to the first run after now
+func scheduleFirstRun(inst *Instruction, now time.Time) error {
+	next, ok := inst.NextRun(now)
+	if !ok {
+		return multierr.Combine(ErrValidation, validation.Errors{
+			"start_at": errors.New("no runs are left after the current time"),
+		})
+	}
+
+	inst.NextRunAt = next
+	inst.NextAttemptAt = next
+	inst.Attempts = 0
+	return nil
+}
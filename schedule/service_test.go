@@ -0,0 +1,422 @@
+package schedule_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/balance"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/schedule"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+var (
+	john = account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	mary = account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+)
+
+// setup creates the accounts and ledgers, and deposits to john's account
+func setup(t *testing.T, store *repotest.Repos, deposit int64) transaction.Service {
+	ctx := context.TODO()
+
+	for _, accnt := range []account.Account{john, mary} {
+		_, err := store.AccountRepo.CreateAccount(ctx, accnt)
+		require.NoError(t, err)
+	}
+
+	err := ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	xactSvc := transaction.NewService(store.AccountRepo,
+		store.LedgerRepo, store.XactRepo, store.BalRepo)
+
+	if deposit > 0 {
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(deposit),
+		})
+		require.NoError(t, err)
+	}
+
+	return xactSvc
+}
+
+func TestScheduleService(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+	setup(t, store, 0)
+
+	scheduleSvc := schedule.NewService(store.ScheduleRepo, store.AccountRepo)
+
+	startAt := time.Now().Add(time.Hour)
+	inst, err := scheduleSvc.CreateInstruction(ctx, schedule.Instruction{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(10),
+		Desc:        "allowance",
+		Frequency:   schedule.FrequencyDaily,
+		StartAt:     startAt,
+	})
+	require.NoError(t, err)
+
+	t.Run("create instruction", func(t *testing.T) {
+		assert.NotEmpty(t, inst.InstructionID)
+		assert.Equal(t, schedule.StatusActive, inst.Status)
+		assert.Equal(t, startAt.UTC().Truncate(time.Second), inst.StartAt)
+		assert.Equal(t, inst.StartAt, inst.NextRunAt)
+		assert.Equal(t, inst.StartAt, inst.NextAttemptAt)
+
+		tc := []struct {
+			name   string
+			inst   schedule.Instruction
+			expect error
+		}{
+			{
+				name: "one-off in the past",
+				inst: schedule.Instruction{FromAccount: john.AccountID,
+					ToAccount: mary.AccountID, Amount: decimal.NewFromInt(10),
+					Frequency: schedule.FrequencyOnce, StartAt: time.Now().Add(-time.Hour)},
+				expect: schedule.ErrValidation,
+			},
+			{
+				name: "sending account not found",
+				inst: schedule.Instruction{FromAccount: account.AccountID("idontexist"),
+					ToAccount: mary.AccountID, Amount: decimal.NewFromInt(10),
+					Frequency: schedule.FrequencyOnce, StartAt: startAt},
+				expect: transaction.ErrSendingAccountNotFound,
+			},
+			{
+				name: "receiving account not found",
+				inst: schedule.Instruction{FromAccount: john.AccountID,
+					ToAccount: account.AccountID("idontexist"), Amount: decimal.NewFromInt(10),
+					Frequency: schedule.FrequencyOnce, StartAt: startAt},
+				expect: transaction.ErrReceivingAccountNotFound,
+			},
+			{
+				name: "amount precision",
+				inst: schedule.Instruction{FromAccount: john.AccountID,
+					ToAccount: mary.AccountID, Amount: decimal.RequireFromString("10.001"),
+					Frequency: schedule.FrequencyOnce, StartAt: startAt},
+				expect: transaction.ErrAmountPrecision,
+			},
+		}
+
+		for _, tt := range tc {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := scheduleSvc.CreateInstruction(ctx, tt.inst)
+				assert.ErrorIs(t, err, tt.expect)
+			})
+		}
+	})
+
+	t.Run("get instruction", func(t *testing.T) {
+		got, err := scheduleSvc.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, inst.InstructionID, got.InstructionID)
+		assert.Equal(t, "allowance", got.Desc)
+
+		_, err = scheduleSvc.GetInstruction(ctx, schedule.InstructionID("idontexist"))
+		assert.ErrorIs(t, err, schedule.ErrInstructionNotFound)
+	})
+
+	t.Run("list instructions", func(t *testing.T) {
+		insts, err := scheduleSvc.ListInstructions(ctx, mary.AccountID)
+		require.NoError(t, err)
+		require.Len(t, insts, 1)
+		assert.Equal(t, inst.InstructionID, insts[0].InstructionID)
+
+		_, err = scheduleSvc.ListInstructions(ctx, account.AccountID("john"))
+		assert.ErrorIs(t, err, schedule.ErrValidation)
+	})
+
+	t.Run("update instruction", func(t *testing.T) {
+		got, err := scheduleSvc.UpdateInstruction(ctx, schedule.Instruction{
+			InstructionID: inst.InstructionID,
+			Amount:        decimal.NewFromInt(20),
+			Frequency:     schedule.FrequencyCron,
+			CronExpr:      "0 9 * * 1",
+			StartAt:       startAt,
+		})
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(20).Equal(got.Amount))
+		assert.Empty(t, got.Desc)
+		assert.Equal(t, schedule.FrequencyCron, got.Frequency)
+		assert.Equal(t, john.AccountID, got.FromAccount)
+
+		// the next run is on a monday at 9:00
+		assert.Equal(t, time.Monday, got.NextRunAt.Weekday())
+		assert.Equal(t, 9, got.NextRunAt.Hour())
+		assert.True(t, got.NextRunAt.After(startAt))
+
+		_, err = scheduleSvc.UpdateInstruction(ctx, schedule.Instruction{
+			InstructionID: inst.InstructionID,
+			Amount:        decimal.NewFromInt(20),
+		})
+		assert.ErrorIs(t, err, schedule.ErrValidation)
+
+		_, err = scheduleSvc.UpdateInstruction(ctx, schedule.Instruction{
+			InstructionID: schedule.InstructionID("idontexist"),
+		})
+		assert.ErrorIs(t, err, schedule.ErrInstructionNotFound)
+	})
+
+	t.Run("list runs", func(t *testing.T) {
+		runs, err := scheduleSvc.ListRuns(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Empty(t, runs)
+
+		_, err = scheduleSvc.ListRuns(ctx, schedule.InstructionID("idontexist"))
+		assert.ErrorIs(t, err, schedule.ErrInstructionNotFound)
+	})
+
+	t.Run("cancel instruction", func(t *testing.T) {
+		got, err := scheduleSvc.CancelInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, schedule.StatusCancelled, got.Status)
+
+		_, err = scheduleSvc.CancelInstruction(ctx, inst.InstructionID)
+		assert.ErrorIs(t, err, schedule.ErrInstructionNotActive)
+
+		_, err = scheduleSvc.UpdateInstruction(ctx, *got)
+		assert.ErrorIs(t, err, schedule.ErrInstructionNotActive)
+	})
+}
+
+func TestWorker(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+	xactSvc := setup(t, store, 100)
+
+	balService := balance.NewService(store.BalRepo)
+	scheduleSvc := schedule.NewService(store.ScheduleRepo, store.AccountRepo)
+	worker := schedule.NewWorker(store.ScheduleRepo, xactSvc, "worker1",
+		schedule.WithMaxAttempts(2), schedule.WithRetryDelay(time.Minute))
+
+	assertBal := func(t *testing.T, accntID account.AccountID, expect int64) {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(expect).Equal(bal.CurrentBal),
+			"expecting %d, got %s", expect, bal.CurrentBal)
+	}
+
+	t.Run("recurring", func(t *testing.T) {
+		inst, err := scheduleSvc.CreateInstruction(ctx, schedule.Instruction{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(30),
+			Frequency:   schedule.FrequencyDaily,
+			StartAt:     time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		startAt := inst.StartAt
+
+		n, err := worker.RunDue(ctx, startAt.Add(-time.Second))
+		require.NoError(t, err)
+		assert.Zero(t, n, "not yet due")
+
+		n, err = worker.RunDue(ctx, startAt)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		assertBal(t, john.AccountID, 70)
+		assertBal(t, mary.AccountID, 30)
+
+		got, err := scheduleSvc.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, schedule.StatusActive, got.Status)
+		assert.Equal(t, startAt.Add(24*time.Hour), got.NextRunAt)
+
+		runs, err := scheduleSvc.ListRuns(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.Equal(t, startAt, runs[0].ScheduledAt)
+		assert.NotEmpty(t, runs[0].XactNo)
+
+		n, err = worker.RunDue(ctx, startAt)
+		require.NoError(t, err)
+		assert.Zero(t, n, "already ran")
+
+		_, err = scheduleSvc.CancelInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		inst, err := scheduleSvc.CreateInstruction(ctx, schedule.Instruction{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(1000),
+			Frequency:   schedule.FrequencyOnce,
+			StartAt:     time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		startAt := inst.StartAt
+
+		n, err := worker.RunDue(ctx, startAt)
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		got, err := scheduleSvc.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, schedule.StatusActive, got.Status)
+		assert.Equal(t, 1, got.Attempts)
+		assert.Equal(t, startAt, got.NextRunAt)
+		assert.Equal(t, startAt.Add(time.Minute), got.NextAttemptAt)
+		assert.Contains(t, got.LastError, transaction.ErrInsufficientBalance.Error())
+
+		n, err = worker.RunDue(ctx, startAt.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		got, err = scheduleSvc.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, schedule.StatusFailed, got.Status)
+
+		runs, err := scheduleSvc.ListRuns(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		require.Len(t, runs, 2)
+		for i, run := range runs {
+			assert.Equal(t, i+1, run.Attempt)
+			assert.Equal(t, startAt, run.ScheduledAt)
+			assert.Empty(t, run.XactNo)
+			assert.Contains(t, run.Error, transaction.ErrInsufficientBalance.Error())
+		}
+
+		assertBal(t, john.AccountID, 70)
+	})
+
+	t.Run("lease expired during the run", func(t *testing.T) {
+		inst, err := scheduleSvc.CreateInstruction(ctx, schedule.Instruction{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(10),
+			Frequency:   schedule.FrequencyOnce,
+			StartAt:     time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+		startAt := inst.StartAt
+
+		// worker2 takes over the run after the lease of slowWorker
+		// has expired, but before slowWorker has recorded the run
+		worker2 := schedule.NewWorker(store.ScheduleRepo, xactSvc, "worker2")
+		slowXactSvc := &hookXactService{Service: xactSvc, afterTransfer: func() {
+			n, err := worker2.RunDue(ctx, startAt.Add(time.Hour))
+			require.NoError(t, err)
+			assert.Equal(t, 1, n)
+		}}
+		slowWorker := schedule.NewWorker(store.ScheduleRepo, slowXactSvc, "slowworker",
+			schedule.WithLeaseTTL(time.Minute))
+
+		n, err := slowWorker.RunDue(ctx, startAt)
+		require.NoError(t, err)
+		assert.Zero(t, n, "lease lost")
+
+		// the transfer was made once
+		assertBal(t, john.AccountID, 60)
+
+		runs, err := scheduleSvc.ListRuns(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		require.Len(t, runs, 1)
+		assert.NotEmpty(t, runs[0].XactNo)
+
+		got, err := scheduleSvc.GetInstruction(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Equal(t, schedule.StatusCompleted, got.Status)
+	})
+}
+
+// hookXactService calls the hook after a transfer
+type hookXactService struct {
+	transaction.Service
+	afterTransfer func()
+}
+
+func (s *hookXactService) MakeTransfer(ctx context.Context,
+	tr transaction.TransferXact) (*transaction.Xact, error) {
+	xact, err := s.Service.MakeTransfer(ctx, tr)
+	s.afterTransfer()
+	return xact, err
+}
+
+func TestWorkerConcurrency(t *testing.T) {
+	// txdb connections shares a single transaction, hence, we need real ones
+	store, closeStore := teststore.MustOpenSchema(fmt.Sprintf("schedule_concurrency_%d", time.Now().UnixNano()))
+	defer func() {
+		assert.NoError(t, closeStore())
+	}()
+
+	ctx := context.TODO()
+	xactSvc := setup(t, store, 100)
+
+	balService := balance.NewService(store.BalRepo)
+	scheduleSvc := schedule.NewService(store.ScheduleRepo, store.AccountRepo)
+
+	const n = 10
+	startAt := time.Now().Add(time.Hour)
+	for i := 0; i < n; i++ {
+		_, err := scheduleSvc.CreateInstruction(ctx, schedule.Instruction{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(1),
+			Frequency:   schedule.FrequencyDaily,
+			StartAt:     startAt,
+		})
+		require.NoError(t, err)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int
+	)
+	for i := 0; i < 3; i++ {
+		worker := schedule.NewWorker(store.ScheduleRepo, xactSvc,
+			fmt.Sprintf("worker%d", i), schedule.WithBatchSize(2))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := worker.RunDue(ctx, startAt.Add(time.Minute))
+			assert.NoError(t, err)
+
+			mu.Lock()
+			total += count
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, n, total)
+
+	bal, err := balService.GetAccntBal(ctx, john.AccountID)
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(100-n).Equal(bal.CurrentBal))
+
+	insts, err := scheduleSvc.ListInstructions(ctx, john.AccountID)
+	require.NoError(t, err)
+	for _, inst := range insts {
+		runs, err := scheduleSvc.ListRuns(ctx, inst.InstructionID)
+		require.NoError(t, err)
+		assert.Len(t, runs, 1)
+	}
+}
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Status is the instruction status
+type Status int
+
+// List of instruction statuses
+const (
+	// StatusActive is an instruction that has runs left
+	StatusActive Status = iota + 1
+	// StatusCompleted is an instruction that has no runs left
+	StatusCompleted
+	// StatusFailed is a one-off instruction whose run has failed
+	// after all the attempts
+	StatusFailed
+	// StatusCancelled is an instruction that was cancelled
+	StatusCancelled
+)
+
+// String implements Stringer
+func (s Status) String() string {
+	if s < 0 || int(s) > len(statuses)-1 {
+		return statuses[0]
+	}
+
+	return statuses[s]
+}
+
+var statuses = [...]string{
+	"invalid",
+	"active",
+	"completed",
+	"failed",
+	"cancelled",
+}
+
+// Value implements driver.Valuer interface
+func (s Status) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan implements sql.Scanner interface
+func (s *Status) Scan(src interface{}) error {
+	if src == nil {
+		*s = Status(0)
+		return nil
+	}
+
+	val, ok := src.(string)
+	if !ok {
+		return errors.New("src is not string")
+	}
+
+	*s = strToStatus(val)
+	return nil
+}
+
+// strToStatus takes a string and returns the status
+func strToStatus(str string) Status {
+	for i, name := range statuses {
+		if i > 0 && name == str {
+			return Status(i)
+		}
+	}
+
+	return Status(0)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (s Status) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(s.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+
+	*s = strToStatus(str)
+	return nil
+}
@@ -0,0 +1,172 @@
+package schedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// NewHTTPHandler returns the schedule http handler
+func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	createInstructionHandler := kithttp.NewServer(
+		newCreateInstructionEndpoint(s),
+		decodeCreateInstructionRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	listInstructionsHandler := kithttp.NewServer(
+		newListInstructionsEndpoint(s),
+		decodeListInstructionsRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	getInstructionHandler := kithttp.NewServer(
+		newGetInstructionEndpoint(s),
+		decodeGetInstructionRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	updateInstructionHandler := kithttp.NewServer(
+		newUpdateInstructionEndpoint(s),
+		decodeUpdateInstructionRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	cancelInstructionHandler := kithttp.NewServer(
+		newCancelInstructionEndpoint(s),
+		decodeCancelInstructionRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	listRunsHandler := kithttp.NewServer(
+		newListRunsEndpoint(s),
+		decodeListRunsRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodPost, "/", createInstructionHandler)
+	mux.Method(http.MethodGet, "/", listInstructionsHandler)
+	mux.Method(http.MethodGet, "/{id}", getInstructionHandler)
+	mux.Method(http.MethodPut, "/{id}", updateInstructionHandler)
+	mux.Method(http.MethodDelete, "/{id}", cancelInstructionHandler)
+	mux.Method(http.MethodGet, "/{id}/runs", listRunsHandler)
+
+	return mux
+}
+
+var (
+	errBadRoute = errors.New("bad route")
+)
+
+func decodeCreateInstructionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request instructionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func decodeListInstructionsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accntID := r.URL.Query().Get("account_id")
+	return listInstructionsRequest{AccountID: account.AccountID(accntID)}, nil
+}
+
+func decodeGetInstructionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	instID := chi.URLParam(r, "id")
+	if instID == "" {
+		return nil, errBadRoute
+	}
+
+	return getInstructionRequest{InstructionID: InstructionID(instID)}, nil
+}
+
+func decodeUpdateInstructionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	instID := chi.URLParam(r, "id")
+	if instID == "" {
+		return nil, errBadRoute
+	}
+
+	var request instructionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.InstructionID = InstructionID(instID)
+
+	return request, nil
+}
+
+func decodeCancelInstructionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	instID := chi.URLParam(r, "id")
+	if instID == "" {
+		return nil, errBadRoute
+	}
+
+	return cancelInstructionRequest{InstructionID: InstructionID(instID)}, nil
+}
+
+func decodeListRunsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	instID := chi.URLParam(r, "id")
+	if instID == "" {
+		return nil, errBadRoute
+	}
+
+	return listRunsRequest{InstructionID: InstructionID(instID)}, nil
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+// errorer is an error interface for response
+type errorer interface {
+	error() error
+}
+
+// encode errors from business-logic
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if errors.Is(err, ErrValidation) ||
+		errors.Is(err, transaction.ErrDifferentCurrencies) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	} else if errors.Is(err, ErrInstructionNotActive) ||
+		errors.Is(err, ErrInstructionBusy) {
+		w.WriteHeader(http.StatusConflict)
+	} else if errors.Is(err, ErrInstructionNotFound) ||
+		errors.Is(err, transaction.ErrSendingAccountNotFound) ||
+		errors.Is(err, transaction.ErrReceivingAccountNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
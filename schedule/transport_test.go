@@ -0,0 +1,166 @@
+package schedule_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/schedule"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	setup(t, store, 0)
+
+	logger := log.NewNopLogger()
+	var scheduleService schedule.Service
+	scheduleService = schedule.NewService(store.ScheduleRepo, store.AccountRepo)
+	scheduleService = schedule.NewLoggingService(logger, scheduleService)
+
+	scheduleHandler := schedule.NewHTTPHandler(scheduleService, logger)
+
+	ctx := context.TODO()
+
+	type instructionResp struct {
+		Instruction struct {
+			InstructionID string    `json:"id"`
+			Amount        string    `json:"amount"`
+			Frequency     string    `json:"frequency"`
+			Status        string    `json:"status"`
+			NextRunAt     time.Time `json:"next_run_at"`
+		} `json:"instruction"`
+	}
+
+	serve := func(t *testing.T, method, target string, req interface{}) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if req != nil {
+			err := json.NewEncoder(&body).Encode(req)
+			require.NoError(t, err)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, method, target, &body)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		scheduleHandler.ServeHTTP(rr, httpReq)
+		return rr
+	}
+
+	startAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	var instID string
+	t.Run("create instruction", func(t *testing.T) {
+		rr := serve(t, http.MethodPost, "/", map[string]interface{}{
+			"from_account": john.AccountID,
+			"to_account":   mary.AccountID,
+			"amount":       "10",
+			"frequency":    "monthly",
+			"start_at":     startAt,
+		})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp instructionResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		instID = resp.Instruction.InstructionID
+		assert.NotEmpty(t, instID)
+		assert.Equal(t, "monthly", resp.Instruction.Frequency)
+		assert.Equal(t, "active", resp.Instruction.Status)
+		assert.True(t, startAt.Equal(resp.Instruction.NextRunAt))
+
+		t.Run("validation error", func(t *testing.T) {
+			rr := serve(t, http.MethodPost, "/", map[string]interface{}{
+				"from_account": john.AccountID,
+				"to_account":   mary.AccountID,
+				"amount":       "10",
+				"frequency":    "cron",
+				"cron_expr":    "every day",
+				"start_at":     startAt,
+			})
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+
+		t.Run("account not found", func(t *testing.T) {
+			rr := serve(t, http.MethodPost, "/", map[string]interface{}{
+				"from_account": "idontexist",
+				"to_account":   mary.AccountID,
+				"amount":       "10",
+				"frequency":    "once",
+				"start_at":     startAt,
+			})
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+
+	t.Run("get instruction", func(t *testing.T) {
+		rr := serve(t, http.MethodGet, "/"+instID, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp instructionResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, instID, resp.Instruction.InstructionID)
+
+		rr = serve(t, http.MethodGet, "/idontexist", nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("list instructions", func(t *testing.T) {
+		rr := serve(t, http.MethodGet, "/?account_id="+string(john.AccountID), nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Instructions []struct {
+				InstructionID string `json:"id"`
+			} `json:"instructions"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Instructions, 1)
+		assert.Equal(t, instID, resp.Instructions[0].InstructionID)
+	})
+
+	t.Run("update instruction", func(t *testing.T) {
+		rr := serve(t, http.MethodPut, "/"+instID, map[string]interface{}{
+			"amount":    "25.5",
+			"frequency": "weekly",
+			"start_at":  startAt,
+		})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp instructionResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "25.5", resp.Instruction.Amount)
+		assert.Equal(t, "weekly", resp.Instruction.Frequency)
+	})
+
+	t.Run("list runs", func(t *testing.T) {
+		rr := serve(t, http.MethodGet, "/"+instID+"/runs", nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("cancel instruction", func(t *testing.T) {
+		rr := serve(t, http.MethodDelete, "/"+instID, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp instructionResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "cancelled", resp.Instruction.Status)
+
+		rr = serve(t, http.MethodDelete, "/"+instID, nil)
+		require.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
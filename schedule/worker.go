@@ -0,0 +1,213 @@
+package schedule
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// List of worker defaults
+const (
+	defaultInterval    = 10 * time.Second
+	defaultLeaseTTL    = time.Minute
+	defaultBatchSize   = 10
+	defaultMaxAttempts = 3
+	defaultRetryDelay  = time.Minute
+)
+
+// Worker runs the due instructions through the transaction service. Several
+// workers can run against the same database, an instruction is leased by one
+// worker at a time and the transfer of a run is made at most once using an
+// idempotency key derived from the instruction and the scheduled time.
+//
+// A failed run is retried with an exponential backoff, after the max attempts
+// the run is skipped i.e. a recurring instruction continues with its next run
+// and a one-off instruction fails. The runs missed while no worker is running
+// are skipped, only the latest due run is made.
+type Worker struct {
+	scheduleRepo Repository
+	xactService  transaction.Service
+	// owner identifies the worker in the leases
+	owner       string
+	logger      log.Logger
+	interval    time.Duration
+	leaseTTL    time.Duration
+	batchSize   int
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+// WorkerOption is an option for the worker
+type WorkerOption func(*Worker)
+
+// WithInterval sets how often the worker polls for due instructions
+func WithInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.interval = d
+	}
+}
+
+// WithLeaseTTL sets how long an instruction is leased to the worker,
+// it must be longer than a transfer takes
+func WithLeaseTTL(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.leaseTTL = d
+	}
+}
+
+// WithBatchSize sets the max number of instructions leased per poll
+func WithBatchSize(n int) WorkerOption {
+	return func(w *Worker) {
+		w.batchSize = n
+	}
+}
+
+// WithMaxAttempts sets the max number of attempts of a run
+func WithMaxAttempts(n int) WorkerOption {
+	return func(w *Worker) {
+		w.maxAttempts = n
+	}
+}
+
+// WithRetryDelay sets the delay before retrying a failed run,
+// it is doubled on every attempt
+func WithRetryDelay(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.retryDelay = d
+	}
+}
+
+// WithLogger sets the logger of the worker
+func WithLogger(logger log.Logger) WorkerOption {
+	return func(w *Worker) {
+		w.logger = logger
+	}
+}
+
+// NewWorker takes a schedule repository, a transaction service and
+// the unique name of the worker, and returns a worker
+func NewWorker(scheduleRepo Repository, xactService transaction.Service,
+	owner string, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		scheduleRepo: scheduleRepo,
+		xactService:  xactService,
+		owner:        owner,
+		logger:       log.NewNopLogger(),
+		interval:     defaultInterval,
+		leaseTTL:     defaultLeaseTTL,
+		batchSize:    defaultBatchSize,
+		maxAttempts:  defaultMaxAttempts,
+		retryDelay:   defaultRetryDelay,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Run runs the due instructions every interval until the context is done
+func (w *Worker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := w.RunDue(ctx, time.Now())
+		if err != nil {
+			_ = w.logger.Log("err", err)
+		} else if n > 0 {
+			_ = w.logger.Log("msg", "ran due instructions", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue runs the instructions that are due at now, and returns the number
+// of runs. It keeps going until there are no due instructions left.
+func (w *Worker) RunDue(ctx context.Context, now time.Time) (int, error) {
+	var count int
+	for {
+		insts, err := w.scheduleRepo.LeaseDueInstructions(ctx,
+			w.owner, now, w.leaseTTL, w.batchSize)
+		if err != nil {
+			return count, errors.Wrap(err, "lease due instructions")
+		}
+
+		for _, inst := range insts {
+			err = w.run(ctx, *inst, now)
+			if err != nil {
+				_ = w.logger.Log("instruction_id", inst.InstructionID, "err", err)
+				continue
+			}
+			count++
+		}
+
+		if len(insts) < w.batchSize {
+			return count, nil
+		}
+	}
+}
+
+// run makes the transfer of the instruction and records the run
+func (w *Worker) run(ctx context.Context, inst Instruction, now time.Time) error {
+	xact, xactErr := w.xactService.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount:    inst.FromAccount,
+		ToAccount:      inst.ToAccount,
+		Amount:         inst.Amount,
+		IdempotencyKey: inst.idempotencyKey(),
+	})
+
+	run := Run{
+		InstructionID: inst.InstructionID,
+		ScheduledAt:   inst.NextRunAt,
+		Attempt:       inst.Attempts + 1,
+	}
+
+	if xactErr != nil {
+		run.Error = xactErr.Error()
+		inst.LastError = xactErr.Error()
+		inst.Attempts++
+		if inst.Attempts < w.maxAttempts {
+			inst.NextAttemptAt = now.Add(w.retryDelay << uint(inst.Attempts-1))
+			return w.recordRun(ctx, inst, run)
+		}
+	} else {
+		run.XactNo = xact.XactNo
+		inst.LastError = ""
+	}
+
+	next, ok := inst.NextRun(now)
+	switch {
+	case ok:
+		inst.NextRunAt = next
+		inst.NextAttemptAt = next
+		inst.Attempts = 0
+	case xactErr != nil && inst.Frequency == FrequencyOnce:
+		inst.Status = StatusFailed
+	default:
+		inst.Status = StatusCompleted
+	}
+
+	return w.recordRun(ctx, inst, run)
+}
+
+// recordRun records the run, the instruction is still leased by the worker
+func (w *Worker) recordRun(ctx context.Context, inst Instruction, run Run) error {
+	inst.LeaseOwner = w.owner
+	err := w.scheduleRepo.RecordRun(ctx, inst, run)
+	if err != nil {
+		return errors.Wrap(err, "record run")
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/etc/tx"
+)
+
+// AccountRepository implements the account repository
+// interface and uses sqlite as back-end
+type AccountRepository struct{ db *DB }
+
+var _ account.Repository = (*AccountRepository)(nil)
+
+// NewAccountRepository returns an account repository
+func NewAccountRepository(db *DB) *AccountRepository {
+	return &AccountRepository{db: db}
+}
+
+// CreateAccount creates an account
+func (ar *AccountRepository) CreateAccount(ctx context.Context, accnt account.Account) (account.AccountID, error) {
+	stmnt := `insert into accounts (account_id, currency, overdraft_limit)
+		values (?, ?, ?)`
+	_, err := ar.db.ExecContext(ctx, stmnt, accnt.AccountID,
+		accnt.Currency, amount(accnt.OverdraftLimit))
+	if err != nil {
+		return "", errors.Wrap(err, "exec context")
+	}
+
+	return accnt.AccountID, nil
+}
+
+// GetAccount retrieves an account
+func (ar *AccountRepository) GetAccount(ctx context.Context, accntID account.AccountID) (*account.Account, error) {
+	stmnt := `select account_id, currency, status, overdraft_limit from accounts
+		where account_id = ?`
+
+	var ac account.Account
+	err := ar.db.QueryRowContext(ctx, stmnt, accntID).
+		Scan(&ac.AccountID, &ac.Currency, &ac.Status, scanAmount(&ac.OverdraftLimit))
+	if err != nil {
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &ac, nil
+}
+
+// ListAccounts retrieves the list of accounts
+func (ar *AccountRepository) ListAccounts(ctx context.Context) ([]*account.Account, error) {
+	stmnt := `select account_id, currency, status, overdraft_limit from accounts`
+
+	rows, err := ar.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	accnts := []*account.Account{}
+	for rows.Next() {
+		var accnt account.Account
+		err = rows.Scan(&accnt.AccountID, &accnt.Currency,
+			&accnt.Status, scanAmount(&accnt.OverdraftLimit))
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		accnts = append(accnts, &accnt)
+	}
+
+	return accnts, nil
+}
+
+// IsAccountExists returns true if an account exists
+func (ar *AccountRepository) IsAccountExists(ctx context.Context, accntID account.AccountID) (bool, error) {
+	stmnt := "select exists(select 1 from accounts where account_id=?)"
+	var exists bool
+	err := ar.db.QueryRowContext(ctx, stmnt, accntID).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return false, errors.Wrap(err, "query row context")
+	}
+
+	return exists, nil
+}
+
+// GetAccountStatus retrieves the account status within tx
+func (ar *AccountRepository) GetAccountStatus(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID) (account.Status, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	stmnt := "select status from accounts where account_id = ?"
+	var status account.Status
+	err = txx.conn.QueryRowContext(ctx, stmnt, accntID).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, account.ErrAccountNotFound
+		}
+		return 0, errors.Wrap(err, "query row context")
+	}
+
+	return status, nil
+}
+
+// UpdateAccountStatus updates the account status and records the status change within tx
+func (ar *AccountRepository) UpdateAccountStatus(ctx context.Context,
+	tx tx.Tx, sc account.StatusChange) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := "update accounts set status = ? where account_id = ?"
+	_, err = txx.conn.ExecContext(ctx, stmnt, sc.Status, sc.AccountID)
+	if err != nil {
+		return errors.Wrap(err, "update account status")
+	}
+
+	stmnt = `insert into account_status_changes (
+			account_id, from_status, status, reason, changed_by, ts
+		) values (?, ?, ?, ?, ?, ?)`
+	_, err = txx.conn.ExecContext(ctx, stmnt, sc.AccountID, sc.FromStatus,
+		sc.Status, sc.Reason, sc.ChangedBy, timestamp(txx.ts))
+	if err != nil {
+		return errors.Wrap(err, "insert status change")
+	}
+
+	return nil
+}
+
+// ListStatusChanges retrieves the status changes of the account
+func (ar *AccountRepository) ListStatusChanges(ctx context.Context,
+	accntID account.AccountID) ([]*account.StatusChange, error) {
+	stmnt := `select account_id, from_status, status, reason, changed_by, ts
+		from account_status_changes where account_id = ? order by ts, rowid`
+
+	rows, err := ar.db.QueryContext(ctx, stmnt, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	scs := []*account.StatusChange{}
+	for rows.Next() {
+		var sc account.StatusChange
+		err = rows.Scan(&sc.AccountID, &sc.FromStatus, &sc.Status,
+			&sc.Reason, &sc.ChangedBy, scanTs(&sc.Ts))
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		scs = append(scs, &sc)
+	}
+
+	return scs, nil
+}
+
+// GetOverdraftLimit retrieves the overdraft limit of the account within tx
+func (ar *AccountRepository) GetOverdraftLimit(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID) (decimal.Decimal, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	stmnt := "select overdraft_limit from accounts where account_id = ?"
+	var limit decimal.Decimal
+	err = txx.conn.QueryRowContext(ctx, stmnt, accntID).Scan(scanAmount(&limit))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return decimal.Zero, account.ErrAccountNotFound
+		}
+		return decimal.Zero, errors.Wrap(err, "query row context")
+	}
+
+	return limit, nil
+}
+
+// UpdateOverdraftLimit updates the overdraft limit of the account within tx
+func (ar *AccountRepository) UpdateOverdraftLimit(ctx context.Context,
+	tx tx.Tx, accntID account.AccountID, limit decimal.Decimal) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := "update accounts set overdraft_limit = ? where account_id = ?"
+	_, err = txx.conn.ExecContext(ctx, stmnt, amount(limit), accntID)
+	if err != nil {
+		return errors.Wrap(err, "update overdraft limit")
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/balance"
+	"github.com/stevenferrer/kalupi/etc/tx"
+)
+
+// BalanceRepository implements the balance repository
+// interface and uses sqlite as back-end
+type BalanceRepository struct{ db *DB }
+
+var _ balance.Repository = (*BalanceRepository)(nil)
+
+// NewBalanceRepository returns a balance repository
+func NewBalanceRepository(db *DB) *BalanceRepository {
+	return &BalanceRepository{db: db}
+}
+
+// BeginTx begins a new tx
+func (br *BalanceRepository) BeginTx(ctx context.Context) (tx.Tx, error) {
+	return beginTx(ctx, br.db)
+}
+
+// GetAccntBal retrieves the account balance without locking the account
+func (br *BalanceRepository) GetAccntBal(ctx context.Context, accntID account.AccountID) (*account.Balance, error) {
+	stmnt := `select coalesce(b.total_debit, 0), coalesce(b.total_credit, 0),
+			coalesce(b.current_balance, 0), b.ts,
+			(select coalesce(sum(h.amount), 0) from holds h
+				where h.account_id = a.account_id and h.status = 'active'
+				and h.expires_at > ?)
+		from accounts a left join account_balances b
+			on b.account_id = a.account_id
+		where a.account_id = ?`
+
+	accntBal := account.Balance{AccountID: accntID}
+	var held decimal.Decimal
+	err := br.db.QueryRowContext(ctx, stmnt, timestamp(time.Now()), accntID).Scan(
+		scanAmount(&accntBal.TotalDebit),
+		scanAmount(&accntBal.TotalCredit),
+		scanAmount(&accntBal.CurrentBal),
+		scanTs(&accntBal.Ts),
+		scanAmount(&held),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, account.ErrAccountNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+	accntBal.AvailableBal = accntBal.CurrentBal.Sub(held)
+
+	return &accntBal, nil
+}
+
+// GetAccntBalForUpdate retrieves the account balance within tx,
+// the account is locked until the tx is committed or rolled back
+func (br *BalanceRepository) GetAccntBalForUpdate(ctx context.Context, tx tx.Tx, accntID account.AccountID) (*account.Balance, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lockAccnt(ctx, txx, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "lock account")
+	}
+
+	stmnt := `select account_id, total_debit, total_credit, current_balance, ts
+		from account_balances where account_id=?`
+
+	var accntBal account.Balance
+	err = txx.conn.QueryRowContext(ctx, stmnt, accntID).Scan(
+		&accntBal.AccountID,
+		scanAmount(&accntBal.TotalDebit),
+		scanAmount(&accntBal.TotalCredit),
+		scanAmount(&accntBal.CurrentBal),
+		scanTs(&accntBal.Ts),
+	)
+	if err != nil {
+		// no transaction record yet
+		if err != sql.ErrNoRows {
+			return nil, errors.Wrap(err, "query row context")
+		}
+		accntBal = account.Balance{AccountID: accntID}
+	}
+
+	stmnt = `select coalesce(sum(amount), 0) from holds where account_id=?
+		and status = 'active' and expires_at > ?`
+
+	var held decimal.Decimal
+	err = txx.conn.QueryRowContext(ctx, stmnt, accntID, timestamp(time.Now())).
+		Scan(scanAmount(&held))
+	if err != nil {
+		return nil, errors.Wrap(err, "query held amount")
+	}
+	accntBal.AvailableBal = accntBal.CurrentBal.Sub(held)
+
+	return &accntBal, nil
+}
+
+// GetAccntBalAsOf retrieves the account balance from the postings made before the time
+func (br *BalanceRepository) GetAccntBalAsOf(ctx context.Context,
+	accntID account.AccountID, asOf time.Time) (*account.Balance, error) {
+	// a ledger's debit is the account's credit and vice versa
+	stmnt := `select
+			coalesce(sum(at.amount) filter (where at.xact_type = 'Cr'), 0),
+			coalesce(sum(at.amount) filter (where at.xact_type = 'Dr'), 0),
+			max(at.ts)
+		from accounts a left join account_transactions at
+			on at.account_id = a.account_id and at.ts < ?
+		where a.account_id = ?
+		group by a.account_id`
+
+	accntBal := account.Balance{AccountID: accntID}
+	err := br.db.QueryRowContext(ctx, stmnt, timestamp(asOf), accntID).Scan(
+		scanAmount(&accntBal.TotalDebit),
+		scanAmount(&accntBal.TotalCredit),
+		scanTs(&accntBal.Ts),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, account.ErrAccountNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+	accntBal.CurrentBal = accntBal.TotalCredit.Sub(accntBal.TotalDebit)
+	accntBal.AvailableBal = accntBal.CurrentBal
+
+	return &accntBal, nil
+}
+
+// LockAccnts locks the accounts within tx. The tx already holds the write
+// lock of the database, hence, this only checks that the accounts exist.
+func (br *BalanceRepository) LockAccnts(ctx context.Context, tx tx.Tx, accntIDs ...account.AccountID) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, accntID := range accntIDs {
+		err = lockAccnt(ctx, txx, accntID)
+		if err != nil {
+			return errors.Wrapf(err, "lock account %s", accntID)
+		}
+	}
+
+	return nil
+}
+
+// lockAccnt checks that the account exists within tx, the
+// account is effectively locked until the tx is done
+func lockAccnt(ctx context.Context, txx *Tx, accntID account.AccountID) error {
+	stmnt := `select account_id from accounts where account_id=?`
+
+	var id account.AccountID
+	err := txx.conn.QueryRowContext(ctx, stmnt, accntID).Scan(&id)
+	if err != nil {
+		return errors.Wrap(err, "query row context")
+	}
+
+	return nil
+}
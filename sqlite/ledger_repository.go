@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/ledger"
+)
+
+// LedgerRepository implements the ledger repository
+// interface and uses sqlite as back-end
+type LedgerRepository struct{ db *DB }
+
+var _ ledger.Repository = (*LedgerRepository)(nil)
+
+// NewLedgerRepository returns a new ledger repository
+func NewLedgerRepository(db *DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// CreateLedgersIfNotExists will create the ledgers if it doesn't exists in database yet
+func (lr *LedgerRepository) CreateLedgersIfNotExists(ctx context.Context, lgs ...ledger.Ledger) error {
+	for _, lg := range lgs {
+		exist, err := lr.isLedgerExists(ctx, lg.LedgerNo)
+		if err != nil {
+			return errors.Wrap(err, "is ledger exist")
+		}
+
+		if !exist {
+			err = lr.createLedger(ctx, lg)
+			if err != nil {
+				return errors.Wrap(err, "create ledger")
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetLedger retrieves the ledger
+func (lr *LedgerRepository) GetLedger(ctx context.Context, ledgerNo ledger.LedgerNo) (*ledger.Ledger, error) {
+	stmnt := `select ledger_no, account_type, currency, name
+		from ledgers where ledger_no = ?`
+	var lg ledger.Ledger
+	err := lr.db.QueryRowContext(ctx, stmnt, ledgerNo).
+		Scan(&lg.LedgerNo, &lg.AccountType, &lg.Currency, &lg.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ledger.ErrLedgerNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &lg, nil
+}
+
+// ListLedgers retrieves the list of ledgers
+func (lr *LedgerRepository) ListLedgers(ctx context.Context) ([]*ledger.Ledger, error) {
+	stmnt := `select ledger_no, account_type, currency, name from ledgers`
+
+	rows, err := lr.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	lgs := []*ledger.Ledger{}
+	for rows.Next() {
+		var lg ledger.Ledger
+		err = rows.Scan(&lg.LedgerNo, &lg.AccountType, &lg.Currency, &lg.Name)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		lgs = append(lgs, &lg)
+	}
+
+	return lgs, nil
+}
+
+// GetLedgerBal retrieves the ledger balance from the postings made before the time
+func (lr *LedgerRepository) GetLedgerBal(ctx context.Context,
+	ledgerNo ledger.LedgerNo, asOf time.Time) (*ledger.Balance, error) {
+	stmnt := `select l.currency,
+			coalesce(sum(p.amount) filter (where p.xact_type = 'Dr'), 0),
+			coalesce(sum(p.amount) filter (where p.xact_type = 'Cr'), 0),
+			max(p.ts)
+		from ledgers l left join (
+			select ledger_no, xact_type, amount, ts from account_transactions
+			where ledger_no = ? and ts < ?
+			union all
+			select ledger_no, xact_type, amount, ts from ledger_transactions
+			where ledger_no = ? and ts < ?
+		) p on p.ledger_no = l.ledger_no
+		where l.ledger_no = ?
+		group by l.currency`
+
+	lgBal := ledger.Balance{LedgerNo: ledgerNo}
+	err := lr.db.QueryRowContext(ctx, stmnt, ledgerNo, timestamp(asOf),
+		ledgerNo, timestamp(asOf), ledgerNo).Scan(
+		&lgBal.Currency,
+		scanAmount(&lgBal.TotalDebit),
+		scanAmount(&lgBal.TotalCredit),
+		scanTs(&lgBal.Ts),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ledger.ErrLedgerNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+	lgBal.CurrentBal = lgBal.TotalDebit.Sub(lgBal.TotalCredit)
+
+	return &lgBal, nil
+}
+
+// GetTrialBalance retrieves the ledger and account balances from the postings
+// made before the time. The balances are read in one statement so that they
+// are from the same snapshot.
+func (lr *LedgerRepository) GetTrialBalance(ctx context.Context,
+	asOf time.Time) (*ledger.TrialBalance, error) {
+	// a ledger's debit is the account's credit and vice versa
+	stmnt := `with postings as (
+			select ledger_no, xact_type, account_id, amount, ts
+			from account_transactions where ts < ?
+			union all
+			select ledger_no, xact_type, null, amount, ts
+			from ledger_transactions where ts < ?
+		)
+		select 'L', l.ledger_no, l.currency,
+			coalesce(sum(p.amount) filter (where p.xact_type = 'Dr'), 0),
+			coalesce(sum(p.amount) filter (where p.xact_type = 'Cr'), 0),
+			max(p.ts)
+		from ledgers l left join postings p on p.ledger_no = l.ledger_no
+		group by l.ledger_no, l.currency
+		union all
+		select 'A', a.account_id, a.currency,
+			coalesce(sum(p.amount) filter (where p.xact_type = 'Cr'), 0),
+			coalesce(sum(p.amount) filter (where p.xact_type = 'Dr'), 0),
+			max(p.ts)
+		from postings p join accounts a on a.account_id = p.account_id
+		group by a.account_id, a.currency
+		order by 1 desc, 2`
+
+	rows, err := lr.db.QueryContext(ctx, stmnt, timestamp(asOf), timestamp(asOf))
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	tb := ledger.TrialBalance{
+		AsOf:     asOf,
+		Ledgers:  []*ledger.Balance{},
+		Accounts: []*ledger.AccntBalance{},
+	}
+	for rows.Next() {
+		var (
+			kind, id      string
+			curr          currency.Currency
+			debit, credit decimal.Decimal
+			ts            *time.Time
+		)
+		err = rows.Scan(&kind, &id, &curr, scanAmount(&debit),
+			scanAmount(&credit), scanTs(&ts))
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		if kind == "L" {
+			tb.Ledgers = append(tb.Ledgers, &ledger.Balance{
+				LedgerNo:    ledger.LedgerNo(id),
+				Currency:    curr,
+				TotalDebit:  debit,
+				TotalCredit: credit,
+				CurrentBal:  debit.Sub(credit),
+				Ts:          ts,
+			})
+			continue
+		}
+
+		tb.Accounts = append(tb.Accounts, &ledger.AccntBalance{
+			AccountID:   account.AccountID(id),
+			Currency:    curr,
+			TotalDebit:  debit,
+			TotalCredit: credit,
+			CurrentBal:  credit.Sub(debit),
+			Ts:          ts,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return &tb, nil
+}
+
+// createLedger is a helper method for creating a ledger
+func (lr *LedgerRepository) createLedger(ctx context.Context, lg ledger.Ledger) error {
+	stmnt := `insert into ledgers (ledger_no, account_type, currency, name)
+		values (?, ?, ?, ?)`
+	_, err := lr.db.ExecContext(ctx, stmnt, lg.LedgerNo,
+		lg.AccountType, lg.Currency, lg.Name)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// isLedgerExists is a helper method for checking ledger existence
+func (lr *LedgerRepository) isLedgerExists(ctx context.Context, ledgerNo ledger.LedgerNo) (bool, error) {
+	stmnt := "select exists(select 1 from ledgers where ledger_no=?)"
+	var exists bool
+	err := lr.db.QueryRowContext(ctx, stmnt, ledgerNo).Scan(&exists)
+	if err != nil && err != sql.ErrNoRows {
+		return false, errors.Wrap(err, "query row context")
+	}
+
+	return exists, nil
+}
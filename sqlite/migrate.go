@@ -0,0 +1,427 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/lopezator/migrator"
+)
+
+// defaultOpts is the default migration options
+var defaultOpts = []migrator.Option{migrator.WithLogger(newNopLogger())}
+
+// Migrate migrates the database to the latest version
+func Migrate(db *DB, opts ...migrator.Option) error {
+	if len(opts) == 0 {
+		opts = defaultOpts
+	}
+
+	opts = append(opts, migrations)
+
+	m, err := migrator.New(opts...)
+	if err != nil {
+		return err
+	}
+
+	return m.Migrate(db.DB)
+}
+
+// nopLogger is a nop logger for migrator
+type nopLogger struct{}
+
+func newNopLogger() migrator.Logger {
+	return &nopLogger{}
+}
+
+func (l *nopLogger) Printf(string, ...interface{}) {}
+
+// migrations are list of database migrations, the amounts are
+// scaled integers and the timestamps are unix nanoseconds
+var migrations = migrator.Migrations(
+	&migrator.Migration{
+		Name: "create accounts table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table accounts (
+				account_id text primary key,
+				currency text not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create ledgers table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table ledgers (
+				ledger_no text primary key,
+				account_type text not null,
+				currency text not null,
+				name text not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create account_transactions table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table account_transactions (
+				xact_no text not null, -- reference number
+				ledger_no text not null references ledgers(ledger_no),
+				xact_type text not null,
+				account_id text not null references accounts(account_id),
+				xact_type_ext text not null,
+				amount integer not null,
+				"desc" text not null default '',
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index account_transactions_xact_no_idx
+				on account_transactions (xact_no)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// used by the paginated transaction history
+			stmnt = `create index account_transactions_account_id_ts_xact_no_idx
+				on account_transactions (account_id, ts, xact_no)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create account_balances table",
+		Func: func(tx *sql.Tx) error {
+			// the running balances are updated on every insert to account_transactions
+			stmnt := `create table account_balances (
+				account_id text primary key references accounts(account_id),
+				total_credit integer not null default 0,
+				total_debit integer not null default 0,
+				current_balance integer not null default 0,
+				ts integer not null -- last update
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create ledger_transactions table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table ledger_transactions (
+				xact_no text not null, -- reference number
+				ledger_no text not null references ledgers(ledger_no),
+				xact_type text not null,
+				amount integer not null,
+				"desc" text not null default '',
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index ledger_transactions_xact_no_idx
+				on ledger_transactions (xact_no)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create fx_transfers table",
+		Func: func(tx *sql.Tx) error {
+			// the rate is stored as text to keep its precision
+			stmnt := `create table fx_transfers (
+				xact_no text primary key, -- reference number
+				from_account text not null references accounts(account_id),
+				to_account text not null references accounts(account_id),
+				from_currency text not null,
+				to_currency text not null,
+				from_amount integer not null,
+				to_amount integer not null,
+				rate text not null,
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create idempotency_keys table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table idempotency_keys (
+				key text primary key,
+				fingerprint text not null, -- sha256 of request
+				xact_no text not null, -- reference number
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create xact_reversals table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table xact_reversals (
+				xact_no text primary key, -- reversal or refund reference number
+				orig_xact_no text not null, -- original reference number
+				xact_type_ext text not null, -- Rv or Rf
+				amount integer not null,
+				reason text not null default '',
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			// a transaction can only be reversed once
+			stmnt = `create unique index xact_reversals_orig_xact_no_rv_idx
+				on xact_reversals (orig_xact_no) where xact_type_ext = 'Rv'`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create holds table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table holds (
+				hold_id text primary key,
+				account_id text not null references accounts(account_id),
+				amount integer not null,
+				status text not null, -- active, captured or voided
+				captured_amount integer not null default 0,
+				xact_no text, -- capture reference number
+				expires_at integer not null,
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index holds_account_id_status_idx
+				on holds (account_id, status)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "add accounts status column",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `alter table accounts
+				add column status text not null default 'active'`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create account_status_changes table",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table account_status_changes (
+				account_id text not null references accounts(account_id),
+				from_status text not null,
+				status text not null,
+				reason text not null,
+				changed_by text not null,
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index account_status_changes_account_id_ts_idx
+				on account_status_changes (account_id, ts)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "add accounts overdraft_limit column",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `alter table accounts
+				add column overdraft_limit integer not null default 0`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+
+	&migrator.Migration{
+		Name: "create transfer_instructions and instruction_runs tables",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table transfer_instructions (
+				instruction_id text primary key,
+				from_account text not null references accounts(account_id),
+				to_account text not null references accounts(account_id),
+				amount integer not null,
+				"desc" text not null default '',
+				frequency text not null,
+				cron_expr text not null default '',
+				start_at integer not null,
+				end_at integer,
+				status text not null,
+				next_run_at integer not null,
+				next_attempt_at integer not null,
+				attempts integer not null default 0,
+				last_error text not null default '',
+				lease_owner text,
+				lease_expires_at integer,
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index transfer_instructions_status_next_attempt_at_idx
+				on transfer_instructions (status, next_attempt_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create table instruction_runs (
+				instruction_id text not null
+					references transfer_instructions(instruction_id),
+				scheduled_at integer not null,
+				attempt integer not null,
+				xact_no text,
+				error text not null default '',
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index instruction_runs_instruction_id_ts_idx
+				on instruction_runs (instruction_id, ts)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create outbox tables",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table outbox_events (
+				event_id integer primary key autoincrement,
+				seq integer unique, -- assigned after commit
+				type text not null,
+				payload text not null,
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index outbox_events_unsequenced_idx
+				on outbox_events (event_id) where seq is null`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create table outbox_cursors (
+				relay text primary key,
+				seq integer not null,
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+	&migrator.Migration{
+		Name: "Create webhook tables",
+		Func: func(tx *sql.Tx) error {
+			stmnt := `create table webhook_subscriptions (
+				subscription_id text primary key,
+				account_id text references accounts(account_id),
+				xact_types text not null default '[]',
+				url text not null,
+				secret text not null,
+				ts integer not null
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create table webhook_deliveries (
+				delivery_id text primary key,
+				subscription_id text not null
+					references webhook_subscriptions(subscription_id)
+						on delete cascade,
+				event_seq integer not null,
+				event_type text not null,
+				payload text not null,
+				status text not null,
+				attempts integer not null default 0,
+				next_attempt_at integer not null,
+				last_status_code integer not null default 0,
+				last_error text not null default '',
+				delivered_at integer,
+				lease_owner text,
+				lease_expires_at integer,
+				ts integer not null,
+				unique(subscription_id, event_seq)
+			)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			stmnt = `create index webhook_deliveries_status_next_attempt_at_idx
+				on webhook_deliveries (status, next_attempt_at)`
+			if _, err := tx.Exec(stmnt); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	},
+)
@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/outbox"
+)
+
+// OutboxRepository implements the outbox repository
+// interface and uses sqlite as back-end
+type OutboxRepository struct{ db *DB }
+
+var _ outbox.Repository = (*OutboxRepository)(nil)
+
+// NewOutboxRepository returns an outbox repository
+func NewOutboxRepository(db *DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// createEvent writes the event to the outbox within tx
+func createEvent(ctx context.Context, txx *Tx, ev *outbox.Event) error {
+	stmnt := `insert into outbox_events (type, payload, ts) values (?, ?, ?)`
+	_, err := txx.conn.ExecContext(ctx, stmnt, ev.Type,
+		string(ev.Payload), timestamp(txx.ts))
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// SequenceEvents assigns the next sequence numbers to the committed
+// events that don't have one, in the order they were written
+func (or *OutboxRepository) SequenceEvents(ctx context.Context) (err error) {
+	// the tx holds the write lock, the events are committed in order
+	txx, err := beginTx(ctx, or.db)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	var lastSeq int64
+	stmnt := `select coalesce(max(seq), 0) from outbox_events`
+	err = txx.conn.QueryRowContext(ctx, stmnt).Scan(&lastSeq)
+	if err != nil {
+		err = errors.Wrap(err, "query row context")
+		return
+	}
+
+	stmnt = `select event_id from outbox_events where seq is null order by event_id`
+	rows, err := txx.conn.QueryContext(ctx, stmnt)
+	if err != nil {
+		err = errors.Wrap(err, "query context")
+		return
+	}
+
+	eventIDs := []int64{}
+	for rows.Next() {
+		var eventID int64
+		err = rows.Scan(&eventID)
+		if err != nil {
+			rows.Close()
+			err = errors.Wrap(err, "scan")
+			return
+		}
+		eventIDs = append(eventIDs, eventID)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		err = errors.Wrap(err, "rows err")
+		return
+	}
+
+	stmnt = `update outbox_events set seq = ? where event_id = ?`
+	for i, eventID := range eventIDs {
+		_, err = txx.conn.ExecContext(ctx, stmnt, lastSeq+int64(i)+1, eventID)
+		if err != nil {
+			err = errors.Wrap(err, "sequence event")
+			return
+		}
+	}
+
+	return nil
+}
+
+// ListEvents retrieves up to n sequenced events after the sequence number
+func (or *OutboxRepository) ListEvents(ctx context.Context,
+	after int64, n int) ([]*outbox.Event, error) {
+	stmnt := `select seq, type, payload, ts from outbox_events
+		where seq > ? order by seq limit ?`
+	rows, err := or.db.QueryContext(ctx, stmnt, after, n)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	evs := []*outbox.Event{}
+	for rows.Next() {
+		var (
+			ev      outbox.Event
+			payload string
+		)
+		err = rows.Scan(&ev.Seq, &ev.Type, &payload, scanTs(&ev.Ts))
+		if err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+		ev.Payload = []byte(payload)
+
+		evs = append(evs, &ev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return evs, nil
+}
+
+// GetCursor retrieves the sequence number of the last event published by the relay
+func (or *OutboxRepository) GetCursor(ctx context.Context, relay string) (int64, error) {
+	stmnt := `select seq from outbox_cursors where relay = ?`
+
+	var seq int64
+	err := or.db.QueryRowContext(ctx, stmnt, relay).Scan(&seq)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, errors.Wrap(err, "query row context")
+	}
+
+	return seq, nil
+}
+
+// SetCursor sets the sequence number of the last event published by the relay
+func (or *OutboxRepository) SetCursor(ctx context.Context, relay string, seq int64) error {
+	stmnt := `insert into outbox_cursors (relay, seq, ts) values (?, ?, ?)
+		on conflict (relay) do update set seq = excluded.seq, ts = excluded.ts`
+	_, err := or.db.ExecContext(ctx, stmnt, relay, seq, timestamp(time.Now()))
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/sqlite"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) (*repotest.Repos, func()) {
+		db, err := sqlite.Open(filepath.Join(t.TempDir(), "kalupi.db"))
+		require.NoError(t, err)
+
+		err = sqlite.Migrate(db)
+		require.NoError(t, err)
+
+		return &repotest.Repos{
+			AccountRepo:  sqlite.NewAccountRepository(db),
+			LedgerRepo:   sqlite.NewLedgerRepository(db),
+			XactRepo:     sqlite.NewXactRepository(db),
+			BalRepo:      sqlite.NewBalanceRepository(db),
+			ScheduleRepo: sqlite.NewScheduleRepository(db),
+			OutboxRepo:   sqlite.NewOutboxRepository(db),
+			WebhookRepo:  sqlite.NewWebhookRepository(db),
+		}, func() { db.Close() }
+	})
+}
@@ -0,0 +1,302 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/schedule"
+)
+
+// ScheduleRepository implements the schedule repository
+// interface and uses sqlite as back-end
+type ScheduleRepository struct{ db *DB }
+
+var _ schedule.Repository = (*ScheduleRepository)(nil)
+
+// NewScheduleRepository returns a schedule repository
+func NewScheduleRepository(db *DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// instructionCols are the columns scanned by scanInstruction
+const instructionCols = `instruction_id, from_account, to_account, amount,
+	"desc", frequency, cron_expr, start_at, end_at, status, next_run_at,
+	next_attempt_at, attempts, last_error, coalesce(lease_owner, ''),
+	lease_expires_at, ts`
+
+// CreateInstruction creates an instruction
+func (sr *ScheduleRepository) CreateInstruction(ctx context.Context,
+	inst schedule.Instruction) error {
+	stmnt := `insert into transfer_instructions (
+			instruction_id, from_account, to_account, amount, "desc",
+			frequency, cron_expr, start_at, end_at, status,
+			next_run_at, next_attempt_at, ts
+		) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := sr.db.ExecContext(ctx, stmnt,
+		inst.InstructionID, inst.FromAccount, inst.ToAccount,
+		amount(inst.Amount), inst.Desc, inst.Frequency, inst.CronExpr,
+		timestamp(inst.StartAt), nullTimestamp(inst.EndAt), inst.Status,
+		timestamp(inst.NextRunAt), timestamp(inst.NextAttemptAt),
+		timestamp(time.Now()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// GetInstruction retrieves the instruction
+func (sr *ScheduleRepository) GetInstruction(ctx context.Context,
+	instID schedule.InstructionID) (*schedule.Instruction, error) {
+	stmnt := `select ` + instructionCols + `
+		from transfer_instructions where instruction_id = ?`
+
+	inst, err := scanInstruction(sr.db.QueryRowContext(ctx, stmnt, instID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, schedule.ErrInstructionNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return inst, nil
+}
+
+// ListInstructions retrieves the instructions sent from or to
+// the account, or all the instructions if the account id is empty
+func (sr *ScheduleRepository) ListInstructions(ctx context.Context,
+	accntID account.AccountID) ([]*schedule.Instruction, error) {
+	stmnt := `select ` + instructionCols + `
+		from transfer_instructions
+		where ? = '' or from_account = ? or to_account = ?
+		order by ts, rowid`
+
+	rows, err := sr.db.QueryContext(ctx, stmnt, accntID, accntID, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanInstructions(rows)
+}
+
+// UpdateInstruction updates the amount, desc, schedule, status,
+// next run and attempts of the instruction unless it is leased
+func (sr *ScheduleRepository) UpdateInstruction(ctx context.Context,
+	inst schedule.Instruction, now time.Time) error {
+	stmnt := `update transfer_instructions set amount = ?, "desc" = ?,
+			frequency = ?, cron_expr = ?, start_at = ?, end_at = ?,
+			status = ?, next_run_at = ?, next_attempt_at = ?, attempts = ?
+		where instruction_id = ?
+			and (lease_expires_at is null or lease_expires_at <= ?)`
+	res, err := sr.db.ExecContext(ctx, stmnt,
+		amount(inst.Amount), inst.Desc, inst.Frequency, inst.CronExpr,
+		timestamp(inst.StartAt), nullTimestamp(inst.EndAt), inst.Status,
+		timestamp(inst.NextRunAt), timestamp(inst.NextAttemptAt),
+		inst.Attempts, inst.InstructionID, timestamp(now),
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		_, err = sr.GetInstruction(ctx, inst.InstructionID)
+		if err != nil {
+			return err
+		}
+		return schedule.ErrInstructionBusy
+	}
+
+	return nil
+}
+
+// LeaseDueInstructions leases up to n active instructions that are due
+func (sr *ScheduleRepository) LeaseDueInstructions(ctx context.Context, owner string,
+	now time.Time, ttl time.Duration, n int) (insts []*schedule.Instruction, err error) {
+	// The tx holds the write lock, the other workers wait until
+	// it's done and won't see the instructions leased by this tx
+	txx, err := beginTx(ctx, sr.db)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	stmnt := `select ` + instructionCols + `
+		from transfer_instructions
+		where status = ? and next_attempt_at <= ?
+			and (lease_expires_at is null or lease_expires_at <= ?)
+		order by next_attempt_at
+		limit ?`
+	rows, err := txx.conn.QueryContext(ctx, stmnt, schedule.StatusActive,
+		timestamp(now), timestamp(now), n)
+	if err != nil {
+		err = errors.Wrap(err, "query context")
+		return
+	}
+
+	insts, err = scanInstructions(rows)
+	rows.Close()
+	if err != nil {
+		return
+	}
+
+	leaseExpiresAt := now.Add(ttl)
+	stmnt = `update transfer_instructions set lease_owner = ?,
+		lease_expires_at = ? where instruction_id = ?`
+	for _, inst := range insts {
+		_, err = txx.conn.ExecContext(ctx, stmnt, owner,
+			timestamp(leaseExpiresAt), inst.InstructionID)
+		if err != nil {
+			err = errors.Wrap(err, "lease instruction")
+			return
+		}
+
+		inst.LeaseOwner = owner
+		inst.LeaseExpiresAt = &leaseExpiresAt
+	}
+
+	return insts, nil
+}
+
+// RecordRun updates the instruction leased by the owner,
+// releases the lease and records the run
+func (sr *ScheduleRepository) RecordRun(ctx context.Context,
+	inst schedule.Instruction, run schedule.Run) (err error) {
+	txx, err := beginTx(ctx, sr.db)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	stmnt := `update transfer_instructions set status = ?, next_run_at = ?,
+			next_attempt_at = ?, attempts = ?, last_error = ?,
+			lease_owner = null, lease_expires_at = null
+		where instruction_id = ? and lease_owner = ?`
+	res, err := txx.conn.ExecContext(ctx, stmnt, inst.Status,
+		timestamp(inst.NextRunAt), timestamp(inst.NextAttemptAt),
+		inst.Attempts, inst.LastError, inst.InstructionID, inst.LeaseOwner,
+	)
+	if err != nil {
+		err = errors.Wrap(err, "update instruction")
+		return
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		err = errors.Wrap(err, "rows affected")
+		return
+	}
+
+	if n == 0 {
+		err = schedule.ErrLeaseLost
+		return
+	}
+
+	stmnt = `insert into instruction_runs (
+			instruction_id, scheduled_at, attempt, xact_no, error, ts
+		) values (?, ?, ?, nullif(?, ''), ?, ?)`
+	_, err = txx.conn.ExecContext(ctx, stmnt, run.InstructionID,
+		timestamp(run.ScheduledAt), run.Attempt, run.XactNo,
+		run.Error, timestamp(txx.ts))
+	if err != nil {
+		err = errors.Wrap(err, "insert run")
+		return
+	}
+
+	return nil
+}
+
+// ListRuns retrieves the runs of the instruction
+func (sr *ScheduleRepository) ListRuns(ctx context.Context,
+	instID schedule.InstructionID) ([]*schedule.Run, error) {
+	stmnt := `select instruction_id, scheduled_at, attempt,
+			coalesce(xact_no, ''), error, ts
+		from instruction_runs where instruction_id = ? order by ts, rowid`
+
+	rows, err := sr.db.QueryContext(ctx, stmnt, instID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	runs := []*schedule.Run{}
+	for rows.Next() {
+		var run schedule.Run
+		err = rows.Scan(&run.InstructionID, scanTime(&run.ScheduledAt),
+			&run.Attempt, &run.XactNo, &run.Error, scanTs(&run.Ts))
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		runs = append(runs, &run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return runs, nil
+}
+
+// scanner is a sql row or rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanInstruction scans the instruction row
+func scanInstruction(row scanner) (*schedule.Instruction, error) {
+	var inst schedule.Instruction
+	err := row.Scan(
+		&inst.InstructionID, &inst.FromAccount, &inst.ToAccount,
+		scanAmount(&inst.Amount), &inst.Desc, &inst.Frequency,
+		&inst.CronExpr, scanTime(&inst.StartAt), scanTs(&inst.EndAt),
+		&inst.Status, scanTime(&inst.NextRunAt), scanTime(&inst.NextAttemptAt),
+		&inst.Attempts, &inst.LastError, &inst.LeaseOwner,
+		scanTs(&inst.LeaseExpiresAt), scanTs(&inst.Ts),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &inst, nil
+}
+
+// scanInstructions scans the instruction rows
+func scanInstructions(rows *sql.Rows) ([]*schedule.Instruction, error) {
+	insts := []*schedule.Instruction{}
+	for rows.Next() {
+		inst, err := scanInstruction(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		insts = append(insts, inst)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "rows err")
+	}
+
+	return insts, nil
+}
@@ -0,0 +1,261 @@
+// Package sqlite implements the repositories and uses sqlite as back-end,
+// it is used for local development and embedded deployments.
+//
+// The amounts are stored as integers scaled by 10^4 so that the sums
+// and comparisons are exact i.e. 1.2345 is stored as 12345, and the
+// timestamps are stored as unix nanoseconds.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/multierr"
+	sqlitedrv "modernc.org/sqlite"
+
+	"github.com/stevenferrer/kalupi/etc/tx"
+)
+
+// pragmas are run on every new connection
+var pragmas = []string{
+	"pragma foreign_keys = on",
+	// wait up to 5s for the write lock
+	"pragma busy_timeout = 5000",
+	// the readers don't block the writer and vice versa
+	"pragma journal_mode = wal",
+}
+
+// DB is a sqlite database. The txs are serialized so that waiting for the
+// write lock honors the context, the driver retries a busy database until
+// the lock is released.
+type DB struct {
+	*sql.DB
+	// sem is held by the running tx
+	sem chan struct{}
+}
+
+// Open opens the sqlite database file
+func Open(file string) (*DB, error) {
+	db := sql.OpenDB(&connector{file: file, drv: &sqlitedrv.Driver{}})
+
+	err := db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &DB{DB: db, sem: make(chan struct{}, 1)}, nil
+}
+
+// connector runs the pragmas on every new connection
+type connector struct {
+	file string
+	drv  driver.Driver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.drv.Open(c.file)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("expecting conn to be driver.ExecerContext")
+	}
+
+	for _, pragma := range pragmas {
+		_, err = execer.ExecContext(ctx, pragma, nil)
+		if err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, pragma)
+		}
+	}
+
+	return conn, nil
+}
+
+func (c *connector) Driver() driver.Driver { return c.drv }
+
+// Tx is a sqlite tx. It holds the write lock of the database until it is
+// committed or rolled back, hence, the txs are serialized and the rows read
+// within a tx are effectively locked.
+type Tx struct {
+	db   *DB
+	conn *sql.Conn
+	// ts is the start of the tx, used as the timestamp of the rows
+	ts   time.Time
+	done bool
+}
+
+var _ tx.Tx = (*Tx)(nil)
+
+// beginTx begins a new tx on a dedicated connection,
+// it waits for the running tx to finish
+func beginTx(ctx context.Context, db *DB) (*Tx, error) {
+	select {
+	case db.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		<-db.sem
+		return nil, errors.Wrap(err, "conn")
+	}
+
+	_, err = conn.ExecContext(ctx, "begin immediate")
+	if err != nil {
+		conn.Close()
+		<-db.sem
+		return nil, errors.Wrap(err, "begin immediate")
+	}
+
+	return &Tx{db: db, conn: conn, ts: time.Now()}, nil
+}
+
+// Commit commits the tx
+func (t *Tx) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	defer func() { <-t.db.sem }()
+
+	ctx := context.Background()
+	_, err := t.conn.ExecContext(ctx, "commit")
+	if err != nil {
+		// don't return the connection to the pool with a running tx
+		_, rbErr := t.conn.ExecContext(ctx, "rollback")
+		return multierr.Combine(errors.Wrap(err, "commit"), rbErr, t.conn.Close())
+	}
+
+	return t.conn.Close()
+}
+
+// Rollback aborts the tx
+func (t *Tx) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	defer func() { <-t.db.sem }()
+
+	_, err := t.conn.ExecContext(context.Background(), "rollback")
+	return multierr.Combine(err, t.conn.Close())
+}
+
+// asTx asserts that the tx is a running sqlite tx
+func asTx(tx tx.Tx) (*Tx, error) {
+	txx, ok := tx.(*Tx)
+	if !ok {
+		return nil, errors.New("expecting tx to be *sqlite.Tx")
+	}
+
+	if txx.done {
+		return nil, sql.ErrTxDone
+	}
+
+	return txx, nil
+}
+
+// amountScale is the number of decimal places of the amounts
+const amountScale = 4
+
+// maxAmount is the exclusive limit of the amount, same as numeric(15, 4)
+var maxAmount = decimal.New(1, 15-amountScale)
+
+// amountValuer converts an amount to a scaled integer
+type amountValuer struct{ d decimal.Decimal }
+
+// amount returns the scaled amount, rounded like numeric(15, 4),
+// the conversion fails if the amount doesn't fit in numeric(15, 4)
+func amount(d decimal.Decimal) driver.Valuer {
+	return amountValuer{d: d}
+}
+
+func (v amountValuer) Value() (driver.Value, error) {
+	d := v.d.Round(amountScale)
+	if d.Abs().GreaterThanOrEqual(maxAmount) {
+		return nil, errors.Errorf("amount %s is out of range", v.d)
+	}
+
+	return d.Shift(amountScale).IntPart(), nil
+}
+
+// timestamp returns the unix nanoseconds of the time
+func timestamp(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+// nullTimestamp returns the unix nanoseconds of the time, or nil
+func nullTimestamp(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return timestamp(*t)
+}
+
+// amountScanner scans a scaled amount into a decimal
+type amountScanner struct{ d *decimal.Decimal }
+
+func scanAmount(d *decimal.Decimal) sql.Scanner {
+	return amountScanner{d: d}
+}
+
+func (s amountScanner) Scan(src interface{}) error {
+	n, ok := src.(int64)
+	if !ok {
+		return errors.Errorf("expecting amount to be int64, got %T", src)
+	}
+
+	*s.d = decimal.New(n, -amountScale)
+	return nil
+}
+
+// timeScanner scans unix nanoseconds into a time
+type timeScanner struct{ t *time.Time }
+
+func scanTime(t *time.Time) sql.Scanner {
+	return timeScanner{t: t}
+}
+
+func (s timeScanner) Scan(src interface{}) error {
+	n, ok := src.(int64)
+	if !ok {
+		return errors.Errorf("expecting timestamp to be int64, got %T", src)
+	}
+
+	*s.t = time.Unix(0, n).UTC()
+	return nil
+}
+
+// tsScanner scans unix nanoseconds into a time pointer
+type tsScanner struct{ ts **time.Time }
+
+func scanTs(ts **time.Time) sql.Scanner {
+	return tsScanner{ts: ts}
+}
+
+func (s tsScanner) Scan(src interface{}) error {
+	if src == nil {
+		*s.ts = nil
+		return nil
+	}
+
+	var t time.Time
+	err := timeScanner{t: &t}.Scan(src)
+	if err != nil {
+		return err
+	}
+
+	*s.ts = &t
+	return nil
+}
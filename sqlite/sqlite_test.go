@@ -0,0 +1,144 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/sqlite"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+func TestAmounts(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "kalupi.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = sqlite.Migrate(db)
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+	johnDoe := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err = sqlite.NewAccountRepository(db).CreateAccount(ctx, johnDoe)
+	require.NoError(t, err)
+
+	err = ledger.NewService(sqlite.NewLedgerRepository(db), currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	xactRepo := sqlite.NewXactRepository(db)
+	balRepo := sqlite.NewBalanceRepository(db)
+
+	deposit := func(t *testing.T, amount string) transaction.XactNo {
+		xactNo, err := transaction.NewXactNo()
+		require.NoError(t, err)
+
+		tx, err := xactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		err = xactRepo.CreateXact(ctx, tx, transaction.Transaction{
+			XactNo:      xactNo,
+			LedgerNo:    ledger.CashUSDLedgerNo,
+			XactType:    transaction.XactTypeDebit,
+			AccountID:   johnDoe.AccountID,
+			XactTypeExt: transaction.XactTypeExtDeposit,
+			Amount:      decimal.RequireFromString(amount),
+		})
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		return xactNo
+	}
+
+	tests := []struct {
+		amount, want string
+	}{
+		{"0.1", "0.1"},
+		{"0.2", "0.2"},
+		{"1.2345", "1.2345"},
+		// rounded like numeric(15, 4)
+		{"1.23456", "1.2346"},
+		{"99999999999.9999", "99999999999.9999"},
+	}
+
+	total := decimal.Zero
+	for _, tc := range tests {
+		xactNo := deposit(t, tc.amount)
+
+		xact, err := xactRepo.GetXact(ctx, xactNo)
+		require.NoError(t, err)
+		require.Len(t, xact.Legs, 1)
+		want := decimal.RequireFromString(tc.want)
+		assert.True(t, want.Equal(xact.Legs[0].Amount),
+			"want %s, got %s", want, xact.Legs[0].Amount)
+
+		total = total.Add(want)
+	}
+
+	tx, err := balRepo.BeginTx(ctx)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, tx.Commit())
+	}()
+
+	// the sums are exact
+	bal, err := balRepo.GetAccntBalForUpdate(ctx, tx, johnDoe.AccountID)
+	require.NoError(t, err)
+	assert.True(t, total.Equal(bal.CurrentBal),
+		"want %s, got %s", total, bal.CurrentBal)
+	assert.True(t, total.Equal(bal.TotalCredit))
+	assert.True(t, bal.TotalDebit.IsZero())
+}
+
+func TestTx(t *testing.T) {
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "kalupi.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = sqlite.Migrate(db)
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+	xactRepo := sqlite.NewXactRepository(db)
+
+	t.Run("serialized", func(t *testing.T) {
+		tx, err := xactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+
+		// blocks until the running tx is done
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, err = xactRepo.BeginTx(timeoutCtx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		err = tx.Rollback()
+		require.NoError(t, err)
+
+		tx, err = xactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+	})
+
+	t.Run("done", func(t *testing.T) {
+		tx, err := xactRepo.BeginTx(ctx)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit())
+
+		assert.ErrorIs(t, tx.Commit(), sql.ErrTxDone)
+		assert.ErrorIs(t, tx.Rollback(), sql.ErrTxDone)
+
+		_, err = xactRepo.ListXactLegs(ctx, tx, "xact")
+		assert.ErrorIs(t, err, sql.ErrTxDone)
+	})
+}
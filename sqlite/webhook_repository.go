@@ -0,0 +1,369 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+// WebhookRepository implements the webhook repository
+// interface and uses sqlite as back-end
+type WebhookRepository struct{ db *DB }
+
+var _ webhook.Repository = (*WebhookRepository)(nil)
+
+// NewWebhookRepository returns a webhook repository
+func NewWebhookRepository(db *DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// subscriptionCols are the columns scanned by scanSubscription
+const subscriptionCols = `subscription_id, coalesce(account_id, ''),
+	xact_types, url, secret, ts`
+
+// deliveryCols are the columns scanned by scanDelivery
+const deliveryCols = `delivery_id, subscription_id, event_seq, event_type,
+	payload, status, attempts, next_attempt_at, last_status_code, last_error,
+	delivered_at, coalesce(lease_owner, ''), lease_expires_at, ts`
+
+// CreateSubscription creates a subscription
+func (wr *WebhookRepository) CreateSubscription(ctx context.Context,
+	sub webhook.Subscription) error {
+	xactTypes, err := marshalXactTypes(sub.XactTypeExts)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `insert into webhook_subscriptions (
+			subscription_id, account_id, xact_types, url, secret, ts
+		) values (?, nullif(?, ''), ?, ?, ?, ?)`
+	_, err = wr.db.ExecContext(ctx, stmnt, sub.SubscriptionID, sub.AccountID,
+		xactTypes, sub.URL, sub.Secret, timestamp(time.Now()))
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// GetSubscription retrieves the subscription
+func (wr *WebhookRepository) GetSubscription(ctx context.Context,
+	subID webhook.SubscriptionID) (*webhook.Subscription, error) {
+	stmnt := `select ` + subscriptionCols + `
+		from webhook_subscriptions where subscription_id = ?`
+
+	sub, err := scanSubscription(wr.db.QueryRowContext(ctx, stmnt, subID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, webhook.ErrSubscriptionNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return sub, nil
+}
+
+// ListSubscriptions retrieves the subscriptions to the account and
+// to all the accounts, or all the subscriptions if the account id is empty
+func (wr *WebhookRepository) ListSubscriptions(ctx context.Context,
+	accntID account.AccountID) ([]*webhook.Subscription, error) {
+	stmnt := `select ` + subscriptionCols + `
+		from webhook_subscriptions
+		where ? = '' or account_id is null or account_id = ?
+		order by ts, rowid`
+
+	rows, err := wr.db.QueryContext(ctx, stmnt, accntID, accntID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	subs := []*webhook.Subscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+// DeleteSubscription deletes the subscription and its deliveries
+func (wr *WebhookRepository) DeleteSubscription(ctx context.Context,
+	subID webhook.SubscriptionID) error {
+	// the deliveries are deleted on cascade
+	stmnt := `delete from webhook_subscriptions where subscription_id = ?`
+	res, err := wr.db.ExecContext(ctx, stmnt, subID)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return webhook.ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// CreateDeliveries creates the deliveries that don't exist
+func (wr *WebhookRepository) CreateDeliveries(ctx context.Context,
+	deliveries []webhook.Delivery) (err error) {
+	txx, err := beginTx(ctx, wr.db)
+	if err != nil {
+		return errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	stmnt := `insert into webhook_deliveries (
+			delivery_id, subscription_id, event_seq, event_type,
+			payload, status, next_attempt_at, ts
+		) values (?, ?, ?, ?, ?, ?, ?, ?)
+		on conflict (subscription_id, event_seq) do nothing`
+	for _, d := range deliveries {
+		_, err = txx.conn.ExecContext(ctx, stmnt, d.DeliveryID,
+			d.SubscriptionID, d.EventSeq, d.EventType, string(d.Payload),
+			d.Status, timestamp(d.NextAttemptAt), timestamp(txx.ts))
+		if err != nil {
+			err = errors.Wrap(err, "insert delivery")
+			return
+		}
+	}
+
+	return nil
+}
+
+// GetDelivery retrieves the delivery
+func (wr *WebhookRepository) GetDelivery(ctx context.Context,
+	deliveryID webhook.DeliveryID) (*webhook.Delivery, error) {
+	stmnt := `select ` + deliveryCols + `
+		from webhook_deliveries where delivery_id = ?`
+
+	d, err := scanDelivery(wr.db.QueryRowContext(ctx, stmnt, deliveryID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, webhook.ErrDeliveryNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return d, nil
+}
+
+// ListDeliveries retrieves the deliveries to the subscription
+func (wr *WebhookRepository) ListDeliveries(ctx context.Context,
+	subID webhook.SubscriptionID) ([]*webhook.Delivery, error) {
+	stmnt := `select ` + deliveryCols + `
+		from webhook_deliveries where subscription_id = ?
+		order by event_seq`
+
+	rows, err := wr.db.QueryContext(ctx, stmnt, subID)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+// UpdateDelivery updates the status, attempts and
+// next attempt of the delivery unless it is leased
+func (wr *WebhookRepository) UpdateDelivery(ctx context.Context,
+	d webhook.Delivery, now time.Time) error {
+	stmnt := `update webhook_deliveries set status = ?,
+			attempts = ?, next_attempt_at = ?
+		where delivery_id = ?
+			and (lease_expires_at is null or lease_expires_at <= ?)`
+	res, err := wr.db.ExecContext(ctx, stmnt, d.Status, d.Attempts,
+		timestamp(d.NextAttemptAt), d.DeliveryID, timestamp(now))
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		_, err = wr.GetDelivery(ctx, d.DeliveryID)
+		if err != nil {
+			return err
+		}
+		return webhook.ErrDeliveryBusy
+	}
+
+	return nil
+}
+
+// LeaseDueDeliveries leases up to n pending deliveries that are due
+func (wr *WebhookRepository) LeaseDueDeliveries(ctx context.Context, owner string,
+	now time.Time, ttl time.Duration, n int) (deliveries []*webhook.Delivery, err error) {
+	// The tx holds the write lock, the other workers wait until
+	// it's done and won't see the deliveries leased by this tx
+	txx, err := beginTx(ctx, wr.db)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		if err != nil {
+			_ = txx.Rollback()
+			return
+		}
+		err = multierr.Combine(err, txx.Commit())
+	}()
+
+	stmnt := `select ` + deliveryCols + `
+		from webhook_deliveries
+		where status = ? and next_attempt_at <= ?
+			and (lease_expires_at is null or lease_expires_at <= ?)
+		order by next_attempt_at, event_seq
+		limit ?`
+	rows, err := txx.conn.QueryContext(ctx, stmnt, webhook.StatusPending,
+		timestamp(now), timestamp(now), n)
+	if err != nil {
+		err = errors.Wrap(err, "query context")
+		return
+	}
+
+	deliveries, err = scanDeliveries(rows)
+	rows.Close()
+	if err != nil {
+		return
+	}
+
+	leaseExpiresAt := now.Add(ttl)
+	stmnt = `update webhook_deliveries set lease_owner = ?,
+		lease_expires_at = ? where delivery_id = ?`
+	for _, d := range deliveries {
+		_, err = txx.conn.ExecContext(ctx, stmnt, owner,
+			timestamp(leaseExpiresAt), d.DeliveryID)
+		if err != nil {
+			err = errors.Wrap(err, "lease delivery")
+			return
+		}
+
+		d.LeaseOwner = owner
+		d.LeaseExpiresAt = &leaseExpiresAt
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt updates the delivery leased by the owner and releases the lease
+func (wr *WebhookRepository) RecordAttempt(ctx context.Context, d webhook.Delivery) error {
+	stmnt := `update webhook_deliveries set status = ?, attempts = ?,
+			next_attempt_at = ?, last_status_code = ?, last_error = ?,
+			delivered_at = ?, lease_owner = null, lease_expires_at = null
+		where delivery_id = ? and lease_owner = ?`
+	res, err := wr.db.ExecContext(ctx, stmnt, d.Status, d.Attempts,
+		timestamp(d.NextAttemptAt), d.LastStatusCode, d.LastError,
+		nullTimestamp(d.DeliveredAt), d.DeliveryID, d.LeaseOwner,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "rows affected")
+	}
+
+	if n == 0 {
+		return webhook.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// marshalXactTypes returns the json encoded transaction types
+func marshalXactTypes(xactTypes []transaction.XactTypeExt) (string, error) {
+	if xactTypes == nil {
+		xactTypes = []transaction.XactTypeExt{}
+	}
+
+	b, err := json.Marshal(xactTypes)
+	if err != nil {
+		return "", errors.Wrap(err, "json marshal")
+	}
+
+	return string(b), nil
+}
+
+// scanSubscription scans the subscription row
+func scanSubscription(row scanner) (*webhook.Subscription, error) {
+	var (
+		sub       webhook.Subscription
+		xactTypes string
+	)
+	err := row.Scan(&sub.SubscriptionID, &sub.AccountID,
+		&xactTypes, &sub.URL, &sub.Secret, scanTs(&sub.Ts))
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal([]byte(xactTypes), &sub.XactTypeExts)
+	if err != nil {
+		return nil, errors.Wrap(err, "json unmarshal")
+	}
+
+	if len(sub.XactTypeExts) == 0 {
+		sub.XactTypeExts = nil
+	}
+
+	return &sub, nil
+}
+
+// scanDelivery scans the delivery row
+func scanDelivery(row scanner) (*webhook.Delivery, error) {
+	var (
+		d       webhook.Delivery
+		payload string
+	)
+	err := row.Scan(
+		&d.DeliveryID, &d.SubscriptionID, &d.EventSeq, &d.EventType,
+		&payload, &d.Status, &d.Attempts, scanTime(&d.NextAttemptAt),
+		&d.LastStatusCode, &d.LastError, scanTs(&d.DeliveredAt),
+		&d.LeaseOwner, scanTs(&d.LeaseExpiresAt), scanTs(&d.Ts),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Payload = json.RawMessage(payload)
+	return &d, nil
+}
+
+// scanDeliveries scans the delivery rows
+func scanDeliveries(rows *sql.Rows) ([]*webhook.Delivery, error) {
+	deliveries := []*webhook.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,646 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// XactRepository implements the account transaction repository
+// interface and uses sqlite as back-end
+type XactRepository struct{ db *DB }
+
+var _ transaction.Repository = (*XactRepository)(nil)
+
+// NewXactRepository returns an account transaction repository
+func NewXactRepository(db *DB) *XactRepository {
+	return &XactRepository{db: db}
+}
+
+// BeginTx begins a new tx
+func (tr *XactRepository) BeginTx(ctx context.Context) (tx.Tx, error) {
+	return beginTx(ctx, tr.db)
+}
+
+// CreateXact creates an account transaction within a tx
+func (tr *XactRepository) CreateXact(ctx context.Context,
+	tx tx.Tx, xact transaction.Transaction) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `insert into account_transactions (
+			xact_no, ledger_no, xact_type,
+			account_id, xact_type_ext, amount, "desc", ts
+		) values (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = txx.conn.ExecContext(ctx, stmnt,
+		xact.XactNo, xact.LedgerNo, xact.XactType,
+		xact.AccountID, xact.XactTypeExt,
+		amount(xact.Amount), xact.Desc, timestamp(txx.ts),
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	// a ledger's debit is the account's credit and vice versa
+	credit, debit := decimal.Zero, decimal.Zero
+	if xact.XactType == transaction.XactTypeDebit {
+		credit = xact.Amount
+	} else {
+		debit = xact.Amount
+	}
+
+	// update the running balance of the account
+	stmnt = `insert into account_balances (
+			account_id, total_credit, total_debit, current_balance, ts
+		) values (?, ?, ?, ?, ?)
+		on conflict (account_id) do update set
+			total_credit = account_balances.total_credit + excluded.total_credit,
+			total_debit = account_balances.total_debit + excluded.total_debit,
+			current_balance = account_balances.current_balance + excluded.current_balance,
+			ts = excluded.ts`
+	_, err = txx.conn.ExecContext(ctx, stmnt,
+		xact.AccountID, amount(credit), amount(debit),
+		amount(credit.Sub(debit)), timestamp(txx.ts),
+	)
+	if err != nil {
+		return errors.Wrap(err, "update account balance")
+	}
+
+	// the event is published once the tx is committed
+	ts := txx.ts.UTC()
+	xact.Ts = &ts
+	ev, err := transaction.NewXactEvent(xact)
+	if err != nil {
+		return errors.Wrap(err, "new xact event")
+	}
+
+	err = createEvent(ctx, txx, ev)
+	if err != nil {
+		return errors.Wrap(err, "create event")
+	}
+
+	return nil
+}
+
+// CreateLedgerXact creates a ledger transaction within a tx
+func (tr *XactRepository) CreateLedgerXact(ctx context.Context,
+	tx tx.Tx, lx transaction.LedgerXact) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `insert into ledger_transactions (
+			xact_no, ledger_no, xact_type, amount, "desc", ts
+		) values (?, ?, ?, ?, ?, ?)`
+	_, err = txx.conn.ExecContext(ctx, stmnt,
+		lx.XactNo, lx.LedgerNo, lx.XactType,
+		amount(lx.Amount), lx.Desc, timestamp(txx.ts),
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// CreateFXTransfer records the fx details of a transfer within a tx
+func (tr *XactRepository) CreateFXTransfer(ctx context.Context,
+	tx tx.Tx, fxTr transaction.FXTransfer) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `insert into fx_transfers (
+			xact_no, from_account, to_account, from_currency,
+			to_currency, from_amount, to_amount, rate, ts
+		) values (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = txx.conn.ExecContext(ctx, stmnt,
+		fxTr.XactNo, fxTr.FromAccount, fxTr.ToAccount,
+		fxTr.FromCurrency, fxTr.ToCurrency,
+		amount(fxTr.FromAmount), amount(fxTr.ToAmount),
+		fxTr.Rate.String(), timestamp(txx.ts),
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// CreateIdempotencyKeyIfNotExists creates the idempotency key
+// within a tx, returns false if the key already exists
+func (tr *XactRepository) CreateIdempotencyKeyIfNotExists(ctx context.Context,
+	tx tx.Tx, ik transaction.IdempotencyKey) (bool, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return false, err
+	}
+
+	stmnt := `insert into idempotency_keys (key, fingerprint, xact_no, ts)
+		values (?, ?, ?, ?) on conflict (key) do nothing`
+	res, err := txx.conn.ExecContext(ctx, stmnt, ik.Key,
+		ik.Fingerprint, ik.XactNo, timestamp(txx.ts))
+	if err != nil {
+		return false, errors.Wrap(err, "exec context")
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrap(err, "rows affected")
+	}
+
+	return n == 1, nil
+}
+
+// GetIdempotencyKey retrieves the idempotency key within a tx
+func (tr *XactRepository) GetIdempotencyKey(ctx context.Context,
+	tx tx.Tx, key string) (*transaction.IdempotencyKey, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmnt := `select key, fingerprint, xact_no, ts
+		from idempotency_keys where key = ?`
+
+	var ik transaction.IdempotencyKey
+	err = txx.conn.QueryRowContext(ctx, stmnt, key).
+		Scan(&ik.Key, &ik.Fingerprint, &ik.XactNo, scanTs(&ik.Ts))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, transaction.ErrIdempotencyKeyNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &ik, nil
+}
+
+// GetFXTransfer retrieves the fx details of a transfer
+func (tr *XactRepository) GetFXTransfer(ctx context.Context,
+	xactNo transaction.XactNo) (*transaction.FXTransfer, error) {
+	stmnt := `select xact_no, from_account, to_account, from_currency,
+			to_currency, from_amount, to_amount, rate, ts
+		from fx_transfers where xact_no = ?`
+
+	var fxTr transaction.FXTransfer
+	err := tr.db.QueryRowContext(ctx, stmnt, xactNo).Scan(
+		&fxTr.XactNo, &fxTr.FromAccount, &fxTr.ToAccount,
+		&fxTr.FromCurrency, &fxTr.ToCurrency,
+		scanAmount(&fxTr.FromAmount), scanAmount(&fxTr.ToAmount),
+		&fxTr.Rate, scanTs(&fxTr.Ts),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &fxTr, nil
+}
+
+// GetXact retrieves the transaction and all of its legs
+func (tr *XactRepository) GetXact(ctx context.Context,
+	xactNo transaction.XactNo) (*transaction.Xact, error) {
+	legs, err := queryXactLegs(ctx, tr.db, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query xact legs")
+	}
+
+	if len(legs) == 0 {
+		return nil, transaction.ErrXactNotFound
+	}
+
+	lgLegs, err := queryLedgerXactLegs(ctx, tr.db, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query ledger xact legs")
+	}
+
+	return &transaction.Xact{
+		XactNo:     xactNo,
+		Legs:       legs,
+		LedgerLegs: lgLegs,
+		Ts:         legs[0].Ts,
+	}, nil
+}
+
+// ListXactLegs retrieves the account transactions sharing the xact no within a tx
+func (tr *XactRepository) ListXactLegs(ctx context.Context, tx tx.Tx,
+	xactNo transaction.XactNo) ([]*transaction.Transaction, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryXactLegs(ctx, txx.conn, xactNo)
+}
+
+// ListLedgerXactLegs retrieves the ledger transactions sharing the xact no within a tx
+func (tr *XactRepository) ListLedgerXactLegs(ctx context.Context, tx tx.Tx,
+	xactNo transaction.XactNo) ([]*transaction.LedgerXact, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return queryLedgerXactLegs(ctx, txx.conn, xactNo)
+}
+
+// queryer is implemented by both *sql.DB and *sql.Conn
+type queryer interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}
+
+// queryXactLegs retrieves the account transactions sharing the xact no
+func queryXactLegs(ctx context.Context, q queryer,
+	xactNo transaction.XactNo) ([]*transaction.Transaction, error) {
+	stmnt := `select xact_no, ledger_no, xact_type, account_id,
+		xact_type_ext, amount, "desc", ts from account_transactions
+		where xact_no = ? order by ts, rowid`
+
+	rows, err := q.QueryContext(ctx, stmnt, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanXacts(rows)
+}
+
+// queryLedgerXactLegs retrieves the ledger transactions sharing the xact no
+func queryLedgerXactLegs(ctx context.Context, q queryer,
+	xactNo transaction.XactNo) ([]*transaction.LedgerXact, error) {
+	stmnt := `select xact_no, ledger_no, xact_type, amount, "desc", ts
+		from ledger_transactions where xact_no = ? order by ts, rowid`
+
+	rows, err := q.QueryContext(ctx, stmnt, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	lxs := []*transaction.LedgerXact{}
+	for rows.Next() {
+		var lx transaction.LedgerXact
+		err = rows.Scan(
+			&lx.XactNo, &lx.LedgerNo,
+			&lx.XactType, scanAmount(&lx.Amount),
+			&lx.Desc, scanTs(&lx.Ts),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		lxs = append(lxs, &lx)
+	}
+
+	return lxs, rows.Err()
+}
+
+// scanXacts scans the account transaction rows
+func scanXacts(rows *sql.Rows) ([]*transaction.Transaction, error) {
+	xacts := []*transaction.Transaction{}
+	for rows.Next() {
+		var xact transaction.Transaction
+		err := rows.Scan(
+			&xact.XactNo, &xact.LedgerNo,
+			&xact.XactType, &xact.AccountID,
+			&xact.XactTypeExt, scanAmount(&xact.Amount),
+			&xact.Desc, scanTs(&xact.Ts),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		xacts = append(xacts, &xact)
+	}
+
+	return xacts, rows.Err()
+}
+
+// CreateReversal creates a reversal within a tx
+func (tr *XactRepository) CreateReversal(ctx context.Context,
+	tx tx.Tx, rev transaction.Reversal) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `insert into xact_reversals (
+			xact_no, orig_xact_no, xact_type_ext, amount, reason, ts
+		) values (?, ?, ?, ?, ?, ?)`
+	_, err = txx.conn.ExecContext(ctx, stmnt,
+		rev.XactNo, rev.OrigXactNo, rev.XactTypeExt,
+		amount(rev.Amount), rev.Reason, timestamp(txx.ts),
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// ListReversals retrieves the reversals of the original transaction within a tx
+func (tr *XactRepository) ListReversals(ctx context.Context, tx tx.Tx,
+	origXactNo transaction.XactNo) ([]*transaction.Reversal, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmnt := `select xact_no, orig_xact_no, xact_type_ext, amount, reason, ts
+		from xact_reversals where orig_xact_no = ? order by ts, rowid`
+
+	rows, err := txx.conn.QueryContext(ctx, stmnt, origXactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	revs := []*transaction.Reversal{}
+	for rows.Next() {
+		var rev transaction.Reversal
+		err = rows.Scan(
+			&rev.XactNo, &rev.OrigXactNo,
+			&rev.XactTypeExt, scanAmount(&rev.Amount),
+			&rev.Reason, scanTs(&rev.Ts),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		revs = append(revs, &rev)
+	}
+
+	return revs, rows.Err()
+}
+
+// CreateHold creates a hold within a tx
+func (tr *XactRepository) CreateHold(ctx context.Context,
+	tx tx.Tx, hold transaction.Hold) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `insert into holds (
+			hold_id, account_id, amount, status, expires_at, ts
+		) values (?, ?, ?, ?, ?, ?)`
+	_, err = txx.conn.ExecContext(ctx, stmnt,
+		hold.HoldID, hold.AccountID, amount(hold.Amount),
+		hold.Status, timestamp(hold.ExpiresAt), timestamp(txx.ts),
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// GetHold retrieves the hold
+func (tr *XactRepository) GetHold(ctx context.Context,
+	holdID transaction.HoldID) (*transaction.Hold, error) {
+	stmnt := `select hold_id, account_id, amount, status, captured_amount,
+			coalesce(xact_no, ''), expires_at, ts
+		from holds where hold_id = ?`
+
+	return scanHold(tr.db.QueryRowContext(ctx, stmnt, holdID))
+}
+
+// GetHoldForUpdate retrieves the hold within a tx, the
+// hold is effectively locked until the tx is done
+func (tr *XactRepository) GetHoldForUpdate(ctx context.Context,
+	tx tx.Tx, holdID transaction.HoldID) (*transaction.Hold, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmnt := `select hold_id, account_id, amount, status, captured_amount,
+			coalesce(xact_no, ''), expires_at, ts
+		from holds where hold_id = ?`
+
+	return scanHold(txx.conn.QueryRowContext(ctx, stmnt, holdID))
+}
+
+// scanHold scans the hold row
+func scanHold(row *sql.Row) (*transaction.Hold, error) {
+	var hold transaction.Hold
+	err := row.Scan(
+		&hold.HoldID, &hold.AccountID,
+		scanAmount(&hold.Amount), &hold.Status,
+		scanAmount(&hold.CapturedAmount), &hold.XactNo,
+		scanTime(&hold.ExpiresAt), scanTs(&hold.Ts),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, transaction.ErrHoldNotFound
+		}
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &hold, nil
+}
+
+// UpdateHold updates the status, captured amount
+// and capture xact no of the hold within a tx
+func (tr *XactRepository) UpdateHold(ctx context.Context,
+	tx tx.Tx, hold transaction.Hold) error {
+	txx, err := asTx(tx)
+	if err != nil {
+		return err
+	}
+
+	stmnt := `update holds set status = ?, captured_amount = ?,
+		xact_no = nullif(?, '') where hold_id = ?`
+	_, err = txx.conn.ExecContext(ctx, stmnt,
+		hold.Status, amount(hold.CapturedAmount),
+		hold.XactNo, hold.HoldID,
+	)
+	if err != nil {
+		return errors.Wrap(err, "exec context")
+	}
+
+	return nil
+}
+
+// ListXacts retrieves the list of account transactions
+func (tr *XactRepository) ListXacts(ctx context.Context) ([]*transaction.Transaction, error) {
+	stmnt := `select xact_no, ledger_no, xact_type,
+			account_id, xact_type_ext, amount, "desc", ts
+		from account_transactions order by ts, rowid`
+
+	rows, err := tr.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanXacts(rows)
+}
+
+// ListTransfers retrieves the list of transfer related account transactions,
+// including the fees of the transfers
+func (tr *XactRepository) ListTransfers(ctx context.Context) ([]*transaction.Transaction, error) {
+	stmnt := `select xact_no, ledger_no, xact_type, account_id,
+		xact_type_ext, amount, "desc", ts from account_transactions
+		where xact_type_ext in ('STr', 'RTr') or (xact_type_ext = 'Fe' and
+			xact_no in (select xact_no from account_transactions where xact_type_ext = 'STr'))
+		order by ts, rowid`
+
+	rows, err := tr.db.QueryContext(ctx, stmnt)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanXacts(rows)
+}
+
+// ListAccntXacts retrieves the account transactions matching the filter,
+// sorted by (ts, xact no, leg id) and starting after the cursor if it's set
+func (tr *XactRepository) ListAccntXacts(ctx context.Context,
+	f transaction.XactFilter) ([]*transaction.Transaction, error) {
+	var (
+		conds = []string{"account_id = ?"}
+		args  = []interface{}{f.AccountID}
+	)
+
+	if len(f.XactTypeExts) > 0 {
+		placeholders := make([]string, 0, len(f.XactTypeExts))
+		for _, ttx := range f.XactTypeExts {
+			placeholders = append(placeholders, "?")
+			args = append(args, ttx)
+		}
+		conds = append(conds, "xact_type_ext in ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if f.From != nil {
+		conds = append(conds, "ts >= ?")
+		args = append(args, timestamp(*f.From))
+	}
+
+	if f.To != nil {
+		conds = append(conds, "ts < ?")
+		args = append(args, timestamp(*f.To))
+	}
+
+	if f.MinAmount != nil {
+		conds = append(conds, "amount >= ?")
+		args = append(args, amount(*f.MinAmount))
+	}
+
+	if f.MaxAmount != nil {
+		conds = append(conds, "amount <= ?")
+		args = append(args, amount(*f.MaxAmount))
+	}
+
+	op, dir := "<", "desc"
+	if f.Sort == transaction.SortAsc {
+		op, dir = ">", "asc"
+	}
+
+	// the rowid is the serial leg id
+	if f.Cursor != nil {
+		conds = append(conds, fmt.Sprintf("(ts, xact_no, rowid) %s (?, ?, ?)", op))
+		args = append(args, timestamp(f.Cursor.Ts), f.Cursor.XactNo, f.Cursor.LegID)
+	}
+
+	stmnt := fmt.Sprintf(`select xact_no, ledger_no, xact_type, account_id,
+		xact_type_ext, amount, "desc", ts, rowid from account_transactions
+		where %s order by ts %s, xact_no %s, rowid %s limit ?`,
+		strings.Join(conds, " and "), dir, dir, dir)
+	args = append(args, f.Limit)
+
+	rows, err := tr.db.QueryContext(ctx, stmnt, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	xacts := []*transaction.Transaction{}
+	for rows.Next() {
+		var xact transaction.Transaction
+		err = rows.Scan(
+			&xact.XactNo, &xact.LedgerNo,
+			&xact.XactType, &xact.AccountID,
+			&xact.XactTypeExt, scanAmount(&xact.Amount),
+			&xact.Desc, scanTs(&xact.Ts), &xact.LegID,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "row scan")
+		}
+
+		xacts = append(xacts, &xact)
+	}
+
+	return xacts, rows.Err()
+}
+
+// ListLedgerPostings retrieves the account and ledger transactions of the
+// ledger made from the inclusive start to the exclusive end time, sorted by (ts, xact no)
+func (tr *XactRepository) ListLedgerPostings(ctx context.Context, ledgerNo ledger.LedgerNo,
+	from, to time.Time) ([]*transaction.Transaction, error) {
+	stmnt := `select xact_no, ledger_no, xact_type, account_id,
+			xact_type_ext, amount, "desc", ts
+		from (
+			select xact_no, ledger_no, xact_type, account_id, xact_type_ext,
+				amount, "desc", ts, 0 as seq, rowid as id
+			from account_transactions
+			where ledger_no = ? and ts >= ? and ts < ?
+			union all
+			select xact_no, ledger_no, xact_type, '', null,
+				amount, "desc", ts, 1, rowid
+			from ledger_transactions
+			where ledger_no = ? and ts >= ? and ts < ?
+		) order by ts, xact_no, seq, id`
+
+	rows, err := tr.db.QueryContext(ctx, stmnt,
+		ledgerNo, timestamp(from), timestamp(to),
+		ledgerNo, timestamp(from), timestamp(to),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "query context")
+	}
+	defer rows.Close()
+
+	return scanXacts(rows)
+}
+
+// GetOutgoingUsage retrieves the amount withdrawn and sent, and the
+// number of transfers sent by the account since the time within tx
+func (tr *XactRepository) GetOutgoingUsage(ctx context.Context, tx tx.Tx,
+	accntID account.AccountID, since time.Time) (*limit.Usage, error) {
+	txx, err := asTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	stmnt := `select coalesce(sum(amount), 0),
+			coalesce(sum(case when xact_type_ext = ? then 1 else 0 end), 0)
+		from account_transactions
+		where account_id = ? and xact_type_ext in (?, ?) and ts >= ?`
+
+	var usage limit.Usage
+	err = txx.conn.QueryRowContext(ctx, stmnt, transaction.XactTypeExtSndTransfer,
+		accntID, transaction.XactTypeExtSndTransfer, transaction.XactTypeExtWithdrawal,
+		timestamp(since)).Scan(scanAmount(&usage.Amount), &usage.Transfers)
+	if err != nil {
+		return nil, errors.Wrap(err, "query row context")
+	}
+
+	return &usage, nil
+}
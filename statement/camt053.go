@@ -0,0 +1,406 @@
+package statement
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// camt053Namespace is the namespace of the camt.053.001.02 schema
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// List of camt.053 codes
+const (
+	// codeOpeningBal is the opening booked balance
+	codeOpeningBal = "OPBD"
+	// codeClosingBal is the closing booked balance
+	codeClosingBal = "CLBD"
+	codeCredit     = "CRDT"
+	codeDebit      = "DBIT"
+	// codeBooked is the status of the booked entries
+	codeBooked = "BOOK"
+	// codeIssuer is the issuer of the proprietary bank transaction codes
+	codeIssuer = "KALUPI"
+	// codeLedgerXact is the proprietary bank
+	// transaction code of the ledger transactions
+	codeLedgerXact = "Lg"
+)
+
+// List of camt.053 text lengths
+const (
+	maxText34  = 34
+	maxText140 = 140
+	maxText500 = 500
+)
+
+const (
+	alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	msgIDLen = 16
+)
+
+// NewMsgID generates a new camt.053 message id
+func NewMsgID() (string, error) {
+	id, err := gonanoid.Generate(alphabet, msgIDLen)
+	if err != nil {
+		return "", pkgerrors.Wrap(err, "generate")
+	}
+
+	return id, nil
+}
+
+// bankXactCodes are the ISO bank transaction codes
+// (domain, family and sub-family) of the transaction types
+var bankXactCodes = map[transaction.XactTypeExt][3]string{
+	transaction.XactTypeExtDeposit:     {"PMNT", "CNTR", "CDPT"},
+	transaction.XactTypeExtWithdrawal:  {"PMNT", "CNTR", "CWDL"},
+	transaction.XactTypeExtSndTransfer: {"PMNT", "ICDT", "DMCT"},
+	transaction.XactTypeExtRcvTransfer: {"PMNT", "RCDT", "DMCT"},
+	transaction.XactTypeExtFee:         {"ACMT", "MDOP", "CHRG"},
+}
+
+// WriteCamt053 writes the statement as an ISO 20022 camt.053.001.02
+// BankToCustomerStatement document. The message id is also the
+// statement id, the entries are referenced by the transaction numbers.
+// The account id must fit in the 34 characters of the schema.
+func (st *Statement) WriteCamt053(w io.Writer, msgID string, createdAt time.Time) error {
+	acctID := string(st.AccountID)
+	if st.isLedger() {
+		acctID = string(st.LedgerNo)
+	}
+
+	if utf8.RuneCountInString(acctID) > maxText34 {
+		return multierr.Combine(ErrValidation, validation.Errors{
+			"account_id": errors.New("must have at most 34 characters in camt.053"),
+		})
+	}
+
+	stmt := camtStmt{
+		ID:      msgID,
+		CreDtTm: formatTs(createdAt),
+		FrToDt: camtFrToDt{
+			FrDtTm: formatTs(st.From),
+			ToDtTm: formatTs(st.To),
+		},
+		Acct: camtAcct{
+			ID:  camtAcctID{Othr: camtOthrID{ID: acctID}},
+			Ccy: st.Currency.String(),
+		},
+		Bal: []camtBal{
+			st.camtBal(codeOpeningBal, st.OpeningBal, st.From),
+			st.camtBal(codeClosingBal, st.ClosingBal, st.To),
+		},
+		TxsSummry: st.camtTxsSummry(),
+		Ntry:      make([]camtNtry, 0, len(st.Lines)),
+	}
+
+	for _, line := range st.Lines {
+		stmt.Ntry = append(stmt.Ntry, st.camtNtry(line))
+	}
+
+	doc := camtDocument{
+		Xmlns: camt053Namespace,
+		BkToCstmrStmt: camtBkToCstmrStmt{
+			GrpHdr: camtGrpHdr{
+				MsgID:   msgID,
+				CreDtTm: formatTs(createdAt),
+			},
+			Stmt: stmt,
+		},
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return pkgerrors.Wrap(err, "write header")
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return pkgerrors.Wrap(err, "encode")
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// camtBal returns the balance. A positive account balance is
+// a credit balance, while a positive ledger balance is a debit.
+func (st *Statement) camtBal(code string, bal decimal.Decimal, ts time.Time) camtBal {
+	if st.isLedger() {
+		bal = bal.Neg()
+	}
+
+	return camtBal{
+		Tp:        camtBalTp{CdOrPrtry: camtCd{Cd: code}},
+		Amt:       st.camtAmt(bal),
+		CdtDbtInd: creditDebit(bal),
+		Dt:        camtDt{DtTm: formatTs(ts)},
+	}
+}
+
+// camtTxsSummry returns the summary of the entries
+func (st *Statement) camtTxsSummry() camtTxsSummry {
+	var credits, debits int
+	for _, line := range st.Lines {
+		if line.Credit.IsPositive() {
+			credits++
+		} else {
+			debits++
+		}
+	}
+
+	net := st.TotalCredit.Sub(st.TotalDebit)
+	return camtTxsSummry{
+		TtlNtries: camtTtlNtries{
+			NbOfNtries:    len(st.Lines),
+			Sum:           st.formatAmt(st.TotalCredit.Add(st.TotalDebit)),
+			TtlNetNtryAmt: st.formatAmt(net.Abs()),
+			CdtDbtInd:     creditDebit(net),
+		},
+		TtlCdtNtries: camtNbOfNtries{
+			NbOfNtries: credits,
+			Sum:        st.formatAmt(st.TotalCredit),
+		},
+		TtlDbtNtries: camtNbOfNtries{
+			NbOfNtries: debits,
+			Sum:        st.formatAmt(st.TotalDebit),
+		},
+	}
+}
+
+// camtNtry returns the entry of the line
+func (st *Statement) camtNtry(line *Line) camtNtry {
+	amt, ind := line.Debit, codeDebit
+	if line.Credit.IsPositive() {
+		amt, ind = line.Credit, codeCredit
+	}
+
+	prtryCd := codeLedgerXact
+	if line.XactTypeExt != 0 {
+		prtryCd = line.XactTypeExt.String()
+	}
+
+	bkTxCd := camtBkTxCd{
+		Prtry: camtPrtry{Cd: prtryCd, Issr: codeIssuer},
+	}
+	if codes, ok := bankXactCodes[line.XactTypeExt]; ok {
+		bkTxCd.Domn = &camtDomn{
+			Cd: codes[0],
+			Fmly: camtFmly{
+				Cd:        codes[1],
+				SubFmlyCd: codes[2],
+			},
+		}
+	}
+
+	txDtls := camtTxDtls{
+		Refs: camtRefs{AcctSvcrRef: string(line.XactNo)},
+	}
+	if line.Desc != "" {
+		txDtls.RmtInf = &camtRmtInf{Ustrd: truncate(line.Desc, maxText140)}
+	}
+
+	return camtNtry{
+		NtryRef:      string(line.XactNo),
+		Amt:          st.camtAmt(amt),
+		CdtDbtInd:    ind,
+		RvslInd:      line.XactTypeExt == transaction.XactTypeExtReversal,
+		Sts:          codeBooked,
+		BookgDt:      camtDt{DtTm: formatTs(line.Ts)},
+		ValDt:        camtDt{DtTm: formatTs(line.Ts)},
+		AcctSvcrRef:  string(line.XactNo),
+		BkTxCd:       bkTxCd,
+		NtryDtls:     camtNtryDtls{TxDtls: txDtls},
+		AddtlNtryInf: truncate(line.Desc, maxText500),
+	}
+}
+
+// camtAmt returns the absolute amount in the currency of the statement
+func (st *Statement) camtAmt(amt decimal.Decimal) camtAmt {
+	return camtAmt{Ccy: st.Currency.String(), Value: st.formatAmt(amt.Abs())}
+}
+
+// formatAmt formats the amount with the minor units of the currency,
+// the amount is formatted as is if it has more decimal places
+func (st *Statement) formatAmt(amt decimal.Decimal) string {
+	minorUnits := st.Currency.MinorUnits()
+	if minorUnits == currency.NoMinorUnits {
+		return amt.String()
+	}
+
+	places := int32(minorUnits)
+	if !amt.Equal(amt.Round(places)) {
+		return amt.String()
+	}
+
+	return amt.StringFixed(places)
+}
+
+// creditDebit returns the credit indicator if
+// the amount isn't negative, debit otherwise
+func creditDebit(amt decimal.Decimal) string {
+	if amt.IsNegative() {
+		return codeDebit
+	}
+	return codeCredit
+}
+
+// truncate truncates the text to the max number of characters
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
+}
+
+// camtDocument is the camt.053 document, the elements
+// are in the order of the sequences of the schema
+type camtDocument struct {
+	XMLName       xml.Name          `xml:"Document"`
+	Xmlns         string            `xml:"xmlns,attr"`
+	BkToCstmrStmt camtBkToCstmrStmt `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmrStmt struct {
+	GrpHdr camtGrpHdr `xml:"GrpHdr"`
+	Stmt   camtStmt   `xml:"Stmt"`
+}
+
+type camtGrpHdr struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStmt struct {
+	ID        string        `xml:"Id"`
+	CreDtTm   string        `xml:"CreDtTm"`
+	FrToDt    camtFrToDt    `xml:"FrToDt"`
+	Acct      camtAcct      `xml:"Acct"`
+	Bal       []camtBal     `xml:"Bal"`
+	TxsSummry camtTxsSummry `xml:"TxsSummry"`
+	Ntry      []camtNtry    `xml:"Ntry"`
+}
+
+type camtFrToDt struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAcct struct {
+	ID  camtAcctID `xml:"Id"`
+	Ccy string     `xml:"Ccy"`
+}
+
+type camtAcctID struct {
+	Othr camtOthrID `xml:"Othr"`
+}
+
+type camtOthrID struct {
+	ID string `xml:"Id"`
+}
+
+type camtBal struct {
+	Tp        camtBalTp `xml:"Tp"`
+	Amt       camtAmt   `xml:"Amt"`
+	CdtDbtInd string    `xml:"CdtDbtInd"`
+	Dt        camtDt    `xml:"Dt"`
+}
+
+type camtBalTp struct {
+	CdOrPrtry camtCd `xml:"CdOrPrtry"`
+}
+
+type camtCd struct {
+	Cd string `xml:"Cd"`
+}
+
+type camtAmt struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtDt struct {
+	DtTm string `xml:"DtTm"`
+}
+
+type camtTxsSummry struct {
+	TtlNtries    camtTtlNtries  `xml:"TtlNtries"`
+	TtlCdtNtries camtNbOfNtries `xml:"TtlCdtNtries"`
+	TtlDbtNtries camtNbOfNtries `xml:"TtlDbtNtries"`
+}
+
+type camtTtlNtries struct {
+	NbOfNtries    int    `xml:"NbOfNtries"`
+	Sum           string `xml:"Sum"`
+	TtlNetNtryAmt string `xml:"TtlNetNtryAmt"`
+	CdtDbtInd     string `xml:"CdtDbtInd"`
+}
+
+type camtNbOfNtries struct {
+	NbOfNtries int    `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+type camtNtry struct {
+	NtryRef      string       `xml:"NtryRef"`
+	Amt          camtAmt      `xml:"Amt"`
+	CdtDbtInd    string       `xml:"CdtDbtInd"`
+	RvslInd      bool         `xml:"RvslInd,omitempty"`
+	Sts          string       `xml:"Sts"`
+	BookgDt      camtDt       `xml:"BookgDt"`
+	ValDt        camtDt       `xml:"ValDt"`
+	AcctSvcrRef  string       `xml:"AcctSvcrRef"`
+	BkTxCd       camtBkTxCd   `xml:"BkTxCd"`
+	NtryDtls     camtNtryDtls `xml:"NtryDtls"`
+	AddtlNtryInf string       `xml:"AddtlNtryInf,omitempty"`
+}
+
+type camtBkTxCd struct {
+	Domn  *camtDomn `xml:"Domn,omitempty"`
+	Prtry camtPrtry `xml:"Prtry"`
+}
+
+type camtDomn struct {
+	Cd   string   `xml:"Cd"`
+	Fmly camtFmly `xml:"Fmly"`
+}
+
+type camtFmly struct {
+	Cd        string `xml:"Cd"`
+	SubFmlyCd string `xml:"SubFmlyCd"`
+}
+
+type camtPrtry struct {
+	Cd   string `xml:"Cd"`
+	Issr string `xml:"Issr"`
+}
+
+type camtNtryDtls struct {
+	TxDtls camtTxDtls `xml:"TxDtls"`
+}
+
+type camtTxDtls struct {
+	Refs   camtRefs    `xml:"Refs"`
+	RmtInf *camtRmtInf `xml:"RmtInf,omitempty"`
+}
+
+type camtRefs struct {
+	AcctSvcrRef string `xml:"AcctSvcrRef"`
+}
+
+type camtRmtInf struct {
+	Ustrd string `xml:"Ustrd"`
+}
@@ -0,0 +1,477 @@
+package statement_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/statement"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestStatementWriteCamt053(t *testing.T) {
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2021, 7, 1, 8, 30, 0, 0, time.UTC)
+
+	tc := []struct {
+		name   string
+		golden string
+		st     *statement.Statement
+	}{
+		{
+			name:   "account",
+			golden: "camt053_account.xml",
+			st: &statement.Statement{
+				AccountID:  john.AccountID,
+				Currency:   currency.USD,
+				From:       from,
+				To:         from.AddDate(0, 1, 0),
+				OpeningBal: decimal.RequireFromString("10.5"),
+				Lines: []*statement.Line{
+					{
+						XactNo:      transaction.XactNo("XACT1"),
+						XactTypeExt: transaction.XactTypeExtDeposit,
+						Desc:        "Cash deposit from johndoe",
+						Debit:       decimal.Zero,
+						Credit:      decimal.NewFromInt(100),
+						Balance:     decimal.RequireFromString("110.5"),
+						Ts:          from.Add(time.Hour),
+					},
+					{
+						XactNo:      transaction.XactNo("XACT2"),
+						XactTypeExt: transaction.XactTypeExtSndTransfer,
+						Desc:        "Outgoing cash transfer to maryjane <invoice #1 & #2>",
+						Debit:       decimal.RequireFromString("20.25"),
+						Credit:      decimal.Zero,
+						Balance:     decimal.RequireFromString("90.25"),
+						Ts:          from.Add(2 * time.Hour),
+					},
+					{
+						XactNo:      transaction.XactNo("XACT2"),
+						XactTypeExt: transaction.XactTypeExtFee,
+						Desc:        "Transfer fee",
+						Debit:       decimal.RequireFromString("0.25"),
+						Credit:      decimal.Zero,
+						Balance:     decimal.NewFromInt(90),
+						Ts:          from.Add(2 * time.Hour),
+					},
+					{
+						XactNo:      transaction.XactNo("XACT3"),
+						XactTypeExt: transaction.XactTypeExtReversal,
+						Desc:        strings.Repeat("reversal ", 20),
+						Debit:       decimal.Zero,
+						Credit:      decimal.RequireFromString("20.25"),
+						Balance:     decimal.RequireFromString("110.25"),
+						Ts:          from.Add(3 * time.Hour),
+					},
+				},
+				TotalDebit:  decimal.RequireFromString("20.5"),
+				TotalCredit: decimal.RequireFromString("120.25"),
+				ClosingBal:  decimal.RequireFromString("110.25"),
+			},
+		},
+		{
+			name:   "ledger",
+			golden: "camt053_ledger.xml",
+			st: &statement.Statement{
+				LedgerNo:   ledger.LedgerNo("200-EUR"),
+				Currency:   currency.EUR,
+				From:       from,
+				To:         from.AddDate(0, 1, 0),
+				OpeningBal: decimal.Zero,
+				Lines: []*statement.Line{
+					{
+						XactNo:      transaction.XactNo("XACT4"),
+						AccountID:   account.AccountID("jeandupont"),
+						XactTypeExt: transaction.XactTypeExtRcvTransfer,
+						Desc:        "Incoming fx transfer from johndoe",
+						Debit:       decimal.RequireFromString("45"),
+						Credit:      decimal.Zero,
+						Balance:     decimal.RequireFromString("45"),
+						Ts:          from.Add(time.Hour),
+					},
+					{
+						XactNo:  transaction.XactNo("XACT4"),
+						Desc:    "FX position",
+						Debit:   decimal.Zero,
+						Credit:  decimal.RequireFromString("90"),
+						Balance: decimal.RequireFromString("-45"),
+						Ts:      from.Add(time.Hour),
+					},
+				},
+				TotalDebit:  decimal.RequireFromString("45"),
+				TotalCredit: decimal.RequireFromString("90"),
+				ClosingBal:  decimal.RequireFromString("-45"),
+			},
+		},
+		{
+			name:   "no entries",
+			golden: "camt053_empty.xml",
+			st: &statement.Statement{
+				AccountID:   mary.AccountID,
+				Currency:    currency.JPY,
+				From:        from,
+				To:          from.AddDate(0, 0, 1),
+				OpeningBal:  decimal.NewFromInt(-1500),
+				Lines:       []*statement.Line{},
+				TotalDebit:  decimal.Zero,
+				TotalCredit: decimal.Zero,
+				ClosingBal:  decimal.NewFromInt(-1500),
+			},
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.st.WriteCamt053(&buf, "MSG1", createdAt)
+			require.NoError(t, err)
+
+			validateCamt053(t, buf.Bytes())
+
+			golden := filepath.Join("testdata", tt.golden)
+			if *update {
+				err = ioutil.WriteFile(golden, buf.Bytes(), 0644)
+				require.NoError(t, err)
+			}
+
+			expect, err := ioutil.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expect), buf.String())
+		})
+	}
+
+	t.Run("account id too long", func(t *testing.T) {
+		st := &statement.Statement{
+			AccountID: account.AccountID(strings.Repeat("a", 35)),
+			Currency:  currency.USD,
+			From:      from,
+			To:        from.AddDate(0, 1, 0),
+		}
+
+		var buf bytes.Buffer
+		err := st.WriteCamt053(&buf, "MSG1", createdAt)
+		assert.ErrorIs(t, err, statement.ErrValidation)
+		assert.Zero(t, buf.Len())
+	})
+}
+
+// xmlNode is a generic xml element
+type xmlNode struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+	Nodes   []xmlNode  `xml:",any"`
+}
+
+// xsdElem is an element of a complex type of the schema
+type xsdElem struct {
+	name string
+	// typ is the complex or simple type, it's empty if the
+	// element isn't expected in the statement documents
+	typ      string
+	min, max int
+}
+
+// unbounded is the max occurrence of an unbounded element
+const unbounded = -1
+
+// xsdType is a complex type, the elements are either in a sequence or a choice
+type xsdType struct {
+	choice bool
+	elems  []xsdElem
+}
+
+// camt053Types are the complex types of the camt.053.001.02 schema
+// used by the statement documents, the elements are in schema order
+var camt053Types = map[string]xsdType{
+	"Document": {elems: []xsdElem{
+		{"BkToCstmrStmt", "BankToCustomerStatementV02", 1, 1},
+	}},
+	"BankToCustomerStatementV02": {elems: []xsdElem{
+		{"GrpHdr", "GroupHeader42", 1, 1},
+		{"Stmt", "AccountStatement2", 1, unbounded},
+	}},
+	"GroupHeader42": {elems: []xsdElem{
+		{"MsgId", "Max35Text", 1, 1},
+		{"CreDtTm", "ISODateTime", 1, 1},
+		{"MsgRcpt", "", 0, 1},
+		{"MsgPgntn", "", 0, 1},
+		{"AddtlInf", "Max500Text", 0, 1},
+	}},
+	"AccountStatement2": {elems: []xsdElem{
+		{"Id", "Max35Text", 1, 1},
+		{"ElctrncSeqNb", "", 0, 1},
+		{"LglSeqNb", "", 0, 1},
+		{"CreDtTm", "ISODateTime", 1, 1},
+		{"FrToDt", "DateTimePeriodDetails", 0, 1},
+		{"CpyDplctInd", "", 0, 1},
+		{"RptgSrc", "", 0, 1},
+		{"Acct", "CashAccount20", 1, 1},
+		{"RltdAcct", "", 0, 1},
+		{"Intrst", "", 0, unbounded},
+		{"Bal", "CashBalance3", 1, unbounded},
+		{"TxsSummry", "TotalTransactions2", 0, 1},
+		{"Ntry", "ReportEntry2", 0, unbounded},
+		{"AddtlStmtInf", "Max500Text", 0, 1},
+	}},
+	"DateTimePeriodDetails": {elems: []xsdElem{
+		{"FrDtTm", "ISODateTime", 1, 1},
+		{"ToDtTm", "ISODateTime", 1, 1},
+	}},
+	"CashAccount20": {elems: []xsdElem{
+		{"Id", "AccountIdentification4Choice", 1, 1},
+		{"Tp", "", 0, 1},
+		{"Ccy", "ActiveOrHistoricCurrencyCode", 0, 1},
+		{"Nm", "Max70Text", 0, 1},
+		{"Ownr", "", 0, 1},
+		{"Svcr", "", 0, 1},
+	}},
+	"AccountIdentification4Choice": {choice: true, elems: []xsdElem{
+		{"IBAN", "", 1, 1},
+		{"Othr", "GenericAccountIdentification1", 1, 1},
+	}},
+	"GenericAccountIdentification1": {elems: []xsdElem{
+		{"Id", "Max34Text", 1, 1},
+		{"SchmeNm", "", 0, 1},
+		{"Issr", "Max35Text", 0, 1},
+	}},
+	"CashBalance3": {elems: []xsdElem{
+		{"Tp", "BalanceType12", 1, 1},
+		{"CdtLine", "", 0, 1},
+		{"Amt", "ActiveOrHistoricCurrencyAndAmount", 1, 1},
+		{"CdtDbtInd", "CreditDebitCode", 1, 1},
+		{"Dt", "DateAndDateTimeChoice", 1, 1},
+		{"Avlbty", "", 0, unbounded},
+	}},
+	"BalanceType12": {elems: []xsdElem{
+		{"CdOrPrtry", "BalanceType5Choice", 1, 1},
+		{"SubTp", "", 0, 1},
+	}},
+	"BalanceType5Choice": {choice: true, elems: []xsdElem{
+		{"Cd", "BalanceType12Code", 1, 1},
+		{"Prtry", "Max35Text", 1, 1},
+	}},
+	"DateAndDateTimeChoice": {choice: true, elems: []xsdElem{
+		{"Dt", "", 1, 1},
+		{"DtTm", "ISODateTime", 1, 1},
+	}},
+	"TotalTransactions2": {elems: []xsdElem{
+		{"TtlNtries", "NumberAndSumOfTransactions2", 0, 1},
+		{"TtlCdtNtries", "NumberAndSumOfTransactions1", 0, 1},
+		{"TtlDbtNtries", "NumberAndSumOfTransactions1", 0, 1},
+		{"TtlNtriesPerBkTxCd", "", 0, unbounded},
+	}},
+	"NumberAndSumOfTransactions2": {elems: []xsdElem{
+		{"NbOfNtries", "Max15NumericText", 0, 1},
+		{"Sum", "DecimalNumber", 0, 1},
+		{"TtlNetNtryAmt", "DecimalNumber", 0, 1},
+		{"CdtDbtInd", "CreditDebitCode", 0, 1},
+	}},
+	"NumberAndSumOfTransactions1": {elems: []xsdElem{
+		{"NbOfNtries", "Max15NumericText", 0, 1},
+		{"Sum", "DecimalNumber", 0, 1},
+	}},
+	"ReportEntry2": {elems: []xsdElem{
+		{"NtryRef", "Max35Text", 0, 1},
+		{"Amt", "ActiveOrHistoricCurrencyAndAmount", 1, 1},
+		{"CdtDbtInd", "CreditDebitCode", 1, 1},
+		{"RvslInd", "TrueFalseIndicator", 0, 1},
+		{"Sts", "EntryStatus2Code", 1, 1},
+		{"BookgDt", "DateAndDateTimeChoice", 0, 1},
+		{"ValDt", "DateAndDateTimeChoice", 0, 1},
+		{"AcctSvcrRef", "Max35Text", 0, 1},
+		{"Avlbty", "", 0, unbounded},
+		{"BkTxCd", "BankTransactionCodeStructure4", 1, 1},
+		{"ComssnWvrInd", "", 0, 1},
+		{"AddtlInfInd", "", 0, 1},
+		{"AmtDtls", "", 0, 1},
+		{"Chrgs", "", 0, 1},
+		{"TechInptChanl", "", 0, 1},
+		{"Intrst", "", 0, 1},
+		{"NtryDtls", "EntryDetails1", 0, unbounded},
+		{"AddtlNtryInf", "Max500Text", 0, 1},
+	}},
+	"BankTransactionCodeStructure4": {elems: []xsdElem{
+		{"Domn", "BankTransactionCodeStructure5", 0, 1},
+		{"Prtry", "ProprietaryBankTransactionCodeStructure1", 0, 1},
+	}},
+	"BankTransactionCodeStructure5": {elems: []xsdElem{
+		{"Cd", "ExternalCode", 1, 1},
+		{"Fmly", "BankTransactionCodeStructure6", 1, 1},
+	}},
+	"BankTransactionCodeStructure6": {elems: []xsdElem{
+		{"Cd", "ExternalCode", 1, 1},
+		{"SubFmlyCd", "ExternalCode", 1, 1},
+	}},
+	"ProprietaryBankTransactionCodeStructure1": {elems: []xsdElem{
+		{"Cd", "Max35Text", 1, 1},
+		{"Issr", "Max35Text", 0, 1},
+	}},
+	"EntryDetails1": {elems: []xsdElem{
+		{"Btch", "", 0, 1},
+		{"TxDtls", "EntryTransaction2", 0, unbounded},
+	}},
+	"EntryTransaction2": {elems: []xsdElem{
+		{"Refs", "TransactionReferences2", 0, 1},
+		{"AmtDtls", "", 0, 1},
+		{"Avlbty", "", 0, unbounded},
+		{"BkTxCd", "", 0, 1},
+		{"Chrgs", "", 0, 1},
+		{"Intrst", "", 0, 1},
+		{"RltdPties", "", 0, 1},
+		{"RltdAgts", "", 0, 1},
+		{"Purp", "", 0, 1},
+		{"RltdRmtInf", "", 0, 10},
+		{"RmtInf", "RemittanceInformation5", 0, 1},
+		{"RltdDts", "", 0, 1},
+		{"RltdPric", "", 0, 1},
+		{"RltdQties", "", 0, unbounded},
+		{"FinInstrmId", "", 0, 1},
+		{"Tax", "", 0, 1},
+		{"RtrInf", "", 0, 1},
+		{"CorpActn", "", 0, 1},
+		{"SfkpgAcct", "", 0, 1},
+		{"AddtlTxInf", "Max500Text", 0, 1},
+	}},
+	"TransactionReferences2": {elems: []xsdElem{
+		{"MsgId", "Max35Text", 0, 1},
+		{"AcctSvcrRef", "Max35Text", 0, 1},
+		{"PmtInfId", "Max35Text", 0, 1},
+		{"InstrId", "Max35Text", 0, 1},
+		{"EndToEndId", "Max35Text", 0, 1},
+		{"TxId", "Max35Text", 0, 1},
+		{"MndtId", "Max35Text", 0, 1},
+		{"ChqNb", "Max35Text", 0, 1},
+		{"ClrSysRef", "Max35Text", 0, 1},
+		{"Prtry", "", 0, 1},
+	}},
+	"RemittanceInformation5": {elems: []xsdElem{
+		{"Ustrd", "Max140Text", 0, unbounded},
+		{"Strd", "", 0, unbounded},
+	}},
+}
+
+var (
+	amountRe  = regexp.MustCompile(`^[0-9]{1,13}(\.[0-9]{1,5})?$`)
+	decimalRe = regexp.MustCompile(`^[0-9]{1,18}(\.[0-9]{1,17})?$`)
+	ccyRe     = regexp.MustCompile(`^[A-Z]{3}$`)
+	numericRe = regexp.MustCompile(`^[0-9]{1,15}$`)
+	extCodeRe = regexp.MustCompile(`^[A-Z]{1,4}$`)
+)
+
+// camt053SimpleTypes are the checks of the simple types of the schema
+var camt053SimpleTypes = map[string]func(n xmlNode) bool{
+	"Max34Text":        func(n xmlNode) bool { return maxText(n.Content, 34) },
+	"Max35Text":        func(n xmlNode) bool { return maxText(n.Content, 35) },
+	"Max70Text":        func(n xmlNode) bool { return maxText(n.Content, 70) },
+	"Max140Text":       func(n xmlNode) bool { return maxText(n.Content, 140) },
+	"Max500Text":       func(n xmlNode) bool { return maxText(n.Content, 500) },
+	"Max15NumericText": func(n xmlNode) bool { return numericRe.MatchString(n.Content) },
+	"ISODateTime": func(n xmlNode) bool {
+		_, err := time.Parse(time.RFC3339Nano, n.Content)
+		return err == nil
+	},
+	"ActiveOrHistoricCurrencyCode": func(n xmlNode) bool { return ccyRe.MatchString(n.Content) },
+	"ActiveOrHistoricCurrencyAndAmount": func(n xmlNode) bool {
+		return len(n.Attrs) == 1 && n.Attrs[0].Name.Local == "Ccy" &&
+			ccyRe.MatchString(n.Attrs[0].Value) && amountRe.MatchString(n.Content)
+	},
+	"DecimalNumber":      func(n xmlNode) bool { return decimalRe.MatchString(n.Content) },
+	"CreditDebitCode":    func(n xmlNode) bool { return oneOf(n.Content, "CRDT", "DBIT") },
+	"EntryStatus2Code":   func(n xmlNode) bool { return oneOf(n.Content, "BOOK", "PDNG", "INFO") },
+	"TrueFalseIndicator": func(n xmlNode) bool { return oneOf(n.Content, "true", "false") },
+	"BalanceType12Code": func(n xmlNode) bool {
+		return oneOf(n.Content, "XPCD", "OPAV", "ITAV", "CLAV",
+			"FWAV", "CLBD", "ITBD", "OPBD", "PRCD", "INFO")
+	},
+	"ExternalCode": func(n xmlNode) bool { return extCodeRe.MatchString(n.Content) },
+}
+
+func maxText(s string, max int) bool {
+	n := utf8.RuneCountInString(s)
+	return n >= 1 && n <= max
+}
+
+func oneOf(s string, values ...string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCamt053 validates the document against the shape of the schema
+func validateCamt053(t *testing.T, doc []byte) {
+	var root xmlNode
+	err := xml.Unmarshal(doc, &root)
+	require.NoError(t, err)
+
+	require.Equal(t, "Document", root.XMLName.Local)
+	require.Equal(t, "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02", root.XMLName.Space)
+	validateNode(t, "Document", "Document", root)
+}
+
+// validateNode validates the node of the type
+func validateNode(t *testing.T, path, typ string, n xmlNode) {
+	if check, ok := camt053SimpleTypes[typ]; ok {
+		assert.Empty(t, n.Nodes, path)
+		assert.True(t, check(n), "%s: invalid %s %q", path, typ, n.Content)
+		return
+	}
+
+	ct, ok := camt053Types[typ]
+	if !assert.True(t, ok, "%s: unknown type %s", path, typ) {
+		return
+	}
+	assert.Empty(t, strings.TrimSpace(n.Content), "%s: unexpected content", path)
+
+	if ct.choice {
+		if !assert.Len(t, n.Nodes, 1, "%s: expecting one of the choices", path) {
+			return
+		}
+	}
+
+	// the children must be in the order of the sequence
+	i := 0
+	for _, elem := range ct.elems {
+		count := 0
+		for i < len(n.Nodes) && n.Nodes[i].XMLName.Local == elem.name {
+			child := n.Nodes[i]
+			childPath := path + "/" + elem.name
+			if assert.NotEmpty(t, elem.typ, "%s: unexpected element", childPath) {
+				validateNode(t, childPath, elem.typ, child)
+			}
+			count++
+			i++
+		}
+
+		if !ct.choice && count < elem.min {
+			t.Errorf("%s/%s: missing element", path, elem.name)
+		}
+		if elem.max != unbounded && count > elem.max {
+			t.Errorf("%s/%s: too many elements", path, elem.name)
+		}
+	}
+
+	if i < len(n.Nodes) {
+		t.Errorf("%s/%s: unexpected or out of order element", path, n.Nodes[i].XMLName.Local)
+	}
+}
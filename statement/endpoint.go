@@ -0,0 +1,54 @@
+package statement
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/ledger"
+)
+
+// getStatementRequest is a get statement request
+type getStatementRequest struct {
+	AccountID account.AccountID
+	From      time.Time
+	To        time.Time
+	Format    Format
+}
+
+// getStatementResponse is a get statement response
+type getStatementResponse struct {
+	Statement *Statement `json:"statement,omitempty"`
+	Err       error      `json:"error,omitempty"`
+	format    Format
+}
+
+func (r getStatementResponse) error() error { return r.Err }
+
+// newGetStatementEndpoint returns a get statement endpoint
+func newGetStatementEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getStatementRequest)
+		st, err := s.GetStatement(ctx, req.AccountID, req.From, req.To)
+		return getStatementResponse{Statement: st, Err: err, format: req.Format}, nil
+	}
+}
+
+// getLedgerStatementRequest is a get ledger statement request
+type getLedgerStatementRequest struct {
+	LedgerNo ledger.LedgerNo
+	From     time.Time
+	To       time.Time
+	Format   Format
+}
+
+// newGetLedgerStatementEndpoint returns a get ledger statement endpoint
+func newGetLedgerStatementEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getLedgerStatementRequest)
+		st, err := s.GetLedgerStatement(ctx, req.LedgerNo, req.From, req.To)
+		return getStatementResponse{Statement: st, Err: err, format: req.Format}, nil
+	}
+}
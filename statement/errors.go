@@ -0,0 +1,12 @@
+package statement
+
+import "errors"
+
+// List of statement related errors
+var (
+	// ErrValidation is a statement related validation error
+	ErrValidation = errors.New("validation error")
+	// ErrUnbalanced is an error when the closing balance doesn't
+	// reconcile with the opening balance and the statement lines
+	ErrUnbalanced = errors.New("statement doesn't reconcile")
+)
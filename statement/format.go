@@ -0,0 +1,39 @@
+package statement
+
+import "errors"
+
+// Format is a statement format
+type Format int
+
+// List of statement formats
+const (
+	// FormatJSON is the json statement
+	FormatJSON Format = iota
+	// FormatCSV is the csv statement
+	FormatCSV
+	// FormatCamt053 is the ISO 20022 camt.053 bank to customer statement
+	FormatCamt053
+)
+
+// String implements Stringer
+func (f Format) String() string {
+	return [...]string{
+		"json",
+		"csv",
+		"camt053",
+	}[f]
+}
+
+// ParseFormat parses the statement format, it's json if empty
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "", "json":
+		return FormatJSON, nil
+	case "csv":
+		return FormatCSV, nil
+	case "camt053":
+		return FormatCamt053, nil
+	default:
+		return FormatJSON, errors.New("must be json, csv or camt053")
+	}
+}
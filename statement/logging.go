@@ -0,0 +1,66 @@
+package statement
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/ledger"
+)
+
+// loggingService is a service logging middleware
+type loggingService struct {
+	logger log.Logger
+	s      Service
+}
+
+// NewLoggingService returns a logging service middleware
+func NewLoggingService(logger log.Logger, s Service) Service {
+	return &loggingService{logger: logger, s: s}
+}
+
+// GetStatement logs the get statement params
+func (s *loggingService) GetStatement(ctx context.Context, accntID account.AccountID,
+	from, to time.Time) (st *Statement, err error) {
+	defer func(begin time.Time) {
+		var lines int
+		if st != nil {
+			lines = len(st.Lines)
+		}
+		_ = s.logger.Log(
+			"method", "get_statement",
+			"account_id", accntID,
+			"from", from,
+			"to", to,
+			"lines", lines,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetStatement(ctx, accntID, from, to)
+}
+
+// GetLedgerStatement logs the get ledger statement params
+func (s *loggingService) GetLedgerStatement(ctx context.Context, ledgerNo ledger.LedgerNo,
+	from, to time.Time) (st *Statement, err error) {
+	defer func(begin time.Time) {
+		var lines int
+		if st != nil {
+			lines = len(st.Lines)
+		}
+		_ = s.logger.Log(
+			"method", "get_ledger_statement",
+			"ledger_no", ledgerNo,
+			"from", from,
+			"to", to,
+			"lines", lines,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetLedgerStatement(ctx, ledgerNo, from, to)
+}
@@ -0,0 +1,249 @@
+package statement
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/balance"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+const (
+	// pageSize is the number of account transactions read at a time
+	pageSize = 500
+	// maxAttempts is the max number of times the statement is read
+	// when the postings are committed while reading it
+	maxAttempts = 3
+)
+
+// Service is a statement service
+type Service interface {
+	// GetStatement retrieves the statement of the account of the
+	// postings made from the inclusive start to the exclusive end time
+	GetStatement(ctx context.Context, accntID account.AccountID,
+		from, to time.Time) (*Statement, error)
+	// GetLedgerStatement retrieves the statement of the ledger of the
+	// postings made from the inclusive start to the exclusive end time
+	GetLedgerStatement(ctx context.Context, ledgerNo ledger.LedgerNo,
+		from, to time.Time) (*Statement, error)
+}
+
+// service implements the statement service
+type service struct {
+	accountRepo account.Repository
+	ledgerRepo  ledger.Repository
+	balRepo     balance.Repository
+	xactRepo    transaction.Repository
+}
+
+var _ Service = (*service)(nil)
+
+// NewService takes an account, ledger, balance and
+// transaction repository and returns a statement service
+func NewService(accountRepo account.Repository, ledgerRepo ledger.Repository,
+	balRepo balance.Repository, xactRepo transaction.Repository) Service {
+	return &service{
+		accountRepo: accountRepo,
+		ledgerRepo:  ledgerRepo,
+		balRepo:     balRepo,
+		xactRepo:    xactRepo,
+	}
+}
+
+// source reads the balance as of the time and the postings of the period
+type source struct {
+	getBal    func(ctx context.Context, asOf time.Time) (decimal.Decimal, error)
+	listXacts func(ctx context.Context, from, to time.Time) ([]*transaction.Transaction, error)
+}
+
+// GetStatement retrieves the statement of the account of the
+// postings made from the inclusive start to the exclusive end time
+func (s *service) GetStatement(ctx context.Context, accntID account.AccountID,
+	from, to time.Time) (*Statement, error) {
+	err := validatePeriod(from, to, "account_id", accntID.Validate())
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.accountRepo.IsAccountExists(ctx, accntID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	accnt, err := s.accountRepo.GetAccount(ctx, accntID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "get account")
+	}
+
+	st := Statement{
+		AccountID: accnt.AccountID,
+		Currency:  accnt.Currency,
+		From:      from.UTC(),
+		To:        to.UTC(),
+	}
+
+	return readStatement(ctx, st, source{
+		getBal: func(ctx context.Context, asOf time.Time) (decimal.Decimal, error) {
+			bal, err := s.balRepo.GetAccntBalAsOf(ctx, accntID, asOf)
+			if err != nil {
+				return decimal.Zero, pkgerrors.Wrap(err, "get accnt bal as of")
+			}
+			return bal.CurrentBal, nil
+		},
+		listXacts: func(ctx context.Context, from, to time.Time) ([]*transaction.Transaction, error) {
+			return s.listAccntXacts(ctx, accntID, from, to)
+		},
+	})
+}
+
+// GetLedgerStatement retrieves the statement of the ledger of the
+// postings made from the inclusive start to the exclusive end time
+func (s *service) GetLedgerStatement(ctx context.Context, ledgerNo ledger.LedgerNo,
+	from, to time.Time) (*Statement, error) {
+	err := validatePeriod(from, to, "ledger_no",
+		validation.Validate(string(ledgerNo), validation.Required))
+	if err != nil {
+		return nil, err
+	}
+
+	lg, err := s.ledgerRepo.GetLedger(ctx, ledgerNo)
+	if err != nil {
+		return nil, err
+	}
+
+	st := Statement{
+		LedgerNo: lg.LedgerNo,
+		Currency: lg.Currency,
+		From:     from.UTC(),
+		To:       to.UTC(),
+	}
+
+	return readStatement(ctx, st, source{
+		getBal: func(ctx context.Context, asOf time.Time) (decimal.Decimal, error) {
+			bal, err := s.ledgerRepo.GetLedgerBal(ctx, ledgerNo, asOf)
+			if err != nil {
+				return decimal.Zero, pkgerrors.Wrap(err, "get ledger bal")
+			}
+			return bal.CurrentBal, nil
+		},
+		listXacts: func(ctx context.Context, from, to time.Time) ([]*transaction.Transaction, error) {
+			xacts, err := s.xactRepo.ListLedgerPostings(ctx, ledgerNo, from, to)
+			if err != nil {
+				return nil, pkgerrors.Wrap(err, "list ledger postings")
+			}
+			return xacts, nil
+		},
+	})
+}
+
+// listAccntXacts retrieves the account transactions of the period a page at a time
+func (s *service) listAccntXacts(ctx context.Context, accntID account.AccountID,
+	from, to time.Time) ([]*transaction.Transaction, error) {
+	f := transaction.XactFilter{
+		AccountID: accntID,
+		From:      &from,
+		To:        &to,
+		Sort:      transaction.SortAsc,
+		Limit:     pageSize,
+	}
+
+	xacts := []*transaction.Transaction{}
+	for {
+		page, err := s.xactRepo.ListAccntXacts(ctx, f)
+		if err != nil {
+			return nil, pkgerrors.Wrap(err, "list account xacts")
+		}
+
+		xacts = append(xacts, page...)
+		if len(page) < pageSize {
+			return xacts, nil
+		}
+
+		last := page[len(page)-1]
+		f.Cursor = &transaction.Cursor{Ts: *last.Ts, XactNo: last.XactNo, LegID: last.LegID}
+	}
+}
+
+// readStatement reads the statement. The postings can be committed while
+// reading it, it's read again if the lines don't reconcile with the balances.
+func readStatement(ctx context.Context, st Statement, src source) (*Statement, error) {
+	for attempt := 1; ; attempt++ {
+		st := st
+		err := st.read(ctx, src)
+		if err == nil {
+			return &st, nil
+		}
+
+		if !errors.Is(err, ErrUnbalanced) || attempt == maxAttempts {
+			return nil, err
+		}
+	}
+}
+
+// read reads the opening balance, the lines and the closing balance
+// separately, it returns ErrUnbalanced if the closing balance doesn't
+// reconcile with the opening balance and the lines
+func (st *Statement) read(ctx context.Context, src source) error {
+	openingBal, err := src.getBal(ctx, st.From)
+	if err != nil {
+		return pkgerrors.Wrap(err, "get opening bal")
+	}
+
+	st.OpeningBal = openingBal
+	st.Lines = []*Line{}
+	st.TotalDebit = decimal.Zero
+	st.TotalCredit = decimal.Zero
+	st.ClosingBal = openingBal
+
+	xacts, err := src.listXacts(ctx, st.From, st.To)
+	if err != nil {
+		return err
+	}
+
+	for _, xact := range xacts {
+		st.addXact(xact)
+	}
+
+	closingBal, err := src.getBal(ctx, st.To)
+	if err != nil {
+		return pkgerrors.Wrap(err, "get closing bal")
+	}
+
+	if !st.ClosingBal.Equal(closingBal) {
+		return ErrUnbalanced
+	}
+
+	return nil
+}
+
+// validatePeriod validates the period and the account id or ledger no
+func validatePeriod(from, to time.Time, key string, keyErr error) error {
+	err := validation.Errors{
+		key:    keyErr,
+		"from": validation.Validate(from, validation.Required),
+		"to": validation.Validate(to, validation.Required,
+			validation.By(func(interface{}) error {
+				if !to.After(from) {
+					return errors.New("must be after from")
+				}
+				return nil
+			})),
+	}.Filter()
+	if err != nil {
+		return multierr.Combine(ErrValidation, err)
+	}
+
+	return nil
+}
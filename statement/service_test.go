@@ -0,0 +1,206 @@
+package statement_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/fee"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/statement"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+var (
+	john = account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	mary = account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+)
+
+func TestStatementService(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	setup(t, store)
+
+	stService := statement.NewService(store.AccountRepo, store.LedgerRepo, store.BalRepo, store.XactRepo)
+
+	ctx := context.TODO()
+	now := time.Now()
+
+	t.Run("statement", func(t *testing.T) {
+		st, err := stService.GetStatement(ctx, john.AccountID,
+			now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+
+		assert.Equal(t, john.AccountID, st.AccountID)
+		assert.Equal(t, currency.USD, st.Currency)
+		assert.True(t, st.OpeningBal.IsZero())
+		assert.Equal(t, "100", st.TotalCredit.String())
+		assert.Equal(t, "42", st.TotalDebit.String())
+		assert.Equal(t, "58", st.ClosingBal.String())
+
+		// deposit, withdrawal, fee and sent transfer
+		require.Len(t, st.Lines, 4)
+		bal := st.OpeningBal
+		debits, credits := decimal.Zero, decimal.Zero
+		for _, line := range st.Lines {
+			bal = bal.Add(line.Credit).Sub(line.Debit)
+			assert.True(t, bal.Equal(line.Balance))
+			debits = debits.Add(line.Debit)
+			credits = credits.Add(line.Credit)
+
+			switch line.XactTypeExt {
+			case transaction.XactTypeExtDeposit:
+				assert.Equal(t, "100", line.Credit.String())
+				assert.True(t, line.Debit.IsZero())
+			case transaction.XactTypeExtWithdrawal:
+				assert.Equal(t, "10", line.Debit.String())
+			case transaction.XactTypeExtFee:
+				assert.Equal(t, "2", line.Debit.String())
+			case transaction.XactTypeExtSndTransfer:
+				assert.Equal(t, "30", line.Debit.String())
+			default:
+				t.Errorf("unexpected line %s", line.XactTypeExt)
+			}
+		}
+		assert.True(t, st.ClosingBal.Equal(bal))
+		assert.True(t, st.TotalDebit.Equal(debits))
+		assert.True(t, st.TotalCredit.Equal(credits))
+	})
+
+	t.Run("statement after the postings", func(t *testing.T) {
+		st, err := stService.GetStatement(ctx, john.AccountID,
+			now.Add(time.Hour), now.Add(2*time.Hour))
+		require.NoError(t, err)
+
+		assert.Equal(t, "58", st.OpeningBal.String())
+		assert.Empty(t, st.Lines)
+		assert.True(t, st.TotalDebit.IsZero())
+		assert.True(t, st.TotalCredit.IsZero())
+		assert.Equal(t, "58", st.ClosingBal.String())
+	})
+
+	t.Run("statement before the postings", func(t *testing.T) {
+		st, err := stService.GetStatement(ctx, mary.AccountID,
+			now.Add(-2*time.Hour), now.Add(-time.Hour))
+		require.NoError(t, err)
+
+		assert.True(t, st.OpeningBal.IsZero())
+		assert.Empty(t, st.Lines)
+		assert.True(t, st.ClosingBal.IsZero())
+	})
+
+	t.Run("ledger statement", func(t *testing.T) {
+		// the withdrawal fee is posted to the revenue ledger
+		st, err := stService.GetLedgerStatement(ctx, ledger.LedgerNo("400-USD"),
+			now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+
+		assert.Equal(t, ledger.LedgerNo("400-USD"), st.LedgerNo)
+		assert.Empty(t, st.AccountID)
+		assert.Equal(t, currency.USD, st.Currency)
+		require.Len(t, st.Lines, 1)
+		assert.Equal(t, john.AccountID, st.Lines[0].AccountID)
+		assert.Equal(t, transaction.XactTypeExtFee, st.Lines[0].XactTypeExt)
+		assert.Equal(t, "2", st.Lines[0].Credit.String())
+		assert.True(t, st.TotalDebit.IsZero())
+		assert.Equal(t, "2", st.TotalCredit.String())
+		// the balance of a ledger is its debit less credit
+		assert.Equal(t, "-2", st.ClosingBal.String())
+
+		st, err = stService.GetLedgerStatement(ctx, ledger.LedgerNo("100"),
+			now.Add(-time.Hour), now.Add(time.Hour))
+		require.NoError(t, err)
+
+		bal, err := store.LedgerRepo.GetLedgerBal(ctx, ledger.LedgerNo("100"), now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, st.OpeningBal.IsZero())
+		assert.True(t, bal.CurrentBal.Equal(st.ClosingBal))
+		assert.True(t, bal.TotalDebit.Equal(st.TotalDebit))
+		assert.True(t, bal.TotalCredit.Equal(st.TotalCredit))
+		for _, line := range st.Lines {
+			if line.XactTypeExt != 0 {
+				assert.NotEmpty(t, line.AccountID)
+			}
+		}
+
+		_, err = stService.GetLedgerStatement(ctx, ledger.LedgerNo("idontexist"),
+			now.Add(-time.Hour), now)
+		assert.ErrorIs(t, err, ledger.ErrLedgerNotFound)
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		_, err := stService.GetStatement(ctx, john.AccountID,
+			now, now.Add(-time.Hour))
+		assert.ErrorIs(t, err, statement.ErrValidation)
+		assert.Contains(t, err.Error(), "to: must be after from.")
+
+		_, err = stService.GetStatement(ctx, john.AccountID,
+			time.Time{}, now)
+		assert.ErrorIs(t, err, statement.ErrValidation)
+		assert.Contains(t, err.Error(), "from: cannot be blank.")
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		_, err := stService.GetStatement(ctx, account.AccountID("idontexist"),
+			now.Add(-time.Hour), now)
+		assert.ErrorIs(t, err, account.ErrAccountNotFound)
+	})
+}
+
+func setup(t *testing.T, store *repotest.Repos) {
+	ctx := context.TODO()
+
+	ledgerService := ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD))
+	require.NoError(t, ledgerService.CreateCashLedgers(ctx))
+	require.NoError(t, ledgerService.CreateRevenueLedgers(ctx))
+
+	_, err := store.AccountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	_, err = store.AccountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	feeSchedule, err := fee.NewSchedule(fee.Rule{
+		Currency:    currency.USD,
+		XactTypeExt: transaction.XactTypeExtWithdrawal.String(),
+		Type:        fee.TypeFlat,
+		Amount:      decimal.NewFromInt(2),
+	})
+	require.NoError(t, err)
+
+	xactSvc := transaction.NewService(store.AccountRepo, store.LedgerRepo,
+		store.XactRepo, store.BalRepo, transaction.WithFeeSchedule(feeSchedule))
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(10),
+	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(30),
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,144 @@
+// Package statement contains the account and ledger statements. A statement
+// lists the postings within a period with a running balance, starting from
+// the opening balance and ending with the closing balance. The statements
+// are rendered as json, csv or ISO 20022 camt.053 documents.
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// Statement is an account or ledger statement of the postings made
+// from the inclusive start to the exclusive end of the period.
+// The amounts are from the side of the account or ledger. The balance
+// of an account is its credit less debit, while the balance of a
+// ledger is its debit less credit.
+type Statement struct {
+	AccountID account.AccountID `json:"account_id,omitempty"`
+	LedgerNo  ledger.LedgerNo   `json:"ledger_no,omitempty"`
+	Currency  currency.Currency `json:"currency"`
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	// OpeningBal is the balance from the postings made before the period
+	OpeningBal  decimal.Decimal `json:"opening_balance"`
+	Lines       []*Line         `json:"lines"`
+	TotalDebit  decimal.Decimal `json:"total_debit"`
+	TotalCredit decimal.Decimal `json:"total_credit"`
+	// ClosingBal is the balance from the postings made before the end
+	ClosingBal decimal.Decimal `json:"closing_balance"`
+}
+
+// Line is a statement line
+type Line struct {
+	XactNo transaction.XactNo `json:"xact_no"`
+	// AccountID is the account of the posting in a ledger statement,
+	// it's empty for the ledger transactions
+	AccountID account.AccountID `json:"account_id,omitempty"`
+	// XactTypeExt is empty for the ledger transactions
+	XactTypeExt transaction.XactTypeExt `json:"xact_type_ext,omitempty"`
+	Desc        string                  `json:"desc"`
+	Debit       decimal.Decimal         `json:"debit"`
+	Credit      decimal.Decimal         `json:"credit"`
+	// Balance is the running balance after the line
+	Balance decimal.Decimal `json:"balance"`
+	Ts      time.Time       `json:"ts"`
+}
+
+// isLedger returns true if it's a ledger statement
+func (st *Statement) isLedger() bool {
+	return st.LedgerNo != ""
+}
+
+// addXact adds the transaction as a line. The transaction
+// is from the side of the ledger, the account is credited
+// when the ledger is debited and vice versa.
+func (st *Statement) addXact(xact *transaction.Transaction) {
+	line := &Line{
+		XactNo:      xact.XactNo,
+		XactTypeExt: xact.XactTypeExt,
+		Desc:        xact.Desc,
+		Debit:       decimal.Zero,
+		Credit:      decimal.Zero,
+	}
+	if xact.Ts != nil {
+		line.Ts = xact.Ts.UTC()
+	}
+
+	isDebit := xact.XactType == transaction.XactTypeDebit
+	if st.isLedger() {
+		line.AccountID = xact.AccountID
+	} else {
+		isDebit = !isDebit
+	}
+
+	if isDebit {
+		line.Debit = xact.Amount
+		st.TotalDebit = st.TotalDebit.Add(xact.Amount)
+	} else {
+		line.Credit = xact.Amount
+		st.TotalCredit = st.TotalCredit.Add(xact.Amount)
+	}
+
+	if st.isLedger() {
+		st.ClosingBal = st.ClosingBal.Add(line.Debit).Sub(line.Credit)
+	} else {
+		st.ClosingBal = st.ClosingBal.Add(line.Credit).Sub(line.Debit)
+	}
+	line.Balance = st.ClosingBal
+	st.Lines = append(st.Lines, line)
+}
+
+// csvHeader is the header of the csv statement
+var csvHeader = []string{"ts", "xact_no", "xact_type_ext",
+	"desc", "debit", "credit", "balance"}
+
+// WriteCSV writes the statement as csv. The first row after the header is
+// the opening balance and the last row is the closing balance with the
+// total debit and credit of the lines.
+func (st *Statement) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	rows := make([][]string, 0, len(st.Lines)+3)
+	rows = append(rows, csvHeader, []string{
+		formatTs(st.From), "", "", "opening balance",
+		"", "", st.OpeningBal.String(),
+	})
+
+	for _, line := range st.Lines {
+		var xactTypeExt string
+		if line.XactTypeExt != 0 {
+			xactTypeExt = line.XactTypeExt.String()
+		}
+
+		rows = append(rows, []string{
+			formatTs(line.Ts),
+			string(line.XactNo),
+			xactTypeExt,
+			line.Desc,
+			line.Debit.String(),
+			line.Credit.String(),
+			line.Balance.String(),
+		})
+	}
+
+	rows = append(rows, []string{
+		formatTs(st.To), "", "", "closing balance",
+		st.TotalDebit.String(), st.TotalCredit.String(),
+		st.ClosingBal.String(),
+	})
+
+	return cw.WriteAll(rows)
+}
+
+func formatTs(ts time.Time) string {
+	return ts.UTC().Format(time.RFC3339Nano)
+}
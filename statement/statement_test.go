@@ -0,0 +1,61 @@
+package statement_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/statement"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+func TestStatementWriteCSV(t *testing.T) {
+	from := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	st := &statement.Statement{
+		AccountID:  john.AccountID,
+		Currency:   currency.USD,
+		From:       from,
+		To:         from.AddDate(0, 1, 0),
+		OpeningBal: decimal.RequireFromString("10.5"),
+		Lines: []*statement.Line{
+			{
+				XactNo:      transaction.XactNo("xact1"),
+				XactTypeExt: transaction.XactTypeExtDeposit,
+				Desc:        "salary, june",
+				Debit:       decimal.Zero,
+				Credit:      decimal.NewFromInt(100),
+				Balance:     decimal.RequireFromString("110.5"),
+				Ts:          from.Add(time.Hour),
+			},
+			{
+				XactNo:      transaction.XactNo("xact2"),
+				XactTypeExt: transaction.XactTypeExtWithdrawal,
+				Desc:        "withdrawal",
+				Debit:       decimal.RequireFromString("20.25"),
+				Credit:      decimal.Zero,
+				Balance:     decimal.RequireFromString("90.25"),
+				Ts:          from.Add(2 * time.Hour),
+			},
+		},
+		TotalDebit:  decimal.RequireFromString("20.25"),
+		TotalCredit: decimal.NewFromInt(100),
+		ClosingBal:  decimal.RequireFromString("90.25"),
+	}
+
+	var buf bytes.Buffer
+	err := st.WriteCSV(&buf)
+	require.NoError(t, err)
+
+	expect := `ts,xact_no,xact_type_ext,desc,debit,credit,balance
+2021-06-01T00:00:00Z,,,opening balance,,,10.5
+2021-06-01T01:00:00Z,xact1,Dp,"salary, june",0,100,110.5
+2021-06-01T02:00:00Z,xact2,Wd,withdrawal,20.25,0,90.25
+2021-07-01T00:00:00Z,,,closing balance,20.25,100,90.25
+`
+	assert.Equal(t, expect, buf.String())
+}
@@ -0,0 +1,212 @@
+package statement
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/ledger"
+)
+
+// NewHTTPHandler returns the statement http handler,
+// it is mounted under the account route containing the {id} url param
+func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	getStatementHandler := kithttp.NewServer(
+		newGetStatementEndpoint(s),
+		decodeGetStatementRequest,
+		encodeGetStatementResponse,
+		opts...,
+	)
+
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", getStatementHandler)
+
+	return mux
+}
+
+// NewLedgerHTTPHandler returns the ledger statement http handler,
+// it is mounted under the ledger route containing the {no} url param
+func NewLedgerHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	getLedgerStatementHandler := kithttp.NewServer(
+		newGetLedgerStatementEndpoint(s),
+		decodeGetLedgerStatementRequest,
+		encodeGetStatementResponse,
+		opts...,
+	)
+
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", getLedgerStatementHandler)
+
+	return mux
+}
+
+var (
+	errBadRoute = errors.New("bad route")
+)
+
+func decodeGetStatementRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accntID := chi.URLParam(r, "id")
+	if accntID == "" {
+		return nil, errBadRoute
+	}
+
+	from, to, f, err := parseParams(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return getStatementRequest{
+		AccountID: account.AccountID(accntID),
+		From:      from,
+		To:        to,
+		Format:    f,
+	}, nil
+}
+
+func decodeGetLedgerStatementRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	ledgerNo := chi.URLParam(r, "no")
+	if ledgerNo == "" {
+		return nil, errBadRoute
+	}
+
+	from, to, f, err := parseParams(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	return getLedgerStatementRequest{
+		LedgerNo: ledger.LedgerNo(ledgerNo),
+		From:     from,
+		To:       to,
+		Format:   f,
+	}, nil
+}
+
+// parseParams parses the period and the format query params
+func parseParams(q url.Values) (from, to time.Time, f Format, err error) {
+	from, fromErr := parseTs(q, "from")
+	to, toErr := parseTs(q, "to")
+	f, formatErr := ParseFormat(q.Get("format"))
+
+	err = validation.Errors{
+		"from":   fromErr,
+		"to":     toErr,
+		"format": formatErr,
+	}.Filter()
+	if err != nil {
+		return from, to, f, multierr.Combine(ErrValidation, err)
+	}
+
+	return from, to, f, nil
+}
+
+// parseTs parses the timestamp query param, it's zero if not set
+func parseTs(q url.Values, key string) (time.Time, error) {
+	v := q.Get(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	ts, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, errors.New("must be an RFC 3339 timestamp")
+	}
+
+	return ts, nil
+}
+
+func encodeGetStatementResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	resp := response.(getStatementResponse)
+	if resp.Err != nil || resp.format == FormatJSON {
+		return encodeResponse(ctx, w, resp)
+	}
+
+	st := resp.Statement
+	name := "statement-" + string(st.AccountID)
+	if st.isLedger() {
+		name = "statement-ledger-" + string(st.LedgerNo)
+	}
+	name += "-" + st.From.Format("20060102") + "-" + st.To.Format("20060102")
+
+	if resp.format == FormatCSV {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+		return st.WriteCSV(w)
+	}
+
+	msgID, err := NewMsgID()
+	if err != nil {
+		encodeError(ctx, pkgerrors.Wrap(err, "new msg id"), w)
+		return nil
+	}
+
+	// the document is written to a buffer first, so
+	// that its errors are encoded like the others
+	var buf bytes.Buffer
+	err = st.WriteCamt053(&buf, msgID, time.Now())
+	if err != nil {
+		encodeError(ctx, err, w)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xml"`, name))
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+// errorer is an error interface for response
+type errorer interface {
+	error() error
+}
+
+// encode errors from business-logic
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if errors.Is(err, ErrValidation) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	} else if errors.Is(err, account.ErrAccountNotFound) ||
+		errors.Is(err, ledger.ErrLedgerNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
@@ -0,0 +1,192 @@
+package statement_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/statement"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	setup(t, store)
+
+	logger := log.NewNopLogger()
+	var stService statement.Service
+	stService = statement.NewService(store.AccountRepo, store.LedgerRepo, store.BalRepo, store.XactRepo)
+	stService = statement.NewLoggingService(logger, stService)
+
+	mux := chi.NewMux()
+	mux.Mount("/accounts/{id}/statement", statement.NewHTTPHandler(stService, logger))
+	mux.Mount("/ledgers/{no}/statement", statement.NewLedgerHTTPHandler(stService, logger))
+
+	ctx := context.TODO()
+
+	now := time.Now()
+	query := func(from, to time.Time, format string) string {
+		q := url.Values{}
+		q.Set("from", from.Format(time.RFC3339))
+		q.Set("to", to.Format(time.RFC3339))
+		if format != "" {
+			q.Set("format", format)
+		}
+		return q.Encode()
+	}
+
+	serve := func(t *testing.T, target string) *httptest.ResponseRecorder {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httpReq)
+		return rr
+	}
+
+	t.Run("json", func(t *testing.T) {
+		rr := serve(t, "/accounts/"+string(john.AccountID)+"/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), ""))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Statement struct {
+				AccountID   string `json:"account_id"`
+				Currency    string `json:"currency"`
+				OpeningBal  string `json:"opening_balance"`
+				TotalDebit  string `json:"total_debit"`
+				TotalCredit string `json:"total_credit"`
+				ClosingBal  string `json:"closing_balance"`
+				Lines       []struct {
+					XactNo      string `json:"xact_no"`
+					XactTypeExt string `json:"xact_type_ext"`
+					Debit       string `json:"debit"`
+					Credit      string `json:"credit"`
+					Balance     string `json:"balance"`
+				} `json:"lines"`
+			} `json:"statement"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+
+		st := resp.Statement
+		assert.Equal(t, string(john.AccountID), st.AccountID)
+		assert.Equal(t, "USD", st.Currency)
+		assert.Equal(t, "0", st.OpeningBal)
+		assert.Equal(t, "42", st.TotalDebit)
+		assert.Equal(t, "100", st.TotalCredit)
+		assert.Equal(t, "58", st.ClosingBal)
+		require.Len(t, st.Lines, 4)
+		assert.Equal(t, "58", st.Lines[3].Balance)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		rr := serve(t, "/accounts/"+string(john.AccountID)+"/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), "csv"))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Content-Disposition"), "statement-johndoe-")
+
+		rows, err := csv.NewReader(rr.Body).ReadAll()
+		require.NoError(t, err)
+
+		// header, opening balance, 4 lines and closing balance
+		require.Len(t, rows, 7)
+		assert.Equal(t, []string{"ts", "xact_no", "xact_type_ext",
+			"desc", "debit", "credit", "balance"}, rows[0])
+		assert.Equal(t, "opening balance", rows[1][3])
+		assert.Equal(t, "0", rows[1][6])
+		assert.Equal(t, []string{"closing balance", "42", "100", "58"}, rows[6][3:])
+	})
+
+	t.Run("camt053", func(t *testing.T) {
+		rr := serve(t, "/accounts/"+string(john.AccountID)+"/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), "camt053"))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Content-Disposition"), ".xml")
+
+		validateCamt053(t, rr.Body.Bytes())
+
+		var doc struct {
+			Stmt struct {
+				AcctID string `xml:"Acct>Id>Othr>Id"`
+				Bal    []struct {
+					Cd        string `xml:"Tp>CdOrPrtry>Cd"`
+					Amt       string `xml:"Amt"`
+					CdtDbtInd string `xml:"CdtDbtInd"`
+				} `xml:"Bal"`
+				Ntry []struct {
+					NtryRef string `xml:"NtryRef"`
+				} `xml:"Ntry"`
+			} `xml:"BkToCstmrStmt>Stmt"`
+		}
+		err := xml.Unmarshal(rr.Body.Bytes(), &doc)
+		require.NoError(t, err)
+		assert.Equal(t, string(john.AccountID), doc.Stmt.AcctID)
+		require.Len(t, doc.Stmt.Bal, 2)
+		assert.Equal(t, "OPBD", doc.Stmt.Bal[0].Cd)
+		assert.Equal(t, "0.00", doc.Stmt.Bal[0].Amt)
+		assert.Equal(t, "CLBD", doc.Stmt.Bal[1].Cd)
+		assert.Equal(t, "58.00", doc.Stmt.Bal[1].Amt)
+		assert.Equal(t, "CRDT", doc.Stmt.Bal[1].CdtDbtInd)
+		assert.Len(t, doc.Stmt.Ntry, 4)
+	})
+
+	t.Run("ledger statement", func(t *testing.T) {
+		rr := serve(t, "/ledgers/400-USD/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), ""))
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Statement struct {
+				LedgerNo   string `json:"ledger_no"`
+				ClosingBal string `json:"closing_balance"`
+			} `json:"statement"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "400-USD", resp.Statement.LedgerNo)
+		assert.Equal(t, "-2", resp.Statement.ClosingBal)
+
+		rr = serve(t, "/ledgers/100/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), "camt053"))
+		require.Equal(t, http.StatusOK, rr.Code)
+		validateCamt053(t, rr.Body.Bytes())
+
+		rr = serve(t, "/ledgers/idontexist/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), "camt053"))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		rr := serve(t, "/accounts/"+string(john.AccountID)+"/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), "xml"))
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		rr = serve(t, "/accounts/"+string(john.AccountID)+"/statement?from=yesterday")
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+		rr = serve(t, "/accounts/"+string(john.AccountID)+"/statement")
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		rr := serve(t, "/accounts/idontexist/statement?"+
+			query(now.Add(-time.Hour), now.Add(time.Hour), "csv"))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -0,0 +1,503 @@
+package transaction
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
+)
+
+// List of batch transfer limits
+const (
+	// maxBatchTransfers is the max number of transfers of a batch
+	maxBatchTransfers = 5000
+	// maxBatchIdempotencyKeyLen is the max length of the idempotency key of
+	// a batch, the key of each transfer is suffixed by ":<index>"
+	maxBatchIdempotencyKeyLen = maxIdempotencyKeyLen - 5
+)
+
+// BatchMode is the mode of a batch transfer
+type BatchMode int
+
+// List of batch modes
+const (
+	// BatchModeAtomic posts all the transfers of the batch or none of them
+	BatchModeAtomic BatchMode = iota + 1
+	// BatchModeBestEffort posts the transfers that can be
+	// made and reports the error of the ones that can't
+	BatchModeBestEffort
+)
+
+// String implements Stringer
+func (bm BatchMode) String() string {
+	return [...]string{
+		"invalid",
+		"atomic",
+		"best_effort",
+	}[bm]
+}
+
+// strToBatchMode takes a string and returns the batch mode
+func strToBatchMode(s string) BatchMode {
+	switch s {
+	case "atomic":
+		return BatchModeAtomic
+	case "best_effort":
+		return BatchModeBestEffort
+	}
+
+	return BatchMode(0)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (bm BatchMode) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(bm.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (bm *BatchMode) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	*bm = strToBatchMode(s)
+	return nil
+}
+
+// BatchTransferXact is a batch of transfer transactions
+type BatchTransferXact struct {
+	Mode      BatchMode
+	Transfers []TransferXact
+	// IdempotencyKey is an optional key for safely retrying the
+	// batch, the key of each transfer is suffixed by its index
+	IdempotencyKey string
+}
+
+// Validate validates the batch transfer params, the
+// transfers are validated when the batch is made
+func (bx BatchTransferXact) Validate() error {
+	return validation.Errors{
+		"mode": validation.Validate(bx.Mode,
+			validation.Required.Error("must be either atomic or best_effort"),
+			validation.In(BatchModeAtomic, BatchModeBestEffort).
+				Error("must be either atomic or best_effort"),
+		),
+		"transfers": validation.Validate(len(bx.Transfers),
+			validation.Required.Error("must not be empty"),
+			validation.Max(maxBatchTransfers),
+		),
+		"idempotency_key": validation.Validate(bx.IdempotencyKey,
+			validation.Length(0, maxBatchIdempotencyKeyLen),
+		),
+	}.Filter()
+}
+
+// BatchItem is the result of a transfer of a batch
+type BatchItem struct {
+	// Index is the index of the transfer in the batch
+	Index int
+	// Xact is the transfer transaction, nil if the transfer failed
+	Xact *Xact
+	// Err is the reason why the transfer failed
+	Err error
+}
+
+// BatchTransfer is the result of a batch transfer
+type BatchTransfer struct {
+	Mode BatchMode
+	// Items are the results of the transfers in the same order
+	Items []*BatchItem
+}
+
+// batchTransfer is a transfer of a batch that passed the checks
+// that don't need the accounts to be locked
+type batchTransfer struct {
+	TransferXact
+	xactNo       XactNo
+	from, to     *account.Account
+	cashLedgerNo ledger.LedgerNo
+	fee          decimal.Decimal
+}
+
+// batchSender keeps track of the funds and the usage of a
+// sending account as the transfers of a batch are posted
+type batchSender struct {
+	funds          decimal.Decimal
+	limits         limit.Limits
+	daily, monthly limit.Usage
+}
+
+// MakeBatchTransfer creates the transfer transactions of a batch in
+// a single tx. The accounts are locked once and the balance and limits
+// of each sending account are checked against its running total.
+//
+// In atomic mode, the first transfer that fails rolls back the batch.
+// In best-effort mode, the transfers that fail are skipped and their
+// errors are reported, the batch is rolled back only on internal errors.
+func (s *service) MakeBatchTransfer(ctx context.Context, bx BatchTransferXact) (bt *BatchTransfer, err error) {
+	err = bx.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	atomic := bx.Mode == BatchModeAtomic
+	bt = &BatchTransfer{
+		Mode:  bx.Mode,
+		Items: make([]*BatchItem, len(bx.Transfers)),
+	}
+
+	var (
+		// accounts are shared by the transfers of the batch
+		accnts   = map[account.AccountID]*account.Account{}
+		accntIDs = []account.AccountID{}
+		locked   = map[account.AccountID]bool{}
+		btrs     = make([]*batchTransfer, len(bx.Transfers))
+		prepared = 0
+	)
+	for i, tr := range bx.Transfers {
+		bt.Items[i] = &BatchItem{Index: i}
+		if bx.IdempotencyKey != "" {
+			tr.IdempotencyKey = fmt.Sprintf("%s:%d", bx.IdempotencyKey, i)
+		}
+
+		var btr *batchTransfer
+		btr, err = s.prepareBatchTransfer(ctx, tr, accnts)
+		if err != nil {
+			if atomic {
+				return nil, pkgerrors.Wrapf(err, "transfer %d", i)
+			}
+
+			bt.Items[i].Err = err
+			err = nil
+			continue
+		}
+
+		for _, accntID := range []account.AccountID{btr.from.AccountID, btr.to.AccountID} {
+			if !locked[accntID] {
+				locked[accntID] = true
+				accntIDs = append(accntIDs, accntID)
+			}
+		}
+
+		btrs[i] = btr
+		prepared++
+	}
+
+	// none of the transfers can be made
+	if prepared == 0 {
+		return bt, nil
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			bt = nil
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+			bt = nil
+		}
+	}()
+
+	// lock all the accounts at once in a deterministic order to avoid deadlocks
+	err = s.balRepo.LockAccnts(ctx, tx, accntIDs...)
+	if err != nil {
+		err = pkgerrors.Wrap(err, "lock accounts")
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	unpostable := map[account.AccountID]error{}
+	for _, accntID := range accntIDs {
+		var status account.Status
+		status, err = s.accountRepo.GetAccountStatus(ctx, tx, accntID)
+		if err != nil {
+			err = pkgerrors.Wrap(err, "get account status")
+			return
+		}
+
+		if postErr := status.CheckPostable(); postErr != nil {
+			unpostable[accntID] = pkgerrors.Wrapf(postErr, "account %s", accntID)
+		}
+	}
+
+	// the balance and usage of each sending account is retrieved once
+	senders := map[account.AccountID]*batchSender{}
+	for _, btr := range btrs {
+		if btr == nil {
+			continue
+		}
+
+		if _, ok := senders[btr.from.AccountID]; ok {
+			continue
+		}
+
+		var snd *batchSender
+		snd, err = s.getBatchSender(ctx, tx, btr.from)
+		if err != nil {
+			err = pkgerrors.Wrap(err, "get batch sender")
+			return
+		}
+		senders[btr.from.AccountID] = snd
+	}
+
+	for i, btr := range btrs {
+		if btr == nil {
+			continue
+		}
+
+		var (
+			xact      *Xact
+			rejection error
+		)
+		xact, rejection, err = s.postBatchTransfer(ctx, tx, btr, senders, unpostable)
+		if err != nil {
+			err = pkgerrors.Wrapf(err, "transfer %d", i)
+			return
+		}
+
+		if rejection != nil {
+			if atomic {
+				err = pkgerrors.Wrapf(rejection, "transfer %d", i)
+				return
+			}
+
+			bt.Items[i].Err = rejection
+			continue
+		}
+
+		bt.Items[i].Xact = xact
+	}
+
+	return bt, nil
+}
+
+// prepareBatchTransfer checks the transfer params and its accounts,
+// accnts caches the accounts that were already retrieved
+func (s *service) prepareBatchTransfer(ctx context.Context, tr TransferXact,
+	accnts map[account.AccountID]*account.Account) (*batchTransfer, error) {
+	err := tr.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	from, err := s.getBatchAccount(ctx, tr.FromAccount, accnts)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "get from account")
+	}
+
+	if from == nil {
+		return nil, ErrSendingAccountNotFound
+	}
+
+	to, err := s.getBatchAccount(ctx, tr.ToAccount, accnts)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "get to account")
+	}
+
+	if to == nil {
+		return nil, ErrReceivingAccountNotFound
+	}
+
+	// validate that two accounts have the same currency
+	if from.Currency != to.Currency {
+		return nil, pkgerrors.Wrap(ErrDifferentCurrencies, "sending and receiving account have different currencies")
+	}
+
+	if !from.Currency.IsValidAmount(tr.Amount) {
+		return nil, multierr.Combine(ErrValidation, ErrAmountPrecision)
+	}
+
+	cashLedgerNo, err := ledger.GetCashLedgerNo(from.Currency)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "get cash ledger no")
+	}
+
+	xactNo, err := NewXactNo()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "new xact no")
+	}
+
+	return &batchTransfer{
+		TransferXact: tr,
+		xactNo:       xactNo,
+		from:         from,
+		to:           to,
+		cashLedgerNo: cashLedgerNo,
+		fee:          s.feeSchedule.Fee(from.Currency, XactTypeExtSndTransfer.String(), tr.Amount),
+	}, nil
+}
+
+// getBatchAccount retrieves the account from the cache or from the
+// repository, it returns a nil account if the account doesn't exist
+func (s *service) getBatchAccount(ctx context.Context, accntID account.AccountID,
+	accnts map[account.AccountID]*account.Account) (*account.Account, error) {
+	if accnt, ok := accnts[accntID]; ok {
+		return accnt, nil
+	}
+
+	exists, err := s.accountRepo.IsAccountExists(ctx, accntID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, nil
+	}
+
+	accnt, err := s.accountRepo.GetAccount(ctx, accntID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "get account")
+	}
+	accnts[accntID] = accnt
+
+	return accnt, nil
+}
+
+// getBatchSender retrieves the available funds, limits and usage of
+// the sending account, the account must be locked within tx
+func (s *service) getBatchSender(ctx context.Context, tx tx.Tx, accnt *account.Account) (*batchSender, error) {
+	funds, err := s.getAvailableFunds(ctx, tx, accnt.AccountID)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "get available funds")
+	}
+
+	snd := &batchSender{
+		funds:  funds,
+		limits: s.limitPolicy.Limits(accnt.AccountID, accnt.Currency),
+	}
+	if snd.limits.IsZero() {
+		return snd, nil
+	}
+
+	daily, monthly, err := s.getOutgoingUsage(ctx, tx, accnt.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	snd.daily, snd.monthly = *daily, *monthly
+
+	return snd, nil
+}
+
+// postBatchTransfer posts the transfer within tx. The rejection is the
+// reason why the transfer can't be made, the error is an internal error.
+func (s *service) postBatchTransfer(ctx context.Context, tx tx.Tx, btr *batchTransfer,
+	senders map[account.AccountID]*batchSender,
+	unpostable map[account.AccountID]error) (xact *Xact, rejection, err error) {
+	// the transfer was already made if its key was claimed
+	if btr.IdempotencyKey != "" {
+		var ik *IdempotencyKey
+		ik, err = s.xactRepo.GetIdempotencyKey(ctx, tx, btr.IdempotencyKey)
+		switch {
+		case err == nil:
+			if ik.Fingerprint != btr.fingerprint("transfer") {
+				return nil, ErrIdempotencyKeyConflict, nil
+			}
+
+			xact, err = s.getXact(ctx, tx, ik.XactNo)
+			if err != nil {
+				return nil, nil, pkgerrors.Wrap(err, "get xact")
+			}
+			return xact, nil, nil
+		case !errors.Is(err, ErrIdempotencyKeyNotFound):
+			return nil, nil, pkgerrors.Wrap(err, "get idempotency key")
+		}
+	}
+
+	for _, accntID := range []account.AccountID{btr.from.AccountID, btr.to.AccountID} {
+		if postErr, ok := unpostable[accntID]; ok {
+			return nil, postErr, nil
+		}
+	}
+
+	// sending account must have sufficient balance, the
+	// fee is charged on top of the amount
+	snd := senders[btr.from.AccountID]
+	debit := btr.Amount.Add(btr.fee)
+	if debit.GreaterThan(snd.funds) {
+		return nil, ErrInsufficientBalance, nil
+	}
+
+	if !snd.limits.IsZero() {
+		rejection = snd.limits.Check(btr.Amount, true, snd.daily, snd.monthly)
+		if rejection != nil {
+			return nil, rejection, nil
+		}
+	}
+
+	if btr.IdempotencyKey != "" {
+		var (
+			origXactNo XactNo
+			replayed   bool
+		)
+		origXactNo, replayed, err = s.claimIdempotencyKey(ctx, tx, IdempotencyKey{
+			Key:         btr.IdempotencyKey,
+			Fingerprint: btr.fingerprint("transfer"),
+			XactNo:      btr.xactNo,
+		})
+		if err != nil {
+			if errors.Is(err, ErrIdempotencyKeyConflict) {
+				return nil, err, nil
+			}
+			return nil, nil, pkgerrors.Wrap(err, "claim idempotency key")
+		}
+
+		// the key was claimed by a concurrent batch
+		if replayed {
+			xact, err = s.getXact(ctx, tx, origXactNo)
+			if err != nil {
+				return nil, nil, pkgerrors.Wrap(err, "get xact")
+			}
+			return xact, nil, nil
+		}
+	}
+
+	err = s.postTransfer(ctx, tx, btr.xactNo, btr.cashLedgerNo,
+		btr.from, btr.to, btr.Amount, btr.fee)
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "post transfer")
+	}
+
+	snd.funds = snd.funds.Sub(debit)
+	snd.daily.Amount = snd.daily.Amount.Add(btr.Amount)
+	snd.daily.Transfers++
+	snd.monthly.Amount = snd.monthly.Amount.Add(btr.Amount)
+	snd.monthly.Transfers++
+
+	// the receiving account can spend the amount in the next transfers
+	if rcv, ok := senders[btr.to.AccountID]; ok {
+		rcv.funds = rcv.funds.Add(btr.Amount)
+	}
+
+	xact, err = s.getXact(ctx, tx, btr.xactNo)
+	if err != nil {
+		return nil, nil, pkgerrors.Wrap(err, "get xact")
+	}
+
+	return xact, nil, nil
+}
@@ -12,11 +12,14 @@ import (
 type depositRequest struct {
 	AccountID account.AccountID `json:"account_id"`
 	Amount    decimal.Decimal   `json:"amount"`
+	// IdempotencyKey is taken from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
 }
 
 // depositResponse is a deposit response
 type depositResponse struct {
-	Err error `json:"error,omitempty"`
+	Xact *Xact `json:"transaction,omitempty"`
+	Err  error `json:"error,omitempty"`
 }
 
 func (r depositResponse) error() error { return r.Err }
@@ -25,8 +28,8 @@ func (r depositResponse) error() error { return r.Err }
 func newDepositEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(depositRequest)
-		err := s.MakeDeposit(ctx, DepositXact(req))
-		return depositResponse{Err: err}, nil
+		xact, err := s.MakeDeposit(ctx, DepositXact(req))
+		return depositResponse{Xact: xact, Err: err}, nil
 	}
 }
 
@@ -34,11 +37,15 @@ func newDepositEndpoint(s Service) endpoint.Endpoint {
 type withdrawalRequest struct {
 	AccountID account.AccountID `json:"account_id"`
 	Amount    decimal.Decimal   `json:"amount"`
+	// IdempotencyKey is taken from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
 }
 
 // withdrawalResponse is a withdrawal response
 type withdrawalResponse struct {
-	Err error `json:"error,omitempty"`
+	Xact *Xact            `json:"transaction,omitempty"`
+	Fee  *decimal.Decimal `json:"fee,omitempty"`
+	Err  error            `json:"error,omitempty"`
 }
 
 func (r withdrawalResponse) error() error { return r.Err }
@@ -47,8 +54,8 @@ func (r withdrawalResponse) error() error { return r.Err }
 func newWithdrawalEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(withdrawalRequest)
-		err := s.MakeWithdrawal(ctx, WithdrawalXact(req))
-		return withdrawalResponse{Err: err}, nil
+		xact, err := s.MakeWithdrawal(ctx, WithdrawalXact(req))
+		return withdrawalResponse{Xact: xact, Fee: xactFee(xact), Err: err}, nil
 	}
 }
 
@@ -57,11 +64,15 @@ type paymentRequest struct {
 	FromAccount account.AccountID `json:"from_account"`
 	ToAccount   account.AccountID `json:"to_account"`
 	Amount      decimal.Decimal   `json:"amount"`
+	// IdempotencyKey is taken from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
 }
 
 // paymentResponse is a payment response
 type paymentResponse struct {
-	Err error `json:"error,omitempty"`
+	Xact *Xact            `json:"transaction,omitempty"`
+	Fee  *decimal.Decimal `json:"fee,omitempty"`
+	Err  error            `json:"error,omitempty"`
 }
 
 func (r paymentResponse) error() error { return r.Err }
@@ -70,8 +81,256 @@ func (r paymentResponse) error() error { return r.Err }
 func newPaymentEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(paymentRequest)
-		err := s.MakeTransfer(ctx, TransferXact(req))
-		return paymentResponse{Err: err}, nil
+		xact, err := s.MakeTransfer(ctx, TransferXact(req))
+		return paymentResponse{Xact: xact, Fee: xactFee(xact), Err: err}, nil
+	}
+}
+
+// batchPaymentRequest is a batch payment request
+type batchPaymentRequest struct {
+	Mode     BatchMode        `json:"mode"`
+	Payments []paymentRequest `json:"payments"`
+	// IdempotencyKey is taken from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
+}
+
+// batchPaymentResult is the result of a payment of a batch
+type batchPaymentResult struct {
+	Index int              `json:"index"`
+	Xact  *Xact            `json:"transaction,omitempty"`
+	Fee   *decimal.Decimal `json:"fee,omitempty"`
+	Err   string           `json:"error,omitempty"`
+}
+
+// batchPaymentResponse is a batch payment response
+type batchPaymentResponse struct {
+	Mode    BatchMode             `json:"mode,omitempty"`
+	Results []*batchPaymentResult `json:"results,omitempty"`
+	Err     error                 `json:"error,omitempty"`
+}
+
+func (r batchPaymentResponse) error() error { return r.Err }
+
+// newBatchPaymentEndpoint returns a batch payment endpoint
+func newBatchPaymentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(batchPaymentRequest)
+		trs := make([]TransferXact, 0, len(req.Payments))
+		for _, p := range req.Payments {
+			trs = append(trs, TransferXact(p))
+		}
+
+		bt, err := s.MakeBatchTransfer(ctx, BatchTransferXact{
+			Mode:           req.Mode,
+			Transfers:      trs,
+			IdempotencyKey: req.IdempotencyKey,
+		})
+		if err != nil {
+			return batchPaymentResponse{Err: err}, nil
+		}
+
+		results := make([]*batchPaymentResult, 0, len(bt.Items))
+		for _, item := range bt.Items {
+			result := &batchPaymentResult{
+				Index: item.Index,
+				Xact:  item.Xact,
+				Fee:   xactFee(item.Xact),
+			}
+			if item.Err != nil {
+				result.Err = item.Err.Error()
+			}
+			results = append(results, result)
+		}
+
+		return batchPaymentResponse{Mode: bt.Mode, Results: results}, nil
+	}
+}
+
+// xactFee returns the fee of the transaction, nil if there's no transaction
+func xactFee(xact *Xact) *decimal.Decimal {
+	if xact == nil {
+		return nil
+	}
+
+	fee := xact.Fee()
+	return &fee
+}
+
+// fxPaymentResponse is an fx payment response
+type fxPaymentResponse struct {
+	FXTransfer *FXTransfer `json:"fx_transfer,omitempty"`
+	Err        error       `json:"error,omitempty"`
+}
+
+func (r fxPaymentResponse) error() error { return r.Err }
+
+// newFXPaymentEndpoint returns an fx payment endpoint
+func newFXPaymentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(paymentRequest)
+		fxTr, err := s.MakeFXTransfer(ctx, TransferXact(req))
+		return fxPaymentResponse{FXTransfer: fxTr, Err: err}, nil
+	}
+}
+
+// journalRequest is a journal entry request
+type journalRequest struct {
+	Desc string       `json:"desc"`
+	Legs []JournalLeg `json:"legs"`
+	// IdempotencyKey is taken from the Idempotency-Key header
+	IdempotencyKey string `json:"-"`
+}
+
+// journalResponse is a journal entry response
+type journalResponse struct {
+	Xact *Xact `json:"transaction,omitempty"`
+	Err  error `json:"error,omitempty"`
+}
+
+func (r journalResponse) error() error { return r.Err }
+
+// newJournalEndpoint returns a journal entry endpoint
+func newJournalEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(journalRequest)
+		xact, err := s.MakeJournalEntry(ctx, JournalXact(req))
+		return journalResponse{Xact: xact, Err: err}, nil
+	}
+}
+
+// getXactRequest is a get transaction request
+type getXactRequest struct {
+	XactNo XactNo
+}
+
+// getXactResponse is a get transaction response
+type getXactResponse struct {
+	Xact *Xact `json:"transaction,omitempty"`
+	Err  error `json:"error,omitempty"`
+}
+
+func (r getXactResponse) error() error { return r.Err }
+
+// newGetXactEndpoint returns a get transaction endpoint
+func newGetXactEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getXactRequest)
+		xact, err := s.GetXact(ctx, req.XactNo)
+		return getXactResponse{Xact: xact, Err: err}, nil
+	}
+}
+
+// reverseRequest is a reversal request
+type reverseRequest struct {
+	XactNo XactNo `json:"-"`
+	Reason string `json:"reason"`
+}
+
+// reverseResponse is a reversal response
+type reverseResponse struct {
+	Reversal *Reversal `json:"reversal,omitempty"`
+	Err      error     `json:"error,omitempty"`
+}
+
+func (r reverseResponse) error() error { return r.Err }
+
+// newReverseEndpoint returns a reversal endpoint
+func newReverseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(reverseRequest)
+		rev, err := s.ReverseXact(ctx, ReversalXact(req))
+		return reverseResponse{Reversal: rev, Err: err}, nil
+	}
+}
+
+// refundRequest is a refund request
+type refundRequest struct {
+	XactNo XactNo          `json:"-"`
+	Amount decimal.Decimal `json:"amount"`
+	Reason string          `json:"reason"`
+}
+
+// refundResponse is a refund response
+type refundResponse struct {
+	Refund *Reversal `json:"refund,omitempty"`
+	Err    error     `json:"error,omitempty"`
+}
+
+func (r refundResponse) error() error { return r.Err }
+
+// newRefundEndpoint returns a refund endpoint
+func newRefundEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(refundRequest)
+		rev, err := s.RefundTransfer(ctx, RefundXact(req))
+		return refundResponse{Refund: rev, Err: err}, nil
+	}
+}
+
+// holdRequest is a hold request
+type holdRequest struct {
+	AccountID account.AccountID `json:"account_id"`
+	Amount    decimal.Decimal   `json:"amount"`
+}
+
+// holdResponse is a hold response
+type holdResponse struct {
+	Hold *Hold `json:"hold,omitempty"`
+	Err  error `json:"error,omitempty"`
+}
+
+func (r holdResponse) error() error { return r.Err }
+
+// newHoldEndpoint returns a hold endpoint
+func newHoldEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(holdRequest)
+		hold, err := s.PlaceHold(ctx, HoldXact(req))
+		return holdResponse{Hold: hold, Err: err}, nil
+	}
+}
+
+// captureRequest is a capture request
+type captureRequest struct {
+	HoldID    HoldID            `json:"-"`
+	Amount    decimal.Decimal   `json:"amount"`
+	ToAccount account.AccountID `json:"to_account"`
+}
+
+// newCaptureEndpoint returns a capture endpoint
+func newCaptureEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(captureRequest)
+		hold, err := s.CaptureHold(ctx, CaptureXact(req))
+		return holdResponse{Hold: hold, Err: err}, nil
+	}
+}
+
+// voidRequest is a void request
+type voidRequest struct {
+	HoldID HoldID
+}
+
+// newVoidEndpoint returns a void endpoint
+func newVoidEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(voidRequest)
+		hold, err := s.VoidHold(ctx, req.HoldID)
+		return holdResponse{Hold: hold, Err: err}, nil
+	}
+}
+
+// getHoldRequest is a get hold request
+type getHoldRequest struct {
+	HoldID HoldID
+}
+
+// newGetHoldEndpoint returns a get hold endpoint
+func newGetHoldEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getHoldRequest)
+		hold, err := s.GetHold(ctx, req.HoldID)
+		return holdResponse{Hold: hold, Err: err}, nil
 	}
 }
 
@@ -96,3 +355,32 @@ func newListPaymentsEndpoint(s Service) endpoint.Endpoint {
 		return listPaymentsResponse{Payments: payments}, nil
 	}
 }
+
+// listAccntXactsRequest is a list account transactions request
+type listAccntXactsRequest struct {
+	Filter XactFilter
+}
+
+// listAccntXactsResponse is a list account transactions response
+type listAccntXactsResponse struct {
+	Xacts      []*Transaction `json:"transactions,omitempty"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+	Err        error          `json:"error,omitempty"`
+}
+
+func (r listAccntXactsResponse) error() error { return r.Err }
+
+// newListAccntXactsEndpoint returns a list account transactions endpoint
+func newListAccntXactsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listAccntXactsRequest)
+		page, err := s.ListAccntXacts(ctx, req.Filter)
+		if err != nil {
+			return listAccntXactsResponse{Err: err}, nil
+		}
+		return listAccntXactsResponse{
+			Xacts:      page.Xacts,
+			NextCursor: page.NextCursor,
+		}, nil
+	}
+}
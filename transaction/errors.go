@@ -10,6 +10,9 @@ var (
 	// ErrDifferentCurrencies is an error when transferring money
 	// to an account with different currency
 	ErrDifferentCurrencies = errors.New("different currencies")
+	// ErrSameCurrencies is an error when making an fx
+	// transfer to an account with the same currency
+	ErrSameCurrencies = errors.New("same currencies")
 	// ErrSendingAccountNotFound is an error when
 	// transferring money from an account that doesn't exist
 	ErrSendingAccountNotFound = errors.New("sending account not found")
@@ -22,4 +25,43 @@ var (
 	ErrZeroAmount = errors.New("zero amount")
 	// ErrNegativeAmount is an error when doing a transaction with negative amount
 	ErrNegativeAmount = errors.New("negative amount")
+	// ErrAmountPrecision is an error when the amount has more
+	// decimal places than the minor units of the currency
+	ErrAmountPrecision = errors.New("amount exceeds currency precision")
+	// ErrIdempotencyKeyConflict is an error when the idempotency
+	// key was already used by a request with a different payload
+	ErrIdempotencyKeyConflict = errors.New("idempotency key conflict")
+	// ErrIdempotencyKeyNotFound is an error when the idempotency key doesn't exist
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+	// ErrXactNotFound is an error when the transaction doesn't exist
+	ErrXactNotFound = errors.New("transaction not found")
+	// ErrXactAlreadyReversed is an error when reversing or
+	// refunding a transaction that was already reversed
+	ErrXactAlreadyReversed = errors.New("transaction already reversed")
+	// ErrXactAlreadyRefunded is an error when reversing
+	// a transfer that was already (partially) refunded
+	ErrXactAlreadyRefunded = errors.New("transaction already refunded")
+	// ErrXactNotReversible is an error when reversing a reversal or refund
+	ErrXactNotReversible = errors.New("transaction not reversible")
+	// ErrXactNotRefundable is an error when refunding a
+	// transaction that is not a same-currency transfer
+	ErrXactNotRefundable = errors.New("transaction not refundable")
+	// ErrRefundExceedsAmount is an error when the total refunds
+	// exceeds the amount of the original transfer
+	ErrRefundExceedsAmount = errors.New("refund exceeds transfer amount")
+	// ErrHoldNotFound is an error when the hold doesn't exist
+	ErrHoldNotFound = errors.New("hold not found")
+	// ErrHoldNotActive is an error when capturing or
+	// voiding a hold that was already captured or voided
+	ErrHoldNotActive = errors.New("hold not active")
+	// ErrHoldExpired is an error when capturing or voiding an expired hold
+	ErrHoldExpired = errors.New("hold expired")
+	// ErrCaptureExceedsHold is an error when the
+	// capture amount exceeds the amount of the hold
+	ErrCaptureExceedsHold = errors.New("capture exceeds hold amount")
+	// ErrUnbalancedEntry is an error when the debits of a journal
+	// entry don't equal the credits in one of the currencies
+	ErrUnbalancedEntry = errors.New("unbalanced journal entry")
+	// ErrInvalidCursor is an error when the pagination cursor is malformed
+	ErrInvalidCursor = errors.New("invalid cursor")
 )
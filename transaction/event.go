@@ -0,0 +1,27 @@
+package transaction
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/kalupi/outbox"
+)
+
+// EventTypeXactPosted is the type of the event of a posted transaction leg,
+// the payload is the leg. It is written by the repository in the same tx.
+const EventTypeXactPosted = "transaction.posted"
+
+// NewXactEvent returns the outbox event of the posted transaction leg
+func NewXactEvent(xact Transaction) (*outbox.Event, error) {
+	payload, err := json.Marshal(xact)
+	if err != nil {
+		return nil, errors.Wrap(err, "json marshal")
+	}
+
+	return &outbox.Event{
+		Type:    EventTypeXactPosted,
+		Payload: payload,
+		Ts:      xact.Ts,
+	}, nil
+}
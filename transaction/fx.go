@@ -0,0 +1,44 @@
+package transaction
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+)
+
+// FXTransfer is the record of a cross-currency transfer
+type FXTransfer struct {
+	XactNo       XactNo            `json:"xact_no"`
+	FromAccount  account.AccountID `json:"from_account"`
+	ToAccount    account.AccountID `json:"to_account"`
+	FromCurrency currency.Currency `json:"from_currency"`
+	ToCurrency   currency.Currency `json:"to_currency"`
+	FromAmount   decimal.Decimal   `json:"from_amount"`
+	ToAmount     decimal.Decimal   `json:"to_amount"`
+	// Rate is the applied rate i.e. ToAmount = FromAmount * Rate
+	Rate decimal.Decimal `json:"rate"`
+	Ts   *time.Time      `json:"ts,omitempty"`
+}
+
+// List of fx related precisions
+const (
+	// ratePrecision is the number of decimal places of the applied rate
+	ratePrecision = 10
+	// amountPrecision is the number of decimal places of the amount
+	// columns, used for currencies without minor units i.e. XAU
+	amountPrecision = 4
+)
+
+// convertAmount converts the amount using the rate and
+// rounds it to the minor units of the currency
+func convertAmount(amount, rate decimal.Decimal, curr currency.Currency) decimal.Decimal {
+	places := curr.MinorUnits()
+	if places == currency.NoMinorUnits {
+		places = amountPrecision
+	}
+
+	return amount.Mul(rate).Round(int32(places))
+}
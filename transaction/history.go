@@ -0,0 +1,143 @@
+package transaction
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// List of transaction history limits
+const (
+	// defaultXactsLimit is the default number of transactions in a page
+	defaultXactsLimit = 50
+	// maxXactsLimit is the max number of transactions in a page
+	maxXactsLimit = 500
+)
+
+// SortDirection is the sort direction of the transactions
+type SortDirection int
+
+// List of sort directions
+const (
+	// SortDesc sorts the transactions from newest to oldest
+	SortDesc SortDirection = iota
+	// SortAsc sorts the transactions from oldest to newest
+	SortAsc
+)
+
+// String implements Stringer
+func (sd SortDirection) String() string {
+	if sd == SortAsc {
+		return "asc"
+	}
+
+	return "desc"
+}
+
+// Cursor points to the last transaction of a page, the next page
+// starts right after the (ts, xact no, leg id) tuple. The legs of a
+// transaction share the (ts, xact no), the leg id tells them apart.
+type Cursor struct {
+	Ts     time.Time
+	XactNo XactNo
+	LegID  int64
+}
+
+// Encode encodes the cursor into an opaque string
+func (c Cursor) Encode() string {
+	s := c.Ts.UTC().Format(time.RFC3339Nano) + "|" + string(c.XactNo) +
+		"|" + strconv.FormatInt(c.LegID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// DecodeCursor decodes the opaque string into a cursor
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(b), "|", 3)
+	if len(parts) != 3 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	legID, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{Ts: ts, XactNo: XactNo(parts[1]), LegID: legID}, nil
+}
+
+// XactFilter is the filter of the account transactions
+type XactFilter struct {
+	AccountID account.AccountID
+	// XactTypeExts are the external transaction types, all if empty
+	XactTypeExts []XactTypeExt
+	// From is the inclusive lower bound of the timestamp
+	From *time.Time
+	// To is the exclusive upper bound of the timestamp
+	To *time.Time
+	// MinAmount is the inclusive lower bound of the amount
+	MinAmount *decimal.Decimal
+	// MaxAmount is the inclusive upper bound of the amount
+	MaxAmount *decimal.Decimal
+	// Sort is the sort direction, defaults to newest first
+	Sort SortDirection
+	// Cursor is the cursor of the previous page
+	Cursor *Cursor
+	// Limit is the max number of transactions
+	Limit int
+}
+
+// Validate validates the filter params
+func (f XactFilter) Validate() error {
+	return validation.Errors{
+		"account_id": f.AccountID.Validate(),
+		"xact_type_ext": validation.Validate(f.XactTypeExts,
+			validation.Each(validation.By(func(value interface{}) error {
+				ttx, _ := value.(XactTypeExt)
+				if ttx.String() == "invalid" {
+					return errors.New("must be a valid transaction type")
+				}
+				return nil
+			})),
+		),
+		"to": validation.Validate(f.To, validation.By(func(interface{}) error {
+			if f.From != nil && f.To != nil && !f.To.After(*f.From) {
+				return errors.New("must be after from")
+			}
+			return nil
+		})),
+		"max_amount": validation.Validate(f.MaxAmount, validation.By(func(interface{}) error {
+			if f.MinAmount != nil && f.MaxAmount != nil &&
+				f.MaxAmount.LessThan(*f.MinAmount) {
+				return errors.New("must not be less than min amount")
+			}
+			return nil
+		})),
+		"limit": validation.Validate(f.Limit,
+			validation.Min(0), validation.Max(maxXactsLimit),
+		),
+	}.Filter()
+}
+
+// XactPage is a page of account transactions
+type XactPage struct {
+	Xacts []*Transaction `json:"transactions"`
+	// NextCursor is the cursor of the next page, empty if it's the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
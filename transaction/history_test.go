@@ -0,0 +1,89 @@
+package transaction_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+func TestCursor(t *testing.T) {
+	ts := time.Date(2021, 5, 8, 10, 21, 32, 125612000, time.UTC)
+	cursor := transaction.Cursor{Ts: ts, XactNo: "LM4I8FHC05X0", LegID: 7}
+
+	got, err := transaction.DecodeCursor(cursor.Encode())
+	require.NoError(t, err)
+	assert.True(t, ts.Equal(got.Ts))
+	assert.Equal(t, cursor.XactNo, got.XactNo)
+	assert.Equal(t, cursor.LegID, got.LegID)
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, s := range []string{"", "%%%", "bm90LWEtY3Vyc29y",
+			// without the leg id
+			"MjAyMS0wNS0wOFQxMDoyMTozMi4xMjU2MTJafExNNEk4RkhDMDVYMA",
+			// non-numeric leg id
+			"MjAyMS0wNS0wOFQxMDoyMTozMi4xMjU2MTJafExNNEk4RkhDMDVYMHx4",
+		} {
+			_, err := transaction.DecodeCursor(s)
+			assert.ErrorIs(t, err, transaction.ErrInvalidCursor, s)
+		}
+	})
+}
+
+func TestXactFilter(t *testing.T) {
+	accntID := account.AccountID("johndoe")
+	from := time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	min, max := decimal.NewFromInt(10), decimal.NewFromInt(100)
+
+	f := transaction.XactFilter{
+		AccountID:    accntID,
+		XactTypeExts: []transaction.XactTypeExt{transaction.XactTypeExtDeposit},
+		From:         &from,
+		To:           &to,
+		MinAmount:    &min,
+		MaxAmount:    &max,
+		Limit:        10,
+	}
+	assert.NoError(t, f.Validate())
+
+	t.Run("invalid", func(t *testing.T) {
+		tc := []struct {
+			name string
+			f    transaction.XactFilter
+		}{
+			{
+				name: "account id",
+				f:    transaction.XactFilter{AccountID: "john"},
+			},
+			{
+				name: "xact type ext",
+				f: transaction.XactFilter{
+					AccountID:    accntID,
+					XactTypeExts: []transaction.XactTypeExt{transaction.XactTypeExt(0)},
+				},
+			},
+			{
+				name: "date range",
+				f:    transaction.XactFilter{AccountID: accntID, From: &to, To: &from},
+			},
+			{
+				name: "amount bounds",
+				f:    transaction.XactFilter{AccountID: accntID, MinAmount: &max, MaxAmount: &min},
+			},
+			{
+				name: "limit",
+				f:    transaction.XactFilter{AccountID: accntID, Limit: 1000},
+			},
+		}
+
+		for _, tt := range tc {
+			assert.Error(t, tt.f.Validate(), tt.name)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package transaction
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// HoldID is a hold id
+type HoldID string
+
+// Hold is an authorization hold, it reserves the funds of an
+// account until it is captured, voided or it has expired
+type Hold struct {
+	HoldID    HoldID            `json:"id"`
+	AccountID account.AccountID `json:"account_id"`
+	Amount    decimal.Decimal   `json:"amount"`
+	Status    HoldStatus        `json:"status"`
+	// CapturedAmount is the amount captured from the hold
+	CapturedAmount decimal.Decimal `json:"captured_amount"`
+	// XactNo is the transaction number of the capture
+	XactNo XactNo `json:"xact_no,omitempty"`
+	// ExpiresAt is the time when the hold expires
+	ExpiresAt time.Time  `json:"expires_at"`
+	Ts        *time.Time `json:"ts,omitempty"`
+}
+
+// expire sets the status to expired if the hold is active and has expired
+func (h *Hold) expire(now time.Time) {
+	if h.Status == HoldStatusActive && !now.Before(h.ExpiresAt) {
+		h.Status = HoldStatusExpired
+	}
+}
+
+// HoldStatus is the hold status
+type HoldStatus int
+
+// List of hold statuses
+const (
+	// HoldStatusActive is an active hold, the funds are reserved
+	HoldStatusActive HoldStatus = iota + 1
+	// HoldStatusCaptured is a captured hold
+	HoldStatusCaptured
+	// HoldStatusVoided is a voided hold, the funds are released
+	HoldStatusVoided
+	// HoldStatusExpired is an expired hold, the funds are released.
+	// It is never stored, an active hold is expired once its ttl has elapsed.
+	HoldStatusExpired
+)
+
+// String implements Stringer
+func (hs HoldStatus) String() string {
+	return [...]string{
+		"invalid",
+		"active",
+		"captured",
+		"voided",
+		"expired",
+	}[hs]
+}
+
+// Value implements driver.Valuer interface
+func (hs HoldStatus) Value() (driver.Value, error) {
+	return hs.String(), nil
+}
+
+// Scan implements sql.Scanner interface
+func (hs *HoldStatus) Scan(src interface{}) error {
+	if src == nil {
+		*hs = HoldStatus(0)
+		return nil
+	}
+
+	val, ok := src.(string)
+	if !ok {
+		return errors.New("src is not string")
+	}
+
+	*hs = strToHoldStatus(val)
+	return nil
+}
+
+// strToHoldStatus takes a string and returns the hold status
+func strToHoldStatus(s string) HoldStatus {
+	switch s {
+	case "active":
+		return HoldStatusActive
+	case "captured":
+		return HoldStatusCaptured
+	case "voided":
+		return HoldStatusVoided
+	case "expired":
+		return HoldStatusExpired
+	}
+
+	return HoldStatus(0)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (hs HoldStatus) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(hs.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (hs *HoldStatus) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	*hs = strToHoldStatus(s)
+	return nil
+}
+
+// HoldXact is a hold transaction
+type HoldXact struct {
+	AccountID account.AccountID
+	Amount    decimal.Decimal
+}
+
+// Validate validates the hold params
+func (h HoldXact) Validate() error {
+	return validation.Errors{
+		"account_id": h.AccountID.Validate(),
+		"amount": validation.Validate(h.Amount,
+			validation.By(nonZeroDecimal),
+			validation.By(nonNegativeDecimal),
+		),
+	}.Filter()
+}
+
+// CaptureXact is a hold capture transaction. The hold is captured
+// into a transfer if ToAccount is set, otherwise into a withdrawal.
+type CaptureXact struct {
+	HoldID HoldID
+	// Amount is the amount to capture, the full amount
+	// of the hold is captured if it is zero
+	Amount decimal.Decimal
+	// ToAccount is the optional receiving account
+	ToAccount account.AccountID
+}
+
+// Validate validates the capture params
+func (c CaptureXact) Validate() error {
+	// receiving account is optional
+	var toAccountErr error
+	if c.ToAccount != "" {
+		toAccountErr = c.ToAccount.Validate()
+	}
+
+	return validation.Errors{
+		"hold_id": validation.Validate(string(c.HoldID),
+			validation.Required.Error("must not be empty"),
+		),
+		"amount": validation.Validate(c.Amount,
+			validation.By(nonNegativeDecimal),
+		),
+		"to_account": toAccountErr,
+	}.Filter()
+}
+
+// defaultHoldTTL is the default time-to-live of holds
+const defaultHoldTTL = 7 * 24 * time.Hour
+
+// holdIDLen is the length of the hold id
+const holdIDLen = 16
+
+// NewHoldID generates a hold id
+func NewHoldID() (HoldID, error) {
+	holdIDStr, err := gonanoid.Generate(alphabet, holdIDLen)
+	if err != nil {
+		return "", pkgerrors.Wrap(err, "generate")
+	}
+
+	return HoldID(holdIDStr), nil
+}
@@ -0,0 +1,67 @@
+package transaction
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/kalupi/etc/tx"
+)
+
+// IdempotencyKey is a client provided key that makes a request safe to retry.
+// The key is stored in the same tx as the transaction it created.
+type IdempotencyKey struct {
+	// Key is the client provided key
+	Key string
+	// Fingerprint is the hash of the request
+	Fingerprint string
+	// XactNo is the transaction number created by the original request
+	XactNo XactNo
+	// Ts is the timestamp
+	Ts *time.Time
+}
+
+// maxIdempotencyKeyLen is the max length of the idempotency key
+const maxIdempotencyKeyLen = 255
+
+// fingerprint returns the hash of the operation and its params
+func fingerprint(op string, params ...string) string {
+	h := sha256.New()
+	h.Write([]byte(op))
+	for _, p := range params {
+		h.Write([]byte{0})
+		h.Write([]byte(p))
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// claimIdempotencyKey claims the idempotency key within tx. If the key
+// was already claimed by the same request, it returns the transaction
+// number of the original request and replayed is set to true.
+func (s *service) claimIdempotencyKey(ctx context.Context, tx tx.Tx,
+	ik IdempotencyKey) (xactNo XactNo, replayed bool, err error) {
+	created, err := s.xactRepo.CreateIdempotencyKeyIfNotExists(ctx, tx, ik)
+	if err != nil {
+		return "", false, errors.Wrap(err, "create idempotency key")
+	}
+
+	if created {
+		return ik.XactNo, false, nil
+	}
+
+	orig, err := s.xactRepo.GetIdempotencyKey(ctx, tx, ik.Key)
+	if err != nil {
+		return "", false, errors.Wrap(err, "get idempotency key")
+	}
+
+	// same key but different request
+	if orig.Fingerprint != ik.Fingerprint {
+		return "", false, ErrIdempotencyKeyConflict
+	}
+
+	return orig.XactNo, true, nil
+}
@@ -0,0 +1,124 @@
+package transaction
+
+import (
+	"errors"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/shopspring/decimal"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/ledger"
+)
+
+// List of journal entry limits
+const (
+	// maxJournalLegs is the max number of legs of a journal entry
+	maxJournalLegs = 100
+	// maxDescLen is the max length of a description
+	maxDescLen = 255
+)
+
+// JournalLeg is a debit or credit leg of a journal entry, it
+// is posted to either an account or an internal ledger
+type JournalLeg struct {
+	// AccountID is the account of the leg
+	AccountID account.AccountID `json:"account_id,omitempty"`
+	// LedgerNo is the ledger of the leg
+	LedgerNo ledger.LedgerNo `json:"ledger_no,omitempty"`
+	// XactType debits or credits the account or the ledger. Crediting
+	// an account increases its balance, debiting decreases it.
+	XactType XactType `json:"xact_type"`
+	// Amount is the amount of the leg
+	Amount decimal.Decimal `json:"amount"`
+	// Desc is the description of the leg, defaults to the entry's
+	Desc string `json:"desc,omitempty"`
+}
+
+// Validate validates the journal leg params
+func (jl JournalLeg) Validate() error {
+	errs := validation.Errors{
+		// the xact type is validated by its driver value i.e. Dr
+		"xact_type": validation.Validate(jl.XactType,
+			validation.In(XactTypeDebit.String(), XactTypeCredit.String()).
+				Error("must be either Dr or Cr"),
+		),
+		"amount": validation.Validate(jl.Amount,
+			validation.By(nonZeroDecimal),
+			validation.By(nonNegativeDecimal),
+		),
+		"desc": validation.Validate(jl.Desc,
+			validation.Length(0, maxDescLen),
+		),
+	}
+
+	switch {
+	case jl.AccountID != "" && jl.LedgerNo != "",
+		jl.AccountID == "" && jl.LedgerNo == "":
+		errs["account_id"] = errors.New("either the account id or the ledger no must be set")
+	case jl.AccountID != "":
+		errs["account_id"] = jl.AccountID.Validate()
+	}
+
+	return errs.Filter()
+}
+
+// JournalXact is a journal entry, the debit and credit
+// legs are posted under a single transaction number
+type JournalXact struct {
+	// Desc is the description of the entry
+	Desc string
+	// Legs are the debit and credit legs
+	Legs []JournalLeg
+	// IdempotencyKey is an optional key for safely retrying the request
+	IdempotencyKey string
+}
+
+// Validate validates the journal entry params
+func (jx JournalXact) Validate() error {
+	return validation.Errors{
+		"desc": validation.Validate(jx.Desc,
+			validation.Required.Error("must not be empty"),
+			validation.Length(1, maxDescLen),
+		),
+		"legs": validation.Validate(jx.Legs,
+			validation.Required.Error("must not be empty"),
+			validation.Length(2, maxJournalLegs),
+		),
+		"idempotency_key": validation.Validate(jx.IdempotencyKey,
+			validation.Length(0, maxIdempotencyKeyLen),
+		),
+	}.Filter()
+}
+
+// fingerprint returns the hash of the journal entry params
+func (jx JournalXact) fingerprint() string {
+	params := []string{jx.Desc}
+	for _, leg := range jx.Legs {
+		params = append(params, string(leg.AccountID), string(leg.LedgerNo),
+			leg.XactType.String(), leg.Amount.String(), leg.Desc)
+	}
+
+	return fingerprint("journal", params...)
+}
+
+// checkBalanced checks that the debits equal the credits in each
+// currency, currs are the currencies of the legs in the same order
+func checkBalanced(legs []JournalLeg, currs []currency.Currency) error {
+	nets := map[currency.Currency]decimal.Decimal{}
+	for i, leg := range legs {
+		if leg.XactType == XactTypeDebit {
+			nets[currs[i]] = nets[currs[i]].Add(leg.Amount)
+		} else {
+			nets[currs[i]] = nets[currs[i]].Sub(leg.Amount)
+		}
+	}
+
+	for _, net := range nets {
+		if !net.IsZero() {
+			return ErrUnbalancedEntry
+		}
+	}
+
+	return nil
+}
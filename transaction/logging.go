@@ -19,7 +19,7 @@ func NewLoggingService(logger log.Logger, s Service) Service {
 }
 
 // MakeDeposit logs the deposit params
-func (s *loggingService) MakeDeposit(ctx context.Context, dp DepositXact) (err error) {
+func (s *loggingService) MakeDeposit(ctx context.Context, dp DepositXact) (xact *Xact, err error) {
 	defer func(begin time.Time) {
 		_ = s.logger.Log(
 			"method", "make_deposit",
@@ -34,7 +34,7 @@ func (s *loggingService) MakeDeposit(ctx context.Context, dp DepositXact) (err e
 }
 
 // MakeWithdrawal logs the withdrawal params
-func (s *loggingService) MakeWithdrawal(ctx context.Context, wd WithdrawalXact) (err error) {
+func (s *loggingService) MakeWithdrawal(ctx context.Context, wd WithdrawalXact) (xact *Xact, err error) {
 	defer func(begin time.Time) {
 		_ = s.logger.Log(
 			"method", "make_withdrawal",
@@ -49,7 +49,7 @@ func (s *loggingService) MakeWithdrawal(ctx context.Context, wd WithdrawalXact)
 }
 
 // MakeTransfer logs the transfer params
-func (s *loggingService) MakeTransfer(ctx context.Context, tr TransferXact) (err error) {
+func (s *loggingService) MakeTransfer(ctx context.Context, tr TransferXact) (xact *Xact, err error) {
 	defer func(begin time.Time) {
 		_ = s.logger.Log(
 			"method", "make_transfer",
@@ -64,6 +64,156 @@ func (s *loggingService) MakeTransfer(ctx context.Context, tr TransferXact) (err
 	return s.s.MakeTransfer(ctx, tr)
 }
 
+// MakeBatchTransfer logs the batch transfer params
+func (s *loggingService) MakeBatchTransfer(ctx context.Context, bx BatchTransferXact) (bt *BatchTransfer, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "make_batch_transfer",
+			"mode", bx.Mode,
+			"transfers", len(bx.Transfers),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.MakeBatchTransfer(ctx, bx)
+}
+
+// MakeFXTransfer logs the fx transfer params
+func (s *loggingService) MakeFXTransfer(ctx context.Context, tr TransferXact) (fxTr *FXTransfer, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "make_fx_transfer",
+			"from_account", tr.FromAccount,
+			"to_account", tr.ToAccount,
+			"amount", tr.Amount,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.MakeFXTransfer(ctx, tr)
+}
+
+// MakeJournalEntry logs the journal entry params
+func (s *loggingService) MakeJournalEntry(ctx context.Context, jx JournalXact) (xact *Xact, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "make_journal_entry",
+			"desc", jx.Desc,
+			"legs", len(jx.Legs),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.MakeJournalEntry(ctx, jx)
+}
+
+// ReverseXact logs the reversal params
+func (s *loggingService) ReverseXact(ctx context.Context, rv ReversalXact) (rev *Reversal, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "reverse_xact",
+			"xact_no", rv.XactNo,
+			"reason", rv.Reason,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ReverseXact(ctx, rv)
+}
+
+// RefundTransfer logs the refund params
+func (s *loggingService) RefundTransfer(ctx context.Context, rf RefundXact) (rev *Reversal, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "refund_transfer",
+			"xact_no", rf.XactNo,
+			"amount", rf.Amount,
+			"reason", rf.Reason,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.RefundTransfer(ctx, rf)
+}
+
+// PlaceHold logs the hold params
+func (s *loggingService) PlaceHold(ctx context.Context, hx HoldXact) (hold *Hold, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "place_hold",
+			"account_id", hx.AccountID,
+			"amount", hx.Amount,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.PlaceHold(ctx, hx)
+}
+
+// CaptureHold logs the capture params
+func (s *loggingService) CaptureHold(ctx context.Context, cx CaptureXact) (hold *Hold, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "capture_hold",
+			"hold_id", cx.HoldID,
+			"amount", cx.Amount,
+			"to_account", cx.ToAccount,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.CaptureHold(ctx, cx)
+}
+
+// VoidHold logs the void params
+func (s *loggingService) VoidHold(ctx context.Context, holdID HoldID) (hold *Hold, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "void_hold",
+			"hold_id", holdID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.VoidHold(ctx, holdID)
+}
+
+// GetHold logs the get hold params
+func (s *loggingService) GetHold(ctx context.Context, holdID HoldID) (hold *Hold, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "get_hold",
+			"hold_id", holdID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetHold(ctx, holdID)
+}
+
+// GetXact logs the get transaction params
+func (s *loggingService) GetXact(ctx context.Context, xactNo XactNo) (xact *Xact, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "get_xact",
+			"xact_no", xactNo,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetXact(ctx, xactNo)
+}
+
 // ListTransfers logs the list transfers params
 func (s *loggingService) ListTransfers(ctx context.Context) (xacts []*Transaction, err error) {
 	defer func(begin time.Time) {
@@ -78,3 +228,25 @@ func (s *loggingService) ListTransfers(ctx context.Context) (xacts []*Transactio
 	return s.s.ListTransfers(ctx)
 
 }
+
+// ListAccntXacts logs the list account transactions params
+func (s *loggingService) ListAccntXacts(ctx context.Context, f XactFilter) (page *XactPage, err error) {
+	defer func(begin time.Time) {
+		count := 0
+		if page != nil {
+			count = len(page.Xacts)
+		}
+
+		_ = s.logger.Log(
+			"method", "list_accnt_xacts",
+			"account_id", f.AccountID,
+			"sort", f.Sort,
+			"limit", f.Limit,
+			"count", count,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ListAccntXacts(ctx, f)
+}
@@ -11,32 +11,68 @@ type Payment struct {
 	Account   account.AccountID `json:"account"`
 	Amount    decimal.Decimal   `json:"amount"`
 	Direction string            `json:"direction"`
+	// Fee is the fee charged to the sending account
+	Fee *decimal.Decimal `json:"fee,omitempty"`
 
 	ToAccount   account.AccountID `json:"to_account,omitempty"`
 	FromAccount account.AccountID `json:"from_account,omitempty"`
 }
 
+// transfer is the send and receive legs of a transfer and its fee
+type transfer struct {
+	snd, rcv *Transaction
+	fee      *decimal.Decimal
+}
+
 // xactsToPayments maps Transactions to Payments
 func xactsToPayments(xacts []*Transaction) []*Payment {
-	// Send and receive always come in pair
+	// the legs of a transfer share the xact no
+	xactNos := []XactNo{}
+	transfers := map[XactNo]*transfer{}
+	for _, xact := range xacts {
+		tr, ok := transfers[xact.XactNo]
+		if !ok {
+			tr = &transfer{}
+			transfers[xact.XactNo] = tr
+			xactNos = append(xactNos, xact.XactNo)
+		}
+
+		switch xact.XactTypeExt {
+		case XactTypeExtSndTransfer:
+			tr.snd = xact
+		case XactTypeExtRcvTransfer:
+			tr.rcv = xact
+		case XactTypeExtFee:
+			fee := xact.Amount
+			if tr.fee != nil {
+				fee = fee.Add(*tr.fee)
+			}
+			tr.fee = &fee
+		}
+	}
+
 	payments := []*Payment{}
-	for i := 0; i < len(xacts); i += 2 {
-		sndXact := xacts[i]
-		rcvXact := xacts[i+1]
+	for _, xactNo := range xactNos {
+		tr := transfers[xactNo]
+		// send and receive always come in pair
+		if tr.snd == nil || tr.rcv == nil {
+			continue
+		}
 
 		payments = append(payments, &Payment{
-			XactNo:    sndXact.XactNo,
-			Account:   sndXact.AccountID,
-			Amount:    sndXact.Amount,
-			ToAccount: rcvXact.AccountID,
+			XactNo:    tr.snd.XactNo,
+			Account:   tr.snd.AccountID,
+			Amount:    tr.snd.Amount,
+			Fee:       tr.fee,
+			ToAccount: tr.rcv.AccountID,
 			Direction: "outgoing",
 		})
 
 		payments = append(payments, &Payment{
-			XactNo:      rcvXact.XactNo,
-			Account:     rcvXact.AccountID,
-			Amount:      rcvXact.Amount,
-			FromAccount: sndXact.AccountID,
+			XactNo:      tr.rcv.XactNo,
+			Account:     tr.rcv.AccountID,
+			Amount:      tr.rcv.Amount,
+			FromAccount: tr.snd.AccountID,
 			Direction:   "incoming",
 		})
 	}
@@ -2,8 +2,12 @@ package transaction
 
 import (
 	"context"
+	"time"
 
+	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
 )
 
 // Repository is a transaction repository
@@ -12,8 +16,50 @@ type Repository interface {
 	BeginTx(context.Context) (tx.Tx, error)
 	// CreateXact creates a transaction within tx
 	CreateXact(context.Context, tx.Tx, Transaction) error
+	// CreateLedgerXact creates a ledger transaction within tx
+	CreateLedgerXact(context.Context, tx.Tx, LedgerXact) error
+	// CreateFXTransfer records the fx details of a transfer within tx
+	CreateFXTransfer(context.Context, tx.Tx, FXTransfer) error
+	// CreateIdempotencyKeyIfNotExists creates the idempotency key within
+	// tx, returns false if the key already exists
+	CreateIdempotencyKeyIfNotExists(context.Context, tx.Tx, IdempotencyKey) (bool, error)
+	// GetIdempotencyKey retrieves the idempotency key within tx, it
+	// returns ErrIdempotencyKeyNotFound if the key doesn't exist
+	GetIdempotencyKey(context.Context, tx.Tx, string) (*IdempotencyKey, error)
+	// GetFXTransfer retrieves the fx details of a transfer
+	GetFXTransfer(context.Context, XactNo) (*FXTransfer, error)
+	// GetXact retrieves the transaction and all of its legs
+	GetXact(context.Context, XactNo) (*Xact, error)
+	// ListXactLegs retrieves the account transactions sharing the xact no within tx
+	ListXactLegs(context.Context, tx.Tx, XactNo) ([]*Transaction, error)
+	// ListLedgerXactLegs retrieves the ledger transactions sharing the xact no within tx
+	ListLedgerXactLegs(context.Context, tx.Tx, XactNo) ([]*LedgerXact, error)
+	// CreateReversal creates a reversal within tx
+	CreateReversal(context.Context, tx.Tx, Reversal) error
+	// ListReversals retrieves the reversals of the original transaction within tx
+	ListReversals(context.Context, tx.Tx, XactNo) ([]*Reversal, error)
+	// CreateHold creates a hold within tx
+	CreateHold(context.Context, tx.Tx, Hold) error
+	// GetHold retrieves the hold
+	GetHold(context.Context, HoldID) (*Hold, error)
+	// GetHoldForUpdate retrieves and locks the hold within tx
+	GetHoldForUpdate(context.Context, tx.Tx, HoldID) (*Hold, error)
+	// UpdateHold updates the status, captured amount
+	// and capture xact no of the hold within tx
+	UpdateHold(context.Context, tx.Tx, Hold) error
 	// ListXacts retrieves the list of transactions
 	ListXacts(context.Context) ([]*Transaction, error)
-	// ListTransfers retrieves the transfer related transactions
+	// ListTransfers retrieves the transfer related transactions and their fees
 	ListTransfers(context.Context) ([]*Transaction, error)
+	// ListAccntXacts retrieves the account transactions matching the filter,
+	// sorted by (ts, xact no, leg id) and starting after the cursor if it's set
+	ListAccntXacts(context.Context, XactFilter) ([]*Transaction, error)
+	// ListLedgerPostings retrieves the account and ledger transactions of the
+	// ledger made from the inclusive start to the exclusive end time, sorted
+	// by (ts, xact no). The ledger transactions have no account id and
+	// external transaction type.
+	ListLedgerPostings(context.Context, ledger.LedgerNo, time.Time, time.Time) ([]*Transaction, error)
+	// GetOutgoingUsage retrieves the amount withdrawn and sent, and the
+	// number of transfers sent by the account since the time within tx
+	GetOutgoingUsage(context.Context, tx.Tx, account.AccountID, time.Time) (*limit.Usage, error)
 }
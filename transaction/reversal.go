@@ -0,0 +1,70 @@
+package transaction
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/shopspring/decimal"
+)
+
+// Reversal links a reversal or refund transaction to the original transaction
+type Reversal struct {
+	// XactNo is the reversal or refund transaction number
+	XactNo XactNo `json:"xact_no"`
+	// OrigXactNo is the original transaction number
+	OrigXactNo XactNo `json:"orig_xact_no"`
+	// XactTypeExt is either a reversal or a refund
+	XactTypeExt XactTypeExt `json:"-"`
+	// Amount is the amount moved back
+	Amount decimal.Decimal `json:"amount"`
+	// Reason is the reason of the reversal or refund
+	Reason string `json:"reason"`
+	// Ts is the timestamp
+	Ts *time.Time `json:"ts,omitempty"`
+}
+
+// maxReasonLen is the max length of the reversal or refund reason
+const maxReasonLen = 255
+
+// ReversalXact is a reversal transaction
+type ReversalXact struct {
+	XactNo XactNo
+	Reason string
+}
+
+// Validate validates the reversal params
+func (rv ReversalXact) Validate() error {
+	return validation.Errors{
+		"xact_no": validation.Validate(string(rv.XactNo),
+			validation.Required.Error("must not be empty"),
+		),
+		"reason": validation.Validate(rv.Reason,
+			validation.Required.Error("must not be empty"),
+			validation.Length(1, maxReasonLen),
+		),
+	}.Filter()
+}
+
+// RefundXact is a (partial) refund of a transfer transaction
+type RefundXact struct {
+	XactNo XactNo
+	Amount decimal.Decimal
+	Reason string
+}
+
+// Validate validates the refund params
+func (rf RefundXact) Validate() error {
+	return validation.Errors{
+		"xact_no": validation.Validate(string(rf.XactNo),
+			validation.Required.Error("must not be empty"),
+		),
+		"amount": validation.Validate(rf.Amount,
+			validation.By(nonZeroDecimal),
+			validation.By(nonNegativeDecimal),
+		),
+		"reason": validation.Validate(rf.Reason,
+			validation.Required.Error("must not be empty"),
+			validation.Length(1, maxReasonLen),
+		),
+	}.Filter()
+}
@@ -3,6 +3,7 @@ package transaction
 import (
 	"context"
 	"fmt"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 	gonanoid "github.com/matoous/go-nanoid/v2"
@@ -12,26 +13,54 @@ import (
 
 	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/balance"
+	"github.com/stevenferrer/kalupi/currency"
 	"github.com/stevenferrer/kalupi/etc/tx"
+	"github.com/stevenferrer/kalupi/fee"
+	"github.com/stevenferrer/kalupi/fx"
 	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
 )
 
 // Service is the transaction service
 type Service interface {
 	// MakeDeposity creates a deposit transaction
-	MakeDeposit(context.Context, DepositXact) error
+	MakeDeposit(context.Context, DepositXact) (*Xact, error)
 	// MakeWithdrawal creates a withdrawal transaction
-	MakeWithdrawal(context.Context, WithdrawalXact) error
+	MakeWithdrawal(context.Context, WithdrawalXact) (*Xact, error)
 	// MakeTransfer creates a transfer transaction
-	MakeTransfer(context.Context, TransferXact) error
-	// ListTransfers retrieves the transfer related transactions
+	MakeTransfer(context.Context, TransferXact) (*Xact, error)
+	// MakeBatchTransfer creates the transfer transactions of a batch
+	MakeBatchTransfer(context.Context, BatchTransferXact) (*BatchTransfer, error)
+	// MakeFXTransfer creates a cross-currency transfer transaction
+	MakeFXTransfer(context.Context, TransferXact) (*FXTransfer, error)
+	// MakeJournalEntry creates a balanced journal entry
+	MakeJournalEntry(context.Context, JournalXact) (*Xact, error)
+	// ReverseXact creates a reversal of a transaction
+	ReverseXact(context.Context, ReversalXact) (*Reversal, error)
+	// RefundTransfer creates a (partial) refund of a transfer transaction
+	RefundTransfer(context.Context, RefundXact) (*Reversal, error)
+	// PlaceHold places an authorization hold on an account
+	PlaceHold(context.Context, HoldXact) (*Hold, error)
+	// CaptureHold captures a hold into a transfer or withdrawal
+	CaptureHold(context.Context, CaptureXact) (*Hold, error)
+	// VoidHold voids a hold
+	VoidHold(context.Context, HoldID) (*Hold, error)
+	// GetHold retrieves a hold
+	GetHold(context.Context, HoldID) (*Hold, error)
+	// GetXact retrieves a transaction and all of its legs
+	GetXact(context.Context, XactNo) (*Xact, error)
+	// ListTransfers retrieves the transfer related transactions and their fees
 	ListTransfers(context.Context) ([]*Transaction, error)
+	// ListAccntXacts retrieves a page of the account transactions
+	ListAccntXacts(context.Context, XactFilter) (*XactPage, error)
 }
 
 // DepositXact is a deposit transaction
 type DepositXact struct {
 	AccountID account.AccountID
 	Amount    decimal.Decimal
+	// IdempotencyKey is an optional key for safely retrying the request
+	IdempotencyKey string
 }
 
 // Validate valiates the deposit params
@@ -42,13 +71,23 @@ func (dp DepositXact) Validate() error {
 			validation.By(nonZeroDecimal),
 			validation.By(nonNegativeDecimal),
 		),
+		"idempotency_key": validation.Validate(dp.IdempotencyKey,
+			validation.Length(0, maxIdempotencyKeyLen),
+		),
 	}.Filter()
 }
 
+// fingerprint returns the hash of the deposit params
+func (dp DepositXact) fingerprint() string {
+	return fingerprint("deposit", string(dp.AccountID), dp.Amount.String())
+}
+
 // WithdrawalXact is a withdrawal transaction
 type WithdrawalXact struct {
 	AccountID account.AccountID
 	Amount    decimal.Decimal
+	// IdempotencyKey is an optional key for safely retrying the request
+	IdempotencyKey string
 }
 
 // Validate validates the withdrawal params
@@ -59,14 +98,24 @@ func (wd WithdrawalXact) Validate() error {
 			validation.By(nonZeroDecimal),
 			validation.By(nonNegativeDecimal),
 		),
+		"idempotency_key": validation.Validate(wd.IdempotencyKey,
+			validation.Length(0, maxIdempotencyKeyLen),
+		),
 	}.Filter()
 }
 
+// fingerprint returns the hash of the withdrawal params
+func (wd WithdrawalXact) fingerprint() string {
+	return fingerprint("withdrawal", string(wd.AccountID), wd.Amount.String())
+}
+
 // TransferXact is a transfer transaction
 type TransferXact struct {
 	FromAccount account.AccountID
 	ToAccount   account.AccountID
 	Amount      decimal.Decimal
+	// IdempotencyKey is an optional key for safely retrying the request
+	IdempotencyKey string
 }
 
 // Validate validates the transfer params
@@ -78,19 +127,65 @@ func (tr TransferXact) Validate() error {
 			validation.By(nonZeroDecimal),
 			validation.By(nonNegativeDecimal),
 		),
+		"idempotency_key": validation.Validate(tr.IdempotencyKey,
+			validation.Length(0, maxIdempotencyKeyLen),
+		),
 	}.Filter()
 }
 
+// fingerprint returns the hash of the transfer params, op
+// distinguishes the same-currency and cross-currency transfers
+func (tr TransferXact) fingerprint(op string) string {
+	return fingerprint(op, string(tr.FromAccount),
+		string(tr.ToAccount), tr.Amount.String())
+}
+
 // services is a transaction service implementation
 type service struct {
 	accountRepo account.Repository
 	ledgerRepo  ledger.Repository
 	xactRepo    Repository
 	balRepo     balance.Repository
+
+	rateProvider fx.RateProvider
+	holdTTL      time.Duration
+	feeSchedule  *fee.Schedule
+	limitPolicy  *limit.Policy
 }
 
 var _ Service = (*service)(nil)
 
+// Option is a transaction service option
+type Option func(*service)
+
+// WithRateProvider sets the rate provider used in fx transfers
+func WithRateProvider(rateProvider fx.RateProvider) Option {
+	return func(s *service) {
+		s.rateProvider = rateProvider
+	}
+}
+
+// WithHoldTTL sets the time-to-live of holds
+func WithHoldTTL(ttl time.Duration) Option {
+	return func(s *service) {
+		s.holdTTL = ttl
+	}
+}
+
+// WithFeeSchedule sets the fee schedule of the withdrawals and transfers
+func WithFeeSchedule(feeSchedule *fee.Schedule) Option {
+	return func(s *service) {
+		s.feeSchedule = feeSchedule
+	}
+}
+
+// WithLimitPolicy sets the limits of the withdrawals and transfers
+func WithLimitPolicy(limitPolicy *limit.Policy) Option {
+	return func(s *service) {
+		s.limitPolicy = limitPolicy
+	}
+}
+
 // NewService takes an account, ledger, xact,
 // balance repo and returns a transaction service
 func NewService(
@@ -98,53 +193,65 @@ func NewService(
 	ledgerRepo ledger.Repository,
 	xactRepo Repository,
 	balRepo balance.Repository,
+	opts ...Option,
 ) Service {
-	return &service{
+	s := &service{
 		accountRepo: accountRepo,
 		ledgerRepo:  ledgerRepo,
 		xactRepo:    xactRepo,
 		balRepo:     balRepo,
+		holdTTL:     defaultHoldTTL,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 // MakeDeposity creates a deposit transaction
-func (s *service) MakeDeposit(ctx context.Context, dp DepositXact) (err error) {
+func (s *service) MakeDeposit(ctx context.Context, dp DepositXact) (xact *Xact, err error) {
 	err = dp.Validate()
 	if err != nil {
-		return multierr.Combine(ErrValidation, err)
+		return nil, multierr.Combine(ErrValidation, err)
 	}
 
 	var exists bool
 	exists, err = s.accountRepo.IsAccountExists(ctx, dp.AccountID)
 	if err != nil {
-		return errors.Wrap(err, "is account exists")
+		return nil, errors.Wrap(err, "is account exists")
 	}
 
 	if !exists {
-		return account.ErrAccountNotFound
+		return nil, account.ErrAccountNotFound
 	}
 
 	var accnt *account.Account
 	accnt, err = s.accountRepo.GetAccount(ctx, dp.AccountID)
 	if err != nil {
-		return errors.Wrap(err, "get account")
+		return nil, errors.Wrap(err, "get account")
+	}
+
+	if !accnt.Currency.IsValidAmount(dp.Amount) {
+		return nil, multierr.Combine(ErrValidation, ErrAmountPrecision)
 	}
 
 	var cashLedgerNo ledger.LedgerNo
 	cashLedgerNo, err = ledger.GetCashLedgerNo(accnt.Currency)
 	if err != nil {
-		return errors.Wrap(err, "get cash ledger no")
+		return nil, errors.Wrap(err, "get cash ledger no")
 	}
 
 	var xactNo XactNo
 	xactNo, err = NewXactNo()
 	if err != nil {
-		return errors.Wrap(err, "new xact number")
+		return nil, errors.Wrap(err, "new xact number")
 	}
 
 	tx, err := s.xactRepo.BeginTx(ctx)
 	if err != nil {
-		return errors.Wrap(err, "begin tx")
+		return nil, errors.Wrap(err, "begin tx")
 
 	}
 	defer func() {
@@ -160,6 +267,43 @@ func (s *service) MakeDeposit(ctx context.Context, dp DepositXact) (err error) {
 		}
 	}()
 
+	if dp.IdempotencyKey != "" {
+		var (
+			origXactNo XactNo
+			replayed   bool
+		)
+		origXactNo, replayed, err = s.claimIdempotencyKey(ctx, tx, IdempotencyKey{
+			Key:         dp.IdempotencyKey,
+			Fingerprint: dp.fingerprint(),
+			XactNo:      xactNo,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "claim idempotency key")
+			return
+		}
+
+		// deposit was already made
+		if replayed {
+			xact, err = s.getXact(ctx, tx, origXactNo)
+			if err != nil {
+				err = errors.Wrap(err, "get xact")
+			}
+			return
+		}
+	}
+
+	err = s.balRepo.LockAccnts(ctx, tx, accnt.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "lock account")
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, accnt.AccountID)
+	if err != nil {
+		return
+	}
+
 	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
 		XactNo:      xactNo,
 		LedgerNo:    cashLedgerNo,
@@ -174,48 +318,60 @@ func (s *service) MakeDeposit(ctx context.Context, dp DepositXact) (err error) {
 		return
 	}
 
-	return nil
+	xact, err = s.getXact(ctx, tx, xactNo)
+	if err != nil {
+		err = errors.Wrap(err, "get xact")
+		return
+	}
+
+	return xact, nil
 }
 
 // MakeWithdrawal creates a withdrawal transaction
-func (s *service) MakeWithdrawal(ctx context.Context, wd WithdrawalXact) (err error) {
+func (s *service) MakeWithdrawal(ctx context.Context, wd WithdrawalXact) (xact *Xact, err error) {
 	err = wd.Validate()
 	if err != nil {
-		return multierr.Combine(ErrValidation, err)
+		return nil, multierr.Combine(ErrValidation, err)
 	}
 
 	var exists bool
 	exists, err = s.accountRepo.IsAccountExists(ctx, wd.AccountID)
 	if err != nil {
-		return errors.Wrap(err, "is account exists")
+		return nil, errors.Wrap(err, "is account exists")
 	}
 
 	if !exists {
-		return account.ErrAccountNotFound
+		return nil, account.ErrAccountNotFound
 	}
 
 	var accnt *account.Account
 	accnt, err = s.accountRepo.GetAccount(ctx, wd.AccountID)
 	if err != nil {
-		return errors.Wrap(err, "get account")
+		return nil, errors.Wrap(err, "get account")
+	}
+
+	if !accnt.Currency.IsValidAmount(wd.Amount) {
+		return nil, multierr.Combine(ErrValidation, ErrAmountPrecision)
 	}
 
 	var cashLedgerNo ledger.LedgerNo
 	cashLedgerNo, err = ledger.GetCashLedgerNo(accnt.Currency)
 	if err != nil {
-		return errors.Wrap(err, "get cash ledger no")
+		return nil, errors.Wrap(err, "get cash ledger no")
 	}
 
+	wdFee := s.feeSchedule.Fee(accnt.Currency, XactTypeExtWithdrawal.String(), wd.Amount)
+
 	var xactNo XactNo
 	xactNo, err = NewXactNo()
 	if err != nil {
-		return errors.Wrap(err, "new xact no")
+		return nil, errors.Wrap(err, "new xact no")
 	}
 
 	var tx tx.Tx
 	tx, err = s.xactRepo.BeginTx(ctx)
 	if err != nil {
-		return errors.Wrap(err, "begin tx")
+		return nil, errors.Wrap(err, "begin tx")
 	}
 	defer func() {
 		// rollback if there are errors
@@ -230,18 +386,55 @@ func (s *service) MakeWithdrawal(ctx context.Context, wd WithdrawalXact) (err er
 		}
 	}()
 
-	var bal *account.Balance
-	bal, err = s.balRepo.GetAccntBal(ctx, tx, accnt.AccountID)
+	if wd.IdempotencyKey != "" {
+		var (
+			origXactNo XactNo
+			replayed   bool
+		)
+		origXactNo, replayed, err = s.claimIdempotencyKey(ctx, tx, IdempotencyKey{
+			Key:         wd.IdempotencyKey,
+			Fingerprint: wd.fingerprint(),
+			XactNo:      xactNo,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "claim idempotency key")
+			return
+		}
+
+		// withdrawal was already made
+		if replayed {
+			xact, err = s.getXact(ctx, tx, origXactNo)
+			if err != nil {
+				err = errors.Wrap(err, "get xact")
+			}
+			return
+		}
+	}
+
+	var funds decimal.Decimal
+	funds, err = s.getAvailableFunds(ctx, tx, accnt.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get available funds")
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, accnt.AccountID)
 	if err != nil {
-		err = errors.Wrap(err, "get account balance")
 		return
 	}
 
-	if wd.Amount.GreaterThan(bal.CurrentBal) {
+	// the fee is charged on top of the amount
+	if wd.Amount.Add(wdFee).GreaterThan(funds) {
 		err = ErrInsufficientBalance
 		return
 	}
 
+	err = s.checkLimits(ctx, tx, accnt, wd.Amount, false)
+	if err != nil {
+		return
+	}
+
 	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
 		XactNo:      xactNo,
 		LedgerNo:    cashLedgerNo,
@@ -256,49 +449,68 @@ func (s *service) MakeWithdrawal(ctx context.Context, wd WithdrawalXact) (err er
 		return
 	}
 
-	return nil
+	err = s.chargeFee(ctx, tx, xactNo, accnt, wdFee,
+		fmt.Sprintf("Cash withdrawal fee from %s", accnt.AccountID))
+	if err != nil {
+		err = errors.Wrap(err, "charge fee")
+		return
+	}
+
+	xact, err = s.getXact(ctx, tx, xactNo)
+	if err != nil {
+		err = errors.Wrap(err, "get xact")
+		return
+	}
+
+	return xact, nil
 }
 
 // MakeTransfer creates a transfer transaction
-func (s *service) MakeTransfer(ctx context.Context, tr TransferXact) (err error) {
+func (s *service) MakeTransfer(ctx context.Context, tr TransferXact) (xact *Xact, err error) {
 	err = s.validateTransfer(ctx, tr)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var from *account.Account
 	from, err = s.accountRepo.GetAccount(ctx, tr.FromAccount)
 	if err != nil {
-		return errors.Wrap(err, "get from account")
+		return nil, errors.Wrap(err, "get from account")
 	}
 
 	var to *account.Account
 	to, err = s.accountRepo.GetAccount(ctx, tr.ToAccount)
 	if err != nil {
-		return errors.Wrap(err, "get to account")
+		return nil, errors.Wrap(err, "get to account")
 	}
 
 	// validate that two accounts have the same currency
 	if from.Currency != to.Currency {
-		return errors.Wrap(ErrDifferentCurrencies, "sending and receiving account have different currencies")
+		return nil, errors.Wrap(ErrDifferentCurrencies, "sending and receiving account have different currencies")
+	}
+
+	if !from.Currency.IsValidAmount(tr.Amount) {
+		return nil, multierr.Combine(ErrValidation, ErrAmountPrecision)
 	}
 
 	var cashLedgerNo ledger.LedgerNo
 	cashLedgerNo, err = ledger.GetCashLedgerNo(from.Currency)
 	if err != nil {
-		return errors.Wrap(err, "get cash ledger no")
+		return nil, errors.Wrap(err, "get cash ledger no")
 	}
 
+	trFee := s.feeSchedule.Fee(from.Currency, XactTypeExtSndTransfer.String(), tr.Amount)
+
 	var xactNo XactNo
 	xactNo, err = NewXactNo()
 	if err != nil {
-		return errors.Wrap(err, "new xact no")
+		return nil, errors.Wrap(err, "new xact no")
 	}
 
 	var tx tx.Tx
 	tx, err = s.xactRepo.BeginTx(ctx)
 	if err != nil {
-		return errors.Wrap(err, "begin tx")
+		return nil, errors.Wrap(err, "begin tx")
 	}
 	defer func() {
 		// rollback if there are errors
@@ -313,32 +525,94 @@ func (s *service) MakeTransfer(ctx context.Context, tr TransferXact) (err error)
 		}
 	}()
 
-	var fromBal *account.Balance
-	fromBal, err = s.balRepo.GetAccntBal(ctx, tx, from.AccountID)
+	if tr.IdempotencyKey != "" {
+		var (
+			origXactNo XactNo
+			replayed   bool
+		)
+		origXactNo, replayed, err = s.claimIdempotencyKey(ctx, tx, IdempotencyKey{
+			Key:         tr.IdempotencyKey,
+			Fingerprint: tr.fingerprint("transfer"),
+			XactNo:      xactNo,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "claim idempotency key")
+			return
+		}
+
+		// transfer was already made
+		if replayed {
+			xact, err = s.getXact(ctx, tx, origXactNo)
+			if err != nil {
+				err = errors.Wrap(err, "get xact")
+			}
+			return
+		}
+	}
+
+	// lock both accounts in a deterministic order to avoid deadlocks
+	err = s.balRepo.LockAccnts(ctx, tx, from.AccountID, to.AccountID)
 	if err != nil {
-		err = errors.Wrap(err, "get from account balance")
+		err = errors.Wrap(err, "lock accounts")
 		return
 	}
 
-	// sending account must have sufficient balance
-	if tr.Amount.GreaterThan(fromBal.CurrentBal) {
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, from.AccountID, to.AccountID)
+	if err != nil {
+		return
+	}
+
+	var fromFunds decimal.Decimal
+	fromFunds, err = s.getAvailableFunds(ctx, tx, from.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get from account available funds")
+		return
+	}
+
+	// sending account must have sufficient balance, the
+	// fee is charged on top of the amount
+	if tr.Amount.Add(trFee).GreaterThan(fromFunds) {
 		err = ErrInsufficientBalance
 		return
 	}
 
+	err = s.checkLimits(ctx, tx, from, tr.Amount, true)
+	if err != nil {
+		return
+	}
+
+	err = s.postTransfer(ctx, tx, xactNo, cashLedgerNo, from, to, tr.Amount, trFee)
+	if err != nil {
+		err = errors.Wrap(err, "post transfer")
+		return
+	}
+
+	xact, err = s.getXact(ctx, tx, xactNo)
+	if err != nil {
+		err = errors.Wrap(err, "get xact")
+		return
+	}
+
+	return xact, nil
+}
+
+// postTransfer posts the legs of a transfer within tx and charges the
+// fee to the sending account, the accounts must be locked within tx
+func (s *service) postTransfer(ctx context.Context, tx tx.Tx, xactNo XactNo,
+	cashLedgerNo ledger.LedgerNo, from, to *account.Account, amount, fee decimal.Decimal) error {
 	// debit the sending account
-	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+	err := s.xactRepo.CreateXact(ctx, tx, Transaction{
 		XactNo:      xactNo,
 		LedgerNo:    cashLedgerNo,
 		XactType:    XactTypeCredit, // credit ledger's cash
 		AccountID:   from.AccountID,
 		XactTypeExt: XactTypeExtSndTransfer, // debit sending account's cash
-		Amount:      tr.Amount,
+		Amount:      amount,
 		Desc:        fmt.Sprintf("Outgoing cash transfer to %s", to.AccountID),
 	})
 	if err != nil {
-		err = errors.Wrap(err, "create snd xact")
-		return
+		return errors.Wrap(err, "create snd xact")
 	}
 
 	// credit the receiving account
@@ -348,48 +622,1465 @@ func (s *service) MakeTransfer(ctx context.Context, tr TransferXact) (err error)
 		XactType:    XactTypeDebit, // debit ledger's cash
 		AccountID:   to.AccountID,
 		XactTypeExt: XactTypeExtRcvTransfer, // credit receiving account's cash
-		Amount:      tr.Amount,
+		Amount:      amount,
 		Desc:        fmt.Sprintf("Incoming cash transfer from %s", from.AccountID),
 	})
 	if err != nil {
-		err = errors.Wrap(err, "create rcv xact")
-		return
+		return errors.Wrap(err, "create rcv xact")
+	}
+
+	err = s.chargeFee(ctx, tx, xactNo, from, fee,
+		fmt.Sprintf("Cash transfer fee to %s", to.AccountID))
+	if err != nil {
+		return errors.Wrap(err, "charge fee")
 	}
 
 	return nil
 }
 
-func (s *service) validateTransfer(ctx context.Context, tr TransferXact) error {
-	err := tr.Validate()
+// chargeFee debits the fee from the account and credits the
+// revenue ledger of the account's currency, if the fee is non-zero
+func (s *service) chargeFee(ctx context.Context, tx tx.Tx, xactNo XactNo,
+	accnt *account.Account, amount decimal.Decimal, desc string) error {
+	if amount.IsZero() {
+		return nil
+	}
+
+	revenueLedgerNo, err := ledger.GetRevenueLedgerNo(accnt.Currency)
 	if err != nil {
-		return multierr.Combine(ErrValidation, err)
+		return errors.Wrap(err, "get revenue ledger no")
 	}
 
-	// verify sending and receiving account exists
-	fromExists, err := s.accountRepo.IsAccountExists(ctx, tr.FromAccount)
+	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+		XactNo:      xactNo,
+		LedgerNo:    revenueLedgerNo,
+		XactType:    XactTypeCredit, // credit ledger's revenue
+		AccountID:   accnt.AccountID,
+		XactTypeExt: XactTypeExtFee, // debit account's cash
+		Amount:      amount,
+		Desc:        desc,
+	})
 	if err != nil {
-		return errors.Wrap(err, "is sending account exists")
+		return errors.Wrap(err, "create fee xact")
 	}
 
-	if !fromExists {
-		return ErrSendingAccountNotFound
+	return nil
+}
+
+// MakeFXTransfer creates a cross-currency transfer transaction. The amount is in
+// the sending account's currency and is converted to the receiving account's
+// currency. The account transactions goes through the cash ledgers of each
+// currency and the money is moved to (and from) the fx position ledgers.
+func (s *service) MakeFXTransfer(ctx context.Context, tr TransferXact) (fxTr *FXTransfer, err error) {
+	err = s.validateTransfer(ctx, tr)
+	if err != nil {
+		return
 	}
 
-	toExists, err := s.accountRepo.IsAccountExists(ctx, tr.ToAccount)
+	var from *account.Account
+	from, err = s.accountRepo.GetAccount(ctx, tr.FromAccount)
 	if err != nil {
-		return errors.Wrap(err, "is receiving account exists")
+		return nil, errors.Wrap(err, "get from account")
 	}
 
-	if !toExists {
-		return ErrReceivingAccountNotFound
+	var to *account.Account
+	to, err = s.accountRepo.GetAccount(ctx, tr.ToAccount)
+	if err != nil {
+		return nil, errors.Wrap(err, "get to account")
 	}
 
-	return nil
-}
+	// validate that two accounts have different currencies
+	if from.Currency == to.Currency {
+		return nil, errors.Wrap(ErrSameCurrencies, "sending and receiving account have the same currency")
+	}
 
-// ListTransfers retrieves the transfer related transactions
-func (s *service) ListTransfers(ctx context.Context) ([]*Transaction, error) {
-	return s.xactRepo.ListTransfers(ctx)
+	if !from.Currency.IsValidAmount(tr.Amount) {
+		return nil, multierr.Combine(ErrValidation, ErrAmountPrecision)
+	}
+
+	var fromCashLedgerNo, toCashLedgerNo ledger.LedgerNo
+	fromCashLedgerNo, err = ledger.GetCashLedgerNo(from.Currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "get from cash ledger no")
+	}
+
+	toCashLedgerNo, err = ledger.GetCashLedgerNo(to.Currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "get to cash ledger no")
+	}
+
+	var fromFXLedgerNo, toFXLedgerNo ledger.LedgerNo
+	fromFXLedgerNo, err = ledger.GetFXLedgerNo(from.Currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "get from fx ledger no")
+	}
+
+	toFXLedgerNo, err = ledger.GetFXLedgerNo(to.Currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "get to fx ledger no")
+	}
+
+	if s.rateProvider == nil {
+		return nil, errors.Wrap(fx.ErrRateNotFound, "no rate provider")
+	}
+
+	var rate decimal.Decimal
+	rate, err = s.rateProvider.GetRate(ctx, from.Currency, to.Currency)
+	if err != nil {
+		return nil, errors.Wrap(err, "get rate")
+	}
+	rate = rate.Round(ratePrecision)
+
+	toAmount := convertAmount(tr.Amount, rate, to.Currency)
+	if !toAmount.IsPositive() {
+		return nil, multierr.Combine(ErrValidation, ErrZeroAmount)
+	}
+
+	var xactNo XactNo
+	xactNo, err = NewXactNo()
+	if err != nil {
+		return nil, errors.Wrap(err, "new xact no")
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	if tr.IdempotencyKey != "" {
+		var (
+			origXactNo XactNo
+			replayed   bool
+		)
+		origXactNo, replayed, err = s.claimIdempotencyKey(ctx, tx, IdempotencyKey{
+			Key:         tr.IdempotencyKey,
+			Fingerprint: tr.fingerprint("fx_transfer"),
+			XactNo:      xactNo,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "claim idempotency key")
+			return
+		}
+
+		// fx transfer was already made
+		if replayed {
+			fxTr, err = s.xactRepo.GetFXTransfer(ctx, origXactNo)
+			if err != nil {
+				err = errors.Wrap(err, "get fx transfer")
+			}
+			return
+		}
+	}
+
+	// lock both accounts in a deterministic order to avoid deadlocks
+	err = s.balRepo.LockAccnts(ctx, tx, from.AccountID, to.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "lock accounts")
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, from.AccountID, to.AccountID)
+	if err != nil {
+		return
+	}
+
+	var fromFunds decimal.Decimal
+	fromFunds, err = s.getAvailableFunds(ctx, tx, from.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get from account available funds")
+		return
+	}
+
+	// sending account must have sufficient balance
+	if tr.Amount.GreaterThan(fromFunds) {
+		err = ErrInsufficientBalance
+		return
+	}
+
+	err = s.checkLimits(ctx, tx, from, tr.Amount, true)
+	if err != nil {
+		return
+	}
+
+	// debit the sending account
+	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+		XactNo:      xactNo,
+		LedgerNo:    fromCashLedgerNo,
+		XactType:    XactTypeCredit, // credit ledger's cash
+		AccountID:   from.AccountID,
+		XactTypeExt: XactTypeExtSndTransfer, // debit sending account's cash
+		Amount:      tr.Amount,
+		Desc:        fmt.Sprintf("Outgoing fx transfer to %s", to.AccountID),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create snd xact")
+		return
+	}
+
+	// credit the receiving account
+	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+		XactNo:      xactNo,
+		LedgerNo:    toCashLedgerNo,
+		XactType:    XactTypeDebit, // debit ledger's cash
+		AccountID:   to.AccountID,
+		XactTypeExt: XactTypeExtRcvTransfer, // credit receiving account's cash
+		Amount:      toAmount,
+		Desc:        fmt.Sprintf("Incoming fx transfer from %s", from.AccountID),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create rcv xact")
+		return
+	}
+
+	// the sending currency's cash stays in the house,
+	// move it from the cash to the fx position ledger
+	for _, lx := range []LedgerXact{
+		{
+			XactNo:   xactNo,
+			LedgerNo: fromCashLedgerNo,
+			XactType: XactTypeDebit,
+			Amount:   tr.Amount,
+			Desc:     fmt.Sprintf("FX %s/%s cash to position", from.Currency, to.Currency),
+		},
+		{
+			XactNo:   xactNo,
+			LedgerNo: fromFXLedgerNo,
+			XactType: XactTypeCredit,
+			Amount:   tr.Amount,
+			Desc:     fmt.Sprintf("FX %s/%s cash to position", from.Currency, to.Currency),
+		},
+		// the receiving currency's cash is funded by the fx position ledger
+		{
+			XactNo:   xactNo,
+			LedgerNo: toFXLedgerNo,
+			XactType: XactTypeDebit,
+			Amount:   toAmount,
+			Desc:     fmt.Sprintf("FX %s/%s position to cash", from.Currency, to.Currency),
+		},
+		{
+			XactNo:   xactNo,
+			LedgerNo: toCashLedgerNo,
+			XactType: XactTypeCredit,
+			Amount:   toAmount,
+			Desc:     fmt.Sprintf("FX %s/%s position to cash", from.Currency, to.Currency),
+		},
+	} {
+		err = s.xactRepo.CreateLedgerXact(ctx, tx, lx)
+		if err != nil {
+			err = errors.Wrap(err, "create ledger xact")
+			return
+		}
+	}
+
+	fxTr = &FXTransfer{
+		XactNo:       xactNo,
+		FromAccount:  from.AccountID,
+		ToAccount:    to.AccountID,
+		FromCurrency: from.Currency,
+		ToCurrency:   to.Currency,
+		FromAmount:   tr.Amount,
+		ToAmount:     toAmount,
+		Rate:         rate,
+	}
+	err = s.xactRepo.CreateFXTransfer(ctx, tx, *fxTr)
+	if err != nil {
+		err = errors.Wrap(err, "create fx transfer")
+		return
+	}
+
+	return fxTr, nil
+}
+
+// MakeJournalEntry creates a journal entry. The account legs are posted
+// against the cash ledger of the account's currency which is then offset,
+// hence, only the ledger legs of the entry move the internal ledgers.
+func (s *service) MakeJournalEntry(ctx context.Context, jx JournalXact) (xact *Xact, err error) {
+	err = jx.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	// currency of each leg
+	currs := make([]currency.Currency, 0, len(jx.Legs))
+	for _, leg := range jx.Legs {
+		var curr currency.Currency
+		curr, err = s.getLegCurrency(ctx, leg)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curr.IsValidAmount(leg.Amount) {
+			return nil, multierr.Combine(ErrValidation, ErrAmountPrecision)
+		}
+
+		currs = append(currs, curr)
+	}
+
+	err = checkBalanced(jx.Legs, currs)
+	if err != nil {
+		return nil, err
+	}
+
+	var xactNo XactNo
+	xactNo, err = NewXactNo()
+	if err != nil {
+		return nil, errors.Wrap(err, "new xact no")
+	}
+
+	// account legs and the offsets of the cash ledgers
+	var (
+		xacts     = []Transaction{}
+		lgXacts   = []LedgerXact{}
+		accntIDs  = []account.AccountID{}
+		debits    = map[account.AccountID]decimal.Decimal{}
+		cashNos   = []ledger.LedgerNo{}
+		cashNets  = map[ledger.LedgerNo]decimal.Decimal{}
+		cashCurrs = map[ledger.LedgerNo]currency.Currency{}
+	)
+	for i, leg := range jx.Legs {
+		desc := leg.Desc
+		if desc == "" {
+			desc = jx.Desc
+		}
+
+		if leg.LedgerNo != "" {
+			lgXacts = append(lgXacts, LedgerXact{
+				XactNo:   xactNo,
+				LedgerNo: leg.LedgerNo,
+				XactType: leg.XactType,
+				Amount:   leg.Amount,
+				Desc:     desc,
+			})
+			continue
+		}
+
+		var cashLedgerNo ledger.LedgerNo
+		cashLedgerNo, err = ledger.GetCashLedgerNo(currs[i])
+		if err != nil {
+			return nil, errors.Wrap(err, "get cash ledger no")
+		}
+
+		// a ledger's debit is the account's credit and vice versa
+		xacts = append(xacts, Transaction{
+			XactNo:      xactNo,
+			LedgerNo:    cashLedgerNo,
+			XactType:    leg.XactType.opposite(),
+			AccountID:   leg.AccountID,
+			XactTypeExt: XactTypeExtJournal,
+			Amount:      leg.Amount,
+			Desc:        desc,
+		})
+
+		if _, ok := debits[leg.AccountID]; !ok {
+			accntIDs = append(accntIDs, leg.AccountID)
+		}
+
+		if _, ok := cashNets[cashLedgerNo]; !ok {
+			cashNos = append(cashNos, cashLedgerNo)
+			cashCurrs[cashLedgerNo] = currs[i]
+		}
+
+		// the offset has the same side as the account leg
+		if leg.XactType == XactTypeDebit {
+			debits[leg.AccountID] = debits[leg.AccountID].Add(leg.Amount)
+			cashNets[cashLedgerNo] = cashNets[cashLedgerNo].Add(leg.Amount)
+		} else {
+			debits[leg.AccountID] = debits[leg.AccountID].Sub(leg.Amount)
+			cashNets[cashLedgerNo] = cashNets[cashLedgerNo].Sub(leg.Amount)
+		}
+	}
+
+	for _, cashLedgerNo := range cashNos {
+		net := cashNets[cashLedgerNo]
+		if net.IsZero() {
+			continue
+		}
+
+		xactType := XactTypeDebit
+		if net.IsNegative() {
+			xactType = XactTypeCredit
+		}
+
+		lgXacts = append(lgXacts, LedgerXact{
+			XactNo:   xactNo,
+			LedgerNo: cashLedgerNo,
+			XactType: xactType,
+			Amount:   net.Abs(),
+			Desc:     fmt.Sprintf("Journal %s cash offset", cashCurrs[cashLedgerNo]),
+		})
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	if jx.IdempotencyKey != "" {
+		var (
+			origXactNo XactNo
+			replayed   bool
+		)
+		origXactNo, replayed, err = s.claimIdempotencyKey(ctx, tx, IdempotencyKey{
+			Key:         jx.IdempotencyKey,
+			Fingerprint: jx.fingerprint(),
+			XactNo:      xactNo,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "claim idempotency key")
+			return
+		}
+
+		// journal entry was already made
+		if replayed {
+			xact, err = s.getXact(ctx, tx, origXactNo)
+			if err != nil {
+				err = errors.Wrap(err, "get xact")
+			}
+			return
+		}
+	}
+
+	// lock the accounts in a deterministic order to avoid deadlocks
+	err = s.balRepo.LockAccnts(ctx, tx, accntIDs...)
+	if err != nil {
+		err = errors.Wrap(err, "lock accounts")
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, accntIDs...)
+	if err != nil {
+		return
+	}
+
+	// accounts debited by the entry must have sufficient balance
+	err = s.checkDebits(ctx, tx, accntIDs, debits)
+	if err != nil {
+		return
+	}
+
+	for _, x := range xacts {
+		err = s.xactRepo.CreateXact(ctx, tx, x)
+		if err != nil {
+			err = errors.Wrap(err, "create jn xact")
+			return
+		}
+	}
+
+	for _, lx := range lgXacts {
+		err = s.xactRepo.CreateLedgerXact(ctx, tx, lx)
+		if err != nil {
+			err = errors.Wrap(err, "create jn ledger xact")
+			return
+		}
+	}
+
+	xact, err = s.getXact(ctx, tx, xactNo)
+	if err != nil {
+		err = errors.Wrap(err, "get xact")
+		return
+	}
+
+	return xact, nil
+}
+
+// getLegCurrency retrieves the currency of the account or the ledger of the leg
+func (s *service) getLegCurrency(ctx context.Context, leg JournalLeg) (currency.Currency, error) {
+	if leg.LedgerNo != "" {
+		lg, err := s.ledgerRepo.GetLedger(ctx, leg.LedgerNo)
+		if err != nil {
+			if errors.Is(err, ledger.ErrLedgerNotFound) {
+				return currency.Currency(0), ledger.ErrLedgerNotFound
+			}
+			return currency.Currency(0), errors.Wrap(err, "get ledger")
+		}
+
+		return lg.Currency, nil
+	}
+
+	exists, err := s.accountRepo.IsAccountExists(ctx, leg.AccountID)
+	if err != nil {
+		return currency.Currency(0), errors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return currency.Currency(0), account.ErrAccountNotFound
+	}
+
+	accnt, err := s.accountRepo.GetAccount(ctx, leg.AccountID)
+	if err != nil {
+		return currency.Currency(0), errors.Wrap(err, "get account")
+	}
+
+	return accnt.Currency, nil
+}
+
+// ReverseXact creates a reversal of a transaction. A mirror entry is posted for
+// every leg sharing the transaction number of the original transaction.
+func (s *service) ReverseXact(ctx context.Context, rv ReversalXact) (rev *Reversal, err error) {
+	err = rv.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	var xactNo XactNo
+	xactNo, err = NewXactNo()
+	if err != nil {
+		return nil, errors.Wrap(err, "new xact no")
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	var legs []*Transaction
+	legs, err = s.xactRepo.ListXactLegs(ctx, tx, rv.XactNo)
+	if err != nil {
+		err = errors.Wrap(err, "list xact legs")
+		return
+	}
+
+	var lgLegs []*LedgerXact
+	lgLegs, err = s.xactRepo.ListLedgerXactLegs(ctx, tx, rv.XactNo)
+	if err != nil {
+		err = errors.Wrap(err, "list ledger xact legs")
+		return
+	}
+
+	if len(legs) == 0 {
+		err = ErrXactNotFound
+		return
+	}
+
+	// reversals and refunds can't be reversed
+	for _, leg := range legs {
+		if leg.XactTypeExt == XactTypeExtReversal ||
+			leg.XactTypeExt == XactTypeExtRefund {
+			err = ErrXactNotReversible
+			return
+		}
+	}
+
+	// lock the accounts in a deterministic order to avoid deadlocks,
+	// the reversals are listed under the lock so that concurrent
+	// reversals and refunds of the transaction are serialized
+	accntIDs := make([]account.AccountID, 0, len(legs))
+	for _, leg := range legs {
+		accntIDs = append(accntIDs, leg.AccountID)
+	}
+
+	err = s.balRepo.LockAccnts(ctx, tx, accntIDs...)
+	if err != nil {
+		err = errors.Wrap(err, "lock accounts")
+		return
+	}
+
+	var revs []*Reversal
+	revs, err = s.xactRepo.ListReversals(ctx, tx, rv.XactNo)
+	if err != nil {
+		err = errors.Wrap(err, "list reversals")
+		return
+	}
+
+	for _, r := range revs {
+		if r.XactTypeExt == XactTypeExtReversal {
+			err = ErrXactAlreadyReversed
+			return
+		}
+		err = ErrXactAlreadyRefunded
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, accntIDs...)
+	if err != nil {
+		return
+	}
+
+	// accounts debited by the reversal must have sufficient balance
+	err = s.checkReversalBalances(ctx, tx, legs)
+	if err != nil {
+		return
+	}
+
+	desc := fmt.Sprintf("Reversal of %s: %s", rv.XactNo, rv.Reason)
+	for _, leg := range legs {
+		err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+			XactNo:      xactNo,
+			LedgerNo:    leg.LedgerNo,
+			XactType:    leg.XactType.opposite(),
+			AccountID:   leg.AccountID,
+			XactTypeExt: XactTypeExtReversal,
+			Amount:      leg.Amount,
+			Desc:        desc,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "create rv xact")
+			return
+		}
+	}
+
+	for _, lgLeg := range lgLegs {
+		err = s.xactRepo.CreateLedgerXact(ctx, tx, LedgerXact{
+			XactNo:   xactNo,
+			LedgerNo: lgLeg.LedgerNo,
+			XactType: lgLeg.XactType.opposite(),
+			Amount:   lgLeg.Amount,
+			Desc:     desc,
+		})
+		if err != nil {
+			err = errors.Wrap(err, "create rv ledger xact")
+			return
+		}
+	}
+
+	rev = &Reversal{
+		XactNo:      xactNo,
+		OrigXactNo:  rv.XactNo,
+		XactTypeExt: XactTypeExtReversal,
+		Amount:      xactAmount(legs),
+		Reason:      rv.Reason,
+	}
+	err = s.xactRepo.CreateReversal(ctx, tx, *rev)
+	if err != nil {
+		err = errors.Wrap(err, "create reversal")
+		return
+	}
+
+	return rev, nil
+}
+
+// getAvailableFunds returns the available balance plus the overdraft limit
+// of the account, the account is locked until the tx is done
+func (s *service) getAvailableFunds(ctx context.Context, tx tx.Tx,
+	accntID account.AccountID) (decimal.Decimal, error) {
+	bal, err := s.balRepo.GetAccntBalForUpdate(ctx, tx, accntID)
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "get account balance")
+	}
+
+	limit, err := s.accountRepo.GetOverdraftLimit(ctx, tx, accntID)
+	if err != nil {
+		return decimal.Zero, errors.Wrap(err, "get overdraft limit")
+	}
+
+	return bal.AvailableBal.Add(limit), nil
+}
+
+// checkLimits checks the amount withdrawn, or sent if transfer is true,
+// by the account against its limits, the account must be locked within tx
+func (s *service) checkLimits(ctx context.Context, tx tx.Tx, accnt *account.Account,
+	amount decimal.Decimal, transfer bool) error {
+	l := s.limitPolicy.Limits(accnt.AccountID, accnt.Currency)
+	if l.IsZero() {
+		return nil
+	}
+
+	daily, monthly, err := s.getOutgoingUsage(ctx, tx, accnt.AccountID)
+	if err != nil {
+		return err
+	}
+
+	return l.Check(amount, transfer, *daily, *monthly)
+}
+
+// getOutgoingUsage retrieves the daily and monthly outgoing usage of the account
+func (s *service) getOutgoingUsage(ctx context.Context, tx tx.Tx,
+	accntID account.AccountID) (daily, monthly *limit.Usage, err error) {
+	// the days and months are in UTC
+	now := time.Now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	daily, err = s.xactRepo.GetOutgoingUsage(ctx, tx, accntID, dayStart)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get daily usage")
+	}
+
+	monthly, err = s.xactRepo.GetOutgoingUsage(ctx, tx, accntID, monthStart)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "get monthly usage")
+	}
+
+	return daily, monthly, nil
+}
+
+// checkPostable checks that the accounts are neither frozen
+// nor closed, the accounts must be locked within tx
+func (s *service) checkPostable(ctx context.Context, tx tx.Tx, accntIDs ...account.AccountID) error {
+	for _, accntID := range accntIDs {
+		status, err := s.accountRepo.GetAccountStatus(ctx, tx, accntID)
+		if err != nil {
+			return errors.Wrap(err, "get account status")
+		}
+
+		err = status.CheckPostable()
+		if err != nil {
+			return errors.Wrapf(err, "account %s", accntID)
+		}
+	}
+
+	return nil
+}
+
+// checkReversalBalances checks that the accounts debited by
+// the reversal of the legs have sufficient balances
+func (s *service) checkReversalBalances(ctx context.Context, tx tx.Tx, legs []*Transaction) error {
+	// net debit of each account
+	accntIDs := []account.AccountID{}
+	debits := map[account.AccountID]decimal.Decimal{}
+	for _, leg := range legs {
+		if _, ok := debits[leg.AccountID]; !ok {
+			accntIDs = append(accntIDs, leg.AccountID)
+		}
+
+		// reversing a debit leg (ledger's debit, account's credit)
+		// debits the account and vice versa
+		if leg.XactType == XactTypeDebit {
+			debits[leg.AccountID] = debits[leg.AccountID].Add(leg.Amount)
+		} else {
+			debits[leg.AccountID] = debits[leg.AccountID].Sub(leg.Amount)
+		}
+	}
+
+	return s.checkDebits(ctx, tx, accntIDs, debits)
+}
+
+// checkDebits checks that the accounts have sufficient
+// balances for their net debits, accntIDs sets the order
+func (s *service) checkDebits(ctx context.Context, tx tx.Tx, accntIDs []account.AccountID,
+	debits map[account.AccountID]decimal.Decimal) error {
+	for _, accntID := range accntIDs {
+		if !debits[accntID].IsPositive() {
+			continue
+		}
+
+		funds, err := s.getAvailableFunds(ctx, tx, accntID)
+		if err != nil {
+			return errors.Wrap(err, "get available funds")
+		}
+
+		if debits[accntID].GreaterThan(funds) {
+			return ErrInsufficientBalance
+		}
+	}
+
+	return nil
+}
+
+// xactAmount returns the amount of the transaction, for
+// transfers it is the amount sent by the sending account
+func xactAmount(legs []*Transaction) decimal.Decimal {
+	for _, leg := range legs {
+		if leg.XactTypeExt == XactTypeExtSndTransfer {
+			return leg.Amount
+		}
+	}
+
+	return legs[0].Amount
+}
+
+// RefundTransfer creates a (partial) refund of a same-currency transfer. The
+// money is moved back from the receiving account to the sending account.
+func (s *service) RefundTransfer(ctx context.Context, rf RefundXact) (rev *Reversal, err error) {
+	err = rf.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	var xactNo XactNo
+	xactNo, err = NewXactNo()
+	if err != nil {
+		return nil, errors.Wrap(err, "new xact no")
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	var legs []*Transaction
+	legs, err = s.xactRepo.ListXactLegs(ctx, tx, rf.XactNo)
+	if err != nil {
+		err = errors.Wrap(err, "list xact legs")
+		return
+	}
+
+	if len(legs) == 0 {
+		err = ErrXactNotFound
+		return
+	}
+
+	var lgLegs []*LedgerXact
+	lgLegs, err = s.xactRepo.ListLedgerXactLegs(ctx, tx, rf.XactNo)
+	if err != nil {
+		err = errors.Wrap(err, "list ledger xact legs")
+		return
+	}
+
+	// only same-currency transfers can be refunded
+	var (
+		snd, rcv *Transaction
+		nLegs    int
+	)
+	for _, leg := range legs {
+		switch leg.XactTypeExt {
+		case XactTypeExtSndTransfer:
+			snd = leg
+		case XactTypeExtRcvTransfer:
+			rcv = leg
+		case XactTypeExtFee:
+			// the fee is kept
+			continue
+		}
+		nLegs++
+	}
+
+	if nLegs != 2 || snd == nil || rcv == nil || len(lgLegs) > 0 {
+		err = ErrXactNotRefundable
+		return
+	}
+
+	var sndAccnt *account.Account
+	sndAccnt, err = s.accountRepo.GetAccount(ctx, snd.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get sending account")
+		return
+	}
+
+	if !sndAccnt.Currency.IsValidAmount(rf.Amount) {
+		err = multierr.Combine(ErrValidation, ErrAmountPrecision)
+		return
+	}
+
+	// lock both accounts in a deterministic order to avoid deadlocks,
+	// the refunded amount is summed under the lock so that concurrent
+	// refunds can't refund more than the transferred amount
+	err = s.balRepo.LockAccnts(ctx, tx, snd.AccountID, rcv.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "lock accounts")
+		return
+	}
+
+	var revs []*Reversal
+	revs, err = s.xactRepo.ListReversals(ctx, tx, rf.XactNo)
+	if err != nil {
+		err = errors.Wrap(err, "list reversals")
+		return
+	}
+
+	refunded := decimal.Zero
+	for _, r := range revs {
+		if r.XactTypeExt == XactTypeExtReversal {
+			err = ErrXactAlreadyReversed
+			return
+		}
+		refunded = refunded.Add(r.Amount)
+	}
+
+	if refunded.Add(rf.Amount).GreaterThan(snd.Amount) {
+		err = ErrRefundExceedsAmount
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, snd.AccountID, rcv.AccountID)
+	if err != nil {
+		return
+	}
+
+	var rcvFunds decimal.Decimal
+	rcvFunds, err = s.getAvailableFunds(ctx, tx, rcv.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get receiving account available funds")
+		return
+	}
+
+	// receiving account must have sufficient balance
+	if rf.Amount.GreaterThan(rcvFunds) {
+		err = ErrInsufficientBalance
+		return
+	}
+
+	// debit the receiving account
+	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+		XactNo:      xactNo,
+		LedgerNo:    rcv.LedgerNo,
+		XactType:    XactTypeCredit, // credit ledger's cash
+		AccountID:   rcv.AccountID,
+		XactTypeExt: XactTypeExtRefund, // debit receiving account's cash
+		Amount:      rf.Amount,
+		Desc:        fmt.Sprintf("Refund of %s to %s: %s", rf.XactNo, snd.AccountID, rf.Reason),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create rcv rf xact")
+		return
+	}
+
+	// credit the sending account
+	err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+		XactNo:      xactNo,
+		LedgerNo:    snd.LedgerNo,
+		XactType:    XactTypeDebit, // debit ledger's cash
+		AccountID:   snd.AccountID,
+		XactTypeExt: XactTypeExtRefund, // credit sending account's cash
+		Amount:      rf.Amount,
+		Desc:        fmt.Sprintf("Refund of %s from %s: %s", rf.XactNo, rcv.AccountID, rf.Reason),
+	})
+	if err != nil {
+		err = errors.Wrap(err, "create snd rf xact")
+		return
+	}
+
+	rev = &Reversal{
+		XactNo:      xactNo,
+		OrigXactNo:  rf.XactNo,
+		XactTypeExt: XactTypeExtRefund,
+		Amount:      rf.Amount,
+		Reason:      rf.Reason,
+	}
+	err = s.xactRepo.CreateReversal(ctx, tx, *rev)
+	if err != nil {
+		err = errors.Wrap(err, "create refund")
+		return
+	}
+
+	return rev, nil
+}
+
+// PlaceHold places an authorization hold on an account. The
+// amount is reserved until the hold is captured, voided or expired.
+func (s *service) PlaceHold(ctx context.Context, hx HoldXact) (hold *Hold, err error) {
+	err = hx.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	var exists bool
+	exists, err = s.accountRepo.IsAccountExists(ctx, hx.AccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	var accnt *account.Account
+	accnt, err = s.accountRepo.GetAccount(ctx, hx.AccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get account")
+	}
+
+	if !accnt.Currency.IsValidAmount(hx.Amount) {
+		return nil, multierr.Combine(ErrValidation, ErrAmountPrecision)
+	}
+
+	var holdID HoldID
+	holdID, err = NewHoldID()
+	if err != nil {
+		return nil, errors.Wrap(err, "new hold id")
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	var funds decimal.Decimal
+	funds, err = s.getAvailableFunds(ctx, tx, accnt.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get available funds")
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, accnt.AccountID)
+	if err != nil {
+		return
+	}
+
+	if hx.Amount.GreaterThan(funds) {
+		err = ErrInsufficientBalance
+		return
+	}
+
+	hold = &Hold{
+		HoldID:         holdID,
+		AccountID:      accnt.AccountID,
+		Amount:         hx.Amount,
+		Status:         HoldStatusActive,
+		CapturedAmount: decimal.Zero,
+		ExpiresAt:      time.Now().Add(s.holdTTL),
+	}
+	err = s.xactRepo.CreateHold(ctx, tx, *hold)
+	if err != nil {
+		err = errors.Wrap(err, "create hold")
+		return
+	}
+
+	return hold, nil
+}
+
+// CaptureHold captures a hold (fully or partially) into a transfer if the
+// receiving account is set, otherwise into a withdrawal. The remaining
+// amount of a partially captured hold is released.
+func (s *service) CaptureHold(ctx context.Context, cx CaptureXact) (hold *Hold, err error) {
+	err = cx.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	var to *account.Account
+	if cx.ToAccount != "" {
+		var exists bool
+		exists, err = s.accountRepo.IsAccountExists(ctx, cx.ToAccount)
+		if err != nil {
+			return nil, errors.Wrap(err, "is receiving account exists")
+		}
+
+		if !exists {
+			return nil, ErrReceivingAccountNotFound
+		}
+
+		to, err = s.accountRepo.GetAccount(ctx, cx.ToAccount)
+		if err != nil {
+			return nil, errors.Wrap(err, "get to account")
+		}
+	}
+
+	var xactNo XactNo
+	xactNo, err = NewXactNo()
+	if err != nil {
+		return nil, errors.Wrap(err, "new xact no")
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	hold, err = s.xactRepo.GetHoldForUpdate(ctx, tx, cx.HoldID)
+	if err != nil {
+		err = errors.Wrap(err, "get hold for update")
+		return
+	}
+
+	err = checkHoldActive(hold)
+	if err != nil {
+		return
+	}
+
+	// capture the full amount by default
+	amount := cx.Amount
+	if amount.IsZero() {
+		amount = hold.Amount
+	}
+
+	if amount.GreaterThan(hold.Amount) {
+		err = ErrCaptureExceedsHold
+		return
+	}
+
+	var from *account.Account
+	from, err = s.accountRepo.GetAccount(ctx, hold.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get from account")
+		return
+	}
+
+	if to != nil && from.Currency != to.Currency {
+		err = errors.Wrap(ErrDifferentCurrencies, "sending and receiving account have different currencies")
+		return
+	}
+
+	if !from.Currency.IsValidAmount(amount) {
+		err = multierr.Combine(ErrValidation, ErrAmountPrecision)
+		return
+	}
+
+	var cashLedgerNo ledger.LedgerNo
+	cashLedgerNo, err = ledger.GetCashLedgerNo(from.Currency)
+	if err != nil {
+		err = errors.Wrap(err, "get cash ledger no")
+		return
+	}
+
+	// the fee is the same as the withdrawal or transfer
+	xactTypeExt := XactTypeExtWithdrawal
+	if to != nil {
+		xactTypeExt = XactTypeExtSndTransfer
+	}
+	captureFee := s.feeSchedule.Fee(from.Currency, xactTypeExt.String(), amount)
+
+	// lock both accounts in a deterministic order to avoid deadlocks
+	accntIDs := []account.AccountID{from.AccountID}
+	if to != nil {
+		accntIDs = append(accntIDs, to.AccountID)
+	}
+
+	err = s.balRepo.LockAccnts(ctx, tx, accntIDs...)
+	if err != nil {
+		err = errors.Wrap(err, "lock accounts")
+		return
+	}
+
+	// posting to frozen or closed accounts is not allowed
+	err = s.checkPostable(ctx, tx, accntIDs...)
+	if err != nil {
+		return
+	}
+
+	var funds decimal.Decimal
+	funds, err = s.getAvailableFunds(ctx, tx, from.AccountID)
+	if err != nil {
+		err = errors.Wrap(err, "get available funds")
+		return
+	}
+
+	// the amount reserved by the hold is released by the
+	// capture, the fee is charged on top of the amount
+	if amount.Add(captureFee).GreaterThan(funds.Add(hold.Amount)) {
+		err = ErrInsufficientBalance
+		return
+	}
+
+	// the limits are checked when the amount is posted
+	err = s.checkLimits(ctx, tx, from, amount, to != nil)
+	if err != nil {
+		return
+	}
+
+	if to == nil {
+		err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+			XactNo:      xactNo,
+			LedgerNo:    cashLedgerNo,
+			XactType:    XactTypeCredit, // credit ledger's cash
+			AccountID:   from.AccountID,
+			XactTypeExt: XactTypeExtWithdrawal, // debit account's cash
+			Amount:      amount,
+			Desc:        fmt.Sprintf("Cash withdrawal from %s, capture of hold %s", from.AccountID, hold.HoldID),
+		})
+		if err != nil {
+			err = errors.Wrap(err, "create wd xact")
+			return
+		}
+	} else {
+		// debit the sending account
+		err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+			XactNo:      xactNo,
+			LedgerNo:    cashLedgerNo,
+			XactType:    XactTypeCredit, // credit ledger's cash
+			AccountID:   from.AccountID,
+			XactTypeExt: XactTypeExtSndTransfer, // debit sending account's cash
+			Amount:      amount,
+			Desc:        fmt.Sprintf("Outgoing cash transfer to %s, capture of hold %s", to.AccountID, hold.HoldID),
+		})
+		if err != nil {
+			err = errors.Wrap(err, "create snd xact")
+			return
+		}
+
+		// credit the receiving account
+		err = s.xactRepo.CreateXact(ctx, tx, Transaction{
+			XactNo:      xactNo,
+			LedgerNo:    cashLedgerNo,
+			XactType:    XactTypeDebit, // debit ledger's cash
+			AccountID:   to.AccountID,
+			XactTypeExt: XactTypeExtRcvTransfer, // credit receiving account's cash
+			Amount:      amount,
+			Desc:        fmt.Sprintf("Incoming cash transfer from %s", from.AccountID),
+		})
+		if err != nil {
+			err = errors.Wrap(err, "create rcv xact")
+			return
+		}
+	}
+
+	feeDesc := fmt.Sprintf("Cash withdrawal fee from %s", from.AccountID)
+	if to != nil {
+		feeDesc = fmt.Sprintf("Cash transfer fee to %s", to.AccountID)
+	}
+
+	err = s.chargeFee(ctx, tx, xactNo, from, captureFee, feeDesc)
+	if err != nil {
+		err = errors.Wrap(err, "charge fee")
+		return
+	}
+
+	hold.Status = HoldStatusCaptured
+	hold.CapturedAmount = amount
+	hold.XactNo = xactNo
+	err = s.xactRepo.UpdateHold(ctx, tx, *hold)
+	if err != nil {
+		err = errors.Wrap(err, "update hold")
+		return
+	}
+
+	return hold, nil
+}
+
+// VoidHold voids a hold, the amount reserved by the hold is released
+func (s *service) VoidHold(ctx context.Context, holdID HoldID) (hold *Hold, err error) {
+	if holdID == "" {
+		return nil, ErrHoldNotFound
+	}
+
+	var tx tx.Tx
+	tx, err = s.xactRepo.BeginTx(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "begin tx")
+	}
+	defer func() {
+		// rollback if there are errors
+		if err != nil {
+			_ = tx.Rollback()
+			return
+		}
+
+		// commit if no errors
+		if commitErr := tx.Commit(); commitErr != nil {
+			err = multierr.Combine(err, commitErr)
+		}
+	}()
+
+	hold, err = s.xactRepo.GetHoldForUpdate(ctx, tx, holdID)
+	if err != nil {
+		err = errors.Wrap(err, "get hold for update")
+		return
+	}
+
+	err = checkHoldActive(hold)
+	if err != nil {
+		return
+	}
+
+	hold.Status = HoldStatusVoided
+	err = s.xactRepo.UpdateHold(ctx, tx, *hold)
+	if err != nil {
+		err = errors.Wrap(err, "update hold")
+		return
+	}
+
+	return hold, nil
+}
+
+// checkHoldActive checks that the hold can be captured or voided
+func checkHoldActive(hold *Hold) error {
+	hold.expire(time.Now())
+
+	switch hold.Status {
+	case HoldStatusActive:
+		return nil
+	case HoldStatusExpired:
+		return ErrHoldExpired
+	}
+
+	return ErrHoldNotActive
+}
+
+// GetHold retrieves a hold
+func (s *service) GetHold(ctx context.Context, holdID HoldID) (*Hold, error) {
+	if holdID == "" {
+		return nil, ErrHoldNotFound
+	}
+
+	hold, err := s.xactRepo.GetHold(ctx, holdID)
+	if err != nil {
+		return nil, errors.Wrap(err, "get hold")
+	}
+	hold.expire(time.Now())
+
+	return hold, nil
+}
+
+func (s *service) validateTransfer(ctx context.Context, tr TransferXact) error {
+	err := tr.Validate()
+	if err != nil {
+		return multierr.Combine(ErrValidation, err)
+	}
+
+	// verify sending and receiving account exists
+	fromExists, err := s.accountRepo.IsAccountExists(ctx, tr.FromAccount)
+	if err != nil {
+		return errors.Wrap(err, "is sending account exists")
+	}
+
+	if !fromExists {
+		return ErrSendingAccountNotFound
+	}
+
+	toExists, err := s.accountRepo.IsAccountExists(ctx, tr.ToAccount)
+	if err != nil {
+		return errors.Wrap(err, "is receiving account exists")
+	}
+
+	if !toExists {
+		return ErrReceivingAccountNotFound
+	}
+
+	return nil
+}
+
+// getXact retrieves a transaction and all of its legs within tx
+func (s *service) getXact(ctx context.Context, tx tx.Tx, xactNo XactNo) (*Xact, error) {
+	legs, err := s.xactRepo.ListXactLegs(ctx, tx, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "list xact legs")
+	}
+
+	if len(legs) == 0 {
+		return nil, ErrXactNotFound
+	}
+
+	lgLegs, err := s.xactRepo.ListLedgerXactLegs(ctx, tx, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "list ledger xact legs")
+	}
+
+	return &Xact{
+		XactNo:     xactNo,
+		Legs:       legs,
+		LedgerLegs: lgLegs,
+		Ts:         legs[0].Ts,
+	}, nil
+}
+
+// GetXact retrieves a transaction and all of its legs
+func (s *service) GetXact(ctx context.Context, xactNo XactNo) (*Xact, error) {
+	if xactNo == "" {
+		return nil, ErrXactNotFound
+	}
+
+	xact, err := s.xactRepo.GetXact(ctx, xactNo)
+	if err != nil {
+		return nil, errors.Wrap(err, "get xact")
+	}
+
+	return xact, nil
+}
+
+// ListTransfers retrieves the transfer related transactions and their fees
+func (s *service) ListTransfers(ctx context.Context) ([]*Transaction, error) {
+	return s.xactRepo.ListTransfers(ctx)
+}
+
+// ListAccntXacts retrieves a page of the account transactions
+func (s *service) ListAccntXacts(ctx context.Context, f XactFilter) (*XactPage, error) {
+	err := f.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	exists, err := s.accountRepo.IsAccountExists(ctx, f.AccountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "is account exists")
+	}
+
+	if !exists {
+		return nil, account.ErrAccountNotFound
+	}
+
+	if f.Limit == 0 {
+		f.Limit = defaultXactsLimit
+	}
+	limit := f.Limit
+
+	// fetch one more to know if there's a next page
+	f.Limit++
+	xacts, err := s.xactRepo.ListAccntXacts(ctx, f)
+	if err != nil {
+		return nil, errors.Wrap(err, "list account xacts")
+	}
+
+	page := &XactPage{Xacts: xacts}
+	if len(xacts) > limit {
+		page.Xacts = xacts[:limit]
+		last := page.Xacts[limit-1]
+		page.NextCursor = Cursor{Ts: *last.Ts, XactNo: last.XactNo, LegID: last.LegID}.Encode()
+	}
+
+	return page, nil
 }
 
 const (
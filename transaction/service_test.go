@@ -2,37 +2,41 @@ package transaction_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/stevenferrer/kalupi/account"
+	accountsvc "github.com/stevenferrer/kalupi/account/service"
 	"github.com/stevenferrer/kalupi/balance"
 	"github.com/stevenferrer/kalupi/currency"
-	"github.com/stevenferrer/kalupi/etc/txdb"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/fee"
+	"github.com/stevenferrer/kalupi/fx"
 	"github.com/stevenferrer/kalupi/ledger"
-	"github.com/stevenferrer/kalupi/postgres"
+	"github.com/stevenferrer/kalupi/limit"
 	"github.com/stevenferrer/kalupi/transaction"
 )
 
 func TestXactService(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
-
-	err := postgres.Migrate(db)
-	require.NoError(t, err)
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
 
 	ctx := context.TODO()
 
 	// setup accounts and ledgers
-	accountRepo := postgres.NewAccountRepository(db)
+	accountRepo := store.AccountRepo
 	john := account.Account{
 		AccountID: account.AccountID("johndoe"),
 		Currency:  currency.USD,
 	}
-	_, err = accountRepo.CreateAccount(ctx, john)
+	_, err := accountRepo.CreateAccount(ctx, john)
 	require.NoError(t, err)
 	mary := account.Account{
 		AccountID: account.AccountID("maryjane"),
@@ -41,19 +45,19 @@ func TestXactService(t *testing.T) {
 	_, err = accountRepo.CreateAccount(ctx, mary)
 	require.NoError(t, err)
 
-	ledgerRepo := postgres.NewLedgerRepository(db)
-	ledgerService := ledger.NewService(ledgerRepo)
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
 	err = ledgerService.CreateCashLedgers(ctx)
 	require.NoError(t, err)
 
-	balRepo := postgres.NewBalanceRepository(db)
+	balRepo := store.BalRepo
 	balService := balance.NewService(balRepo)
 
-	xactRepo := postgres.NewXactRepository(db)
+	xactRepo := store.XactRepo
 	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
 
 	t.Run("make deposit", func(t *testing.T) {
-		err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
 			AccountID: john.AccountID,
 			Amount:    decimal.NewFromInt(100),
 		})
@@ -65,7 +69,7 @@ func TestXactService(t *testing.T) {
 		assert.True(t, decimal.NewFromInt(100).Equal(bal.CurrentBal))
 
 		t.Run("account not found", func(t *testing.T) {
-			err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
 				AccountID: account.AccountID("johntravolta"),
 				Amount:    decimal.NewFromInt(100),
 			})
@@ -74,7 +78,7 @@ func TestXactService(t *testing.T) {
 	})
 
 	t.Run("make withdrawal", func(t *testing.T) {
-		err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
 			AccountID: john.AccountID,
 			Amount:    decimal.NewFromInt(25),
 		})
@@ -86,7 +90,7 @@ func TestXactService(t *testing.T) {
 		assert.True(t, decimal.NewFromInt(75).Equal(bal.CurrentBal))
 
 		t.Run("insufficient balance", func(t *testing.T) {
-			err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
 				AccountID: john.AccountID,
 				Amount:    decimal.NewFromInt(200),
 			})
@@ -94,7 +98,7 @@ func TestXactService(t *testing.T) {
 		})
 
 		t.Run("account not found", func(t *testing.T) {
-			err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
 				AccountID: account.AccountID("johntravolta"),
 				Amount:    decimal.NewFromInt(100),
 			})
@@ -103,7 +107,7 @@ func TestXactService(t *testing.T) {
 	})
 
 	t.Run("make transfer", func(t *testing.T) {
-		err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
 			FromAccount: john.AccountID,
 			ToAccount:   mary.AccountID,
 			Amount:      decimal.NewFromInt(25),
@@ -121,7 +125,7 @@ func TestXactService(t *testing.T) {
 		assert.True(t, decimal.NewFromInt(25).Equal(maryBal.CurrentBal))
 
 		t.Run("insufficient balance", func(t *testing.T) {
-			err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
 				AccountID: john.AccountID,
 				Amount:    decimal.NewFromInt(100),
 			})
@@ -129,14 +133,14 @@ func TestXactService(t *testing.T) {
 		})
 
 		t.Run("sending or receiving account not found", func(t *testing.T) {
-			err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
 				FromAccount: account.AccountID("johntravolta"),
 				ToAccount:   john.AccountID,
 				Amount:      decimal.NewFromInt(100),
 			})
 			assert.ErrorIs(t, err, transaction.ErrSendingAccountNotFound)
 
-			err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
 				FromAccount: john.AccountID,
 				ToAccount:   account.AccountID("johntravolta"),
 				Amount:      decimal.NewFromInt(100),
@@ -150,69 +154,2202 @@ func TestXactService(t *testing.T) {
 		require.NoError(t, err)
 		assert.Len(t, xacts, 2)
 	})
+
+	t.Run("idempotency key", func(t *testing.T) {
+		dp := transaction.DepositXact{
+			AccountID:      mary.AccountID,
+			Amount:         decimal.NewFromInt(10),
+			IdempotencyKey: "dp-mary-1",
+		}
+		// retried deposit must only be posted once
+		for i := 0; i < 3; i++ {
+			_, err = xactSvc.MakeDeposit(ctx, dp)
+			require.NoError(t, err)
+		}
+
+		maryBal, err := balService.GetAccntBal(ctx, mary.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(35).Equal(maryBal.CurrentBal))
+
+		tr := transaction.TransferXact{
+			FromAccount:    mary.AccountID,
+			ToAccount:      john.AccountID,
+			Amount:         decimal.NewFromInt(5),
+			IdempotencyKey: "tr-mary-1",
+		}
+		for i := 0; i < 3; i++ {
+			_, err = xactSvc.MakeTransfer(ctx, tr)
+			require.NoError(t, err)
+		}
+
+		maryBal, err = balService.GetAccntBal(ctx, mary.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(30).Equal(maryBal.CurrentBal))
+
+		t.Run("conflict", func(t *testing.T) {
+			// same key, different amount
+			_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+				AccountID:      mary.AccountID,
+				Amount:         decimal.NewFromInt(20),
+				IdempotencyKey: dp.IdempotencyKey,
+			})
+			assert.ErrorIs(t, err, transaction.ErrIdempotencyKeyConflict)
+
+			// same key, different operation
+			_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+				AccountID:      mary.AccountID,
+				Amount:         decimal.NewFromInt(10),
+				IdempotencyKey: dp.IdempotencyKey,
+			})
+			assert.ErrorIs(t, err, transaction.ErrIdempotencyKeyConflict)
+		})
+	})
 }
 
-func TestXactValidations(t *testing.T) {
-	accnt1 := account.AccountID("johndoe")
-	accnt2 := account.AccountID("maryjane")
-	t.Run("deposit", func(t *testing.T) {
-		t.Run("zero", func(t *testing.T) {
-			dp := transaction.DepositXact{
-				AccountID: accnt1,
-				Amount:    decimal.Zero,
-			}
-			err := dp.Validate()
-			assert.Error(t, err)
+func TestXactServiceFXTransfer(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	jean := account.Account{
+		AccountID: account.AccountID("jeandupont"),
+		Currency:  currency.EUR,
+	}
+	_, err = accountRepo.CreateAccount(ctx, jean)
+	require.NoError(t, err)
+	taro := account.Account{
+		AccountID: account.AccountID("taroyamada"),
+		Currency:  currency.JPY,
+	}
+	_, err = accountRepo.CreateAccount(ctx, taro)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD, currency.EUR, currency.JPY))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+	err = ledgerService.CreateFXLedgers(ctx)
+	require.NoError(t, err)
+
+	rateProvider, err := fx.NewStaticRateProvider(fx.Rate{
+		Base:  currency.USD,
+		Quote: currency.EUR,
+		Rate:  decimal.RequireFromString("0.9"),
+	})
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo,
+		xactRepo, balRepo, transaction.WithRateProvider(rateProvider))
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	t.Run("make fx transfer", func(t *testing.T) {
+		fxTr, err := xactSvc.MakeFXTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   jean.AccountID,
+			Amount:      decimal.RequireFromString("50.55"),
 		})
+		require.NoError(t, err)
 
-		t.Run("negative", func(t *testing.T) {
-			dp := transaction.DepositXact{
-				AccountID: accnt1,
-				Amount:    decimal.NewFromInt(-100),
-			}
-			err := dp.Validate()
-			assert.Error(t, err)
+		assert.NotEmpty(t, fxTr.XactNo)
+		assert.Equal(t, currency.USD, fxTr.FromCurrency)
+		assert.Equal(t, currency.EUR, fxTr.ToCurrency)
+		assert.True(t, decimal.RequireFromString("0.9").Equal(fxTr.Rate))
+		// 50.55 * 0.9 = 45.495, rounded to 2 decimal places
+		assert.True(t, decimal.RequireFromString("45.50").Equal(fxTr.ToAmount))
+
+		johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.RequireFromString("49.45").Equal(johnBal.CurrentBal))
+
+		jeanBal, err := balService.GetAccntBal(ctx, jean.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.RequireFromString("45.50").Equal(jeanBal.CurrentBal))
+
+		t.Run("inverse rate", func(t *testing.T) {
+			fxTr, err := xactSvc.MakeFXTransfer(ctx, transaction.TransferXact{
+				FromAccount: jean.AccountID,
+				ToAccount:   john.AccountID,
+				Amount:      decimal.NewFromInt(9),
+			})
+			require.NoError(t, err)
+			assert.True(t, decimal.NewFromInt(10).Equal(fxTr.ToAmount))
+		})
+
+		t.Run("same currencies", func(t *testing.T) {
+			_, err := xactSvc.MakeFXTransfer(ctx, transaction.TransferXact{
+				FromAccount: john.AccountID,
+				ToAccount:   john.AccountID,
+				Amount:      decimal.NewFromInt(1),
+			})
+			assert.ErrorIs(t, err, transaction.ErrSameCurrencies)
+		})
+
+		t.Run("rate not found", func(t *testing.T) {
+			_, err := xactSvc.MakeFXTransfer(ctx, transaction.TransferXact{
+				FromAccount: john.AccountID,
+				ToAccount:   taro.AccountID,
+				Amount:      decimal.NewFromInt(1),
+			})
+			assert.ErrorIs(t, err, fx.ErrRateNotFound)
+		})
+
+		t.Run("insufficient balance", func(t *testing.T) {
+			_, err := xactSvc.MakeFXTransfer(ctx, transaction.TransferXact{
+				FromAccount: john.AccountID,
+				ToAccount:   jean.AccountID,
+				Amount:      decimal.NewFromInt(1000),
+			})
+			assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
 		})
 	})
 
-	t.Run("withdrawal", func(t *testing.T) {
-		t.Run("zero", func(t *testing.T) {
-			wd := transaction.WithdrawalXact{
-				AccountID: accnt1,
-				Amount:    decimal.Zero,
-			}
-			err := wd.Validate()
-			assert.Error(t, err)
+	t.Run("different currencies", func(t *testing.T) {
+		_, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   jean.AccountID,
+			Amount:      decimal.NewFromInt(1),
 		})
+		assert.ErrorIs(t, err, transaction.ErrDifferentCurrencies)
+	})
+}
 
-		t.Run("negative", func(t *testing.T) {
-			wd := transaction.WithdrawalXact{
-				AccountID: accnt1,
-				Amount:    decimal.NewFromInt(-100),
-			}
-			err := wd.Validate()
-			assert.Error(t, err)
+func TestXactServiceReversal(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	assertBal := func(t *testing.T, accntID account.AccountID, expect int64) {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(expect).Equal(bal.CurrentBal),
+			"%s: expecting %d got %s", accntID, expect, bal.CurrentBal)
+	}
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(40),
+	})
+	require.NoError(t, err)
+	assertBal(t, john.AccountID, 60)
+	assertBal(t, mary.AccountID, 40)
+
+	transfers, err := xactSvc.ListTransfers(ctx)
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	trXactNo := transfers[0].XactNo
+
+	t.Run("refund transfer", func(t *testing.T) {
+		rev, err := xactSvc.RefundTransfer(ctx, transaction.RefundXact{
+			XactNo: trXactNo,
+			Amount: decimal.NewFromInt(15),
+			Reason: "damaged item",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, trXactNo, rev.OrigXactNo)
+		assert.NotEqual(t, trXactNo, rev.XactNo)
+		assertBal(t, john.AccountID, 75)
+		assertBal(t, mary.AccountID, 25)
+
+		t.Run("exceeds amount", func(t *testing.T) {
+			_, err = xactSvc.RefundTransfer(ctx, transaction.RefundXact{
+				XactNo: trXactNo,
+				Amount: decimal.NewFromInt(30),
+				Reason: "damaged item",
+			})
+			assert.ErrorIs(t, err, transaction.ErrRefundExceedsAmount)
+		})
+
+		t.Run("not refundable", func(t *testing.T) {
+			_, err = xactSvc.RefundTransfer(ctx, transaction.RefundXact{
+				XactNo: rev.XactNo,
+				Amount: decimal.NewFromInt(1),
+				Reason: "refund of a refund",
+			})
+			assert.ErrorIs(t, err, transaction.ErrXactNotRefundable)
+		})
+
+		t.Run("not reversible", func(t *testing.T) {
+			_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+				XactNo: rev.XactNo,
+				Reason: "reversal of a refund",
+			})
+			assert.ErrorIs(t, err, transaction.ErrXactNotReversible)
+		})
+
+		t.Run("already refunded", func(t *testing.T) {
+			_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+				XactNo: trXactNo,
+				Reason: "duplicate",
+			})
+			assert.ErrorIs(t, err, transaction.ErrXactAlreadyRefunded)
 		})
 	})
 
-	t.Run("transfer", func(t *testing.T) {
-		t.Run("zero", func(t *testing.T) {
-			wd := transaction.TransferXact{
-				FromAccount: accnt1,
-				ToAccount:   accnt2,
-				Amount:      decimal.Zero,
-			}
-			err := wd.Validate()
-			assert.Error(t, err)
+	t.Run("reverse transfer", func(t *testing.T) {
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(10),
 		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, 65)
+		assertBal(t, mary.AccountID, 35)
 
-		t.Run("negative", func(t *testing.T) {
-			wd := transaction.TransferXact{
-				FromAccount: accnt1,
-				ToAccount:   accnt2,
-				Amount:      decimal.NewFromInt(-100),
-			}
-			err := wd.Validate()
+		transfers, err := xactSvc.ListTransfers(ctx)
+		require.NoError(t, err)
+		require.Len(t, transfers, 4)
+		xactNo := transfers[2].XactNo
+
+		rev, err := xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+			XactNo: xactNo,
+			Reason: "sent by mistake",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, xactNo, rev.OrigXactNo)
+		assert.True(t, decimal.NewFromInt(10).Equal(rev.Amount))
+		assertBal(t, john.AccountID, 75)
+		assertBal(t, mary.AccountID, 25)
+
+		t.Run("already reversed", func(t *testing.T) {
+			_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+				XactNo: xactNo,
+				Reason: "sent by mistake",
+			})
+			assert.ErrorIs(t, err, transaction.ErrXactAlreadyReversed)
+
+			_, err = xactSvc.RefundTransfer(ctx, transaction.RefundXact{
+				XactNo: xactNo,
+				Amount: decimal.NewFromInt(1),
+				Reason: "sent by mistake",
+			})
+			assert.ErrorIs(t, err, transaction.ErrXactAlreadyReversed)
+		})
+	})
+
+	t.Run("reverse deposit", func(t *testing.T) {
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(50),
+		})
+		require.NoError(t, err)
+		assertBal(t, mary.AccountID, 75)
+
+		xacts, err := xactRepo.ListXacts(ctx)
+		require.NoError(t, err)
+		xactNo := xacts[len(xacts)-1].XactNo
+
+		// mary spends the deposit
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(60),
+		})
+		require.NoError(t, err)
+
+		t.Run("insufficient balance", func(t *testing.T) {
+			_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+				XactNo: xactNo,
+				Reason: "bounced cheque",
+			})
+			assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+		})
+
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(60),
+		})
+		require.NoError(t, err)
+
+		_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+			XactNo: xactNo,
+			Reason: "bounced cheque",
+		})
+		require.NoError(t, err)
+		assertBal(t, mary.AccountID, 25)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+			XactNo: transaction.XactNo("IDONTEXIST"),
+			Reason: "not found",
+		})
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+	})
+}
+
+func TestXactServiceHold(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	assertBal := func(t *testing.T, accntID account.AccountID, expectCurrent, expectAvailable int64) {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(expectCurrent).Equal(bal.CurrentBal),
+			"%s: expecting current balance %d got %s", accntID, expectCurrent, bal.CurrentBal)
+		assert.True(t, decimal.NewFromInt(expectAvailable).Equal(bal.AvailableBal),
+			"%s: expecting available balance %d got %s", accntID, expectAvailable, bal.AvailableBal)
+	}
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+	assertBal(t, john.AccountID, 100, 100)
+
+	t.Run("capture into transfer", func(t *testing.T) {
+		hold, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(60),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, transaction.HoldStatusActive, hold.Status)
+		assertBal(t, john.AccountID, 100, 40)
+
+		t.Run("insufficient available balance", func(t *testing.T) {
+			_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+				AccountID: john.AccountID,
+				Amount:    decimal.NewFromInt(50),
+			})
+			assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+
+			_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+				FromAccount: john.AccountID,
+				ToAccount:   mary.AccountID,
+				Amount:      decimal.NewFromInt(50),
+			})
+			assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+
+			_, err = xactSvc.PlaceHold(ctx, transaction.HoldXact{
+				AccountID: john.AccountID,
+				Amount:    decimal.NewFromInt(50),
+			})
+			assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+		})
+
+		t.Run("exceeds hold", func(t *testing.T) {
+			_, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+				HoldID: hold.HoldID,
+				Amount: decimal.NewFromInt(70),
+			})
+			assert.ErrorIs(t, err, transaction.ErrCaptureExceedsHold)
+		})
+
+		// partial capture releases the remaining amount
+		hold, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID:    hold.HoldID,
+			Amount:    decimal.NewFromInt(45),
+			ToAccount: mary.AccountID,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, transaction.HoldStatusCaptured, hold.Status)
+		assert.True(t, decimal.NewFromInt(45).Equal(hold.CapturedAmount))
+		assert.NotEmpty(t, hold.XactNo)
+		assertBal(t, john.AccountID, 55, 55)
+		assertBal(t, mary.AccountID, 45, 45)
+
+		t.Run("already captured", func(t *testing.T) {
+			_, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+				HoldID: hold.HoldID,
+			})
+			assert.ErrorIs(t, err, transaction.ErrHoldNotActive)
+
+			_, err = xactSvc.VoidHold(ctx, hold.HoldID)
+			assert.ErrorIs(t, err, transaction.ErrHoldNotActive)
+		})
+	})
+
+	t.Run("capture into withdrawal", func(t *testing.T) {
+		hold, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(15),
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, 55, 40)
+
+		// captures the full amount
+		hold, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID: hold.HoldID,
+		})
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(15).Equal(hold.CapturedAmount))
+		assertBal(t, john.AccountID, 40, 40)
+	})
+
+	t.Run("void", func(t *testing.T) {
+		hold, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(40),
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, 40, 0)
+
+		hold, err = xactSvc.VoidHold(ctx, hold.HoldID)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.HoldStatusVoided, hold.Status)
+		assertBal(t, john.AccountID, 40, 40)
+
+		hold, err = xactSvc.GetHold(ctx, hold.HoldID)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.HoldStatusVoided, hold.Status)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		xactSvc := transaction.NewService(accountRepo, ledgerRepo,
+			xactRepo, balRepo, transaction.WithHoldTTL(time.Millisecond))
+
+		hold, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(40),
+		})
+		require.NoError(t, err)
+
+		time.Sleep(10 * time.Millisecond)
+		assertBal(t, john.AccountID, 40, 40)
+
+		hold, err = xactSvc.GetHold(ctx, hold.HoldID)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.HoldStatusExpired, hold.Status)
+
+		_, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID: hold.HoldID,
+		})
+		assert.ErrorIs(t, err, transaction.ErrHoldExpired)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err = xactSvc.VoidHold(ctx, transaction.HoldID("IDONTEXIST"))
+		assert.ErrorIs(t, err, transaction.ErrHoldNotFound)
+	})
+}
+
+func TestXactServiceAccntXacts(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	// 3 deposits, 2 withdrawals and 2 transfers
+	for i := 1; i <= 3; i++ {
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(int64(i * 100)),
+		})
+		require.NoError(t, err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(int64(i * 10)),
+		})
+		require.NoError(t, err)
+
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(int64(i * 5)),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("paginate", func(t *testing.T) {
+		for _, sort := range []transaction.SortDirection{transaction.SortAsc, transaction.SortDesc} {
+			var (
+				cursor *transaction.Cursor
+				xacts  []*transaction.Transaction
+				pages  int
+			)
+			for {
+				page, err := xactSvc.ListAccntXacts(ctx, transaction.XactFilter{
+					AccountID: john.AccountID,
+					Sort:      sort,
+					Cursor:    cursor,
+					Limit:     3,
+				})
+				require.NoError(t, err)
+				xacts = append(xacts, page.Xacts...)
+				pages++
+
+				if page.NextCursor == "" {
+					break
+				}
+
+				cursor, err = transaction.DecodeCursor(page.NextCursor)
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, 3, pages, sort.String())
+			require.Len(t, xacts, 7, sort.String())
+
+			// sorted by (ts, xact no) without duplicates
+			seen := map[transaction.XactNo]bool{}
+			for i, xact := range xacts {
+				assert.Equal(t, john.AccountID, xact.AccountID)
+				assert.False(t, seen[xact.XactNo], "duplicate %s", xact.XactNo)
+				seen[xact.XactNo] = true
+
+				if i == 0 {
+					continue
+				}
+
+				prev := xacts[i-1]
+				less := prev.Ts.Before(*xact.Ts) ||
+					(prev.Ts.Equal(*xact.Ts) && prev.XactNo < xact.XactNo)
+				assert.Equal(t, sort == transaction.SortAsc, less, sort.String())
+			}
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		min, max := decimal.NewFromInt(10), decimal.NewFromInt(200)
+		page, err := xactSvc.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID: john.AccountID,
+			XactTypeExts: []transaction.XactTypeExt{
+				transaction.XactTypeExtDeposit,
+				transaction.XactTypeExtWithdrawal,
+			},
+			MinAmount: &min,
+			MaxAmount: &max,
+		})
+		require.NoError(t, err)
+		// deposits of 100 and 200, withdrawals of 10 and 20
+		assert.Len(t, page.Xacts, 4)
+		assert.Empty(t, page.NextCursor)
+
+		page, err = xactSvc.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID:    mary.AccountID,
+			XactTypeExts: []transaction.XactTypeExt{transaction.XactTypeExtRcvTransfer},
+		})
+		require.NoError(t, err)
+		assert.Len(t, page.Xacts, 2)
+
+		// date range
+		from := time.Now().Add(time.Hour)
+		page, err = xactSvc.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID: john.AccountID,
+			From:      &from,
+		})
+		require.NoError(t, err)
+		assert.Len(t, page.Xacts, 0)
+
+		to := time.Now().Add(time.Hour)
+		page, err = xactSvc.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID: john.AccountID,
+			To:        &to,
+		})
+		require.NoError(t, err)
+		assert.Len(t, page.Xacts, 7)
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		_, err := xactSvc.ListAccntXacts(ctx, transaction.XactFilter{
+			AccountID: account.AccountID("idontexist"),
+		})
+		assert.ErrorIs(t, err, account.ErrAccountNotFound)
+	})
+}
+
+func TestXactServiceGetXact(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	dp := transaction.DepositXact{
+		AccountID:      john.AccountID,
+		Amount:         decimal.NewFromInt(100),
+		IdempotencyKey: "deposit-1",
+	}
+	dpXact, err := xactSvc.MakeDeposit(ctx, dp)
+	require.NoError(t, err)
+	assert.NotEmpty(t, dpXact.XactNo)
+	assert.NotNil(t, dpXact.Ts)
+	require.Len(t, dpXact.Legs, 1)
+	assert.Equal(t, transaction.XactTypeExtDeposit, dpXact.Legs[0].XactTypeExt)
+	assert.Empty(t, dpXact.LedgerLegs)
+
+	t.Run("replay", func(t *testing.T) {
+		xact, err := xactSvc.MakeDeposit(ctx, dp)
+		require.NoError(t, err)
+		assert.Equal(t, dpXact.XactNo, xact.XactNo)
+	})
+
+	wdXact, err := xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(10),
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, dpXact.XactNo, wdXact.XactNo)
+	assert.Len(t, wdXact.Legs, 1)
+
+	trXact, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(25),
+	})
+	require.NoError(t, err)
+	assert.Len(t, trXact.Legs, 2)
+
+	t.Run("get xact", func(t *testing.T) {
+		xact, err := xactSvc.GetXact(ctx, trXact.XactNo)
+		require.NoError(t, err)
+		assert.Equal(t, trXact.XactNo, xact.XactNo)
+		assert.Len(t, xact.Legs, 2)
+		assert.Empty(t, xact.LedgerLegs)
+		assert.NotNil(t, xact.Ts)
+	})
+
+	t.Run("xact not found", func(t *testing.T) {
+		_, err := xactSvc.GetXact(ctx, transaction.XactNo("notfound"))
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+
+		_, err = xactSvc.GetXact(ctx, transaction.XactNo(""))
+		assert.ErrorIs(t, err, transaction.ErrXactNotFound)
+	})
+}
+
+func TestXactServiceJournal(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+	jean := account.Account{
+		AccountID: account.AccountID("jeandupont"),
+		Currency:  currency.EUR,
+	}
+	_, err = accountRepo.CreateAccount(ctx, jean)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD, currency.EUR))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+	err = ledgerService.CreateFXLedgers(ctx)
+	require.NoError(t, err)
+
+	usdCashLedgerNo, err := ledger.GetCashLedgerNo(currency.USD)
+	require.NoError(t, err)
+	usdFXLedgerNo, err := ledger.GetFXLedgerNo(currency.USD)
+	require.NoError(t, err)
+	eurFXLedgerNo, err := ledger.GetFXLedgerNo(currency.EUR)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	assertBal := func(t *testing.T, accntID account.AccountID, expect int64) {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(expect).Equal(bal.CurrentBal),
+			"%s: expecting %d got %s", accntID, expect, bal.CurrentBal)
+	}
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	var splitXactNo transaction.XactNo
+	t.Run("split", func(t *testing.T) {
+		xact, err := xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Split",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(30)},
+				{AccountID: mary.AccountID, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(20)},
+				{LedgerNo: usdFXLedgerNo, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(10), Desc: "To position"},
+			},
+		})
+		require.NoError(t, err)
+		splitXactNo = xact.XactNo
+
+		require.Len(t, xact.Legs, 2)
+		for _, leg := range xact.Legs {
+			assert.Equal(t, transaction.XactTypeExtJournal, leg.XactTypeExt)
+			assert.Equal(t, usdCashLedgerNo, leg.LedgerNo)
+			assert.Equal(t, "Split", leg.Desc)
+		}
+
+		// the fx position leg and the offset of the cash ledger
+		require.Len(t, xact.LedgerLegs, 2)
+		lgLegs := map[ledger.LedgerNo]*transaction.LedgerXact{}
+		for _, lgLeg := range xact.LedgerLegs {
+			lgLegs[lgLeg.LedgerNo] = lgLeg
+		}
+		require.Contains(t, lgLegs, usdFXLedgerNo)
+		assert.Equal(t, transaction.XactTypeCredit, lgLegs[usdFXLedgerNo].XactType)
+		assert.Equal(t, "To position", lgLegs[usdFXLedgerNo].Desc)
+		require.Contains(t, lgLegs, usdCashLedgerNo)
+		assert.Equal(t, transaction.XactTypeDebit, lgLegs[usdCashLedgerNo].XactType)
+		assert.True(t, decimal.NewFromInt(10).Equal(lgLegs[usdCashLedgerNo].Amount))
+
+		assertBal(t, john.AccountID, 70)
+		assertBal(t, mary.AccountID, 20)
+	})
+
+	t.Run("fx", func(t *testing.T) {
+		_, err := xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "FX USD/EUR",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(10)},
+				{LedgerNo: usdFXLedgerNo, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(10)},
+				{LedgerNo: eurFXLedgerNo, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(9)},
+				{AccountID: jean.AccountID, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(9)},
+			},
+		})
+		require.NoError(t, err)
+
+		assertBal(t, john.AccountID, 60)
+		assertBal(t, jean.AccountID, 9)
+	})
+
+	t.Run("unbalanced", func(t *testing.T) {
+		_, err := xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Unbalanced",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(10)},
+				{AccountID: mary.AccountID, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(9)},
+			},
+		})
+		assert.ErrorIs(t, err, transaction.ErrUnbalancedEntry)
+
+		// the debits and credits are balanced per currency
+		_, err = xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Unbalanced",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(10)},
+				{AccountID: jean.AccountID, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(10)},
+			},
+		})
+		assert.ErrorIs(t, err, transaction.ErrUnbalancedEntry)
+
+		assertBal(t, john.AccountID, 60)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		_, err := xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Too much",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(100)},
+				{AccountID: mary.AccountID, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(100)},
+			},
+		})
+		assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+	})
+
+	t.Run("amount precision", func(t *testing.T) {
+		_, err := xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Precision",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.RequireFromString("0.001")},
+				{AccountID: mary.AccountID, XactType: transaction.XactTypeCredit, Amount: decimal.RequireFromString("0.001")},
+			},
+		})
+		assert.ErrorIs(t, err, transaction.ErrAmountPrecision)
+	})
+
+	t.Run("account or ledger not found", func(t *testing.T) {
+		_, err := xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Not found",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(1)},
+				{AccountID: "johntravolta", XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(1)},
+			},
+		})
+		assert.ErrorIs(t, err, account.ErrAccountNotFound)
+
+		_, err = xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Not found",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(1)},
+				{LedgerNo: "idontexist", XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(1)},
+			},
+		})
+		assert.ErrorIs(t, err, ledger.ErrLedgerNotFound)
+	})
+
+	t.Run("idempotency key", func(t *testing.T) {
+		jx := transaction.JournalXact{
+			Desc: "Retry",
+			Legs: []transaction.JournalLeg{
+				{AccountID: john.AccountID, XactType: transaction.XactTypeDebit, Amount: decimal.NewFromInt(5)},
+				{AccountID: mary.AccountID, XactType: transaction.XactTypeCredit, Amount: decimal.NewFromInt(5)},
+			},
+			IdempotencyKey: "journal-1",
+		}
+		xact, err := xactSvc.MakeJournalEntry(ctx, jx)
+		require.NoError(t, err)
+
+		replayed, err := xactSvc.MakeJournalEntry(ctx, jx)
+		require.NoError(t, err)
+		assert.Equal(t, xact.XactNo, replayed.XactNo)
+
+		assertBal(t, john.AccountID, 55)
+		assertBal(t, mary.AccountID, 25)
+	})
+
+	t.Run("reverse", func(t *testing.T) {
+		_, err := xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+			XactNo: splitXactNo,
+			Reason: "split by mistake",
+		})
+		require.NoError(t, err)
+
+		assertBal(t, john.AccountID, 85)
+		assertBal(t, mary.AccountID, 5)
+	})
+}
+
+func TestXactServiceFees(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+	err = ledgerService.CreateRevenueLedgers(ctx)
+	require.NoError(t, err)
+
+	feeSchedule, err := fee.NewSchedule(
+		fee.Rule{
+			Currency:    currency.USD,
+			XactTypeExt: transaction.XactTypeExtWithdrawal.String(),
+			Type:        fee.TypeFlat,
+			Amount:      decimal.NewFromInt(2),
+		},
+		fee.Rule{
+			Currency:    currency.USD,
+			XactTypeExt: transaction.XactTypeExtSndTransfer.String(),
+			Type:        fee.TypePercentage,
+			Percentage:  decimal.NewFromInt(1),
+			Min:         decimal.RequireFromString("0.5"),
+		},
+	)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo,
+		transaction.WithFeeSchedule(feeSchedule))
+
+	assertBal := func(t *testing.T, accntID account.AccountID, expect string) {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.True(t, decimal.RequireFromString(expect).Equal(bal.CurrentBal),
+			"%s: expecting %s got %s", accntID, expect, bal.CurrentBal)
+	}
+
+	revenueLedgerNo, err := ledger.GetRevenueLedgerNo(currency.USD)
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+	assertBal(t, john.AccountID, "100")
+
+	var wdXact *transaction.Xact
+	t.Run("withdrawal fee", func(t *testing.T) {
+		wdXact, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(10),
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "88")
+
+		require.Len(t, wdXact.Legs, 2)
+		var feeLeg *transaction.Transaction
+		for _, leg := range wdXact.Legs {
+			if leg.XactTypeExt == transaction.XactTypeExtFee {
+				feeLeg = leg
+			}
+		}
+		require.NotNil(t, feeLeg)
+		assert.Equal(t, revenueLedgerNo, feeLeg.LedgerNo)
+		assert.Equal(t, transaction.XactTypeCredit, feeLeg.XactType)
+		assert.True(t, decimal.NewFromInt(2).Equal(wdXact.Fee()))
+	})
+
+	var trXact *transaction.Xact
+	t.Run("transfer fee", func(t *testing.T) {
+		trXact, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(50),
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "37.5")
+		assertBal(t, mary.AccountID, "50")
+
+		require.Len(t, trXact.Legs, 3)
+		assert.True(t, decimal.RequireFromString("0.5").Equal(trXact.Fee()))
+
+		xacts, err := xactSvc.ListTransfers(ctx)
+		require.NoError(t, err)
+		assert.Len(t, xacts, 3)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		// the amount is exactly the balance but not the fee
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.RequireFromString("37.5"),
+		})
+		assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.RequireFromString("37.5"),
+		})
+		assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+		assertBal(t, john.AccountID, "37.5")
+	})
+
+	t.Run("no fee", func(t *testing.T) {
+		// there's no deposit fee
+		xact, err := xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(10),
+		})
+		require.NoError(t, err)
+		assert.Len(t, xact.Legs, 1)
+		assert.True(t, xact.Fee().IsZero())
+		assertBal(t, mary.AccountID, "60")
+	})
+
+	t.Run("refund keeps the fee", func(t *testing.T) {
+		_, err = xactSvc.RefundTransfer(ctx, transaction.RefundXact{
+			XactNo: trXact.XactNo,
+			Amount: decimal.NewFromInt(50),
+			Reason: "cancelled order",
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "87.5")
+		assertBal(t, mary.AccountID, "10")
+	})
+
+	t.Run("reversal returns the fee", func(t *testing.T) {
+		_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+			XactNo: wdXact.XactNo,
+			Reason: "failed withdrawal",
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "99.5")
+	})
+
+	t.Run("capture fee", func(t *testing.T) {
+		hold, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(20),
+		})
+		require.NoError(t, err)
+
+		hold, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID:    hold.HoldID,
+			ToAccount: mary.AccountID,
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "79")
+		assertBal(t, mary.AccountID, "30")
+
+		// the fee is posted with the captured transfer
+		xact, err := xactSvc.GetXact(ctx, hold.XactNo)
+		require.NoError(t, err)
+		require.Len(t, xact.Legs, 3)
+		assert.True(t, decimal.RequireFromString("0.5").Equal(xact.Fee()))
+
+		hold, err = xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(79),
+		})
+		require.NoError(t, err)
+
+		// the amount is exactly the balance but not the fee
+		_, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID: hold.HoldID,
+		})
+		assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+
+		hold, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID: hold.HoldID,
+			Amount: decimal.NewFromInt(10),
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "67")
+
+		xact, err = xactSvc.GetXact(ctx, hold.XactNo)
+		require.NoError(t, err)
+		require.Len(t, xact.Legs, 2)
+		assert.True(t, decimal.NewFromInt(2).Equal(xact.Fee()))
+	})
+}
+
+func TestXactServiceAccountStatus(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	err = ledger.NewService(ledgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	accountSvc := accountsvc.New(accountRepo, balRepo, currency.NewSet(currency.USD))
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	changeStatus := func(t *testing.T, accntID account.AccountID, status account.Status) {
+		_, err := accountSvc.ChangeAccountStatus(ctx, account.StatusChange{
+			AccountID: accntID,
+			Status:    status,
+			Reason:    "testing",
+			ChangedBy: "tester",
+		})
+		require.NoError(t, err)
+	}
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	trXact, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(10),
+	})
+	require.NoError(t, err)
+
+	hold, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(5),
+	})
+	require.NoError(t, err)
+
+	t.Run("frozen", func(t *testing.T) {
+		changeStatus(t, mary.AccountID, account.StatusFrozen)
+
+		_, err := xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(10),
+		})
+		assert.ErrorIs(t, err, account.ErrAccountFrozen)
+
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(1),
+		})
+		assert.ErrorIs(t, err, account.ErrAccountFrozen)
+
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(10),
+		})
+		assert.ErrorIs(t, err, account.ErrAccountFrozen)
+
+		_, err = xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+			XactNo: trXact.XactNo,
+			Reason: "sent by mistake",
+		})
+		assert.ErrorIs(t, err, account.ErrAccountFrozen)
+
+		_, err = xactSvc.MakeJournalEntry(ctx, transaction.JournalXact{
+			Desc: "Adjustment",
+			Legs: []transaction.JournalLeg{
+				{
+					AccountID: john.AccountID,
+					XactType:  transaction.XactTypeCredit,
+					Amount:    decimal.NewFromInt(1),
+				},
+				{
+					AccountID: mary.AccountID,
+					XactType:  transaction.XactTypeDebit,
+					Amount:    decimal.NewFromInt(1),
+				},
+			},
+		})
+		assert.ErrorIs(t, err, account.ErrAccountFrozen)
+
+		_, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID:    hold.HoldID,
+			ToAccount: mary.AccountID,
+		})
+		assert.ErrorIs(t, err, account.ErrAccountFrozen)
+
+		t.Run("unfrozen", func(t *testing.T) {
+			changeStatus(t, mary.AccountID, account.StatusActive)
+
+			_, err := xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+				AccountID: mary.AccountID,
+				Amount:    decimal.NewFromInt(10),
+			})
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("void hold of a frozen account", func(t *testing.T) {
+		changeStatus(t, john.AccountID, account.StatusFrozen)
+
+		_, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(5),
+		})
+		assert.ErrorIs(t, err, account.ErrAccountFrozen)
+
+		// voiding only releases the reserved amount
+		_, err = xactSvc.VoidHold(ctx, hold.HoldID)
+		assert.NoError(t, err)
+
+		changeStatus(t, john.AccountID, account.StatusActive)
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		_, err := xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(20),
+		})
+		require.NoError(t, err)
+
+		changeStatus(t, mary.AccountID, account.StatusClosed)
+
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(10),
+		})
+		assert.ErrorIs(t, err, account.ErrAccountClosed)
+
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(10),
+		})
+		assert.ErrorIs(t, err, account.ErrAccountClosed)
+	})
+}
+
+func TestXactServiceOverdraft(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID:      account.AccountID("johndoe"),
+		Currency:       currency.USD,
+		OverdraftLimit: decimal.NewFromInt(50),
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	err = ledger.NewService(ledgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	assertBal := func(t *testing.T, accntID account.AccountID, expect string) {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.True(t, decimal.RequireFromString(expect).Equal(bal.CurrentBal),
+			"%s: expecting %s got %s", accntID, expect, bal.CurrentBal)
+	}
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(20),
+	})
+	require.NoError(t, err)
+
+	t.Run("withdrawal", func(t *testing.T) {
+		_, err := xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(40),
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "-20")
+
+		// mary has no overdraft
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(1),
+		})
+		assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		// beyond the limit
+		_, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.RequireFromString("30.01"),
+		})
+		assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+
+		// down to the limit
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(30),
+		})
+		require.NoError(t, err)
+		assertBal(t, john.AccountID, "-50")
+		assertBal(t, mary.AccountID, "30")
+	})
+
+	t.Run("hold", func(t *testing.T) {
+		_, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(1),
+		})
+		assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+	})
+}
+
+func TestXactServiceLimits(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	err = ledger.NewService(ledgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	limitPolicy, err := limit.NewPolicy(
+		limit.Limits{
+			Currency:          currency.USD,
+			MaxAmount:         decimal.NewFromInt(100),
+			MaxDailyAmount:    decimal.NewFromInt(150),
+			MaxDailyTransfers: 2,
+		},
+		// mary has no max amount
+		limit.Limits{
+			AccountID:      mary.AccountID,
+			MaxDailyAmount: decimal.NewFromInt(1000),
+		},
+	)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo,
+		transaction.WithLimitPolicy(limitPolicy))
+
+	for _, accntID := range []account.AccountID{john.AccountID, mary.AccountID} {
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: accntID,
+			Amount:    decimal.NewFromInt(1000),
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("max amount", func(t *testing.T) {
+		_, err := xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.RequireFromString("100.01"),
+		})
+		assert.ErrorIs(t, err, limit.ErrLimitExceeded)
+		assert.Contains(t, err.Error(), "max amount 100")
+
+		// deposits aren't limited
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(500),
+		})
+		assert.NoError(t, err)
+
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: mary.AccountID,
+			Amount:    decimal.NewFromInt(500),
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		// the hold isn't limited but its capture is
+		hold, err := xactSvc.PlaceHold(ctx, transaction.HoldXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.RequireFromString("100.01"),
+		})
+		require.NoError(t, err)
+
+		_, err = xactSvc.CaptureHold(ctx, transaction.CaptureXact{
+			HoldID:    hold.HoldID,
+			ToAccount: mary.AccountID,
+		})
+		assert.ErrorIs(t, err, limit.ErrLimitExceeded)
+		assert.Contains(t, err.Error(), "max amount 100")
+
+		_, err = xactSvc.VoidHold(ctx, hold.HoldID)
+		require.NoError(t, err)
+	})
+
+	t.Run("max daily amount", func(t *testing.T) {
+		_, err := xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(100),
+		})
+		require.NoError(t, err)
+
+		_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+			FromAccount: john.AccountID,
+			ToAccount:   mary.AccountID,
+			Amount:      decimal.NewFromInt(50),
+		})
+		require.NoError(t, err)
+
+		_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.RequireFromString("0.01"),
+		})
+		assert.ErrorIs(t, err, limit.ErrLimitExceeded)
+		assert.Contains(t, err.Error(), "max daily amount 150")
+	})
+
+	t.Run("max daily transfers", func(t *testing.T) {
+		tom := account.Account{
+			AccountID: account.AccountID("tomcruise"),
+			Currency:  currency.USD,
+		}
+		_, err = accountRepo.CreateAccount(ctx, tom)
+		require.NoError(t, err)
+
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: tom.AccountID,
+			Amount:    decimal.NewFromInt(10),
+		})
+		require.NoError(t, err)
+
+		transfer := func() error {
+			_, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+				FromAccount: tom.AccountID,
+				ToAccount:   john.AccountID,
+				Amount:      decimal.NewFromInt(1),
+			})
+			return err
+		}
+
+		require.NoError(t, transfer())
+		require.NoError(t, transfer())
+
+		err := transfer()
+		assert.ErrorIs(t, err, limit.ErrLimitExceeded)
+		assert.Contains(t, err.Error(), "max daily transfers 2")
+	})
+
+	t.Run("account limits", func(t *testing.T) {
+		// mary's limits replace the global limits
+		for i := 0; i < 3; i++ {
+			_, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+				FromAccount: mary.AccountID,
+				ToAccount:   john.AccountID,
+				Amount:      decimal.NewFromInt(1),
+			})
+			require.NoError(t, err)
+		}
+	})
+}
+
+func TestXactServiceBatchTransfer(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	tom := account.Account{
+		AccountID: account.AccountID("tomcruise"),
+		Currency:  currency.USD,
+	}
+	jane := account.Account{
+		AccountID: account.AccountID("janedoe"),
+		Currency:  currency.EUR,
+	}
+	for _, accnt := range []account.Account{john, mary, tom, jane} {
+		_, err := accountRepo.CreateAccount(ctx, accnt)
+		require.NoError(t, err)
+	}
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err := ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+	err = ledgerService.CreateRevenueLedgers(ctx)
+	require.NoError(t, err)
+
+	feeSchedule, err := fee.NewSchedule(fee.Rule{
+		Currency:    currency.USD,
+		XactTypeExt: transaction.XactTypeExtSndTransfer.String(),
+		Type:        fee.TypeFlat,
+		Amount:      decimal.NewFromInt(1),
+	})
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo,
+		transaction.WithFeeSchedule(feeSchedule))
+
+	assertBal := func(t *testing.T, accntID account.AccountID, expect string) {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.True(t, decimal.RequireFromString(expect).Equal(bal.CurrentBal),
+			"%s: expecting %s got %s", accntID, expect, bal.CurrentBal)
+	}
+
+	transfer := func(from, to account.Account, amount string) transaction.TransferXact {
+		return transaction.TransferXact{
+			FromAccount: from.AccountID,
+			ToAccount:   to.AccountID,
+			Amount:      decimal.RequireFromString(amount),
+		}
+	}
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	t.Run("atomic", func(t *testing.T) {
+		bt, err := xactSvc.MakeBatchTransfer(ctx, transaction.BatchTransferXact{
+			Mode: transaction.BatchModeAtomic,
+			Transfers: []transaction.TransferXact{
+				transfer(john, mary, "10"),
+				transfer(john, tom, "20"),
+				// mary spends the amount she received in the batch
+				transfer(mary, tom, "5"),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, bt.Items, 3)
+		for i, item := range bt.Items {
+			assert.Equal(t, i, item.Index)
+			assert.NoError(t, item.Err)
+			require.NotNil(t, item.Xact)
+			assert.Len(t, item.Xact.Legs, 3)
+		}
+
+		assertBal(t, john.AccountID, "68")
+		assertBal(t, mary.AccountID, "4")
+		assertBal(t, tom.AccountID, "25")
+
+		t.Run("insufficient balance", func(t *testing.T) {
+			// each transfer is within the balance but not both
+			_, err := xactSvc.MakeBatchTransfer(ctx, transaction.BatchTransferXact{
+				Mode: transaction.BatchModeAtomic,
+				Transfers: []transaction.TransferXact{
+					transfer(john, mary, "60"),
+					transfer(john, tom, "10"),
+				},
+			})
+			assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+			assert.Contains(t, err.Error(), "transfer 1")
+
+			assertBal(t, john.AccountID, "68")
+			assertBal(t, mary.AccountID, "4")
+		})
+
+		t.Run("receiving account not found", func(t *testing.T) {
+			_, err := xactSvc.MakeBatchTransfer(ctx, transaction.BatchTransferXact{
+				Mode: transaction.BatchModeAtomic,
+				Transfers: []transaction.TransferXact{
+					transfer(john, mary, "1"),
+					transfer(john, account.Account{AccountID: "idontexist"}, "1"),
+				},
+			})
+			assert.ErrorIs(t, err, transaction.ErrReceivingAccountNotFound)
+			assert.Contains(t, err.Error(), "transfer 1")
+			assertBal(t, john.AccountID, "68")
+		})
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		bt, err := xactSvc.MakeBatchTransfer(ctx, transaction.BatchTransferXact{
+			Mode: transaction.BatchModeBestEffort,
+			Transfers: []transaction.TransferXact{
+				transfer(john, mary, "60"),
+				transfer(john, tom, "10"),
+				transfer(john, jane, "1"),
+				transfer(tom, mary, "5"),
+				transfer(john, mary, "0.001"),
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, bt.Items, 5)
+
+		assert.NotNil(t, bt.Items[0].Xact)
+		assert.NoError(t, bt.Items[0].Err)
+		assert.Nil(t, bt.Items[1].Xact)
+		assert.ErrorIs(t, bt.Items[1].Err, transaction.ErrInsufficientBalance)
+		assert.ErrorIs(t, bt.Items[2].Err, transaction.ErrDifferentCurrencies)
+		assert.NotNil(t, bt.Items[3].Xact)
+		assert.ErrorIs(t, bt.Items[4].Err, transaction.ErrValidation)
+
+		assertBal(t, john.AccountID, "7")
+		assertBal(t, mary.AccountID, "69")
+		assertBal(t, tom.AccountID, "19")
+	})
+
+	t.Run("idempotency key", func(t *testing.T) {
+		bx := transaction.BatchTransferXact{
+			Mode: transaction.BatchModeBestEffort,
+			Transfers: []transaction.TransferXact{
+				transfer(tom, mary, "1"),
+				transfer(tom, mary, "100"),
+			},
+			IdempotencyKey: "payroll-2021-01",
+		}
+		bt, err := xactSvc.MakeBatchTransfer(ctx, bx)
+		require.NoError(t, err)
+		require.NotNil(t, bt.Items[0].Xact)
+		assert.ErrorIs(t, bt.Items[1].Err, transaction.ErrInsufficientBalance)
+		assertBal(t, tom.AccountID, "17")
+
+		// the transfer that was made is replayed, the other is retried
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: tom.AccountID,
+			Amount:    decimal.NewFromInt(100),
+		})
+		require.NoError(t, err)
+
+		replayed, err := xactSvc.MakeBatchTransfer(ctx, bx)
+		require.NoError(t, err)
+		require.NotNil(t, replayed.Items[0].Xact)
+		assert.Equal(t, bt.Items[0].Xact.XactNo, replayed.Items[0].Xact.XactNo)
+		require.NotNil(t, replayed.Items[1].Xact)
+		assertBal(t, tom.AccountID, "16")
+
+		t.Run("conflict", func(t *testing.T) {
+			bx.Transfers[0].Amount = decimal.NewFromInt(2)
+			bt, err := xactSvc.MakeBatchTransfer(ctx, bx)
+			require.NoError(t, err)
+			assert.ErrorIs(t, bt.Items[0].Err, transaction.ErrIdempotencyKeyConflict)
+			assertBal(t, tom.AccountID, "16")
+		})
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		_, err := xactSvc.MakeBatchTransfer(ctx, transaction.BatchTransferXact{
+			Transfers: []transaction.TransferXact{transfer(john, mary, "1")},
+		})
+		assert.ErrorIs(t, err, transaction.ErrValidation)
+		assert.Contains(t, err.Error(), "mode: must be either atomic or best_effort")
+
+		_, err = xactSvc.MakeBatchTransfer(ctx, transaction.BatchTransferXact{
+			Mode: transaction.BatchModeAtomic,
+		})
+		assert.ErrorIs(t, err, transaction.ErrValidation)
+		assert.Contains(t, err.Error(), "transfers: must not be empty")
+	})
+}
+
+func TestXactServiceConcurrency(t *testing.T) {
+	// txdb connections shares a single transaction, hence, we need real ones
+	store, closeStore := teststore.MustOpenSchema(fmt.Sprintf("xact_concurrency_%d", time.Now().UnixNano()))
+	defer func() {
+		assert.NoError(t, closeStore())
+	}()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactSvc := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	for _, accntID := range []account.AccountID{john.AccountID, mary.AccountID} {
+		_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: accntID,
+			Amount:    decimal.NewFromInt(100),
+		})
+		require.NoError(t, err)
+	}
+
+	const n = 20
+	amount := decimal.NewFromInt(10)
+
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		withdrawn   = decimal.Zero
+		errs        = make(chan error, 4*n)
+		accntIDs    = []account.AccountID{john.AccountID, mary.AccountID}
+		counterpart = map[account.AccountID]account.AccountID{
+			john.AccountID: mary.AccountID,
+			mary.AccountID: john.AccountID,
+		}
+	)
+
+	for i := 0; i < n; i++ {
+		for _, accntID := range accntIDs {
+			wg.Add(2)
+			go func(accntID account.AccountID) {
+				defer wg.Done()
+
+				_, err := xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+					AccountID: accntID,
+					Amount:    amount,
+				})
+				if err == nil {
+					mu.Lock()
+					withdrawn = withdrawn.Add(amount)
+					mu.Unlock()
+				}
+				errs <- err
+			}(accntID)
+
+			// transfers in both directions
+			go func(accntID account.AccountID) {
+				defer wg.Done()
+
+				_, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+					FromAccount: accntID,
+					ToAccount:   counterpart[accntID],
+					Amount:      amount,
+				})
+				errs <- err
+			}(accntID)
+		}
+	}
+
+	wg.Wait()
+	close(errs)
+
+	// no deadlocks, only insufficient balance errors are expected
+	for err := range errs {
+		if err != nil {
+			assert.ErrorIs(t, err, transaction.ErrInsufficientBalance)
+		}
+	}
+
+	total := decimal.Zero
+	for _, accntID := range accntIDs {
+		bal, err := balService.GetAccntBal(ctx, accntID)
+		require.NoError(t, err)
+		assert.False(t, bal.CurrentBal.IsNegative(),
+			"%s: balance should not be negative, got %s", accntID, bal.CurrentBal)
+		total = total.Add(bal.CurrentBal)
+	}
+
+	assert.True(t, decimal.NewFromInt(200).Sub(withdrawn).Equal(total),
+		"expecting total balance of %s got %s", decimal.NewFromInt(200).Sub(withdrawn), total)
+}
+
+func TestRefundConcurrency(t *testing.T) {
+	// txdb connections shares a single transaction, hence, we need real ones
+	store, closeStore := teststore.MustOpenSchema(fmt.Sprintf("refund_concurrency_%d", time.Now().UnixNano()))
+	defer func() {
+		assert.NoError(t, closeStore())
+	}()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerService := ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	xactSvc := transaction.NewService(accountRepo, store.LedgerRepo, store.XactRepo, store.BalRepo)
+
+	_, err = xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	trXact, err := xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(50),
+	})
+	require.NoError(t, err)
+
+	const n = 10
+	amount := decimal.NewFromInt(10)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		refunded = decimal.Zero
+		reversed bool
+		errs     = make(chan error, n+1)
+	)
+
+	// the refunds of the transfer races with its reversal
+	wg.Add(n + 1)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+
+			_, err := xactSvc.RefundTransfer(ctx, transaction.RefundXact{
+				XactNo: trXact.XactNo,
+				Amount: amount,
+				Reason: "partial refund",
+			})
+			if err == nil {
+				mu.Lock()
+				refunded = refunded.Add(amount)
+				mu.Unlock()
+			}
+			errs <- err
+		}()
+	}
+
+	go func() {
+		defer wg.Done()
+
+		_, err := xactSvc.ReverseXact(ctx, transaction.ReversalXact{
+			XactNo: trXact.XactNo,
+			Reason: "duplicate",
+		})
+		if err == nil {
+			mu.Lock()
+			reversed = true
+			mu.Unlock()
+		}
+		errs <- err
+	}()
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			assert.True(t, errors.Is(err, transaction.ErrRefundExceedsAmount) ||
+				errors.Is(err, transaction.ErrXactAlreadyReversed) ||
+				errors.Is(err, transaction.ErrXactAlreadyRefunded),
+				"unexpected error: %v", err)
+		}
+	}
+
+	// a transfer is either reversed or refunded up to the transferred amount
+	if reversed {
+		assert.True(t, refunded.IsZero(), "expecting no refunds got %s", refunded)
+	} else {
+		assert.True(t, refunded.Equal(decimal.NewFromInt(50)),
+			"expecting refunds of 50 got %s", refunded)
+	}
+
+	bal, err := balance.NewService(store.BalRepo).GetAccntBal(ctx, mary.AccountID)
+	require.NoError(t, err)
+	if reversed {
+		assert.True(t, bal.CurrentBal.IsZero())
+	} else {
+		assert.True(t, decimal.NewFromInt(50).Sub(refunded).Equal(bal.CurrentBal))
+	}
+}
+
+func TestXactValidations(t *testing.T) {
+	accnt1 := account.AccountID("johndoe")
+	accnt2 := account.AccountID("maryjane")
+	t.Run("deposit", func(t *testing.T) {
+		t.Run("zero", func(t *testing.T) {
+			dp := transaction.DepositXact{
+				AccountID: accnt1,
+				Amount:    decimal.Zero,
+			}
+			err := dp.Validate()
+			assert.Error(t, err)
+		})
+
+		t.Run("negative", func(t *testing.T) {
+			dp := transaction.DepositXact{
+				AccountID: accnt1,
+				Amount:    decimal.NewFromInt(-100),
+			}
+			err := dp.Validate()
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("withdrawal", func(t *testing.T) {
+		t.Run("zero", func(t *testing.T) {
+			wd := transaction.WithdrawalXact{
+				AccountID: accnt1,
+				Amount:    decimal.Zero,
+			}
+			err := wd.Validate()
+			assert.Error(t, err)
+		})
+
+		t.Run("negative", func(t *testing.T) {
+			wd := transaction.WithdrawalXact{
+				AccountID: accnt1,
+				Amount:    decimal.NewFromInt(-100),
+			}
+			err := wd.Validate()
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("reversal", func(t *testing.T) {
+		rv := transaction.ReversalXact{XactNo: "LM4I8FHC05X0"}
+		err := rv.Validate()
+		assert.Error(t, err, "reason is required")
+	})
+
+	t.Run("refund", func(t *testing.T) {
+		rf := transaction.RefundXact{
+			XactNo: "LM4I8FHC05X0",
+			Amount: decimal.NewFromInt(-100),
+			Reason: "refund",
+		}
+		err := rf.Validate()
+		assert.Error(t, err)
+	})
+
+	t.Run("hold", func(t *testing.T) {
+		hx := transaction.HoldXact{
+			AccountID: accnt1,
+			Amount:    decimal.Zero,
+		}
+		err := hx.Validate()
+		assert.Error(t, err)
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		cx := transaction.CaptureXact{
+			HoldID: "3RUE0XQ1DC5B9MZK",
+			Amount: decimal.NewFromInt(-100),
+		}
+		err := cx.Validate()
+		assert.Error(t, err)
+
+		cx = transaction.CaptureXact{
+			HoldID:    "3RUE0XQ1DC5B9MZK",
+			ToAccount: "john",
+		}
+		err = cx.Validate()
+		assert.Error(t, err, "to_account is too short")
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		t.Run("zero", func(t *testing.T) {
+			wd := transaction.TransferXact{
+				FromAccount: accnt1,
+				ToAccount:   accnt2,
+				Amount:      decimal.Zero,
+			}
+			err := wd.Validate()
+			assert.Error(t, err)
+		})
+
+		t.Run("negative", func(t *testing.T) {
+			wd := transaction.TransferXact{
+				FromAccount: accnt1,
+				ToAccount:   accnt2,
+				Amount:      decimal.NewFromInt(-100),
+			}
+			err := wd.Validate()
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		leg := transaction.JournalLeg{
+			AccountID: accnt1,
+			XactType:  transaction.XactTypeDebit,
+			Amount:    decimal.NewFromInt(100),
+		}
+		err := leg.Validate()
+		assert.NoError(t, err)
+
+		t.Run("account and ledger", func(t *testing.T) {
+			leg := leg
+			leg.LedgerNo = ledger.CashUSDLedgerNo
+			err := leg.Validate()
+			assert.Error(t, err)
+
+			leg.AccountID, leg.LedgerNo = "", ""
+			err = leg.Validate()
+			assert.Error(t, err)
+		})
+
+		t.Run("xact type", func(t *testing.T) {
+			leg := leg
+			leg.XactType = transaction.XactType(0)
+			err := leg.Validate()
+			assert.Error(t, err)
+		})
+
+		t.Run("single leg", func(t *testing.T) {
+			jx := transaction.JournalXact{
+				Desc: "Single",
+				Legs: []transaction.JournalLeg{leg},
+			}
+			err := jx.Validate()
+			assert.Error(t, err)
+		})
+
+		t.Run("invalid leg", func(t *testing.T) {
+			invalid := leg
+			invalid.Amount = decimal.NewFromInt(-100)
+			jx := transaction.JournalXact{
+				Desc: "Invalid",
+				Legs: []transaction.JournalLeg{leg, invalid},
+			}
+			err := jx.Validate()
 			assert.Error(t, err)
 		})
 	})
@@ -1,7 +1,9 @@
 package transaction
 
 import (
+	"bytes"
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -18,26 +20,79 @@ type XactNo string
 // Note: Ledger and account must have the same currency.
 type Transaction struct {
 	// XactNo is a transaction reference number
-	XactNo XactNo
+	XactNo XactNo `json:"xact_no"`
 
 	// LedgerNo is the ledger number of internal account
-	LedgerNo ledger.LedgerNo
+	LedgerNo ledger.LedgerNo `json:"ledger_no"`
 	// XactType is the transaction type (debit or credit)
-	XactType XactType
+	XactType XactType `json:"xact_type"`
 
 	// AccountId is the external account id
-	AccountID account.AccountID
+	AccountID account.AccountID `json:"account_id"`
 	// XactTypeExt is the external transaction type (deposit, withdrawal, transfer)
-	XactTypeExt XactTypeExt
+	XactTypeExt XactTypeExt `json:"xact_type_ext"`
 
 	// Amount is the amount of transaction
-	Amount decimal.Decimal
+	Amount decimal.Decimal `json:"amount"`
 
 	// Desc is a short description of entry i.e. deposit, withdrawal
-	Desc string
+	Desc string `json:"desc"`
 
 	// Ts is the timestamp
-	Ts *time.Time
+	Ts *time.Time `json:"ts,omitempty"`
+
+	// LegID is the serial number of the leg, it orders
+	// the legs sharing the same timestamp and XactNo
+	LegID int64 `json:"-"`
+}
+
+// LedgerXact represents an internal ledger transaction i.e. the movement
+// between the cash and fx position ledgers. Unlike Transaction, it doesn't
+// involve an external account so it only balances with the other ledger
+// transactions sharing the same XactNo.
+type LedgerXact struct {
+	// XactNo is a transaction reference number
+	XactNo XactNo `json:"xact_no"`
+
+	// LedgerNo is the ledger number of internal account
+	LedgerNo ledger.LedgerNo `json:"ledger_no"`
+	// XactType is the transaction type (debit or credit)
+	XactType XactType `json:"xact_type"`
+
+	// Amount is the amount of transaction
+	Amount decimal.Decimal `json:"amount"`
+
+	// Desc is a short description of entry
+	Desc string `json:"desc"`
+
+	// Ts is the timestamp
+	Ts *time.Time `json:"ts,omitempty"`
+}
+
+// Xact is a transaction with all the legs sharing the XactNo
+type Xact struct {
+	// XactNo is a transaction reference number
+	XactNo XactNo `json:"xact_no"`
+
+	// Legs are the account transactions
+	Legs []*Transaction `json:"legs"`
+	// LedgerLegs are the ledger transactions
+	LedgerLegs []*LedgerXact `json:"ledger_legs,omitempty"`
+
+	// Ts is the timestamp
+	Ts *time.Time `json:"ts,omitempty"`
+}
+
+// Fee returns the total of the fee legs
+func (x *Xact) Fee() decimal.Decimal {
+	f := decimal.Zero
+	for _, leg := range x.Legs {
+		if leg.XactTypeExt == XactTypeExtFee {
+			f = f.Add(leg.Amount)
+		}
+	}
+
+	return f
 }
 
 // XactType is the transaction type
@@ -81,6 +136,38 @@ func (tt *XactType) Scan(src interface{}) error {
 	return nil
 }
 
+// MarshalJSON implements the json.Marshaler interface
+func (tt XactType) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(tt.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (tt *XactType) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	*tt = strToXactType(s)
+	return nil
+}
+
+// opposite returns the opposite transaction type
+func (tt XactType) opposite() XactType {
+	switch tt {
+	case XactTypeDebit:
+		return XactTypeCredit
+	case XactTypeCredit:
+		return XactTypeDebit
+	}
+
+	return XactType(0)
+}
+
 // strToXactType takes a string and returns the transaction type
 func strToXactType(s string) XactType {
 	switch s {
@@ -108,6 +195,18 @@ const (
 	// XactTypeRcvTransfer is an incomming transfer.
 	// The receiving account will be credited.
 	XactTypeExtRcvTransfer
+	// XactTypeExtReversal is a reversal of a transaction.
+	// The account is debited or credited depending on the reversed leg.
+	XactTypeExtReversal
+	// XactTypeExtRefund is a (partial) refund of a transfer.
+	// The receiving account is debited and the sending account is credited.
+	XactTypeExtRefund
+	// XactTypeExtJournal is an account leg of a journal entry.
+	// The account is debited or credited depending on the leg.
+	XactTypeExtJournal
+	// XactTypeExtFee is a fee of a transaction.
+	// The account is debited and the revenue ledger is credited.
+	XactTypeExtFee
 )
 
 // String implements Stringer interface
@@ -118,6 +217,10 @@ func (ttx XactTypeExt) String() string {
 		"Wd",
 		"STr",
 		"RTr",
+		"Rv",
+		"Rf",
+		"Jn",
+		"Fe",
 	}[ttx]
 }
 
@@ -142,6 +245,26 @@ func (ttx *XactTypeExt) Scan(src interface{}) error {
 	return nil
 }
 
+// MarshalJSON implements the json.Marshaler interface
+func (ttx XactTypeExt) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(ttx.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (ttx *XactTypeExt) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+
+	*ttx = strToXactTypeExt(s)
+	return nil
+}
+
 // strToXactTypeExt takes a string and returns the external transaction type
 func strToXactTypeExt(s string) XactTypeExt {
 	switch s {
@@ -153,6 +276,14 @@ func strToXactTypeExt(s string) XactTypeExt {
 		return XactTypeExtSndTransfer
 	case "RTr":
 		return XactTypeExtRcvTransfer
+	case "Rv":
+		return XactTypeExtReversal
+	case "Rf":
+		return XactTypeExtRefund
+	case "Jn":
+		return XactTypeExtJournal
+	case "Fe":
+		return XactTypeExtFee
 	}
 
 	return XactTypeExt(0)
@@ -1,6 +1,7 @@
 package transaction_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -62,6 +63,17 @@ func TestXactType(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotNil(t, value)
 	})
+
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(transaction.XactTypeCredit)
+		require.NoError(t, err)
+		assert.Equal(t, `"Cr"`, string(b))
+
+		var tt transaction.XactType
+		err = json.Unmarshal(b, &tt)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.XactTypeCredit, tt)
+	})
 }
 
 func TestXactTypeExt(t *testing.T) {
@@ -90,6 +102,22 @@ func TestXactTypeExt(t *testing.T) {
 				tt:     transaction.XactTypeExtRcvTransfer,
 				expect: "RTr",
 			},
+			{
+				tt:     transaction.XactTypeExtReversal,
+				expect: "Rv",
+			},
+			{
+				tt:     transaction.XactTypeExtRefund,
+				expect: "Rf",
+			},
+			{
+				tt:     transaction.XactTypeExtJournal,
+				expect: "Jn",
+			},
+			{
+				tt:     transaction.XactTypeExtFee,
+				expect: "Fe",
+			},
 		}
 
 		for _, tt := range tc {
@@ -118,6 +146,22 @@ func TestXactTypeExt(t *testing.T) {
 				s:      "RTr",
 				expect: transaction.XactTypeExtRcvTransfer,
 			},
+			{
+				s:      "Rv",
+				expect: transaction.XactTypeExtReversal,
+			},
+			{
+				s:      "Rf",
+				expect: transaction.XactTypeExtRefund,
+			},
+			{
+				s:      "Jn",
+				expect: transaction.XactTypeExtJournal,
+			},
+			{
+				s:      "Fe",
+				expect: transaction.XactTypeExtFee,
+			},
 		}
 
 		for _, tt := range tc {
@@ -133,4 +177,15 @@ func TestXactTypeExt(t *testing.T) {
 		require.NoError(t, err)
 		assert.NotNil(t, value)
 	})
+
+	t.Run("json", func(t *testing.T) {
+		b, err := json.Marshal(transaction.XactTypeExtSndTransfer)
+		require.NoError(t, err)
+		assert.Equal(t, `"STr"`, string(b))
+
+		var ttx transaction.XactTypeExt
+		err = json.Unmarshal(b, &ttx)
+		require.NoError(t, err)
+		assert.Equal(t, transaction.XactTypeExtSndTransfer, ttx)
+	})
 }
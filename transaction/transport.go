@@ -4,12 +4,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/transport"
 	kithttp "github.com/go-kit/kit/transport/http"
+	"github.com/shopspring/decimal"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/fx"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/limit"
 )
 
 // NewHTTPHandler returns a transaction http handler
@@ -40,6 +52,27 @@ func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
 		opts...,
 	)
 
+	batchPaymentHandler := kithttp.NewServer(
+		newBatchPaymentEndpoint(s),
+		decodeBatchPaymentRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	fxPaymentHandler := kithttp.NewServer(
+		newFXPaymentEndpoint(s),
+		decodePaymentRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	journalHandler := kithttp.NewServer(
+		newJournalEndpoint(s),
+		decodeJournalRequest,
+		encodeResponse,
+		opts...,
+	)
+
 	listPaymentsHandler := kithttp.NewServer(
 		newListPaymentsEndpoint(s),
 		decodeListPaymentsRequest,
@@ -47,6 +80,55 @@ func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
 		opts...,
 	)
 
+	getXactHandler := kithttp.NewServer(
+		newGetXactEndpoint(s),
+		decodeGetXactRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	reverseHandler := kithttp.NewServer(
+		newReverseEndpoint(s),
+		decodeReverseRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	refundHandler := kithttp.NewServer(
+		newRefundEndpoint(s),
+		decodeRefundRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	holdHandler := kithttp.NewServer(
+		newHoldEndpoint(s),
+		decodeHoldRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	getHoldHandler := kithttp.NewServer(
+		newGetHoldEndpoint(s),
+		decodeGetHoldRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	captureHandler := kithttp.NewServer(
+		newCaptureEndpoint(s),
+		decodeCaptureRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	voidHandler := kithttp.NewServer(
+		newVoidEndpoint(s),
+		decodeVoidRequest,
+		encodeResponse,
+		opts...,
+	)
+
 	mux := chi.NewMux()
 
 	mux.Method(http.MethodPost, "/deposit", depositHandler)
@@ -55,16 +137,61 @@ func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
 	mux.Route("/payments", func(r chi.Router) {
 		r.Method(http.MethodPost, "/", paymentHandler)
 		r.Method(http.MethodGet, "/", listPaymentsHandler)
+		r.Method(http.MethodPost, "/batch", batchPaymentHandler)
+		r.Method(http.MethodPost, "/fx", fxPaymentHandler)
 	})
 
+	mux.Method(http.MethodPost, "/journal", journalHandler)
+
+	mux.Route("/holds", func(r chi.Router) {
+		r.Method(http.MethodPost, "/", holdHandler)
+		r.Method(http.MethodGet, "/{hold_id}", getHoldHandler)
+		r.Method(http.MethodPost, "/{hold_id}/capture", captureHandler)
+		r.Method(http.MethodPost, "/{hold_id}/void", voidHandler)
+	})
+
+	mux.Method(http.MethodGet, "/{xact_no}", getXactHandler)
+	mux.Method(http.MethodPost, "/{xact_no}/reverse", reverseHandler)
+	mux.Method(http.MethodPost, "/{xact_no}/refund", refundHandler)
+
 	return mux
 }
 
+// NewAccountHTTPHandler returns the http handler of the account transactions,
+// it is mounted under the account route containing the {id} url param
+func NewAccountHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	listAccntXactsHandler := kithttp.NewServer(
+		newListAccntXactsEndpoint(s),
+		decodeListAccntXactsRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodGet, "/", listAccntXactsHandler)
+
+	return mux
+}
+
+var (
+	errBadRoute = errors.New("bad route")
+)
+
+// idempotencyKeyHeader is the header containing the idempotency key
+const idempotencyKeyHeader = "Idempotency-Key"
+
 func decodeDepositRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var request depositRequest
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	request.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
 
 	return request, nil
 }
@@ -74,6 +201,7 @@ func decodeWithdrawalRequest(_ context.Context, r *http.Request) (interface{}, e
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	request.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
 
 	return request, nil
 }
@@ -83,14 +211,205 @@ func decodePaymentRequest(_ context.Context, r *http.Request) (interface{}, erro
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
 		return nil, err
 	}
+	request.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
+
+	return request, nil
+}
+
+func decodeBatchPaymentRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request batchPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
 
 	return request, nil
 }
 
+func decodeJournalRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request journalRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
+
+	return request, nil
+}
+
+func decodeGetXactRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	xactNo := chi.URLParam(r, "xact_no")
+	if xactNo == "" {
+		return nil, errBadRoute
+	}
+
+	return getXactRequest{XactNo: XactNo(xactNo)}, nil
+}
+
+func decodeReverseRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	xactNo := chi.URLParam(r, "xact_no")
+	if xactNo == "" {
+		return nil, errBadRoute
+	}
+
+	var request reverseRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.XactNo = XactNo(xactNo)
+
+	return request, nil
+}
+
+func decodeRefundRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	xactNo := chi.URLParam(r, "xact_no")
+	if xactNo == "" {
+		return nil, errBadRoute
+	}
+
+	var request refundRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.XactNo = XactNo(xactNo)
+
+	return request, nil
+}
+
+func decodeHoldRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request holdRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func decodeGetHoldRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	holdID := chi.URLParam(r, "hold_id")
+	if holdID == "" {
+		return nil, errBadRoute
+	}
+
+	return getHoldRequest{HoldID: HoldID(holdID)}, nil
+}
+
+func decodeCaptureRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	holdID := chi.URLParam(r, "hold_id")
+	if holdID == "" {
+		return nil, errBadRoute
+	}
+
+	var request captureRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+	request.HoldID = HoldID(holdID)
+
+	return request, nil
+}
+
+func decodeVoidRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	holdID := chi.URLParam(r, "hold_id")
+	if holdID == "" {
+		return nil, errBadRoute
+	}
+
+	return voidRequest{HoldID: HoldID(holdID)}, nil
+}
+
 func decodeListPaymentsRequest(_ context.Context, _ *http.Request) (interface{}, error) {
 	return listPaymentsRequest{}, nil
 }
 
+func decodeListAccntXactsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accountID := chi.URLParam(r, "id")
+	if accountID == "" {
+		return nil, errBadRoute
+	}
+
+	f, err := parseXactFilter(r.URL.Query())
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+	f.AccountID = account.AccountID(accountID)
+
+	return listAccntXactsRequest{Filter: *f}, nil
+}
+
+// parseXactFilter parses the transaction filter from the query params
+func parseXactFilter(q url.Values) (*XactFilter, error) {
+	var f XactFilter
+
+	if v := q.Get("type"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			ttx := strToXactTypeExt(s)
+			if ttx == XactTypeExt(0) {
+				return nil, fmt.Errorf("type: invalid transaction type %q", s)
+			}
+			f.XactTypeExts = append(f.XactTypeExts, ttx)
+		}
+	}
+
+	for _, p := range []struct {
+		key string
+		ts  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		v := q.Get(p.key)
+		if v == "" {
+			continue
+		}
+
+		ts, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: must be an RFC 3339 timestamp", p.key)
+		}
+		*p.ts = &ts
+	}
+
+	for _, p := range []struct {
+		key    string
+		amount **decimal.Decimal
+	}{{"min_amount", &f.MinAmount}, {"max_amount", &f.MaxAmount}} {
+		v := q.Get(p.key)
+		if v == "" {
+			continue
+		}
+
+		amount, err := decimal.NewFromString(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s: must be a decimal", p.key)
+		}
+		*p.amount = &amount
+	}
+
+	switch q.Get("sort") {
+	case "", "desc":
+		f.Sort = SortDesc
+	case "asc":
+		f.Sort = SortAsc
+	default:
+		return nil, errors.New("sort: must be either asc or desc")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, errors.New("limit: must be an integer")
+		}
+		f.Limit = limit
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := DecodeCursor(v)
+		if err != nil {
+			return nil, err
+		}
+		f.Cursor = cursor
+	}
+
+	return &f, nil
+}
+
 func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
 	if e, ok := response.(errorer); ok && e.error() != nil {
 		encodeError(ctx, e.error(), w)
@@ -109,10 +428,31 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 
 	if errors.Is(err, ErrValidation) ||
-		errors.Is(err, ErrInsufficientBalance) {
+		errors.Is(err, ErrInsufficientBalance) ||
+		errors.Is(err, ErrDifferentCurrencies) ||
+		errors.Is(err, ErrSameCurrencies) ||
+		errors.Is(err, fx.ErrRateNotFound) ||
+		errors.Is(err, ErrXactNotReversible) ||
+		errors.Is(err, ErrXactNotRefundable) ||
+		errors.Is(err, ErrRefundExceedsAmount) ||
+		errors.Is(err, ErrHoldExpired) ||
+		errors.Is(err, ErrCaptureExceedsHold) ||
+		errors.Is(err, ErrUnbalancedEntry) ||
+		errors.Is(err, limit.ErrLimitExceeded) {
 		w.WriteHeader(http.StatusUnprocessableEntity)
+	} else if errors.Is(err, ErrIdempotencyKeyConflict) ||
+		errors.Is(err, ErrXactAlreadyReversed) ||
+		errors.Is(err, ErrXactAlreadyRefunded) ||
+		errors.Is(err, ErrHoldNotActive) ||
+		errors.Is(err, account.ErrAccountFrozen) ||
+		errors.Is(err, account.ErrAccountClosed) {
+		w.WriteHeader(http.StatusConflict)
 	} else if errors.Is(err, ErrSendingAccountNotFound) ||
-		errors.Is(err, ErrReceivingAccountNotFound) {
+		errors.Is(err, ErrReceivingAccountNotFound) ||
+		errors.Is(err, ErrXactNotFound) ||
+		errors.Is(err, account.ErrAccountNotFound) ||
+		errors.Is(err, ErrHoldNotFound) ||
+		errors.Is(err, ledger.ErrLedgerNotFound) {
 		w.WriteHeader(http.StatusNotFound)
 	} else {
 		w.WriteHeader(http.StatusInternalServerError)
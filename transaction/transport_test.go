@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-kit/kit/log"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -16,28 +17,26 @@ import (
 	"github.com/stevenferrer/kalupi/account"
 	"github.com/stevenferrer/kalupi/balance"
 	"github.com/stevenferrer/kalupi/currency"
-	"github.com/stevenferrer/kalupi/etc/txdb"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/fee"
 	"github.com/stevenferrer/kalupi/ledger"
-	"github.com/stevenferrer/kalupi/postgres"
+	"github.com/stevenferrer/kalupi/limit"
 	"github.com/stevenferrer/kalupi/transaction"
 )
 
 func TestHTTPHandler(t *testing.T) {
-	db := txdb.MustOpen()
-	defer db.Close()
-
-	err := postgres.Migrate(db)
-	require.NoError(t, err)
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
 
 	ctx := context.TODO()
 
 	// setup accounts and ledgers
-	accountRepo := postgres.NewAccountRepository(db)
+	accountRepo := store.AccountRepo
 	john := account.Account{
 		AccountID: account.AccountID("johndoe"),
 		Currency:  currency.USD,
 	}
-	_, err = accountRepo.CreateAccount(ctx, john)
+	_, err := accountRepo.CreateAccount(ctx, john)
 	require.NoError(t, err)
 	mary := account.Account{
 		AccountID: account.AccountID("maryjane"),
@@ -46,15 +45,15 @@ func TestHTTPHandler(t *testing.T) {
 	_, err = accountRepo.CreateAccount(ctx, mary)
 	require.NoError(t, err)
 
-	ledgerRepo := postgres.NewLedgerRepository(db)
-	ledgerService := ledger.NewService(ledgerRepo)
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
 	err = ledgerService.CreateCashLedgers(ctx)
 	require.NoError(t, err)
 
-	balRepo := postgres.NewBalanceRepository(db)
+	balRepo := store.BalRepo
 	balService := balance.NewService(balRepo)
 
-	xactRepo := postgres.NewXactRepository(db)
+	xactRepo := store.XactRepo
 	xactService := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
 
 	logger := log.NewNopLogger()
@@ -77,11 +76,49 @@ func TestHTTPHandler(t *testing.T) {
 		xactHandler.ServeHTTP(rr, httpReq)
 		require.Equal(t, http.StatusOK, rr.Code)
 
+		var resp struct {
+			Xact transaction.Xact `json:"transaction"`
+			Err  string           `json:"error"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Err)
+		assert.NotEmpty(t, resp.Xact.XactNo)
+		assert.NotNil(t, resp.Xact.Ts)
+		require.Len(t, resp.Xact.Legs, 1)
+		assert.Equal(t, transaction.XactTypeExtDeposit, resp.Xact.Legs[0].XactTypeExt)
+
 		// check balance
 		johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
 		require.NoError(t, err)
 		assert.True(t, decimal.NewFromInt(100).Equal(johnBal.CurrentBal), "john should now have a balance of 100")
 
+		t.Run("get transaction", func(t *testing.T) {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "/"+string(resp.Xact.XactNo), nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var getResp struct {
+				Xact transaction.Xact `json:"transaction"`
+			}
+			err = json.NewDecoder(rr.Body).Decode(&getResp)
+			require.NoError(t, err)
+			assert.Equal(t, resp.Xact.XactNo, getResp.Xact.XactNo)
+			assert.Len(t, getResp.Xact.Legs, 1)
+
+			t.Run("not found", func(t *testing.T) {
+				httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "/IDONTEXIST", nil)
+				require.NoError(t, err)
+
+				rr := httptest.NewRecorder()
+				xactHandler.ServeHTTP(rr, httpReq)
+				require.Equal(t, http.StatusNotFound, rr.Code)
+			})
+		})
+
 		t.Run("validation error", func(t *testing.T) {
 			req = map[string]interface{}{
 				"account_id": john.AccountID,
@@ -200,6 +237,46 @@ func TestHTTPHandler(t *testing.T) {
 		})
 	})
 
+	t.Run("idempotency key", func(t *testing.T) {
+		var req = map[string]interface{}{
+			"account_id": mary.AccountID,
+			"amount":     10,
+		}
+		b, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		for i := 0; i < 2; i++ {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "/deposit", bytes.NewBuffer(b))
+			require.NoError(t, err)
+			httpReq.Header.Set("Idempotency-Key", "dp-mary-1")
+
+			rr := httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusOK, rr.Code)
+		}
+
+		maryBal, err := balService.GetAccntBal(ctx, mary.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(40).Equal(maryBal.CurrentBal), "mary should now have a balance of 40")
+
+		t.Run("conflict", func(t *testing.T) {
+			req = map[string]interface{}{
+				"account_id": mary.AccountID,
+				"amount":     20,
+			}
+			b, err = json.Marshal(req)
+			require.NoError(t, err)
+
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "/deposit", bytes.NewBuffer(b))
+			require.NoError(t, err)
+			httpReq.Header.Set("Idempotency-Key", "dp-mary-1")
+
+			rr := httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusConflict, rr.Code)
+		})
+	})
+
 	t.Run("list payments", func(t *testing.T) {
 		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "/payments", nil)
 		require.NoError(t, err)
@@ -217,5 +294,533 @@ func TestHTTPHandler(t *testing.T) {
 		assert.Empty(t, resp.Err)
 
 		assert.Len(t, resp.Payments, 2)
+
+		t.Run("refund payment", func(t *testing.T) {
+			var req = map[string]interface{}{
+				"amount": 10,
+				"reason": "damaged item",
+			}
+			b, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			url := "/" + string(resp.Payments[0].XactNo) + "/refund"
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+			require.NoError(t, err)
+			assert.True(t, decimal.NewFromInt(55).Equal(johnBal.CurrentBal), "john should now have a balance of 55")
+		})
+
+		t.Run("reverse payment", func(t *testing.T) {
+			var req = map[string]interface{}{
+				"reason": "sent by mistake",
+			}
+			b, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			// already refunded
+			url := "/" + string(resp.Payments[0].XactNo) + "/reverse"
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusConflict, rr.Code)
+
+			// not found
+			httpReq, err = http.NewRequestWithContext(ctx, http.MethodPost, "/IDONTEXIST/reverse", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr = httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+	t.Run("holds", func(t *testing.T) {
+		var req = map[string]interface{}{
+			"account_id": john.AccountID,
+			"amount":     20,
+		}
+		b, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "/holds", bytes.NewBuffer(b))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		xactHandler.ServeHTTP(rr, httpReq)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Hold struct {
+				HoldID transaction.HoldID     `json:"id"`
+				Status transaction.HoldStatus `json:"status"`
+			} `json:"hold"`
+			Err string `json:"error"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Err)
+		assert.Equal(t, transaction.HoldStatusActive, resp.Hold.Status)
+
+		johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.NewFromInt(35).Equal(johnBal.AvailableBal), "john should now have an available balance of 35")
+
+		t.Run("capture", func(t *testing.T) {
+			var req = map[string]interface{}{
+				"amount":     5,
+				"to_account": mary.AccountID,
+			}
+			b, err := json.Marshal(req)
+			require.NoError(t, err)
+
+			url := "/holds/" + string(resp.Hold.HoldID) + "/capture"
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+			require.NoError(t, err)
+			assert.True(t, decimal.NewFromInt(50).Equal(johnBal.CurrentBal), "john should now have a balance of 50")
+			assert.True(t, decimal.NewFromInt(50).Equal(johnBal.AvailableBal), "john should now have an available balance of 50")
+		})
+
+		t.Run("void", func(t *testing.T) {
+			// already captured
+			url := "/holds/" + string(resp.Hold.HoldID) + "/void"
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusConflict, rr.Code)
+
+			// not found
+			httpReq, err = http.NewRequestWithContext(ctx, http.MethodPost, "/holds/IDONTEXIST/void", nil)
+			require.NoError(t, err)
+
+			rr = httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+
+	t.Run("list account transactions", func(t *testing.T) {
+		mux := chi.NewMux()
+		mux.Mount("/accounts/{id}/transactions", transaction.NewAccountHTTPHandler(xactService, logger))
+
+		url := "/accounts/" + string(john.AccountID) + "/transactions?type=Dp,STr&limit=1&sort=asc"
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httpReq)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Xacts      []*transaction.Transaction `json:"transactions"`
+			NextCursor string                     `json:"next_cursor"`
+			Err        string                     `json:"error"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Err)
+		require.Len(t, resp.Xacts, 1)
+		assert.Contains(t, []transaction.XactTypeExt{
+			transaction.XactTypeExtDeposit,
+			transaction.XactTypeExtSndTransfer,
+		}, resp.Xacts[0].XactTypeExt)
+		assert.NotEmpty(t, resp.NextCursor)
+
+		t.Run("validation error", func(t *testing.T) {
+			url := "/accounts/" + string(john.AccountID) + "/transactions?sort=sideways"
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+
+		t.Run("account not found", func(t *testing.T) {
+			httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "/accounts/idontexist/transactions", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+
+	t.Run("make journal entry", func(t *testing.T) {
+		johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+		require.NoError(t, err)
+
+		var req = map[string]interface{}{
+			"desc": "Split bill",
+			"legs": []map[string]interface{}{
+				{"account_id": john.AccountID, "xact_type": "Dr", "amount": "5"},
+				{"account_id": mary.AccountID, "xact_type": "Cr", "amount": "5"},
+			},
+		}
+		b, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "/journal", bytes.NewBuffer(b))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		xactHandler.ServeHTTP(rr, httpReq)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Xact transaction.Xact `json:"transaction"`
+			Err  string           `json:"error"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Empty(t, resp.Err)
+		require.Len(t, resp.Xact.Legs, 2)
+		assert.Equal(t, transaction.XactTypeExtJournal, resp.Xact.Legs[0].XactTypeExt)
+
+		newJohnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+		require.NoError(t, err)
+		assert.True(t, johnBal.CurrentBal.Sub(decimal.NewFromInt(5)).Equal(newJohnBal.CurrentBal))
+
+		t.Run("unbalanced", func(t *testing.T) {
+			req = map[string]interface{}{
+				"desc": "Split bill",
+				"legs": []map[string]interface{}{
+					{"account_id": john.AccountID, "xact_type": "Dr", "amount": "5"},
+					{"account_id": mary.AccountID, "xact_type": "Cr", "amount": "4"},
+				},
+			}
+			b, err = json.Marshal(req)
+			require.NoError(t, err)
+
+			httpReq, err = http.NewRequestWithContext(ctx, http.MethodPost, "/journal", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr = httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+
+		t.Run("ledger not found", func(t *testing.T) {
+			req = map[string]interface{}{
+				"desc": "Split bill",
+				"legs": []map[string]interface{}{
+					{"account_id": john.AccountID, "xact_type": "Dr", "amount": "5"},
+					{"ledger_no": "idontexist", "xact_type": "Cr", "amount": "5"},
+				},
+			}
+			b, err = json.Marshal(req)
+			require.NoError(t, err)
+
+			httpReq, err = http.NewRequestWithContext(ctx, http.MethodPost, "/journal", bytes.NewBuffer(b))
+			require.NoError(t, err)
+
+			rr = httptest.NewRecorder()
+			xactHandler.ServeHTTP(rr, httpReq)
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+}
+
+func TestHTTPHandlerFees(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	ledgerService := ledger.NewService(ledgerRepo, currency.NewSet(currency.USD))
+	err = ledgerService.CreateCashLedgers(ctx)
+	require.NoError(t, err)
+	err = ledgerService.CreateRevenueLedgers(ctx)
+	require.NoError(t, err)
+
+	feeSchedule, err := fee.NewSchedule(fee.Rule{
+		Currency:    currency.USD,
+		XactTypeExt: transaction.XactTypeExtSndTransfer.String(),
+		Type:        fee.TypeFlat,
+		Amount:      decimal.NewFromInt(1),
+	})
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	xactRepo := store.XactRepo
+	xactService := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo,
+		transaction.WithFeeSchedule(feeSchedule))
+
+	_, err = xactService.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	logger := log.NewNopLogger()
+	xactHandler := transaction.NewHTTPHandler(xactService, logger)
+
+	t.Run("make payment", func(t *testing.T) {
+		var req = map[string]interface{}{
+			"from_account": john.AccountID,
+			"to_account":   mary.AccountID,
+			"amount":       30,
+		}
+		b, err := json.Marshal(req)
+		require.NoError(t, err)
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "/payments", bytes.NewBuffer(b))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		xactHandler.ServeHTTP(rr, httpReq)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Xact transaction.Xact `json:"transaction"`
+			Fee  decimal.Decimal  `json:"fee"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Len(t, resp.Xact.Legs, 3)
+		assert.True(t, decimal.NewFromInt(1).Equal(resp.Fee))
+	})
+
+	t.Run("list payments", func(t *testing.T) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, "/payments", nil)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		xactHandler.ServeHTTP(rr, httpReq)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp = struct {
+			Payments []*transaction.Payment `json:"payments"`
+		}{}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Payments, 2)
+
+		outgoing, incoming := resp.Payments[0], resp.Payments[1]
+		assert.Equal(t, "outgoing", outgoing.Direction)
+		require.NotNil(t, outgoing.Fee)
+		assert.True(t, decimal.NewFromInt(1).Equal(*outgoing.Fee))
+		assert.Equal(t, "incoming", incoming.Direction)
+		assert.Nil(t, incoming.Fee)
+	})
+}
+
+func TestHTTPHandlerLimits(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	err = ledger.NewService(ledgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	limitPolicy, err := limit.NewPolicy(limit.Limits{
+		Currency:  currency.USD,
+		MaxAmount: decimal.NewFromInt(50),
+	})
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	xactRepo := store.XactRepo
+	xactService := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo,
+		transaction.WithLimitPolicy(limitPolicy))
+
+	_, err = xactService.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	logger := log.NewNopLogger()
+	xactHandler := transaction.NewHTTPHandler(xactService, logger)
+
+	var req = map[string]interface{}{
+		"account_id": john.AccountID,
+		"amount":     60,
+	}
+	b, err := json.Marshal(req)
+	require.NoError(t, err)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "/withdraw", bytes.NewBuffer(b))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	xactHandler.ServeHTTP(rr, httpReq)
+	require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	var resp = map[string]interface{}{}
+	err = json.NewDecoder(rr.Body).Decode(&resp)
+	require.NoError(t, err)
+	assert.Equal(t, "max amount 50: limit exceeded", resp["error"])
+}
+
+func TestHTTPHandlerBatchPayments(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+
+	// setup accounts and ledgers
+	accountRepo := store.AccountRepo
+	john := account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	_, err := accountRepo.CreateAccount(ctx, john)
+	require.NoError(t, err)
+	mary := account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+	_, err = accountRepo.CreateAccount(ctx, mary)
+	require.NoError(t, err)
+
+	ledgerRepo := store.LedgerRepo
+	err = ledger.NewService(ledgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	balRepo := store.BalRepo
+	balService := balance.NewService(balRepo)
+
+	xactRepo := store.XactRepo
+	xactService := transaction.NewService(accountRepo, ledgerRepo, xactRepo, balRepo)
+
+	_, err = xactService.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	logger := log.NewNopLogger()
+	xactService = transaction.NewLoggingService(logger, xactService)
+	xactHandler := transaction.NewHTTPHandler(xactService, logger)
+
+	batch := func(t *testing.T, mode string, amounts ...int) *httptest.ResponseRecorder {
+		payments := []map[string]interface{}{}
+		for _, amount := range amounts {
+			payments = append(payments, map[string]interface{}{
+				"from_account": john.AccountID,
+				"to_account":   mary.AccountID,
+				"amount":       amount,
+			})
+		}
+
+		b, err := json.Marshal(map[string]interface{}{
+			"mode":     mode,
+			"payments": payments,
+		})
+		require.NoError(t, err)
+
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, "/payments/batch", bytes.NewBuffer(b))
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		xactHandler.ServeHTTP(rr, httpReq)
+		return rr
+	}
+
+	type batchResp struct {
+		Mode    string `json:"mode"`
+		Results []struct {
+			Index int               `json:"index"`
+			Xact  *transaction.Xact `json:"transaction"`
+			Err   string            `json:"error"`
+		} `json:"results"`
+		Err string `json:"error"`
+	}
+
+	t.Run("atomic", func(t *testing.T) {
+		rr := batch(t, "atomic", 10, 20)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp batchResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "atomic", resp.Mode)
+		require.Len(t, resp.Results, 2)
+		for i, result := range resp.Results {
+			assert.Equal(t, i, result.Index)
+			assert.NotNil(t, result.Xact)
+			assert.Empty(t, result.Err)
+		}
+
+		t.Run("insufficient balance", func(t *testing.T) {
+			rr := batch(t, "atomic", 60, 20)
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+			var resp batchResp
+			err := json.NewDecoder(rr.Body).Decode(&resp)
+			require.NoError(t, err)
+			assert.Equal(t, "transfer 1: insufficient balance", resp.Err)
+
+			johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+			require.NoError(t, err)
+			assert.True(t, decimal.NewFromInt(70).Equal(johnBal.CurrentBal))
+		})
+	})
+
+	t.Run("best effort", func(t *testing.T) {
+		rr := batch(t, "best_effort", 60, 20, 10)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp batchResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "best_effort", resp.Mode)
+		require.Len(t, resp.Results, 3)
+		assert.NotNil(t, resp.Results[0].Xact)
+		assert.Nil(t, resp.Results[1].Xact)
+		assert.Equal(t, "insufficient balance", resp.Results[1].Err)
+		assert.NotNil(t, resp.Results[2].Xact)
+
+		johnBal, err := balService.GetAccntBal(ctx, john.AccountID)
+		require.NoError(t, err)
+		assert.True(t, decimal.Zero.Equal(johnBal.CurrentBal))
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		rr := batch(t, "sequential", 10)
+		require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
 	})
 }
@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// DeliveryID is a delivery id
+type DeliveryID string
+
+// deliveryIDLen is the length of the delivery id
+const deliveryIDLen = 16
+
+// NewDeliveryID generates a new delivery id
+func NewDeliveryID() (DeliveryID, error) {
+	id, err := gonanoid.Generate(alphabet, deliveryIDLen)
+	if err != nil {
+		return "", pkgerrors.Wrap(err, "generate")
+	}
+
+	return DeliveryID(id), nil
+}
+
+// Delivery is the delivery of an event to a subscription, there's
+// at most one delivery per event and subscription
+type Delivery struct {
+	DeliveryID     DeliveryID     `json:"id"`
+	SubscriptionID SubscriptionID `json:"subscription_id"`
+	// EventSeq is the sequence number of the outbox event
+	EventSeq  int64  `json:"event_seq"`
+	EventType string `json:"event_type"`
+	// Payload is the request body i.e. the json encoded outbox event
+	Payload json.RawMessage `json:"payload"`
+	Status  Status          `json:"status"`
+
+	// Attempts is the number of attempts since the
+	// delivery was created or last redelivered
+	Attempts int `json:"attempts"`
+	// NextAttemptAt is when the delivery is attempted next
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// LastStatusCode is the response status of the
+	// last attempt, 0 if there was no response
+	LastStatusCode int `json:"last_status_code,omitempty"`
+	// LastError is the error of the last failed attempt
+	LastError string `json:"last_error,omitempty"`
+	// DeliveredAt is when the delivery has succeeded
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+
+	// LeaseOwner is the worker sending the delivery
+	LeaseOwner string `json:"-"`
+	// LeaseExpiresAt is when the lease of the worker expires
+	LeaseExpiresAt *time.Time `json:"-"`
+
+	Ts *time.Time `json:"ts,omitempty"`
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/stevenferrer/kalupi/outbox"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// Dispatcher is an outbox publisher that creates the deliveries of the
+// postings to the matching subscriptions, the deliveries are sent by the
+// worker. An event that is published again doesn't create more deliveries.
+type Dispatcher struct {
+	webhookRepo Repository
+}
+
+var _ outbox.Publisher = (*Dispatcher)(nil)
+
+// NewDispatcher takes a webhook repository and returns a dispatcher
+func NewDispatcher(webhookRepo Repository) *Dispatcher {
+	return &Dispatcher{webhookRepo: webhookRepo}
+}
+
+// Publish creates the deliveries of the event
+func (d *Dispatcher) Publish(ctx context.Context, ev *outbox.Event) error {
+	if ev.Type != transaction.EventTypeXactPosted {
+		return nil
+	}
+
+	var xact transaction.Transaction
+	err := json.Unmarshal(ev.Payload, &xact)
+	if err != nil {
+		return errors.Wrap(err, "json unmarshal")
+	}
+
+	subs, err := d.webhookRepo.ListSubscriptions(ctx, xact.AccountID)
+	if err != nil {
+		return errors.Wrap(err, "list subscriptions")
+	}
+
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrap(err, "json marshal")
+	}
+
+	now := time.Now()
+	deliveries := []Delivery{}
+	for _, sub := range subs {
+		if !sub.Matches(xact) {
+			continue
+		}
+
+		deliveryID, err := NewDeliveryID()
+		if err != nil {
+			return errors.Wrap(err, "new delivery id")
+		}
+
+		deliveries = append(deliveries, Delivery{
+			DeliveryID:     deliveryID,
+			SubscriptionID: sub.SubscriptionID,
+			EventSeq:       ev.Seq,
+			EventType:      ev.Type,
+			Payload:        payload,
+			Status:         StatusPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	err = d.webhookRepo.CreateDeliveries(ctx, deliveries)
+	if err != nil {
+		return errors.Wrap(err, "create deliveries")
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// createSubscriptionRequest is a create subscription request
+type createSubscriptionRequest struct {
+	AccountID    account.AccountID         `json:"account_id"`
+	XactTypeExts []transaction.XactTypeExt `json:"xact_types"`
+	URL          string                    `json:"url"`
+	Secret       string                    `json:"secret"`
+}
+
+// subscriptionResponse is a subscription response
+type subscriptionResponse struct {
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Err          error         `json:"error,omitempty"`
+}
+
+func (r subscriptionResponse) error() error { return r.Err }
+
+// newCreateSubscriptionEndpoint returns a create subscription endpoint
+func newCreateSubscriptionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(createSubscriptionRequest)
+		sub, err := s.CreateSubscription(ctx, Subscription{
+			AccountID:    req.AccountID,
+			XactTypeExts: req.XactTypeExts,
+			URL:          req.URL,
+			Secret:       req.Secret,
+		})
+		return subscriptionResponse{Subscription: sub, Err: err}, nil
+	}
+}
+
+// getSubscriptionRequest is a get subscription request
+type getSubscriptionRequest struct {
+	SubscriptionID SubscriptionID
+}
+
+// newGetSubscriptionEndpoint returns a get subscription endpoint
+func newGetSubscriptionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(getSubscriptionRequest)
+		sub, err := s.GetSubscription(ctx, req.SubscriptionID)
+		return subscriptionResponse{Subscription: sub, Err: err}, nil
+	}
+}
+
+// listSubscriptionsRequest is a list subscriptions request
+type listSubscriptionsRequest struct {
+	AccountID account.AccountID
+}
+
+// listSubscriptionsResponse is a list subscriptions response
+type listSubscriptionsResponse struct {
+	Subscriptions []*Subscription `json:"subscriptions,omitempty"`
+	Err           error           `json:"error,omitempty"`
+}
+
+func (r listSubscriptionsResponse) error() error { return r.Err }
+
+// newListSubscriptionsEndpoint returns a list subscriptions endpoint
+func newListSubscriptionsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listSubscriptionsRequest)
+		subs, err := s.ListSubscriptions(ctx, req.AccountID)
+		return listSubscriptionsResponse{Subscriptions: subs, Err: err}, nil
+	}
+}
+
+// deleteSubscriptionRequest is a delete subscription request
+type deleteSubscriptionRequest struct {
+	SubscriptionID SubscriptionID
+}
+
+// deleteSubscriptionResponse is a delete subscription response
+type deleteSubscriptionResponse struct {
+	Err error `json:"error,omitempty"`
+}
+
+func (r deleteSubscriptionResponse) error() error { return r.Err }
+
+// newDeleteSubscriptionEndpoint returns a delete subscription endpoint
+func newDeleteSubscriptionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(deleteSubscriptionRequest)
+		err := s.DeleteSubscription(ctx, req.SubscriptionID)
+		return deleteSubscriptionResponse{Err: err}, nil
+	}
+}
+
+// listDeliveriesRequest is a list deliveries request
+type listDeliveriesRequest struct {
+	SubscriptionID SubscriptionID
+}
+
+// listDeliveriesResponse is a list deliveries response
+type listDeliveriesResponse struct {
+	Deliveries []*Delivery `json:"deliveries,omitempty"`
+	Err        error       `json:"error,omitempty"`
+}
+
+func (r listDeliveriesResponse) error() error { return r.Err }
+
+// newListDeliveriesEndpoint returns a list deliveries endpoint
+func newListDeliveriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(listDeliveriesRequest)
+		deliveries, err := s.ListDeliveries(ctx, req.SubscriptionID)
+		return listDeliveriesResponse{Deliveries: deliveries, Err: err}, nil
+	}
+}
+
+// redeliverRequest is a redeliver request
+type redeliverRequest struct {
+	DeliveryID DeliveryID
+}
+
+// deliveryResponse is a delivery response
+type deliveryResponse struct {
+	Delivery *Delivery `json:"delivery,omitempty"`
+	Err      error     `json:"error,omitempty"`
+}
+
+func (r deliveryResponse) error() error { return r.Err }
+
+// newRedeliverEndpoint returns a redeliver endpoint
+func newRedeliverEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(redeliverRequest)
+		d, err := s.Redeliver(ctx, req.DeliveryID)
+		return deliveryResponse{Delivery: d, Err: err}, nil
+	}
+}
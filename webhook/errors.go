@@ -0,0 +1,21 @@
+package webhook
+
+import "errors"
+
+// List of webhook related errors
+var (
+	// ErrValidation is a webhook related validation error
+	ErrValidation = errors.New("validation error")
+	// ErrSubscriptionNotFound is an error when the subscription doesn't exist
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	// ErrDeliveryNotFound is an error when the delivery doesn't exist
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	// ErrDeliveryBusy is an error when redelivering
+	// a delivery while a worker is sending it
+	ErrDeliveryBusy = errors.New("delivery busy")
+	// ErrLeaseLost is an error when recording the attempt of a
+	// delivery whose lease was taken over by another worker
+	ErrLeaseLost = errors.New("lease lost")
+	// ErrInvalidSignature is an error when the signature doesn't match
+	ErrInvalidSignature = errors.New("invalid signature")
+)
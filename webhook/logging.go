@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// loggingService is a service logging middleware
+type loggingService struct {
+	logger log.Logger
+	s      Service
+}
+
+// NewLoggingService returns a logging service middleware
+func NewLoggingService(logger log.Logger, s Service) Service {
+	return &loggingService{logger: logger, s: s}
+}
+
+// CreateSubscription logs the create subscription params
+func (s *loggingService) CreateSubscription(ctx context.Context,
+	in Subscription) (sub *Subscription, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "create_subscription",
+			"account_id", in.AccountID,
+			"url", in.URL,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.CreateSubscription(ctx, in)
+}
+
+// GetSubscription logs the get subscription params
+func (s *loggingService) GetSubscription(ctx context.Context,
+	subID SubscriptionID) (sub *Subscription, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "get_subscription",
+			"subscription_id", subID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.GetSubscription(ctx, subID)
+}
+
+// ListSubscriptions logs the list subscriptions params
+func (s *loggingService) ListSubscriptions(ctx context.Context,
+	accntID account.AccountID) (subs []*Subscription, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list_subscriptions",
+			"account_id", accntID,
+			"count", len(subs),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ListSubscriptions(ctx, accntID)
+}
+
+// DeleteSubscription logs the delete subscription params
+func (s *loggingService) DeleteSubscription(ctx context.Context,
+	subID SubscriptionID) (err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "delete_subscription",
+			"subscription_id", subID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.DeleteSubscription(ctx, subID)
+}
+
+// ListDeliveries logs the list deliveries params
+func (s *loggingService) ListDeliveries(ctx context.Context,
+	subID SubscriptionID) (deliveries []*Delivery, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "list_deliveries",
+			"subscription_id", subID,
+			"count", len(deliveries),
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.ListDeliveries(ctx, subID)
+}
+
+// Redeliver logs the redeliver params
+func (s *loggingService) Redeliver(ctx context.Context,
+	deliveryID DeliveryID) (d *Delivery, err error) {
+	defer func(begin time.Time) {
+		_ = s.logger.Log(
+			"method", "redeliver",
+			"delivery_id", deliveryID,
+			"took", time.Since(begin),
+			"err", err,
+		)
+	}(time.Now())
+
+	return s.s.Redeliver(ctx, deliveryID)
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// Repository is a webhook repository
+type Repository interface {
+	// CreateSubscription creates a subscription
+	CreateSubscription(context.Context, Subscription) error
+	// GetSubscription retrieves the subscription
+	GetSubscription(context.Context, SubscriptionID) (*Subscription, error)
+	// ListSubscriptions retrieves the subscriptions to the account and
+	// to all the accounts, or all the subscriptions if the account id is empty
+	ListSubscriptions(context.Context, account.AccountID) ([]*Subscription, error)
+	// DeleteSubscription deletes the subscription and its deliveries
+	DeleteSubscription(context.Context, SubscriptionID) error
+	// CreateDeliveries creates the deliveries, the deliveries of
+	// an event to a subscription that already exist are skipped
+	CreateDeliveries(context.Context, []Delivery) error
+	// GetDelivery retrieves the delivery
+	GetDelivery(context.Context, DeliveryID) (*Delivery, error)
+	// ListDeliveries retrieves the deliveries to the
+	// subscription in the order of the events
+	ListDeliveries(context.Context, SubscriptionID) ([]*Delivery, error)
+	// UpdateDelivery updates the status, attempts and next attempt of
+	// the delivery. It returns ErrDeliveryBusy if the delivery is leased
+	// by a worker at the given time.
+	UpdateDelivery(context.Context, Delivery, time.Time) error
+	// LeaseDueDeliveries leases up to n pending deliveries whose next
+	// attempt is due at the given time and whose lease has expired, to the
+	// owner until the lease ttl has elapsed. The deliveries leased by
+	// another worker are skipped.
+	LeaseDueDeliveries(ctx context.Context, owner string,
+		now time.Time, ttl time.Duration, n int) ([]*Delivery, error)
+	// RecordAttempt updates the status, attempts, next attempt and the
+	// result of the last attempt of the delivery, and releases its lease.
+	// It returns ErrLeaseLost if the delivery is no longer leased by the owner.
+	RecordAttempt(context.Context, Delivery) error
+}
@@ -0,0 +1,154 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	pkgerrors "github.com/pkg/errors"
+	"go.uber.org/multierr"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// Service is a webhook service
+type Service interface {
+	// CreateSubscription creates a subscription, a secret
+	// is generated if the subscription doesn't have one
+	CreateSubscription(context.Context, Subscription) (*Subscription, error)
+	// GetSubscription retrieves the subscription
+	GetSubscription(context.Context, SubscriptionID) (*Subscription, error)
+	// ListSubscriptions retrieves the subscriptions to the account and
+	// to all the accounts, or all the subscriptions if the account id is empty
+	ListSubscriptions(context.Context, account.AccountID) ([]*Subscription, error)
+	// DeleteSubscription deletes the subscription and its deliveries
+	DeleteSubscription(context.Context, SubscriptionID) error
+	// ListDeliveries retrieves the deliveries to the subscription
+	ListDeliveries(context.Context, SubscriptionID) ([]*Delivery, error)
+	// Redeliver sends the delivery again, it is retried
+	// with all the attempts even if it has succeeded
+	Redeliver(context.Context, DeliveryID) (*Delivery, error)
+}
+
+// service implements the webhook service
+type service struct {
+	webhookRepo Repository
+	accountRepo account.Repository
+}
+
+var _ Service = (*service)(nil)
+
+// NewService takes a webhook and account repository and returns a webhook service
+func NewService(webhookRepo Repository, accountRepo account.Repository) Service {
+	return &service{
+		webhookRepo: webhookRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+// CreateSubscription creates a subscription
+func (s *service) CreateSubscription(ctx context.Context, sub Subscription) (*Subscription, error) {
+	err := sub.Validate()
+	if err != nil {
+		return nil, multierr.Combine(ErrValidation, err)
+	}
+
+	if sub.AccountID != "" {
+		exists, err := s.accountRepo.IsAccountExists(ctx, sub.AccountID)
+		if err != nil {
+			return nil, pkgerrors.Wrap(err, "is account exists")
+		}
+
+		if !exists {
+			return nil, account.ErrAccountNotFound
+		}
+	}
+
+	sub.SubscriptionID, err = NewSubscriptionID()
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "new subscription id")
+	}
+
+	if sub.Secret == "" {
+		sub.Secret, err = NewSecret()
+		if err != nil {
+			return nil, pkgerrors.Wrap(err, "new secret")
+		}
+	}
+
+	err = s.webhookRepo.CreateSubscription(ctx, sub)
+	if err != nil {
+		return nil, pkgerrors.Wrap(err, "create subscription")
+	}
+
+	return s.webhookRepo.GetSubscription(ctx, sub.SubscriptionID)
+}
+
+// GetSubscription retrieves the subscription without the secret
+func (s *service) GetSubscription(ctx context.Context, subID SubscriptionID) (*Subscription, error) {
+	sub, err := s.webhookRepo.GetSubscription(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Secret = ""
+	return sub, nil
+}
+
+// ListSubscriptions retrieves the subscriptions without the secrets
+func (s *service) ListSubscriptions(ctx context.Context,
+	accntID account.AccountID) ([]*Subscription, error) {
+	if accntID != "" {
+		err := accntID.Validate()
+		if err != nil {
+			return nil, multierr.Combine(ErrValidation,
+				validation.Errors{"account_id": err})
+		}
+	}
+
+	subs, err := s.webhookRepo.ListSubscriptions(ctx, accntID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range subs {
+		sub.Secret = ""
+	}
+
+	return subs, nil
+}
+
+// DeleteSubscription deletes the subscription and its deliveries
+func (s *service) DeleteSubscription(ctx context.Context, subID SubscriptionID) error {
+	return s.webhookRepo.DeleteSubscription(ctx, subID)
+}
+
+// ListDeliveries retrieves the deliveries to the subscription
+func (s *service) ListDeliveries(ctx context.Context, subID SubscriptionID) ([]*Delivery, error) {
+	_, err := s.webhookRepo.GetSubscription(ctx, subID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepo.ListDeliveries(ctx, subID)
+}
+
+// Redeliver makes the delivery pending and due now, with no attempts
+func (s *service) Redeliver(ctx context.Context, deliveryID DeliveryID) (*Delivery, error) {
+	d, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	d.Status = StatusPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+
+	err = s.webhookRepo.UpdateDelivery(ctx, *d, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.webhookRepo.GetDelivery(ctx, deliveryID)
+}
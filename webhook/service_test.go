@@ -0,0 +1,432 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/currency"
+	"github.com/stevenferrer/kalupi/etc/repotest"
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/ledger"
+	"github.com/stevenferrer/kalupi/outbox"
+	"github.com/stevenferrer/kalupi/transaction"
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+var (
+	john = account.Account{
+		AccountID: account.AccountID("johndoe"),
+		Currency:  currency.USD,
+	}
+	mary = account.Account{
+		AccountID: account.AccountID("maryjane"),
+		Currency:  currency.USD,
+	}
+)
+
+// setup creates the accounts and ledgers
+func setup(t *testing.T, store *repotest.Repos) transaction.Service {
+	ctx := context.TODO()
+
+	for _, accnt := range []account.Account{john, mary} {
+		_, err := store.AccountRepo.CreateAccount(ctx, accnt)
+		require.NoError(t, err)
+	}
+
+	err := ledger.NewService(store.LedgerRepo, currency.NewSet(currency.USD)).CreateCashLedgers(ctx)
+	require.NoError(t, err)
+
+	return transaction.NewService(store.AccountRepo,
+		store.LedgerRepo, store.XactRepo, store.BalRepo)
+}
+
+// request is a request received by the receiver
+type request struct {
+	path    string
+	header  http.Header
+	event   outbox.Event
+	sigErr  error
+	payload transaction.Transaction
+}
+
+// receiver is a webhook receiver that verifies the signatures
+type receiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	secrets  map[string]string // by path
+	status   int
+	requests []request
+}
+
+func newReceiver(t *testing.T) *receiver {
+	rcvr := &receiver{secrets: map[string]string{}, status: http.StatusOK}
+	rcvr.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		rcvr.mu.Lock()
+		defer rcvr.mu.Unlock()
+
+		req := request{path: r.URL.Path, header: r.Header}
+		req.sigErr = webhook.VerifySignature(rcvr.secrets[r.URL.Path],
+			r.Header.Get(webhook.SignatureHeader), body, time.Now(), 0)
+
+		err = json.Unmarshal(body, &req.event)
+		require.NoError(t, err)
+		err = json.Unmarshal(req.event.Payload, &req.payload)
+		require.NoError(t, err)
+
+		rcvr.requests = append(rcvr.requests, req)
+		w.WriteHeader(rcvr.status)
+	}))
+
+	return rcvr
+}
+
+// subscribe creates a subscription to the path of the receiver
+func (rcvr *receiver) subscribe(t *testing.T, svc webhook.Service,
+	path string, sub webhook.Subscription) *webhook.Subscription {
+	sub.URL = rcvr.URL + path
+	created, err := svc.CreateSubscription(context.TODO(), sub)
+	require.NoError(t, err)
+	require.NotEmpty(t, created.Secret)
+
+	rcvr.mu.Lock()
+	rcvr.secrets[path] = created.Secret
+	rcvr.mu.Unlock()
+
+	return created
+}
+
+// take returns the received requests and clears them
+func (rcvr *receiver) take() []request {
+	rcvr.mu.Lock()
+	defer rcvr.mu.Unlock()
+
+	reqs := rcvr.requests
+	rcvr.requests = nil
+	return reqs
+}
+
+// signedAt returns the time the request was signed at
+func signedAt(t *testing.T, header http.Header) time.Time {
+	for _, part := range strings.Split(header.Get(webhook.SignatureHeader), ",") {
+		if strings.HasPrefix(part, "t=") {
+			unix, err := strconv.ParseInt(strings.TrimPrefix(part, "t="), 10, 64)
+			require.NoError(t, err)
+			return time.Unix(unix, 0)
+		}
+	}
+
+	require.Fail(t, "missing signature time")
+	return time.Time{}
+}
+
+func (rcvr *receiver) setStatus(status int) {
+	rcvr.mu.Lock()
+	rcvr.status = status
+	rcvr.mu.Unlock()
+}
+
+func TestWebhookService(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+	setup(t, store)
+
+	webhookSvc := webhook.NewService(store.WebhookRepo, store.AccountRepo)
+
+	var subID webhook.SubscriptionID
+	t.Run("create subscription", func(t *testing.T) {
+		sub, err := webhookSvc.CreateSubscription(ctx, webhook.Subscription{
+			AccountID:    john.AccountID,
+			XactTypeExts: []transaction.XactTypeExt{transaction.XactTypeExtDeposit},
+			URL:          "https://example.com/hooks",
+		})
+		require.NoError(t, err)
+		subID = sub.SubscriptionID
+		assert.NotEmpty(t, subID)
+		assert.Len(t, sub.Secret, 32, "generated secret")
+
+		sub, err = webhookSvc.CreateSubscription(ctx, webhook.Subscription{
+			URL:    "https://example.com/all",
+			Secret: "mysecretmysecret",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "mysecretmysecret", sub.Secret)
+
+		t.Run("validation errors", func(t *testing.T) {
+			tc := []struct {
+				name   string
+				sub    webhook.Subscription
+				expect string
+			}{
+				{name: "missing url", sub: webhook.Subscription{},
+					expect: "validation error; url: cannot be blank."},
+				{name: "not http", sub: webhook.Subscription{URL: "ftp://example.com"},
+					expect: "validation error; url: must be a valid http or https url."},
+				{name: "short secret", sub: webhook.Subscription{
+					URL: "https://example.com", Secret: "short"},
+					expect: "validation error; secret: the length must be between 16 and 128."},
+				{name: "invalid xact type", sub: webhook.Subscription{
+					URL:          "https://example.com",
+					XactTypeExts: []transaction.XactTypeExt{0}},
+					expect: "validation error; xact_types: (0: must be a valid transaction type.)."},
+			}
+
+			for _, tt := range tc {
+				t.Run(tt.name, func(t *testing.T) {
+					_, err := webhookSvc.CreateSubscription(ctx, tt.sub)
+					assert.EqualError(t, err, tt.expect)
+					assert.ErrorIs(t, err, webhook.ErrValidation)
+				})
+			}
+		})
+
+		t.Run("account not found", func(t *testing.T) {
+			_, err := webhookSvc.CreateSubscription(ctx, webhook.Subscription{
+				AccountID: account.AccountID("idontexist"),
+				URL:       "https://example.com/hooks",
+			})
+			assert.ErrorIs(t, err, account.ErrAccountNotFound)
+		})
+	})
+
+	t.Run("get subscription", func(t *testing.T) {
+		sub, err := webhookSvc.GetSubscription(ctx, subID)
+		require.NoError(t, err)
+		assert.Equal(t, john.AccountID, sub.AccountID)
+		assert.Equal(t, []transaction.XactTypeExt{transaction.XactTypeExtDeposit},
+			sub.XactTypeExts)
+		assert.Empty(t, sub.Secret, "the secret is only returned on creation")
+
+		_, err = webhookSvc.GetSubscription(ctx, webhook.SubscriptionID("idontexist"))
+		assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+	})
+
+	t.Run("list subscriptions", func(t *testing.T) {
+		subs, err := webhookSvc.ListSubscriptions(ctx, john.AccountID)
+		require.NoError(t, err)
+		require.Len(t, subs, 2)
+		for _, sub := range subs {
+			assert.Empty(t, sub.Secret)
+		}
+
+		subs, err = webhookSvc.ListSubscriptions(ctx, mary.AccountID)
+		require.NoError(t, err)
+		assert.Len(t, subs, 1)
+
+		_, err = webhookSvc.ListSubscriptions(ctx, account.AccountID("x"))
+		assert.ErrorIs(t, err, webhook.ErrValidation)
+	})
+
+	t.Run("delete subscription", func(t *testing.T) {
+		err := webhookSvc.DeleteSubscription(ctx, subID)
+		require.NoError(t, err)
+
+		_, err = webhookSvc.ListDeliveries(ctx, subID)
+		assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+
+		err = webhookSvc.DeleteSubscription(ctx, subID)
+		assert.ErrorIs(t, err, webhook.ErrSubscriptionNotFound)
+	})
+}
+
+func TestWorker(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	ctx := context.TODO()
+	xactSvc := setup(t, store)
+	webhookSvc := webhook.NewService(store.WebhookRepo, store.AccountRepo)
+
+	rcvr := newReceiver(t)
+	defer rcvr.Close()
+
+	johnSub := rcvr.subscribe(t, webhookSvc, "/john", webhook.Subscription{
+		AccountID: john.AccountID,
+		XactTypeExts: []transaction.XactTypeExt{
+			transaction.XactTypeExtDeposit,
+			transaction.XactTypeExtWithdrawal,
+		},
+	})
+	rcvr.subscribe(t, webhookSvc, "/received", webhook.Subscription{
+		XactTypeExts: []transaction.XactTypeExt{transaction.XactTypeExtRcvTransfer},
+	})
+
+	relay := outbox.NewRelay(store.OutboxRepo,
+		webhook.NewDispatcher(store.WebhookRepo), "webhooks")
+
+	retryDelay := time.Minute
+	worker := webhook.NewWorker(store.WebhookRepo, "worker1",
+		webhook.WithHTTPClient(rcvr.Client()),
+		webhook.WithMaxAttempts(3),
+		webhook.WithRetryDelay(retryDelay),
+	)
+
+	_, err := xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(100),
+	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeTransfer(ctx, transaction.TransferXact{
+		FromAccount: john.AccountID,
+		ToAccount:   mary.AccountID,
+		Amount:      decimal.NewFromInt(30),
+	})
+	require.NoError(t, err)
+
+	_, err = xactSvc.MakeWithdrawal(ctx, transaction.WithdrawalXact{
+		AccountID: john.AccountID,
+		Amount:    decimal.NewFromInt(10),
+	})
+	require.NoError(t, err)
+
+	t.Run("deliver", func(t *testing.T) {
+		n, err := worker.SendDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n, "not yet dispatched")
+
+		_, err = relay.RelayEvents(ctx)
+		require.NoError(t, err)
+
+		// the events published again are not delivered again
+		_, err = outbox.NewRelay(store.OutboxRepo,
+			webhook.NewDispatcher(store.WebhookRepo), "webhooks2").RelayEvents(ctx)
+		require.NoError(t, err)
+
+		n, err = worker.SendDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 3, n)
+
+		reqs := rcvr.take()
+		require.Len(t, reqs, 3)
+
+		byPath := map[string][]request{}
+		for _, req := range reqs {
+			assert.NoError(t, req.sigErr)
+			assert.NotEmpty(t, req.header.Get(webhook.DeliveryHeader))
+			assert.Equal(t, transaction.EventTypeXactPosted,
+				req.header.Get(webhook.EventHeader))
+			byPath[req.path] = append(byPath[req.path], req)
+		}
+
+		require.Len(t, byPath["/john"], 2)
+		assert.Equal(t, transaction.XactTypeExtDeposit, byPath["/john"][0].payload.XactTypeExt)
+		assert.Equal(t, transaction.XactTypeExtWithdrawal, byPath["/john"][1].payload.XactTypeExt)
+		assert.Less(t, byPath["/john"][0].event.Seq, byPath["/john"][1].event.Seq)
+
+		require.Len(t, byPath["/received"], 1)
+		assert.Equal(t, mary.AccountID, byPath["/received"][0].payload.AccountID)
+		assert.True(t, decimal.NewFromInt(30).Equal(byPath["/received"][0].payload.Amount))
+
+		deliveries, err := webhookSvc.ListDeliveries(ctx, johnSub.SubscriptionID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		for _, d := range deliveries {
+			assert.Equal(t, webhook.StatusSucceeded, d.Status)
+			assert.Equal(t, 1, d.Attempts)
+			assert.Equal(t, http.StatusOK, d.LastStatusCode)
+			assert.NotNil(t, d.DeliveredAt)
+		}
+
+		n, err = worker.SendDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, n, "already delivered")
+	})
+
+	var failedID webhook.DeliveryID
+	t.Run("retry with backoff", func(t *testing.T) {
+		rcvr.setStatus(http.StatusServiceUnavailable)
+
+		_, err := xactSvc.MakeDeposit(ctx, transaction.DepositXact{
+			AccountID: john.AccountID,
+			Amount:    decimal.NewFromInt(5),
+		})
+		require.NoError(t, err)
+
+		_, err = relay.RelayEvents(ctx)
+		require.NoError(t, err)
+
+		now := time.Now()
+		// the attempts are retried after 1m then 2m
+		for i, next := range []time.Time{
+			now,
+			now.Add(retryDelay),
+			now.Add(3 * retryDelay),
+		} {
+			n, err := worker.SendDue(ctx, next.Add(-time.Second))
+			require.NoError(t, err)
+			assert.Zero(t, n, "attempt %d is not yet due", i+1)
+
+			n, err = worker.SendDue(ctx, next)
+			require.NoError(t, err)
+			assert.Equal(t, 1, n, "attempt %d", i+1)
+		}
+		reqs := rcvr.take()
+		require.Len(t, reqs, 3)
+		for _, req := range reqs {
+			// signed when sent rather than when due
+			assert.WithinDuration(t, time.Now(), signedAt(t, req.header), 10*time.Second)
+		}
+
+		deliveries, err := webhookSvc.ListDeliveries(ctx, johnSub.SubscriptionID)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 3)
+
+		failed := deliveries[2]
+		failedID = failed.DeliveryID
+		assert.Equal(t, webhook.StatusFailed, failed.Status)
+		assert.Equal(t, 3, failed.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, failed.LastStatusCode)
+		assert.Equal(t, "unexpected status 503", failed.LastError)
+		assert.Nil(t, failed.DeliveredAt)
+
+		n, err := worker.SendDue(ctx, now.Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, n, "no attempts are left")
+	})
+
+	t.Run("redeliver", func(t *testing.T) {
+		rcvr.setStatus(http.StatusNoContent)
+
+		d, err := webhookSvc.Redeliver(ctx, failedID)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.StatusPending, d.Status)
+		assert.Zero(t, d.Attempts)
+
+		n, err := worker.SendDue(ctx, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		reqs := rcvr.take()
+		require.Len(t, reqs, 1)
+		assert.NoError(t, reqs[0].sigErr)
+		assert.Equal(t, string(failedID), reqs[0].header.Get(webhook.DeliveryHeader))
+
+		d, err = store.WebhookRepo.GetDelivery(ctx, failedID)
+		require.NoError(t, err)
+		assert.Equal(t, webhook.StatusSucceeded, d.Status)
+		assert.Equal(t, 1, d.Attempts)
+		assert.Empty(t, d.LastError)
+
+		_, err = webhookSvc.Redeliver(ctx, webhook.DeliveryID("idontexist"))
+		assert.ErrorIs(t, err, webhook.ErrDeliveryNotFound)
+	})
+}
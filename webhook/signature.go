@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// List of webhook request headers
+const (
+	// SignatureHeader is the signature of the request i.e. t=<unix time>,v1=<hex hmac>
+	SignatureHeader = "Kalupi-Signature"
+	// DeliveryHeader is the delivery id, it's the same on every attempt
+	DeliveryHeader = "Kalupi-Delivery"
+	// EventHeader is the event type i.e. transaction.posted
+	EventHeader = "Kalupi-Event"
+)
+
+// Sign returns the signature header of the body signed at the
+// time. The signature is the HMAC-SHA256 of "<unix time>.<body>".
+func Sign(secret string, ts time.Time, body []byte) string {
+	unix := ts.Unix()
+	return fmt.Sprintf("t=%d,v1=%s", unix, computeSignature(secret, unix, body))
+}
+
+// VerifySignature verifies the signature header of the body, the signature
+// must not be older than the tolerance at now. The age of the signature is
+// not checked if the tolerance is zero.
+func VerifySignature(secret, header string, body []byte,
+	now time.Time, tolerance time.Duration) error {
+	var (
+		unix int64
+		sigs []string
+		err  error
+	)
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return ErrInvalidSignature
+		}
+
+		switch kv[0] {
+		case "t":
+			unix, err = strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return ErrInvalidSignature
+			}
+		case "v1":
+			sigs = append(sigs, kv[1])
+		}
+	}
+
+	if unix == 0 || len(sigs) == 0 {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 && now.Sub(time.Unix(unix, 0)) > tolerance {
+		return ErrInvalidSignature
+	}
+
+	expected := computeSignature(secret, unix, body)
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+// computeSignature returns the hex encoded HMAC-SHA256 of the signed payload
+func computeSignature(secret string, unix int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%d.", unix)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
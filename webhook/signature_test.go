@@ -0,0 +1,56 @@
+package webhook_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+func TestSignature(t *testing.T) {
+	secret := "whsecretwhsecret"
+	body := []byte(`{"seq":1}`)
+	ts := time.Unix(1600000000, 0)
+
+	header := webhook.Sign(secret, ts, body)
+	assert.Equal(t, "t=1600000000,v1=6ef0c9be8e89f06d931c842cb56a9866b57544dda69538c7e4356b8e68044db0", header)
+
+	tc := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		now       time.Time
+		tolerance time.Duration
+		valid     bool
+	}{
+		{name: "valid", secret: secret, header: header, body: body,
+			now: ts.Add(time.Minute), tolerance: 5 * time.Minute, valid: true},
+		{name: "no tolerance", secret: secret, header: header, body: body,
+			now: ts.Add(time.Hour), valid: true},
+		{name: "too old", secret: secret, header: header, body: body,
+			now: ts.Add(time.Hour), tolerance: 5 * time.Minute},
+		{name: "wrong secret", secret: "anothersecretabc", header: header, body: body},
+		{name: "tampered body", secret: secret, header: header, body: []byte(`{"seq":2}`)},
+		{name: "tampered time", secret: secret, body: body,
+			header: "t=1600000001," + header[len("t=1600000000,"):]},
+		{name: "extra signature", secret: secret, body: body,
+			header: header + ",v1=deadbeef", valid: true},
+		{name: "missing signature", secret: secret, header: "t=1600000000", body: body},
+		{name: "malformed", secret: secret, header: "garbage", body: body},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			err := webhook.VerifySignature(tt.secret, tt.header,
+				tt.body, tt.now, tt.tolerance)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, webhook.ErrInvalidSignature)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Status is the delivery status
+type Status int
+
+// List of delivery statuses
+const (
+	// StatusPending is a delivery that is yet to be sent or retried
+	StatusPending Status = iota + 1
+	// StatusSucceeded is a delivery that was acknowledged by the receiver
+	StatusSucceeded
+	// StatusFailed is a delivery that has failed after all the attempts
+	StatusFailed
+)
+
+// String implements Stringer
+func (s Status) String() string {
+	if s < 0 || int(s) > len(statuses)-1 {
+		return statuses[0]
+	}
+
+	return statuses[s]
+}
+
+var statuses = [...]string{
+	"invalid",
+	"pending",
+	"succeeded",
+	"failed",
+}
+
+// Value implements driver.Valuer interface
+func (s Status) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// Scan implements sql.Scanner interface
+func (s *Status) Scan(src interface{}) error {
+	if src == nil {
+		*s = Status(0)
+		return nil
+	}
+
+	val, ok := src.(string)
+	if !ok {
+		return errors.New("src is not string")
+	}
+
+	*s = strToStatus(val)
+	return nil
+}
+
+// strToStatus takes a string and returns the status
+func strToStatus(str string) Status {
+	for i, name := range statuses {
+		if i > 0 && name == str {
+			return Status(i)
+		}
+	}
+
+	return Status(0)
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (s Status) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBuffer([]byte(`"`))
+	buf.WriteString(s.String())
+	buf.WriteByte(byte('"'))
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var str string
+	err := json.Unmarshal(data, &str)
+	if err != nil {
+		return err
+	}
+
+	*s = strToStatus(str)
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package webhook contains the webhook subscriptions to the account
+// activity. The postings are delivered from the outbox after commit, as
+// signed http requests that are retried with an exponential backoff.
+package webhook
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	pkgerrors "github.com/pkg/errors"
+
+	"github.com/stevenferrer/kalupi/account"
+	"github.com/stevenferrer/kalupi/transaction"
+)
+
+// SubscriptionID is a subscription id
+type SubscriptionID string
+
+const (
+	alphabet          = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	subscriptionIDLen = 12
+	// secretAlphabet is the alphabet of the generated secrets
+	secretAlphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// List of secret lengths
+	secretLen    = 32
+	minSecretLen = 16
+	maxSecretLen = 128
+	// maxURLLen is the max length of the url
+	maxURLLen = 2048
+)
+
+// NewSubscriptionID generates a new subscription id
+func NewSubscriptionID() (SubscriptionID, error) {
+	id, err := gonanoid.Generate(alphabet, subscriptionIDLen)
+	if err != nil {
+		return "", pkgerrors.Wrap(err, "generate")
+	}
+
+	return SubscriptionID(id), nil
+}
+
+// NewSecret generates a new signing secret
+func NewSecret() (string, error) {
+	secret, err := gonanoid.Generate(secretAlphabet, secretLen)
+	if err != nil {
+		return "", pkgerrors.Wrap(err, "generate")
+	}
+
+	return secret, nil
+}
+
+// Subscription is a webhook subscription to the postings of an account
+type Subscription struct {
+	SubscriptionID SubscriptionID `json:"id"`
+	// AccountID is the subscribed account, all the accounts if empty
+	AccountID account.AccountID `json:"account_id,omitempty"`
+	// XactTypeExts are the subscribed transaction types, all if empty
+	XactTypeExts []transaction.XactTypeExt `json:"xact_types,omitempty"`
+	// URL is where the events are posted to
+	URL string `json:"url"`
+	// Secret is the key of the signatures, it's only
+	// returned when the subscription is created
+	Secret string     `json:"secret,omitempty"`
+	Ts     *time.Time `json:"ts,omitempty"`
+}
+
+// Validate validates the subscription
+func (sub Subscription) Validate() error {
+	return validation.Errors{
+		"account_id": sub.validateAccountID(),
+		"xact_types": validation.Validate(sub.XactTypeExts,
+			validation.Each(validation.By(func(value interface{}) error {
+				ttx, _ := value.(transaction.XactTypeExt)
+				if ttx.String() == "invalid" {
+					return errors.New("must be a valid transaction type")
+				}
+				return nil
+			})),
+		),
+		"url": validation.Validate(sub.URL,
+			validation.Required,
+			validation.Length(0, maxURLLen),
+			validation.By(func(interface{}) error {
+				u, err := url.Parse(sub.URL)
+				if err != nil || (u.Scheme != "http" && u.Scheme != "https") ||
+					u.Host == "" {
+					return errors.New("must be a valid http or https url")
+				}
+				return nil
+			}),
+		),
+		"secret": validation.Validate(sub.Secret,
+			validation.Length(minSecretLen, maxSecretLen),
+		),
+	}.Filter()
+}
+
+// validateAccountID validates the account id unless it's empty
+func (sub Subscription) validateAccountID() error {
+	if sub.AccountID == "" {
+		return nil
+	}
+
+	return sub.AccountID.Validate()
+}
+
+// Matches returns true if the posting is subscribed to
+func (sub Subscription) Matches(xact transaction.Transaction) bool {
+	if sub.AccountID != "" && sub.AccountID != xact.AccountID {
+		return false
+	}
+
+	if len(sub.XactTypeExts) == 0 {
+		return true
+	}
+
+	for _, ttx := range sub.XactTypeExts {
+		if ttx == xact.XactTypeExt {
+			return true
+		}
+	}
+
+	return false
+}
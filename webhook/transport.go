@@ -0,0 +1,163 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/transport"
+	kithttp "github.com/go-kit/kit/transport/http"
+
+	"github.com/stevenferrer/kalupi/account"
+)
+
+// NewHTTPHandler returns the webhook http handler
+func NewHTTPHandler(s Service, logger kitlog.Logger) http.Handler {
+	opts := []kithttp.ServerOption{
+		kithttp.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
+		kithttp.ServerErrorEncoder(encodeError),
+	}
+
+	createSubscriptionHandler := kithttp.NewServer(
+		newCreateSubscriptionEndpoint(s),
+		decodeCreateSubscriptionRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	listSubscriptionsHandler := kithttp.NewServer(
+		newListSubscriptionsEndpoint(s),
+		decodeListSubscriptionsRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	getSubscriptionHandler := kithttp.NewServer(
+		newGetSubscriptionEndpoint(s),
+		decodeGetSubscriptionRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	deleteSubscriptionHandler := kithttp.NewServer(
+		newDeleteSubscriptionEndpoint(s),
+		decodeDeleteSubscriptionRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	listDeliveriesHandler := kithttp.NewServer(
+		newListDeliveriesEndpoint(s),
+		decodeListDeliveriesRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	redeliverHandler := kithttp.NewServer(
+		newRedeliverEndpoint(s),
+		decodeRedeliverRequest,
+		encodeResponse,
+		opts...,
+	)
+
+	mux := chi.NewMux()
+
+	mux.Method(http.MethodPost, "/", createSubscriptionHandler)
+	mux.Method(http.MethodGet, "/", listSubscriptionsHandler)
+	mux.Method(http.MethodGet, "/{id}", getSubscriptionHandler)
+	mux.Method(http.MethodDelete, "/{id}", deleteSubscriptionHandler)
+	mux.Method(http.MethodGet, "/{id}/deliveries", listDeliveriesHandler)
+	mux.Method(http.MethodPost, "/deliveries/{id}/redeliver", redeliverHandler)
+
+	return mux
+}
+
+var (
+	errBadRoute = errors.New("bad route")
+)
+
+func decodeCreateSubscriptionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	var request createSubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+func decodeListSubscriptionsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	accntID := r.URL.Query().Get("account_id")
+	return listSubscriptionsRequest{AccountID: account.AccountID(accntID)}, nil
+}
+
+func decodeGetSubscriptionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	subID := chi.URLParam(r, "id")
+	if subID == "" {
+		return nil, errBadRoute
+	}
+
+	return getSubscriptionRequest{SubscriptionID: SubscriptionID(subID)}, nil
+}
+
+func decodeDeleteSubscriptionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	subID := chi.URLParam(r, "id")
+	if subID == "" {
+		return nil, errBadRoute
+	}
+
+	return deleteSubscriptionRequest{SubscriptionID: SubscriptionID(subID)}, nil
+}
+
+func decodeListDeliveriesRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	subID := chi.URLParam(r, "id")
+	if subID == "" {
+		return nil, errBadRoute
+	}
+
+	return listDeliveriesRequest{SubscriptionID: SubscriptionID(subID)}, nil
+}
+
+func decodeRedeliverRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	deliveryID := chi.URLParam(r, "id")
+	if deliveryID == "" {
+		return nil, errBadRoute
+	}
+
+	return redeliverRequest{DeliveryID: DeliveryID(deliveryID)}, nil
+}
+
+func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(response)
+}
+
+// errorer is an error interface for response
+type errorer interface {
+	error() error
+}
+
+// encode errors from business-logic
+func encodeError(_ context.Context, err error, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if errors.Is(err, ErrValidation) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	} else if errors.Is(err, ErrDeliveryBusy) {
+		w.WriteHeader(http.StatusConflict)
+	} else if errors.Is(err, ErrSubscriptionNotFound) ||
+		errors.Is(err, ErrDeliveryNotFound) ||
+		errors.Is(err, account.ErrAccountNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+	} else {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error()})
+}
@@ -0,0 +1,183 @@
+package webhook_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stevenferrer/kalupi/etc/teststore"
+	"github.com/stevenferrer/kalupi/webhook"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	store, closeStore := teststore.MustOpen()
+	defer closeStore()
+
+	setup(t, store)
+
+	logger := log.NewNopLogger()
+	var webhookService webhook.Service
+	webhookService = webhook.NewService(store.WebhookRepo, store.AccountRepo)
+	webhookService = webhook.NewLoggingService(logger, webhookService)
+
+	webhookHandler := webhook.NewHTTPHandler(webhookService, logger)
+
+	ctx := context.TODO()
+
+	type subscriptionResp struct {
+		Subscription struct {
+			SubscriptionID string   `json:"id"`
+			AccountID      string   `json:"account_id"`
+			XactTypes      []string `json:"xact_types"`
+			URL            string   `json:"url"`
+			Secret         string   `json:"secret"`
+		} `json:"subscription"`
+	}
+
+	serve := func(t *testing.T, method, target string, req interface{}) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if req != nil {
+			err := json.NewEncoder(&body).Encode(req)
+			require.NoError(t, err)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, method, target, &body)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		webhookHandler.ServeHTTP(rr, httpReq)
+		return rr
+	}
+
+	var subID string
+	t.Run("create subscription", func(t *testing.T) {
+		rr := serve(t, http.MethodPost, "/", map[string]interface{}{
+			"account_id": john.AccountID,
+			"xact_types": []string{"Dp", "RTr"},
+			"url":        "https://example.com/hooks",
+		})
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp subscriptionResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		subID = resp.Subscription.SubscriptionID
+		assert.NotEmpty(t, subID)
+		assert.Equal(t, []string{"Dp", "RTr"}, resp.Subscription.XactTypes)
+		assert.NotEmpty(t, resp.Subscription.Secret)
+
+		t.Run("validation error", func(t *testing.T) {
+			rr := serve(t, http.MethodPost, "/", map[string]interface{}{
+				"xact_types": []string{"Xx"},
+				"url":        "https://example.com/hooks",
+			})
+			require.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		})
+
+		t.Run("account not found", func(t *testing.T) {
+			rr := serve(t, http.MethodPost, "/", map[string]interface{}{
+				"account_id": "idontexist",
+				"url":        "https://example.com/hooks",
+			})
+			require.Equal(t, http.StatusNotFound, rr.Code)
+		})
+	})
+
+	t.Run("get subscription", func(t *testing.T) {
+		rr := serve(t, http.MethodGet, "/"+subID, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp subscriptionResp
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, subID, resp.Subscription.SubscriptionID)
+		assert.Equal(t, string(john.AccountID), resp.Subscription.AccountID)
+		assert.Empty(t, resp.Subscription.Secret)
+
+		rr = serve(t, http.MethodGet, "/idontexist", nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("list subscriptions", func(t *testing.T) {
+		rr := serve(t, http.MethodGet, "/?account_id="+string(john.AccountID), nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Subscriptions []struct {
+				SubscriptionID string `json:"id"`
+			} `json:"subscriptions"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Subscriptions, 1)
+		assert.Equal(t, subID, resp.Subscriptions[0].SubscriptionID)
+	})
+
+	t.Run("deliveries", func(t *testing.T) {
+		err := store.WebhookRepo.CreateDeliveries(ctx, []webhook.Delivery{{
+			DeliveryID:     webhook.DeliveryID("dlv1"),
+			SubscriptionID: webhook.SubscriptionID(subID),
+			EventSeq:       1,
+			EventType:      "transaction.posted",
+			Payload:        json.RawMessage(`{"seq":1}`),
+			Status:         webhook.StatusFailed,
+			NextAttemptAt:  time.Now(),
+		}})
+		require.NoError(t, err)
+
+		rr := serve(t, http.MethodGet, "/"+subID+"/deliveries", nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Deliveries []struct {
+				DeliveryID string          `json:"id"`
+				Status     string          `json:"status"`
+				Payload    json.RawMessage `json:"payload"`
+			} `json:"deliveries"`
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		require.Len(t, resp.Deliveries, 1)
+		assert.Equal(t, "dlv1", resp.Deliveries[0].DeliveryID)
+		assert.Equal(t, "failed", resp.Deliveries[0].Status)
+		assert.JSONEq(t, `{"seq":1}`, string(resp.Deliveries[0].Payload))
+
+		rr = serve(t, http.MethodGet, "/idontexist/deliveries", nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("redeliver", func(t *testing.T) {
+		rr := serve(t, http.MethodPost, "/deliveries/dlv1/redeliver", nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp struct {
+			Delivery struct {
+				DeliveryID string `json:"id"`
+				Status     string `json:"status"`
+			} `json:"delivery"`
+		}
+		err := json.NewDecoder(rr.Body).Decode(&resp)
+		require.NoError(t, err)
+		assert.Equal(t, "dlv1", resp.Delivery.DeliveryID)
+		assert.Equal(t, "pending", resp.Delivery.Status)
+
+		rr = serve(t, http.MethodPost, "/deliveries/idontexist/redeliver", nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("delete subscription", func(t *testing.T) {
+		rr := serve(t, http.MethodDelete, "/"+subID, nil)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		rr = serve(t, http.MethodDelete, "/"+subID, nil)
+		require.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
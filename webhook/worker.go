@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+// List of worker defaults
+const (
+	defaultInterval    = 5 * time.Second
+	defaultLeaseTTL    = time.Minute
+	defaultBatchSize   = 10
+	defaultMaxAttempts = 8
+	defaultRetryDelay  = 30 * time.Second
+	defaultHTTPTimeout = 10 * time.Second
+)
+
+// Worker sends the due deliveries. Several workers can run against the same
+// database, a delivery is leased by one worker at a time. The requests are
+// signed with the secret of the subscription, any response other than 2xx is
+// a failed attempt.
+//
+// A failed delivery is retried with an exponential backoff, after the max
+// attempts the delivery fails and is only sent again if it's redelivered.
+type Worker struct {
+	webhookRepo Repository
+	// owner identifies the worker in the leases
+	owner       string
+	client      *http.Client
+	logger      log.Logger
+	interval    time.Duration
+	leaseTTL    time.Duration
+	batchSize   int
+	maxAttempts int
+	retryDelay  time.Duration
+}
+
+// WorkerOption is an option for the worker
+type WorkerOption func(*Worker)
+
+// WithInterval sets how often the worker polls for due deliveries
+func WithInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.interval = d
+	}
+}
+
+// WithLeaseTTL sets how long a delivery is leased to the worker,
+// it must be longer than the timeout of the http client
+func WithLeaseTTL(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.leaseTTL = d
+	}
+}
+
+// WithBatchSize sets the max number of deliveries leased per poll
+func WithBatchSize(n int) WorkerOption {
+	return func(w *Worker) {
+		w.batchSize = n
+	}
+}
+
+// WithMaxAttempts sets the max number of attempts of a delivery
+func WithMaxAttempts(n int) WorkerOption {
+	return func(w *Worker) {
+		w.maxAttempts = n
+	}
+}
+
+// WithRetryDelay sets the delay before retrying a failed delivery,
+// it is doubled on every attempt
+func WithRetryDelay(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		w.retryDelay = d
+	}
+}
+
+// WithHTTPClient sets the http client of the worker
+func WithHTTPClient(client *http.Client) WorkerOption {
+	return func(w *Worker) {
+		w.client = client
+	}
+}
+
+// WithLogger sets the logger of the worker
+func WithLogger(logger log.Logger) WorkerOption {
+	return func(w *Worker) {
+		w.logger = logger
+	}
+}
+
+// NewWorker takes a webhook repository and the
+// unique name of the worker, and returns a worker
+func NewWorker(webhookRepo Repository, owner string, opts ...WorkerOption) *Worker {
+	w := &Worker{
+		webhookRepo: webhookRepo,
+		owner:       owner,
+		client:      &http.Client{Timeout: defaultHTTPTimeout},
+		logger:      log.NewNopLogger(),
+		interval:    defaultInterval,
+		leaseTTL:    defaultLeaseTTL,
+		batchSize:   defaultBatchSize,
+		maxAttempts: defaultMaxAttempts,
+		retryDelay:  defaultRetryDelay,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Run sends the due deliveries every interval until the context is done
+func (w *Worker) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := w.SendDue(ctx, time.Now())
+		if err != nil {
+			_ = w.logger.Log("err", err)
+		} else if n > 0 {
+			_ = w.logger.Log("msg", "sent due deliveries", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends the deliveries that are due at now, and returns the number
+// of attempts. It keeps going until there are no due deliveries left.
+func (w *Worker) SendDue(ctx context.Context, now time.Time) (int, error) {
+	var count int
+	for {
+		deliveries, err := w.webhookRepo.LeaseDueDeliveries(ctx,
+			w.owner, now, w.leaseTTL, w.batchSize)
+		if err != nil {
+			return count, errors.Wrap(err, "lease due deliveries")
+		}
+
+		for _, d := range deliveries {
+			err = w.send(ctx, *d, now)
+			if err != nil {
+				_ = w.logger.Log("delivery_id", d.DeliveryID, "err", err)
+				continue
+			}
+			count++
+		}
+
+		if len(deliveries) < w.batchSize {
+			return count, nil
+		}
+	}
+}
+
+// send sends the delivery and records the attempt
+func (w *Worker) send(ctx context.Context, d Delivery, now time.Time) error {
+	sub, err := w.webhookRepo.GetSubscription(ctx, d.SubscriptionID)
+	if err != nil {
+		return errors.Wrap(err, "get subscription")
+	}
+
+	statusCode, sendErr := w.post(ctx, *sub, d)
+
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	if sendErr == nil {
+		d.Status = StatusSucceeded
+		d.LastError = ""
+		d.DeliveredAt = &now
+	} else {
+		d.LastError = sendErr.Error()
+		if d.Attempts < w.maxAttempts {
+			d.NextAttemptAt = now.Add(w.retryDelay << uint(d.Attempts-1))
+		} else {
+			d.Status = StatusFailed
+		}
+	}
+
+	d.LeaseOwner = w.owner
+	err = w.webhookRepo.RecordAttempt(ctx, d)
+	if err != nil {
+		return errors.Wrap(err, "record attempt")
+	}
+
+	return nil
+}
+
+// post posts the payload of the delivery to the subscription url,
+// and returns the response status if there was a response. The request
+// is signed when it's sent, the deliveries of a batch can be sent well
+// after the time they were due.
+func (w *Worker) post(ctx context.Context, sub Subscription, d Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "new request")
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(SignatureHeader, Sign(sub.Secret, time.Now(), d.Payload))
+	req.Header.Set(DeliveryHeader, string(d.DeliveryID))
+	req.Header.Set(EventHeader, d.EventType)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "do request")
+	}
+	defer resp.Body.Close()
+
+	// drain the body so that the connection is reused
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}